@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/alert"
 	"github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/cron"
 	"github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/tezos"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/config"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/log"
-	mongosvc "github.com/guillaumedebavelaere/tezos-delegation/pkg/mongo"
-	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mongo"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/backend"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/parquet"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/webhook"
 )
 
 const appName = "delegation_aggregation"
@@ -24,11 +32,53 @@ func run() int {
 		API   struct {
 			Tezos tezos.Config
 		}
-		Datastore struct {
-			Mongo mongosvc.Config
-		}
+		Cron      cron.Config
+		Datastore backend.Config
+		Webhooks  webhook.Config
+		Alerts    alert.Config
 	}
 
+	datastoreDriver := flag.String(
+		"datastore",
+		"",
+		"datastore driver overriding the configuration (mongo, sqlite or memory)",
+	)
+	rebuildCounts := flag.Bool(
+		"rebuild-counts",
+		false,
+		"recompute the delegations counts from the stored delegations instead of aggregating new delegations",
+	)
+	rebuildBakers := flag.Bool(
+		"rebuild-bakers",
+		false,
+		"recompute the bakers aggregates and the delegators from the stored delegations instead of aggregating "+
+			"new delegations",
+	)
+	backfillLegacy := flag.Bool(
+		"backfill-legacy",
+		false,
+		"fetch again the delegations stored without operation id by a previous version, to identify them, "+
+			"instead of aggregating new delegations",
+	)
+	deleteDelegations := flag.String(
+		"delete-delegations",
+		"",
+		"comma separated ids of delegations to delete (e.g. removed by a reorg) instead of aggregating new delegations",
+	)
+	exportParquet := flag.String(
+		"export-parquet",
+		"",
+		"directory to export the stored delegations to as Parquet files, instead of aggregating new delegations",
+	)
+	exportFrom := flag.String("export-from", "", "RFC3339 start (inclusive) of the exported delegations range")
+	exportTo := flag.String("export-to", "", "RFC3339 end (exclusive) of the exported delegations range")
+	exportPartition := flag.String(
+		"export-partition",
+		"",
+		"partition of the exported Parquet files (year or month), a single file when empty",
+	)
+	flag.Parse()
+
 	// parse yaml config
 	if err := config.Parse(appName, &cfg); err != nil {
 		zap.L().Error("couldn't parse config", zap.Error(err))
@@ -36,6 +86,10 @@ func run() int {
 		return 1
 	}
 
+	if *datastoreDriver != "" {
+		cfg.Datastore.Driver = *datastoreDriver
+	}
+
 	if err := config.Validate(cfg); err != nil {
 		zap.L().Error("invalid config", zap.Error(err))
 
@@ -47,8 +101,12 @@ func run() int {
 	tezosService := tezos.NewClient(&cfg.API.Tezos)
 	tezosService.Init()
 
-	mongoClient := mongosvc.New(&cfg.Datastore.Mongo)
-	datastore := mongo.New(mongoClient)
+	datastore, err := backend.New(&cfg.Datastore)
+	if err != nil {
+		zap.L().Error("invalid datastore config", zap.Error(err))
+
+		return 1
+	}
 
 	if err := datastore.Init(); err != nil {
 		zap.L().Error(
@@ -59,7 +117,7 @@ func run() int {
 		return 1
 	}
 
-	defer func(datastore *mongo.Datastore) {
+	defer func(datastore backend.Datastore) {
 		err := datastore.Close()
 		if err != nil {
 			zap.L().Error(
@@ -69,8 +127,58 @@ func run() int {
 		}
 	}(datastore)
 
+	if *exportParquet != "" {
+		return runExportParquet(datastore, *exportParquet, *exportFrom, *exportTo, *exportPartition)
+	}
+
+	alerter, err := alert.New(&cfg.Alerts, datastore)
+	if err != nil {
+		zap.L().Error("invalid alerts config", zap.Error(err))
+
+		return 1
+	}
+
 	// Create new delegation aggregation cron
-	c := cron.New(tezosService, datastore)
+	c := cron.New(&cfg.Cron, tezosService, datastore, webhook.NewDispatcher(&cfg.Webhooks, datastore), alerter)
+
+	if *rebuildCounts {
+		if err := c.RebuildCounts(); err != nil {
+			return 1
+		}
+
+		return 0
+	}
+
+	if *rebuildBakers {
+		if err := c.RebuildBakers(); err != nil {
+			return 1
+		}
+
+		return 0
+	}
+
+	if *backfillLegacy {
+		if err := c.BackfillLegacyDelegations(); err != nil {
+			return 1
+		}
+
+		return 0
+	}
+
+	if *deleteDelegations != "" {
+		ids, err := parseIDs(*deleteDelegations)
+		if err != nil {
+			zap.L().Error("invalid delegations ids", zap.String("ids", *deleteDelegations), zap.Error(err))
+
+			return 1
+		}
+
+		if err := c.DeleteDelegations(ids); err != nil {
+			return 1
+		}
+
+		return 0
+	}
 
 	// run cronjob
 	if err := c.Run(); err != nil {
@@ -82,9 +190,78 @@ func run() int {
 		return 1
 	}
 
+	// deliver the ingested delegations, and retry the failed deliveries
+	if err := c.DispatchWebhooks(); err != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// runExportParquet exports the stored delegations of a range to Parquet files in dir.
+func runExportParquet(ds backend.Datastore, dir, from, to, value string) int {
+	var (
+		filter datastore.Filter
+		err    error
+	)
+
+	if filter.From, err = parseTime(from); err != nil {
+		zap.L().Error("invalid export start", zap.String("from", from), zap.Error(err))
+
+		return 1
+	}
+
+	if filter.To, err = parseTime(to); err != nil {
+		zap.L().Error("invalid export end", zap.String("to", to), zap.Error(err))
+
+		return 1
+	}
+
+	partition, err := parquet.ParsePartition(value)
+	if err != nil {
+		zap.L().Error("invalid export partition", zap.String("partition", value), zap.Error(err))
+
+		return 1
+	}
+
+	paths, err := parquet.ExportFiles(context.Background(), ds, dir, filter, partition)
+	if err != nil {
+		zap.L().Error("couldn't export delegations to parquet", zap.String("dir", dir), zap.Error(err))
+
+		return 1
+	}
+
+	zap.L().Info("delegations exported to parquet", zap.Strings("files", paths))
+
 	return 0
 }
 
+// parseTime parses an optional RFC3339 time.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseIDs parses comma separated ids.
+func parseIDs(value string) ([]int64, error) {
+	fields := strings.Split(value, ",")
+	ids := make([]int64, len(fields))
+
+	for i, field := range fields {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
 func main() {
 	os.Exit(run())
 }
@@ -0,0 +1,325 @@
+// Package alert evaluates alert rules on the ingested delegations, emitting alerts to notifiers.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Rule types.
+const (
+	// RuleAmount alerts on a delegation of an amount strictly above Threshold.
+	RuleAmount = "amount"
+	// RuleBakerOutflow alerts when a baker loses more than MaxOutflowPercent of its delegated balance within Window.
+	RuleBakerOutflow = "bakerOutflow"
+	// RuleWatchedAddress alerts when one of Addresses redelegates or undelegates.
+	RuleWatchedAddress = "watchedAddress"
+)
+
+// percent converts a ratio to a percentage.
+const percent = 100
+
+// Config describes the alerts configuration.
+type Config struct {
+	// Timeout is the timeout of a notification request.
+	Timeout   time.Duration    `validate:"required"`
+	Rules     []RuleConfig     `validate:"dive"`
+	Notifiers []NotifierConfig `validate:"dive"`
+}
+
+// RuleConfig describes an alert rule configuration.
+type RuleConfig struct {
+	// Name identifies the rule, alerts are deduplicated and cooled down per rule.
+	Name string `validate:"required"`
+	// Type is the rule type: amount, bakerOutflow or watchedAddress.
+	Type string `validate:"oneof=amount bakerOutflow watchedAddress"`
+	// Threshold is the amount an alerting delegation is strictly above, for amount rules.
+	Threshold int64 `validate:"required_if=Type amount"`
+	// Baker restricts amount and bakerOutflow rules to a baker, empty means any baker.
+	Baker string
+	// MaxOutflowPercent is the percentage of its delegated balance a baker can lose within Window without
+	// alerting, for bakerOutflow rules.
+	MaxOutflowPercent float64       `validate:"required_if=Type bakerOutflow,gte=0,lte=100"`
+	Window            time.Duration `validate:"required_if=Type bakerOutflow"`
+	// Addresses are the watched delegators, for watchedAddress rules.
+	Addresses []string `validate:"required_if=Type watchedAddress"`
+	// Cooldown is the minimum delay between two alerts of the rule, 0 means no cooldown.
+	Cooldown time.Duration
+	// Notifiers are the names of the notifiers emitting the rule alerts, empty means all notifiers.
+	Notifiers []string
+}
+
+// rule is an alert rule with its notifiers.
+type rule struct {
+	*RuleConfig
+	addresses map[string]bool
+	notifiers []Notifier
+}
+
+// Alerter evaluates the alert rules on the ingested delegations.
+//
+// An alert is stored before being notified: it is emitted once per rule and key, even when its delegations
+// are ingested again, and a rule doesn't alert again before its cooldown elapsed.
+type Alerter struct {
+	rules     []*rule
+	datastore datastore.Datastorer
+	now       func() time.Time
+}
+
+// New creates a new Alerter, failing if a rule references an unknown notifier.
+func New(cfg *Config, datastore datastore.Datastorer) (*Alerter, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	notifiers := make(map[string]Notifier, len(cfg.Notifiers))
+	for i := range cfg.Notifiers {
+		notifiers[cfg.Notifiers[i].Name] = newNotifier(&cfg.Notifiers[i], client)
+	}
+
+	rules := make([]*rule, len(cfg.Rules))
+
+	for i := range cfg.Rules {
+		r := &rule{RuleConfig: &cfg.Rules[i], addresses: map[string]bool{}}
+
+		for _, address := range r.Addresses {
+			r.addresses[address] = true
+		}
+
+		if len(r.RuleConfig.Notifiers) == 0 {
+			for j := range cfg.Notifiers {
+				r.notifiers = append(r.notifiers, notifiers[cfg.Notifiers[j].Name])
+			}
+		}
+
+		for _, name := range r.RuleConfig.Notifiers {
+			notifier, found := notifiers[name]
+			if !found {
+				return nil, fmt.Errorf("unknown notifier %s of alert rule %s", name, r.Name)
+			}
+
+			r.notifiers = append(r.notifiers, notifier)
+		}
+
+		rules[i] = r
+	}
+
+	return &Alerter{rules: rules, datastore: datastore, now: time.Now}, nil
+}
+
+// Evaluate evaluates the rules on stored delegations, emitting the alerts they trigger.
+// Notification failures are logged, as the alerts are already recorded.
+func (a *Alerter) Evaluate(ctx context.Context, delegations []*model.Delegation) error {
+	for _, r := range a.rules {
+		alerts, err := a.alerts(ctx, r, delegations)
+		if err != nil {
+			return err
+		}
+
+		for _, alert := range alerts {
+			if err := a.emit(ctx, r, alert); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// alerts returns the alerts triggered by a rule on delegations.
+func (a *Alerter) alerts(ctx context.Context, r *rule, delegations []*model.Delegation) ([]*model.Alert, error) {
+	var alerts []*model.Alert
+
+	switch r.Type {
+	case RuleAmount:
+		for _, d := range delegations {
+			if d.Amount > r.Threshold && (r.Baker == "" || r.Baker == d.Baker || r.Baker == d.PreviousBaker) {
+				alerts = append(alerts, a.alert(r, delegationKey(d), d.Baker, d, fmt.Sprintf(
+					"%s of %d mutez from %s to baker %s",
+					datastore.DelegationKind(d), d.Amount, d.Delegator, d.Baker,
+				)))
+			}
+		}
+	case RuleWatchedAddress:
+		for _, d := range delegations {
+			if r.addresses[d.Delegator] && d.PreviousBaker != "" {
+				alerts = append(alerts, a.alert(r, delegationKey(d), d.PreviousBaker, d, fmt.Sprintf(
+					"watched address %s %s %d mutez from baker %s to baker %s",
+					d.Delegator, verb(d), d.Amount, d.PreviousBaker, d.Baker,
+				)))
+			}
+		}
+	case RuleBakerOutflow:
+		return a.outflowAlerts(ctx, r, delegations)
+	}
+
+	return alerts, nil
+}
+
+// outflowAlerts returns the alerts of the bakers losing more than the maximum percentage of their delegated
+// balance within the window ending at their latest outflow.
+func (a *Alerter) outflowAlerts(ctx context.Context, r *rule, delegations []*model.Delegation) ([]*model.Alert, error) {
+	var (
+		bakers []string
+		// latest is the latest outflow of each baker
+		latest = map[string]*model.Delegation{}
+	)
+
+	for _, d := range delegations {
+		if d.PreviousBaker == "" || d.PreviousBaker == d.Baker || (r.Baker != "" && r.Baker != d.PreviousBaker) {
+			continue
+		}
+
+		previous, found := latest[d.PreviousBaker]
+		if !found {
+			bakers = append(bakers, d.PreviousBaker)
+		}
+
+		if !found || d.Timestamp.After(previous.Timestamp) ||
+			(d.Timestamp.Equal(previous.Timestamp) && d.ID > previous.ID) {
+			latest[d.PreviousBaker] = d
+		}
+	}
+
+	var alerts []*model.Alert
+
+	for _, address := range bakers {
+		d := latest[address]
+
+		loss, share, err := a.outflow(ctx, r, address, d.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		if share > r.MaxOutflowPercent {
+			alerts = append(alerts, a.alert(r, fmt.Sprintf("baker:%s:%d", address, d.ID), address, d, fmt.Sprintf(
+				"baker %s lost %.2f%% of its delegated balance (%d mutez) within %s",
+				address, share, loss, r.Window,
+			)))
+		}
+	}
+
+	return alerts, nil
+}
+
+// outflow returns the net amount a baker lost within the window ending at end, and its percentage of the
+// baker delegated balance at the start of the window.
+func (a *Alerter) outflow(ctx context.Context, r *rule, address string, end time.Time) (int64, float64, error) {
+	from, to := end.Add(-r.Window), end.Add(time.Millisecond)
+
+	// delegations away from the baker, then to the baker, within the window
+	outflows, err := a.sumAmounts(ctx, datastore.Filter{From: from, To: to, PreviousBaker: address})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	inflows, err := a.sumAmounts(ctx, datastore.Filter{From: from, To: to, Baker: address})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	loss := outflows - inflows
+
+	if loss <= 0 {
+		return loss, 0, nil
+	}
+
+	baker, err := a.datastore.GetBaker(ctx, address)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var delegated int64
+	if baker != nil {
+		delegated = baker.DelegatedAmount
+	}
+
+	return loss, float64(loss) * percent / float64(delegated+loss), nil
+}
+
+// sumAmounts returns the total amount of the delegations matching a filter, ignoring the redelegations to the
+// same baker. The delegations are iterated with their amount and bakers only.
+func (a *Alerter) sumAmounts(ctx context.Context, filter datastore.Filter) (int64, error) {
+	var sum int64
+
+	err := a.datastore.IterateDelegations(
+		ctx,
+		filter,
+		datastore.DelegationsSort{},
+		datastore.Projection{datastore.FieldAmount, datastore.FieldBaker, datastore.FieldPreviousBaker},
+		datastore.Page{},
+		func(d *model.Delegation) error {
+			if d.PreviousBaker != d.Baker {
+				sum += d.Amount
+			}
+
+			return nil
+		},
+	)
+
+	return sum, err
+}
+
+// emit records an alert then notifies it, unless it was already emitted or the rule is cooling down.
+func (a *Alerter) emit(ctx context.Context, r *rule, alert *model.Alert) error {
+	if r.Cooldown > 0 {
+		latest, err := a.datastore.GetLatestAlert(ctx, r.Name)
+		if err != nil {
+			return err
+		}
+
+		if latest != nil && alert.FiredAt.Before(latest.FiredAt.Add(r.Cooldown)) {
+			return nil
+		}
+	}
+
+	created, err := a.datastore.CreateAlert(ctx, alert)
+	if err != nil || !created {
+		return err
+	}
+
+	for _, notifier := range r.notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			zap.L().Error(
+				"couldn't notify alert",
+				zap.String("rule", alert.Rule),
+				zap.String("key", alert.Key),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// alert creates an alert of a rule fired now.
+func (a *Alerter) alert(r *rule, key, baker string, delegation *model.Delegation, message string) *model.Alert {
+	return &model.Alert{
+		Rule:       r.Name,
+		Key:        key,
+		Type:       r.Type,
+		Message:    message,
+		Baker:      baker,
+		Delegation: delegation,
+		FiredAt:    a.now(),
+	}
+}
+
+// delegationKey is the key of an alert on a single delegation.
+func delegationKey(d *model.Delegation) string {
+	return fmt.Sprintf("delegation:%d", d.ID)
+}
+
+// verb describes a delegation away from a baker.
+func verb(d *model.Delegation) string {
+	if d.Baker == "" {
+		return "undelegated"
+	}
+
+	return "redelegated"
+}
@@ -0,0 +1,266 @@
+package alert_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/alert"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+type underTest struct {
+	datastore *memory.Datastore
+	alerter   *alert.Alerter
+	now       time.Time
+
+	mu     sync.Mutex
+	bodies map[string][][]byte
+}
+
+// setupTest creates an alerter with a webhook and a slack notifier, each calling a test server.
+func setupTest(t *testing.T, rules ...alert.RuleConfig) *underTest {
+	t.Helper()
+
+	ut := &underTest{
+		datastore: memory.New(),
+		now:       time.Date(2023, 12, 10, 11, 0, 0, 0, time.UTC),
+		bodies:    map[string][][]byte{},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		ut.mu.Lock()
+		defer ut.mu.Unlock()
+
+		ut.bodies[r.URL.Path] = append(ut.bodies[r.URL.Path], body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	var err error
+
+	ut.alerter, err = alert.New(&alert.Config{
+		Timeout: time.Second,
+		Rules:   rules,
+		Notifiers: []alert.NotifierConfig{
+			{Name: "hook", Type: alert.NotifierWebhook, URL: server.URL + "/hook"},
+			{Name: "slack", Type: alert.NotifierSlack, URL: server.URL + "/slack"},
+			{Name: "log", Type: alert.NotifierLog},
+		},
+	}, ut.datastore)
+	require.NoError(t, err)
+
+	ut.alerter.SetNow(func() time.Time { return ut.now })
+
+	return ut
+}
+
+// evaluate stores delegations then evaluates the rules on them.
+func (ut *underTest) evaluate(t *testing.T, delegations ...*model.Delegation) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+	require.NoError(t, ut.alerter.Evaluate(ctx, delegations))
+}
+
+// alerts returns the alerts posted to the webhook notifier.
+func (ut *underTest) alerts(t *testing.T) []*model.Alert {
+	t.Helper()
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	alerts := make([]*model.Alert, len(ut.bodies["/hook"]))
+
+	for i, body := range ut.bodies["/hook"] {
+		require.NoError(t, json.Unmarshal(body, &alerts[i]))
+	}
+
+	return alerts
+}
+
+var (
+	small = &model.Delegation{
+		ID: 1, Timestamp: time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1small", Block: "block1", Baker: "baker1",
+	}
+	whale = &model.Delegation{
+		ID: 2, Timestamp: time.Date(2022, 12, 1, 11, 0, 0, 0, time.UTC), Amount: 1000,
+		Delegator: "tz1whale", Block: "block2", Baker: "baker1",
+	}
+	other = &model.Delegation{
+		ID: 3, Timestamp: time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC), Amount: 200,
+		Delegator: "tz1other", Block: "block3", Baker: "baker1",
+	}
+	// whaleLeaves is the redelegation of whale to baker2
+	whaleLeaves = &model.Delegation{
+		ID: 4, Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), Amount: 1000,
+		Delegator: "tz1whale", Block: "block4", Baker: "baker2", PreviousBaker: "baker1",
+	}
+	// otherLeaves is the undelegation of other
+	otherLeaves = &model.Delegation{
+		ID: 5, Timestamp: time.Date(2023, 1, 2, 11, 0, 0, 0, time.UTC), Amount: 200,
+		Delegator: "tz1other", Block: "block5", PreviousBaker: "baker1",
+	}
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := alert.New(&alert.Config{
+		Timeout:   time.Second,
+		Rules:     []alert.RuleConfig{{Name: "whales", Type: alert.RuleAmount, Threshold: 1, Notifiers: []string{"unknown"}}},
+		Notifiers: []alert.NotifierConfig{{Name: "log", Type: alert.NotifierLog}},
+	}, memory.New())
+	assert.EqualError(t, err, "unknown notifier unknown of alert rule whales")
+}
+
+func TestAlerter_EvaluateAmount(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, alert.RuleConfig{
+		Name: "whales", Type: alert.RuleAmount, Threshold: 200, Notifiers: []string{"hook", "slack"},
+	})
+
+	// the threshold is exclusive
+	ut.evaluate(t, small, whale, other)
+
+	alerts := ut.alerts(t)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, &model.Alert{
+		Rule:       "whales",
+		Key:        "delegation:2",
+		Type:       alert.RuleAmount,
+		Message:    "delegation of 1000 mutez from tz1whale to baker baker1",
+		Baker:      "baker1",
+		Delegation: whale,
+		FiredAt:    ut.now,
+	}, alerts[0])
+
+	require.Len(t, ut.bodies["/slack"], 1)
+	assert.JSONEq(
+		t,
+		`{"text":"[whales] delegation of 1000 mutez from tz1whale to baker baker1"}`,
+		string(ut.bodies["/slack"][0]),
+	)
+
+	// emitted once, even when its delegation is ingested again
+	ut.now = ut.now.Add(time.Hour)
+	ut.evaluate(t, whale)
+	assert.Len(t, ut.alerts(t), 1)
+
+	latest, err := ut.datastore.GetLatestAlert(context.Background(), "whales")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "delegation:2", latest.Key)
+}
+
+func TestAlerter_EvaluateCooldown(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, alert.RuleConfig{
+		Name: "whales", Type: alert.RuleAmount, Threshold: 99, Cooldown: time.Hour, Notifiers: []string{"hook"},
+	})
+
+	// the second delegation is within the cooldown of the first one
+	ut.evaluate(t, small, whale)
+	require.Len(t, ut.alerts(t), 1)
+	assert.Equal(t, "delegation:1", ut.alerts(t)[0].Key)
+
+	ut.now = ut.now.Add(30 * time.Minute)
+	ut.evaluate(t, other)
+	assert.Len(t, ut.alerts(t), 1)
+
+	ut.now = ut.now.Add(30 * time.Minute)
+	ut.evaluate(t, whaleLeaves)
+	require.Len(t, ut.alerts(t), 2)
+	assert.Equal(t, "delegation:4", ut.alerts(t)[1].Key)
+}
+
+func TestAlerter_EvaluateWatchedAddress(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, alert.RuleConfig{
+		Name: "watched", Type: alert.RuleWatchedAddress, Addresses: []string{"tz1whale", "tz1other"},
+		Notifiers: []string{"hook", "log"},
+	})
+
+	// delegating isn't redelegating
+	ut.evaluate(t, small, whale, other)
+	assert.Empty(t, ut.alerts(t))
+
+	ut.evaluate(t, whaleLeaves, otherLeaves)
+
+	alerts := ut.alerts(t)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "watched address tz1whale redelegated 1000 mutez from baker baker1 to baker baker2", alerts[0].Message)
+	assert.Equal(t, "baker1", alerts[0].Baker)
+	assert.Equal(t, "watched address tz1other undelegated 200 mutez from baker baker1 to baker ", alerts[1].Message)
+}
+
+func TestAlerter_EvaluateBakerOutflow(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, alert.RuleConfig{
+		Name: "outflow", Type: alert.RuleBakerOutflow, MaxOutflowPercent: 50, Window: 24 * time.Hour,
+		Notifiers: []string{"hook"},
+	})
+
+	// baker1 has 1300 delegated, losing 1000 of them is about 77%
+	ut.evaluate(t, small, whale, other)
+	ut.evaluate(t, whaleLeaves)
+
+	alerts := ut.alerts(t)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "baker:baker1:4", alerts[0].Key)
+	assert.Equal(t, "baker1", alerts[0].Baker)
+	assert.Equal(t, "baker baker1 lost 76.92% of its delegated balance (1000 mutez) within 24h0m0s", alerts[0].Message)
+
+	// the next outflow alerts again, as baker1 still lost more than 50% within the window
+	ut.evaluate(t, otherLeaves)
+
+	alerts = ut.alerts(t)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "baker:baker1:5", alerts[1].Key)
+	assert.Equal(t, "baker baker1 lost 92.31% of its delegated balance (1200 mutez) within 24h0m0s", alerts[1].Message)
+}
+
+func TestAlerter_EvaluateBakerOutflowBelowThreshold(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, alert.RuleConfig{
+		Name: "outflow", Type: alert.RuleBakerOutflow, MaxOutflowPercent: 80, Window: 30 * time.Minute,
+		Notifiers: []string{"hook"},
+	})
+
+	// baker1 loses about 77% of its balance, then 67% of the remaining balance within the window
+	ut.evaluate(t, small, whale, other)
+	ut.evaluate(t, whaleLeaves)
+	ut.evaluate(t, otherLeaves)
+
+	assert.Empty(t, ut.alerts(t))
+
+	// delegating to baker1 within the window offsets the loss
+	ut.evaluate(t, &model.Delegation{
+		ID: 6, Timestamp: time.Date(2023, 1, 2, 11, 10, 0, 0, time.UTC), Amount: 1000,
+		Delegator: "tz1new", Block: "block6", Baker: "baker1",
+	}, &model.Delegation{
+		ID: 7, Timestamp: time.Date(2023, 1, 2, 11, 20, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1small", Block: "block7", Baker: "baker2", PreviousBaker: "baker1",
+	})
+
+	assert.Empty(t, ut.alerts(t))
+}
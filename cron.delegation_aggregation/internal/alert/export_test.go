@@ -0,0 +1,8 @@
+package alert
+
+import "time"
+
+// SetNow sets the clock of the alerter.
+func (a *Alerter) SetNow(now func() time.Time) {
+	a.now = now
+}
@@ -0,0 +1,123 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Notifier types.
+const (
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+	NotifierSlack   = "slack"
+)
+
+// maxResponseSize is the maximum number of bytes of a response body read before closing it.
+const maxResponseSize = 64 << 10
+
+// NotifierConfig describes a notifier configuration.
+type NotifierConfig struct {
+	// Name is referenced by the rules notifying it.
+	Name string `validate:"required"`
+	// Type is the notifier type: log, webhook (the alert as JSON) or slack (a Slack-compatible incoming webhook).
+	Type string `validate:"oneof=log webhook slack"`
+	// URL is the URL the alerts are posted to, for webhook and slack notifiers.
+	URL string `validate:"required_unless=Type log,omitempty,url"`
+}
+
+// Notifier emits alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alert *model.Alert) error
+}
+
+// newNotifier creates the notifier of a configuration.
+func newNotifier(cfg *NotifierConfig, client *http.Client) Notifier {
+	switch cfg.Type {
+	case NotifierWebhook:
+		return &webhookNotifier{url: cfg.URL, client: client}
+	case NotifierSlack:
+		return &slackNotifier{url: cfg.URL, client: client}
+	default:
+		return logNotifier{}
+	}
+}
+
+// logNotifier logs the alerts.
+type logNotifier struct{}
+
+// Notify logs an alert.
+func (logNotifier) Notify(_ context.Context, alert *model.Alert) error {
+	zap.L().Warn(
+		alert.Message,
+		zap.String("rule", alert.Rule),
+		zap.String("type", alert.Type),
+		zap.String("baker", alert.Baker),
+		zap.Int64("delegationID", alert.Delegation.ID),
+	)
+
+	return nil
+}
+
+// webhookNotifier posts the alerts as JSON.
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// Notify posts an alert.
+func (n *webhookNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	return post(ctx, n.client, n.url, alert)
+}
+
+// slackNotifier posts the alerts messages to a Slack-compatible incoming webhook.
+type slackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// slackMessage is the body of a Slack incoming webhook request.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts an alert message.
+func (n *slackNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	return post(ctx, n.client, n.url, slackMessage{Text: fmt.Sprintf("[%s] %s", alert.Rule, alert.Message)})
+}
+
+// post posts a JSON body, failing unless the response status is 2xx.
+func post(ctx context.Context, client *http.Client, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -11,17 +11,39 @@ import (
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
 )
 
+// legacyBatchSize is the number of legacy delegations backfilled at once.
+const legacyBatchSize = 100
+
+// Config describes the delegation aggregation cron configuration.
+type Config struct {
+	// ReorgWindow is the trailing window of stored delegations fetched again at each run, from the latest stored
+	// delegation, to record the delegations updated or removed by a reorg. 0 disables it.
+	ReorgWindow time.Duration `validate:"gte=0"`
+}
+
 // Cron describes the delegation aggregation Cron.
 type Cron struct {
+	cfg          *Config
 	tezosService tezos.API
 	datastore    datastore.Datastorer
+	webhooks     WebhookDispatcher
+	alerts       Alerter
 }
 
 // New creates a new Cron.
-func New(tezosService tezos.API, datastore datastore.Datastorer) *Cron {
+func New(
+	cfg *Config,
+	tezosService tezos.API,
+	datastore datastore.Datastorer,
+	webhooks WebhookDispatcher,
+	alerts Alerter,
+) *Cron {
 	return &Cron{
+		cfg:          cfg,
 		tezosService: tezosService,
 		datastore:    datastore,
+		webhooks:     webhooks,
+		alerts:       alerts,
 	}
 }
 
@@ -36,6 +58,13 @@ func (c *Cron) Run() error {
 		return err
 	}
 
+	if latestDelegation != nil && c.cfg.ReorgWindow > 0 {
+		err = c.reconcileDelegations(ctx, latestDelegation.Timestamp.Add(-c.cfg.ReorgWindow))
+		if err != nil {
+			return err
+		}
+	}
+
 	zap.L().Info("list delegations from tezos service ...")
 
 	var latestTimestamp *time.Time
@@ -67,16 +96,236 @@ func (c *Cron) Run() error {
 	return nil
 }
 
+// reconcileDelegations fetches again the delegations since a timestamp, to update the stored delegations which
+// changed, e.g. included in another block, and delete the ones no longer applied, removed by a reorg. New
+// delegations are left to the ingestion, and the legacy delegations to their backfill.
+func (c *Cron) reconcileDelegations(ctx context.Context, from time.Time) error {
+	zap.L().Info("reconcile delegations from tezos service ...", zap.Time("from", from))
+
+	delegations, err := c.tezosService.ListDelegationsSince(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	stored, err := c.datastore.GetDelegations(
+		ctx,
+		datastore.Filter{From: from},
+		datastore.DelegationsSort{},
+		nil,
+		datastore.Page{},
+	)
+	if err != nil {
+		zap.L().Error("couldn't get delegations to reconcile from datastore", zap.Error(err))
+
+		return err
+	}
+
+	storedIDs := make(map[int64]bool, len(stored))
+	for _, delegation := range stored {
+		storedIDs[delegation.ID] = true
+	}
+
+	fetchedIDs := make(map[int64]bool, len(delegations))
+	updated := make([]*model.Delegation, 0, len(delegations))
+
+	for _, delegation := range toModels(delegations) {
+		fetchedIDs[delegation.ID] = true
+
+		// the datastore only records the delegations whose values changed
+		if storedIDs[delegation.ID] {
+			updated = append(updated, delegation)
+		}
+	}
+
+	if len(updated) > 0 {
+		if err := c.datastore.StoreDelegations(ctx, updated); err != nil {
+			zap.L().Error("couldn't store reconciled delegations in datastore", zap.Error(err))
+
+			return err
+		}
+	}
+
+	// a truncated window doesn't tell which delegations were removed
+	if len(delegations) >= tezos.MaxLimit {
+		zap.L().Warn("too many delegations to reconcile, removed delegations not checked", zap.Time("from", from))
+
+		return nil
+	}
+
+	var removed []int64
+
+	for _, delegation := range stored {
+		if delegation.ID > 0 && !fetchedIDs[delegation.ID] {
+			removed = append(removed, delegation.ID)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	zap.L().Info("delete delegations removed from tezos in datastore...", zap.Int64s("ids", removed))
+
+	if err := c.datastore.DeleteDelegations(ctx, removed); err != nil {
+		zap.L().Error("couldn't delete removed delegations in datastore", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// DispatchWebhooks sends the due webhooks deliveries: the delegations ingested since the previous run, and
+// the failed deliveries due for a retry.
+func (c *Cron) DispatchWebhooks() error {
+	zap.L().Info("dispatch webhooks deliveries...")
+
+	err := c.webhooks.Dispatch(context.Background())
+	if err != nil {
+		zap.L().Error("couldn't dispatch webhooks deliveries", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// RebuildCounts recomputes the delegations counters from scratch, in case they drifted.
+func (c *Cron) RebuildCounts() error {
+	zap.L().Info("rebuild delegations counts in datastore...")
+
+	err := c.datastore.RebuildCounts(context.Background())
+	if err != nil {
+		zap.L().Error("couldn't rebuild delegations counts in datastore", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// RebuildBakers recomputes the bakers aggregates and the current delegation of the delegators from scratch,
+// e.g. for the delegations stored before they were maintained.
+func (c *Cron) RebuildBakers() error {
+	zap.L().Info("rebuild bakers and delegators in datastore...")
+
+	err := c.datastore.RebuildBakers(context.Background())
+	if err != nil {
+		zap.L().Error("couldn't rebuild bakers and delegators in datastore", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// DeleteDelegations deletes stored delegations by id, e.g. operations removed from the chain by a reorg.
+// Deletions are recorded in the delegations change log.
+func (c *Cron) DeleteDelegations(ids []int64) error {
+	zap.L().Info("delete delegations in datastore...", zap.Int64s("ids", ids))
+
+	err := c.datastore.DeleteDelegations(context.Background(), ids)
+	if err != nil {
+		zap.L().Error("couldn't delete delegations in datastore", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// BackfillLegacyDelegations replaces the delegations stored before delegations were identified by their
+// operation id, which have a provisional id, with the delegations fetched again from tezos API at their
+// timestamps. The fetched delegations are stored with their id and bakers, and the legacy ones are deleted,
+// so that the delegations ingested again since are no longer duplicated. Historical delegations aren't alerted.
+func (c *Cron) BackfillLegacyDelegations() error {
+	ctx := context.Background()
+
+	zap.L().Info("backfill legacy delegations...")
+
+	for {
+		legacy, err := c.datastore.GetLegacyDelegations(ctx, legacyBatchSize)
+		if err != nil {
+			zap.L().Error("couldn't get legacy delegations from datastore", zap.Error(err))
+
+			return err
+		}
+
+		if len(legacy) == 0 {
+			return nil
+		}
+
+		timestamps := make([]time.Time, len(legacy))
+		ids := make([]int64, len(legacy))
+
+		for i, delegation := range legacy {
+			timestamps[i] = delegation.Timestamp
+			ids[i] = delegation.ID
+		}
+
+		delegations, err := c.tezosService.ListDelegationsAt(ctx, timestamps)
+		if err != nil {
+			return err
+		}
+
+		if len(delegations) > 0 {
+			if err := c.datastore.StoreDelegations(ctx, toModels(delegations)); err != nil {
+				zap.L().Error("couldn't store backfilled delegations in datastore", zap.Error(err))
+
+				return err
+			}
+		}
+
+		if err := c.datastore.DeleteDelegations(ctx, ids); err != nil {
+			zap.L().Error("couldn't delete legacy delegations in datastore", zap.Error(err))
+
+			return err
+		}
+
+		zap.L().Info(
+			"legacy delegations backfilled",
+			zap.Int("legacy", len(legacy)),
+			zap.Int("delegations", len(delegations)),
+		)
+	}
+}
+
 func (c *Cron) storeDelegations(ctx context.Context, tezosDelegations []*tezos.Delegation) error {
+	delegationModels := toModels(tezosDelegations)
+
+	if err := c.datastore.StoreDelegations(ctx, delegationModels); err != nil {
+		return err
+	}
+
+	// alerting doesn't fail the ingestion, the delegations being stored
+	if err := c.alerts.Evaluate(ctx, delegationModels); err != nil {
+		zap.L().Error("couldn't evaluate alert rules", zap.Error(err))
+	}
+
+	return nil
+}
+
+// toModels converts tezos API delegations to datastore delegations.
+func toModels(tezosDelegations []*tezos.Delegation) []*model.Delegation {
 	delegationModels := make([]*model.Delegation, len(tezosDelegations))
 	for i, tezosDelegation := range tezosDelegations {
 		delegationModels[i] = &model.Delegation{
+			ID:        tezosDelegation.ID,
 			Delegator: tezosDelegation.Sender.Address,
 			Block:     tezosDelegation.Block,
 			Amount:    tezosDelegation.Amount,
 			Timestamp: tezosDelegation.Timestamp,
+			Level:     tezosDelegation.Level,
+		}
+
+		if tezosDelegation.NewDelegate != nil {
+			delegationModels[i].Baker = tezosDelegation.NewDelegate.Address
+		}
+
+		if tezosDelegation.PrevDelegate != nil {
+			delegationModels[i].PreviousBaker = tezosDelegation.PrevDelegate.Address
 		}
 	}
 
-	return c.datastore.StoreDelegations(ctx, delegationModels)
+	return delegationModels
 }
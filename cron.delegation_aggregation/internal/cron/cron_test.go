@@ -9,8 +9,10 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/cron"
+	cronmock "github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/cron/mock"
 	"github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/tezos"
 	tezosmock "github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/tezos/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
 	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
 )
@@ -19,22 +21,35 @@ type underTest struct {
 	mockCtrl         *gomock.Controller
 	mockTezosService *tezosmock.MockAPI
 	mockDatastore    *datastoremock.MockDatastorer
+	mockWebhooks     *cronmock.MockWebhookDispatcher
+	mockAlerts       *cronmock.MockAlerter
 	cron             *cron.Cron
 }
 
 func setupTest(t *testing.T) *underTest {
 	t.Helper()
 
+	return setupTestWithConfig(t, &cron.Config{})
+}
+
+func setupTestWithConfig(t *testing.T, cfg *cron.Config) *underTest {
+	t.Helper()
+
 	ut := &underTest{}
 
 	ut.mockCtrl = gomock.NewController(t)
 
 	ut.mockTezosService = tezosmock.NewMockAPI(ut.mockCtrl)
 	ut.mockDatastore = datastoremock.NewMockDatastorer(ut.mockCtrl)
+	ut.mockWebhooks = cronmock.NewMockWebhookDispatcher(ut.mockCtrl)
+	ut.mockAlerts = cronmock.NewMockAlerter(ut.mockCtrl)
 
 	ut.cron = cron.New(
+		cfg,
 		ut.mockTezosService,
 		ut.mockDatastore,
+		ut.mockWebhooks,
+		ut.mockAlerts,
 	)
 
 	return ut
@@ -72,14 +87,23 @@ func TestCron_Run(t *testing.T) {
 					gomock.Nil(),
 				).After(getLatestDelegation).Return([]*tezos.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 1, 1, 17, 0, 0, 0, time.UTC),
 						Amount:    100,
 						Block:     "block2",
+						Level:     3000002,
 						Sender: tezos.Sender{
 							Address: "tz2",
 						},
+						PrevDelegate: &tezos.Delegate{
+							Address: "baker1",
+						},
+						NewDelegate: &tezos.Delegate{
+							Address: "baker2",
+						},
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2023, 1, 1, 16, 0, 0, 0, time.UTC),
 						Amount:    100,
 						Block:     "block1",
@@ -88,14 +112,18 @@ func TestCron_Run(t *testing.T) {
 						},
 					},
 				}, nil)
-				ut.mockDatastore.EXPECT().StoreDelegations(
+				storeDelegations := ut.mockDatastore.EXPECT().StoreDelegations(
 					gomock.Any(),
 					gomock.Eq(
 						[]*model.Delegation{
 							{
-								Delegator: "tz2",
-								Block:     "block2",
-								Amount:    100,
+								ID:            2,
+								Delegator:     "tz2",
+								Block:         "block2",
+								Amount:        100,
+								Level:         3000002,
+								Baker:         "baker2",
+								PreviousBaker: "baker1",
 								Timestamp: time.Date(
 									2023,
 									1,
@@ -108,6 +136,7 @@ func TestCron_Run(t *testing.T) {
 								),
 							},
 							{
+								ID:        1,
 								Delegator: "tz1",
 								Block:     "block1",
 								Amount:    100,
@@ -124,11 +153,12 @@ func TestCron_Run(t *testing.T) {
 							},
 						}),
 				).After(listDelegations).Return(nil)
+				ut.mockAlerts.EXPECT().Evaluate(gomock.Any(), gomock.Len(2)).After(storeDelegations).Return(nil)
 			},
 			wantErr: nil,
 		},
 		{
-			name: "Success second run",
+			name: "Success second run with an alert rules error",
 			init: func(ut *underTest) {
 				lastTimestamp := time.Date(
 					2023,
@@ -144,6 +174,7 @@ func TestCron_Run(t *testing.T) {
 				// One delegation in datastore
 				getLatestDelegation := ut.mockDatastore.EXPECT().GetLatestDelegation(gomock.Any()).
 					Return(&model.Delegation{
+						ID:        1,
 						Delegator: "tz1",
 						Block:     "block1",
 						Amount:    100,
@@ -154,6 +185,7 @@ func TestCron_Run(t *testing.T) {
 					gomock.Eq(&lastTimestamp),
 				).After(getLatestDelegation).Return([]*tezos.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 1, 1, 17, 0, 0, 0, time.UTC),
 						Amount:    100,
 						Block:     "block2",
@@ -162,11 +194,12 @@ func TestCron_Run(t *testing.T) {
 						},
 					},
 				}, nil)
-				ut.mockDatastore.EXPECT().StoreDelegations(
+				storeDelegations := ut.mockDatastore.EXPECT().StoreDelegations(
 					gomock.Any(),
 					gomock.Eq(
 						[]*model.Delegation{
 							{
+								ID:        2,
 								Delegator: "tz2",
 								Block:     "block2",
 								Amount:    100,
@@ -183,6 +216,8 @@ func TestCron_Run(t *testing.T) {
 							},
 						}),
 				).After(listDelegations).Return(nil)
+				// alerting doesn't fail the ingestion
+				ut.mockAlerts.EXPECT().Evaluate(gomock.Any(), gomock.Len(1)).After(storeDelegations).Return(errAny)
 			},
 			wantErr: nil,
 		},
@@ -217,6 +252,7 @@ func TestCron_Run(t *testing.T) {
 				// One delegation in datastore
 				getLatestDelegation := ut.mockDatastore.EXPECT().GetLatestDelegation(gomock.Any()).
 					Return(&model.Delegation{
+						ID:        1,
 						Delegator: "tz1",
 						Block:     "block1",
 						Amount:    100,
@@ -259,6 +295,7 @@ func TestCron_Run(t *testing.T) {
 					gomock.Nil(),
 				).After(latestDelegation).Return([]*tezos.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 1, 1, 17, 0, 0, 0, time.UTC),
 						Amount:    100,
 						Block:     "block2",
@@ -267,6 +304,7 @@ func TestCron_Run(t *testing.T) {
 						},
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2023, 1, 1, 16, 0, 0, 0, time.UTC),
 						Amount:    100,
 						Block:     "block1",
@@ -280,6 +318,7 @@ func TestCron_Run(t *testing.T) {
 					gomock.Eq(
 						[]*model.Delegation{
 							{
+								ID:        2,
 								Delegator: "tz2",
 								Block:     "block2",
 								Amount:    100,
@@ -295,6 +334,7 @@ func TestCron_Run(t *testing.T) {
 								),
 							},
 							{
+								ID:        1,
 								Delegator: "tz1",
 								Block:     "block1",
 								Amount:    100,
@@ -329,3 +369,372 @@ func TestCron_Run(t *testing.T) {
 		})
 	}
 }
+
+//nolint:funlen
+func TestCron_RunReconciles(t *testing.T) {
+	t.Parallel()
+
+	latest := &model.Delegation{
+		ID:        3,
+		Delegator: "tz3",
+		Block:     "block3",
+		Amount:    100,
+		Timestamp: time.Date(2023, 1, 1, 17, 0, 0, 0, time.UTC),
+	}
+	from := latest.Timestamp.Add(-time.Minute)
+	stored := []*model.Delegation{
+		latest,
+		{ID: 2, Delegator: "tz2", Block: "block2", Amount: 100, Timestamp: from.Add(time.Second)},
+		// legacy delegations are left to their backfill
+		{ID: -from.UnixMilli(), Delegator: "tz1", Block: "block1", Amount: 100, Timestamp: from},
+	}
+	// the latest delegation is now included in another block, the delegation 2 was removed by a reorg
+	fetched := []*tezos.Delegation{
+		{ID: 3, Timestamp: latest.Timestamp, Amount: 100, Block: "block4", Sender: tezos.Sender{Address: "tz3"}},
+	}
+
+	cases := []struct {
+		name    string
+		init    func(*underTest)
+		wantErr error
+	}{
+		{
+			name: "Success",
+			init: func(ut *underTest) {
+				getLatestDelegation := ut.mockDatastore.EXPECT().GetLatestDelegation(gomock.Any()).
+					Return(latest, nil)
+				listDelegationsSince := ut.mockTezosService.EXPECT().ListDelegationsSince(gomock.Any(), from).
+					After(getLatestDelegation).Return(fetched, nil)
+				getDelegations := ut.mockDatastore.EXPECT().GetDelegations(
+					gomock.Any(),
+					datastore.Filter{From: from},
+					datastore.DelegationsSort{},
+					gomock.Nil(),
+					datastore.Page{},
+				).After(listDelegationsSince).Return(stored, nil)
+				storeDelegations := ut.mockDatastore.EXPECT().StoreDelegations(
+					gomock.Any(),
+					[]*model.Delegation{
+						{ID: 3, Delegator: "tz3", Block: "block4", Amount: 100, Timestamp: latest.Timestamp},
+					},
+				).After(getDelegations).Return(nil)
+				deleteDelegations := ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), []int64{2}).
+					After(storeDelegations).Return(nil)
+				ut.mockTezosService.EXPECT().ListDelegations(gomock.Any(), &latest.Timestamp).
+					After(deleteDelegations).Return([]*tezos.Delegation{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Success truncated window",
+			init: func(ut *underTest) {
+				truncated := make([]*tezos.Delegation, tezos.MaxLimit)
+				for i := range truncated {
+					truncated[i] = &tezos.Delegation{ID: int64(i + 10), Timestamp: from}
+				}
+
+				ut.mockDatastore.EXPECT().GetLatestDelegation(gomock.Any()).Return(latest, nil)
+				ut.mockTezosService.EXPECT().ListDelegationsSince(gomock.Any(), from).Return(truncated, nil)
+				ut.mockDatastore.EXPECT().
+					GetDelegations(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(stored, nil)
+				// no delegation is deleted
+				ut.mockTezosService.EXPECT().ListDelegations(gomock.Any(), &latest.Timestamp).
+					Return([]*tezos.Delegation{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Error ListDelegationsSince",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetLatestDelegation(gomock.Any()).Return(latest, nil)
+				ut.mockTezosService.EXPECT().ListDelegationsSince(gomock.Any(), from).Return(nil, errAny)
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "Error DeleteDelegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetLatestDelegation(gomock.Any()).Return(latest, nil)
+				ut.mockTezosService.EXPECT().ListDelegationsSince(gomock.Any(), from).Return(fetched, nil)
+				ut.mockDatastore.EXPECT().
+					GetDelegations(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(stored, nil)
+				ut.mockDatastore.EXPECT().StoreDelegations(gomock.Any(), gomock.Len(1)).Return(nil)
+				ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), []int64{2}).Return(errAny)
+			},
+			wantErr: errAny,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTestWithConfig(t, &cron.Config{ReorgWindow: time.Minute})
+			c.init(ut)
+
+			assert.Equal(t, c.wantErr, ut.cron.Run())
+		})
+	}
+}
+
+func TestCron_RebuildCounts(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		init    func(*underTest)
+		wantErr error
+	}{
+		{
+			name: "Success",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().RebuildCounts(gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Error rebuilding counts",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().RebuildCounts(gomock.Any()).Return(errAny)
+			},
+			wantErr: errAny,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			assert.Equal(t, c.wantErr, ut.cron.RebuildCounts())
+		})
+	}
+}
+
+func TestCron_RebuildBakers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		init    func(*underTest)
+		wantErr error
+	}{
+		{
+			name: "Success",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().RebuildBakers(gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Error rebuilding bakers",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().RebuildBakers(gomock.Any()).Return(errAny)
+			},
+			wantErr: errAny,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			assert.Equal(t, c.wantErr, ut.cron.RebuildBakers())
+		})
+	}
+}
+
+func TestCron_DeleteDelegations(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		init    func(*underTest)
+		wantErr error
+	}{
+		{
+			name: "Success",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), gomock.Eq([]int64{1, 2})).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Error deleting delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), gomock.Any()).Return(errAny)
+			},
+			wantErr: errAny,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			assert.Equal(t, c.wantErr, ut.cron.DeleteDelegations([]int64{1, 2}))
+		})
+	}
+}
+
+func TestCron_BackfillLegacyDelegations(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC)
+	legacy := []*model.Delegation{
+		{
+			ID:        -timestamp.UnixMilli(),
+			Timestamp: timestamp,
+			Amount:    124428330,
+			Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
+			Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+		},
+	}
+
+	cases := []struct {
+		name    string
+		init    func(*underTest)
+		wantErr error
+	}{
+		{
+			name: "Success",
+			init: func(ut *underTest) {
+				gomock.InOrder(
+					ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), 100).Return(legacy, nil),
+					ut.mockTezosService.EXPECT().ListDelegationsAt(gomock.Any(), []time.Time{timestamp}).
+						Return([]*tezos.Delegation{
+							{
+								ID:          1098907648,
+								Timestamp:   timestamp,
+								Amount:      124428330,
+								Sender:      tezos.Sender{Address: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx"},
+								Block:       "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+								Level:       4920311,
+								NewDelegate: &tezos.Delegate{Address: "tz1aRoaRhSpRYvFdyvgWLL6TGyRoGF51wDjM"},
+							},
+						}, nil),
+					ut.mockDatastore.EXPECT().StoreDelegations(gomock.Any(), []*model.Delegation{
+						{
+							ID:        1098907648,
+							Timestamp: timestamp,
+							Amount:    124428330,
+							Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
+							Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+							Level:     4920311,
+							Baker:     "tz1aRoaRhSpRYvFdyvgWLL6TGyRoGF51wDjM",
+						},
+					}).Return(nil),
+					ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), []int64{-timestamp.UnixMilli()}).
+						Return(nil),
+					ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), 100).Return(nil, nil),
+				)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Success without fetched delegation",
+			init: func(ut *underTest) {
+				gomock.InOrder(
+					ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), 100).Return(legacy, nil),
+					ut.mockTezosService.EXPECT().ListDelegationsAt(gomock.Any(), gomock.Any()).Return(nil, nil),
+					ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), gomock.Any()).Return(nil),
+					ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), 100).Return(nil, nil),
+				)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Error getting legacy delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), gomock.Any()).Return(nil, errAny)
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "Error listing delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), gomock.Any()).Return(legacy, nil)
+				ut.mockTezosService.EXPECT().ListDelegationsAt(gomock.Any(), gomock.Any()).Return(nil, errAny)
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "Error deleting legacy delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetLegacyDelegations(gomock.Any(), gomock.Any()).Return(legacy, nil)
+				ut.mockTezosService.EXPECT().ListDelegationsAt(gomock.Any(), gomock.Any()).Return(nil, nil)
+				ut.mockDatastore.EXPECT().DeleteDelegations(gomock.Any(), gomock.Any()).Return(errAny)
+			},
+			wantErr: errAny,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			assert.Equal(t, c.wantErr, ut.cron.BackfillLegacyDelegations())
+		})
+	}
+}
+
+func TestCron_DispatchWebhooks(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		init    func(*underTest)
+		wantErr error
+	}{
+		{
+			name: "Success",
+			init: func(ut *underTest) {
+				ut.mockWebhooks.EXPECT().Dispatch(gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Error dispatching webhooks",
+			init: func(ut *underTest) {
+				ut.mockWebhooks.EXPECT().Dispatch(gomock.Any()).Return(errAny)
+			},
+			wantErr: errAny,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			assert.Equal(t, c.wantErr, ut.cron.DispatchWebhooks())
+		})
+	}
+}
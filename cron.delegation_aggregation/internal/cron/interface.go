@@ -0,0 +1,17 @@
+package cron
+
+import (
+	"context"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// WebhookDispatcher describes the dispatcher of the webhooks deliveries.
+type WebhookDispatcher interface {
+	Dispatch(ctx context.Context) error
+}
+
+// Alerter describes the evaluator of the alert rules on the ingested delegations.
+type Alerter interface {
+	Evaluate(ctx context.Context, delegations []*model.Delegation) error
+}
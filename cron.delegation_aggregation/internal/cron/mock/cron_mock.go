@@ -0,0 +1,91 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/cron (interfaces: WebhookDispatcher,Alerter)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./internal/cron/mock/cron_mock.go -package=mock_cron github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/cron WebhookDispatcher,Alerter
+//
+// Package mock_cron is a generated GoMock package.
+package mock_cron
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWebhookDispatcher is a mock of WebhookDispatcher interface.
+type MockWebhookDispatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookDispatcherMockRecorder
+}
+
+// MockWebhookDispatcherMockRecorder is the mock recorder for MockWebhookDispatcher.
+type MockWebhookDispatcherMockRecorder struct {
+	mock *MockWebhookDispatcher
+}
+
+// NewMockWebhookDispatcher creates a new mock instance.
+func NewMockWebhookDispatcher(ctrl *gomock.Controller) *MockWebhookDispatcher {
+	mock := &MockWebhookDispatcher{ctrl: ctrl}
+	mock.recorder = &MockWebhookDispatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookDispatcher) EXPECT() *MockWebhookDispatcherMockRecorder {
+	return m.recorder
+}
+
+// Dispatch mocks base method.
+func (m *MockWebhookDispatcher) Dispatch(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dispatch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockWebhookDispatcherMockRecorder) Dispatch(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockWebhookDispatcher)(nil).Dispatch), arg0)
+}
+
+// MockAlerter is a mock of Alerter interface.
+type MockAlerter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlerterMockRecorder
+}
+
+// MockAlerterMockRecorder is the mock recorder for MockAlerter.
+type MockAlerterMockRecorder struct {
+	mock *MockAlerter
+}
+
+// NewMockAlerter creates a new mock instance.
+func NewMockAlerter(ctrl *gomock.Controller) *MockAlerter {
+	mock := &MockAlerter{ctrl: ctrl}
+	mock.recorder = &MockAlerterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlerter) EXPECT() *MockAlerterMockRecorder {
+	return m.recorder
+}
+
+// Evaluate mocks base method.
+func (m *MockAlerter) Evaluate(arg0 context.Context, arg1 []*model.Delegation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Evaluate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Evaluate indicates an expected call of Evaluate.
+func (mr *MockAlerterMockRecorder) Evaluate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Evaluate", reflect.TypeOf((*MockAlerter)(nil).Evaluate), arg0, arg1)
+}
@@ -3,6 +3,8 @@ package tezos
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -17,20 +19,39 @@ type Sender struct {
 	Address string `json:"address"`
 }
 
+// Delegate describes a baker (delegate) in tezos API.
+type Delegate struct {
+	Address string `json:"address"`
+}
+
 // Delegation represents the tezos delegation model.
 type Delegation struct {
+	ID        int64 `json:"id"`
 	Timestamp time.Time
 	Amount    int64  `json:"amount"`
 	Sender    Sender `json:"sender"`
 	Block     string `json:"block"`
+	Level     int64  `json:"level"`
+	// PrevDelegate is the baker delegated to before, nil if none.
+	PrevDelegate *Delegate `json:"prevDelegate"`
+	// NewDelegate is the baker delegated to, nil when undelegating.
+	NewDelegate *Delegate `json:"newDelegate"`
 }
 
+// MaxLimit is the maximum number of operations returned by a tezos API request.
+const MaxLimit = 10000
+
+// delegationFields are the selected fields of the delegations.
+const delegationFields = "id,timestamp,amount,sender,block,level,prevDelegate,newDelegate"
+
 // ListDelegations returns delegations list.
 func (c *Client) ListDelegations(ctx context.Context, fromTimestamp *time.Time) ([]*Delegation, error) {
 	params := map[string]string{}
 	// select only needed fields
-	params["select"] = "timestamp,amount,sender,block"
+	params["select"] = delegationFields
 	params["limit"] = "100"
+	// failed, backtracked and skipped delegations don't change the delegations state
+	params["status"] = "applied"
 
 	if fromTimestamp != nil {
 		// filter by timestamp greater than fromTimestamp
@@ -41,6 +62,39 @@ func (c *Client) ListDelegations(ctx context.Context, fromTimestamp *time.Time)
 		params["sort.desc"] = "id"
 	}
 
+	return c.listDelegations(ctx, params)
+}
+
+// ListDelegationsSince returns the applied delegations with a timestamp greater than or equal to fromTimestamp,
+// by id, at most MaxLimit.
+func (c *Client) ListDelegationsSince(ctx context.Context, fromTimestamp time.Time) ([]*Delegation, error) {
+	return c.listDelegations(ctx, map[string]string{
+		"select":       delegationFields,
+		"limit":        strconv.Itoa(MaxLimit),
+		"status":       "applied",
+		"timestamp.ge": fromTimestamp.UTC().Format(time.RFC3339),
+		"sort.asc":     "id",
+	})
+}
+
+// ListDelegationsAt returns the applied delegations at the given timestamps, by id.
+func (c *Client) ListDelegationsAt(ctx context.Context, timestamps []time.Time) ([]*Delegation, error) {
+	values := make([]string, len(timestamps))
+	for i, timestamp := range timestamps {
+		values[i] = timestamp.UTC().Format(time.RFC3339)
+	}
+
+	return c.listDelegations(ctx, map[string]string{
+		"select":       delegationFields,
+		"limit":        strconv.Itoa(MaxLimit),
+		"status":       "applied",
+		"timestamp.in": strings.Join(values, ","),
+		"sort.asc":     "id",
+	})
+}
+
+// listDelegations returns the delegations matching the query params.
+func (c *Client) listDelegations(ctx context.Context, params map[string]string) ([]*Delegation, error) {
 	delegations := []*Delegation{}
 
 	resp, err := c.C().R().
@@ -30,18 +30,28 @@ func TestTezos_ListDelegations(t *testing.T) {
 			init: func(ut *underTest) {
 				ut.mockTransport.RegisterResponder(http.MethodGet,
 					"https://api.tezos.test/v1/operations/delegations"+
-						"?limit=100&select=timestamp%2Camount%2Csender%2Cblock&sort.desc=id",
+						"?limit=100&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate&sort.desc=id&status=applied",
 					httpmock.NewStringResponder(http.StatusOK, `
 						[
 							{
+								"id": 1098907648,
 								"timestamp": "2023-12-10T11:01:01Z",
 								"amount": 124428330,
 								"sender": {
 									"address": "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx"
 								},
-								"block": "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG"
+								"block": "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+								"level": 4920311,
+								"prevDelegate": {
+									"alias": "Baking Benjamins",
+									"address": "tz1S5WxdZR5f9NzsPXhr7L9L1vrEb5spZFur"
+								},
+								"newDelegate": {
+									"address": "tz1aRoaRhSpRYvFdyvgWLL6TGyRoGF51wDjM"
+								}
 							},
 							{
+								"id": 1098907647,
 								"timestamp": "2023-12-10T11:00:01Z",
 								"amount": 499836,
 								"sender": {
@@ -54,14 +64,23 @@ func TestTezos_ListDelegations(t *testing.T) {
 			},
 			want: []*tezos.Delegation{
 				{
+					ID:        1098907648,
 					Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 					Amount:    124428330,
 					Sender: tezos.Sender{
 						Address: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 					},
 					Block: "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+					Level: 4920311,
+					PrevDelegate: &tezos.Delegate{
+						Address: "tz1S5WxdZR5f9NzsPXhr7L9L1vrEb5spZFur",
+					},
+					NewDelegate: &tezos.Delegate{
+						Address: "tz1aRoaRhSpRYvFdyvgWLL6TGyRoGF51wDjM",
+					},
 				},
 				{
+					ID:        1098907647,
 					Timestamp: time.Date(2023, 12, 10, 11, 0, 1, 0, time.UTC),
 					Amount:    499836,
 					Sender: tezos.Sender{
@@ -78,7 +97,7 @@ func TestTezos_ListDelegations(t *testing.T) {
 			init: func(ut *underTest) {
 				ut.mockTransport.RegisterResponder(http.MethodGet,
 					"https://api.tezos.test/v1/operations/delegations"+
-						"?limit=100&select=timestamp%2Camount%2Csender%2Cblock&sort.desc=id",
+						"?limit=100&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate&sort.desc=id&status=applied",
 					func(req *http.Request) (*http.Response, error) {
 						return nil, terrs.NewTestError()
 					})
@@ -89,7 +108,7 @@ func TestTezos_ListDelegations(t *testing.T) {
 				&url.Error{
 					Op: "Get",
 					URL: "https://api.tezos.test/v1/operations/delegations" +
-						"?limit=100&select=timestamp%2Camount%2Csender%2Cblock&sort.desc=id",
+						"?limit=100&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate&sort.desc=id&status=applied",
 					Err: terrs.NewTestError(),
 				},
 			),
@@ -99,10 +118,11 @@ func TestTezos_ListDelegations(t *testing.T) {
 			init: func(ut *underTest) {
 				ut.mockTransport.RegisterResponder(http.MethodGet,
 					"https://api.tezos.test/v1/operations/delegations"+
-						"?limit=100&select=timestamp%2Camount%2Csender%2Cblock&sort.desc=id",
+						"?limit=100&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate&sort.desc=id&status=applied",
 					httpmock.NewStringResponder(http.StatusOK, `
 						[
 							{
+								"id": 1098907648,
 								"timestamp": "2023-12-10T11:01:01Z",
 								"amount": 124428330,
 								"sender": {
@@ -111,6 +131,7 @@ func TestTezos_ListDelegations(t *testing.T) {
 								"block": "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG"
 							},
 							{
+								"id": 1098907647,
 								"timestamp": "2023-12-10T11:00:01Z",
 								"amount": 499836,
 								"sender": {
@@ -134,7 +155,7 @@ func TestTezos_ListDelegations(t *testing.T) {
 			init: func(ut *underTest) {
 				ut.mockTransport.RegisterResponder(http.MethodGet,
 					"https://api.tezos.test/v1/operations/delegations"+
-						"?limit=100&select=timestamp%2Camount%2Csender%2Cblock&sort.desc=id",
+						"?limit=100&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate&sort.desc=id&status=applied",
 					func(req *http.Request) (*http.Response, error) {
 						return httpmock.NewJsonResponse(http.StatusInternalServerError, map[string]string{
 							"code": "500",
@@ -167,3 +188,88 @@ func TestTezos_ListDelegations(t *testing.T) {
 		})
 	}
 }
+
+func TestTezos_ListDelegationsAt(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, nil)
+	defer ut.mockTransport.Reset()
+
+	ut.mockTransport.RegisterResponder(http.MethodGet,
+		"https://api.tezos.test/v1/operations/delegations"+
+			"?limit=10000&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate"+
+			"&sort.asc=id&status=applied&timestamp.in=2023-12-10T11%3A00%3A01Z%2C2023-12-10T11%3A01%3A01Z",
+		httpmock.NewStringResponder(http.StatusOK, `
+			[
+				{
+					"id": 1098907648,
+					"timestamp": "2023-12-10T11:01:01Z",
+					"amount": 124428330,
+					"sender": {
+						"address": "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx"
+					},
+					"block": "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+					"level": 4920311
+				}]
+		`))
+
+	resp, err := ut.client.ListDelegationsAt(context.Background(), []time.Time{
+		time.Date(2023, 12, 10, 11, 0, 1, 0, time.UTC),
+		time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*tezos.Delegation{
+		{
+			ID:        1098907648,
+			Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
+			Amount:    124428330,
+			Sender: tezos.Sender{
+				Address: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
+			},
+			Block: "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+			Level: 4920311,
+		},
+	}, resp)
+}
+
+func TestTezos_ListDelegationsSince(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, nil)
+	defer ut.mockTransport.Reset()
+
+	ut.mockTransport.RegisterResponder(http.MethodGet,
+		"https://api.tezos.test/v1/operations/delegations"+
+			"?limit=10000&select=id%2Ctimestamp%2Camount%2Csender%2Cblock%2Clevel%2CprevDelegate%2CnewDelegate"+
+			"&sort.asc=id&status=applied&timestamp.ge=2023-12-10T11%3A00%3A01Z",
+		httpmock.NewStringResponder(http.StatusOK, `
+			[
+				{
+					"id": 1098907648,
+					"timestamp": "2023-12-10T11:01:01Z",
+					"amount": 124428330,
+					"sender": {
+						"address": "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx"
+					},
+					"block": "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+					"level": 4920311
+				}]
+		`))
+
+	resp, err := ut.client.ListDelegationsSince(context.Background(), time.Date(2023, 12, 10, 11, 0, 1, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*tezos.Delegation{
+		{
+			ID:        1098907648,
+			Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
+			Amount:    124428330,
+			Sender: tezos.Sender{
+				Address: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
+			},
+			Block: "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+			Level: 4920311,
+		},
+	}, resp)
+}
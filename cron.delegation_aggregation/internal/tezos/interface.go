@@ -11,4 +11,6 @@ import (
 type API interface {
 	http.Client
 	ListDelegations(ctx context.Context, fromTimestamp *time.Time) ([]*Delegation, error)
+	ListDelegationsSince(ctx context.Context, fromTimestamp time.Time) ([]*Delegation, error)
+	ListDelegationsAt(ctx context.Context, timestamps []time.Time) ([]*Delegation, error)
 }
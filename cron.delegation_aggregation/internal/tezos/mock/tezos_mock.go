@@ -81,3 +81,33 @@ func (mr *MockAPIMockRecorder) ListDelegations(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegations", reflect.TypeOf((*MockAPI)(nil).ListDelegations), arg0, arg1)
 }
+
+// ListDelegationsAt mocks base method.
+func (m *MockAPI) ListDelegationsAt(arg0 context.Context, arg1 []time.Time) ([]*tezos.Delegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDelegationsAt", arg0, arg1)
+	ret0, _ := ret[0].([]*tezos.Delegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDelegationsAt indicates an expected call of ListDelegationsAt.
+func (mr *MockAPIMockRecorder) ListDelegationsAt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegationsAt", reflect.TypeOf((*MockAPI)(nil).ListDelegationsAt), arg0, arg1)
+}
+
+// ListDelegationsSince mocks base method.
+func (m *MockAPI) ListDelegationsSince(arg0 context.Context, arg1 time.Time) ([]*tezos.Delegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDelegationsSince", arg0, arg1)
+	ret0, _ := ret[0].([]*tezos.Delegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDelegationsSince indicates an expected call of ListDelegationsSince.
+func (mr *MockAPIMockRecorder) ListDelegationsSince(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegationsSince", reflect.TypeOf((*MockAPI)(nil).ListDelegationsSince), arg0, arg1)
+}
@@ -18,5 +18,12 @@ func init() {
 			Interface: []string{"API"},
 			Pkg:       "github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/tezos",
 		},
+		{
+			Name:      "cron",
+			Type:      gen.Mock,
+			Dest:      "./internal/cron",
+			Interface: []string{"WebhookDispatcher", "Alerter"},
+			Pkg:       "github.com/guillaumedebavelaere/tezos-delegation/cron.delegation_aggregation/internal/cron",
+		},
 	}
 }
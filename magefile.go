@@ -25,6 +25,13 @@ var genFiles = []*gen.File{
 		Interface: []string{"Datastorer"},
 		Pkg:       "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore",
 	},
+	{
+		Name:      "client",
+		Type:      gen.Mock,
+		Dest:      "./pkg/client",
+		Interface: []string{"API"},
+		Pkg:       "github.com/guillaumedebavelaere/tezos-delegation/pkg/client",
+	},
 }
 
 // Help prints the help message.
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	bakersResource          = "xtz/bakers"
+	bakerResource           = "xtz/bakers/{address}"
+	bakerDelegatorsResource = "xtz/bakers/{address}/delegators"
+)
+
+// BakersPage is a page of bakers.
+type BakersPage struct {
+	Bakers     []*model.Baker
+	TotalPages int
+}
+
+// ListBakers returns a page of bakers, sorted by sort (delegators or delegatedAmount, prefixed by - for a
+// descending order).
+func (c *Client) ListBakers(ctx context.Context, sort string, page Page) (*BakersPage, error) {
+	params := page.values()
+	setString(params, "sort", sort)
+
+	bakers := &BakersPage{}
+
+	resp, err := send(c.request(ctx, nil, params), http.MethodGet, bakersResource, &bakers.Bakers)
+	if err != nil {
+		return nil, err
+	}
+
+	bakers.TotalPages, _ = strconv.Atoi(resp.GetHeader("X-Total-Pages"))
+
+	return bakers, nil
+}
+
+// GetBaker returns the baker of address.
+func (c *Client) GetBaker(ctx context.Context, address string) (*model.Baker, error) {
+	baker := &model.Baker{}
+
+	_, err := send(c.request(ctx, map[string]string{"address": address}, nil), http.MethodGet, bakerResource, baker)
+	if err != nil {
+		return nil, err
+	}
+
+	return baker, nil
+}
+
+// ListBakerDelegators returns a page of the delegators of the baker of address, as of asOf (a RFC3339
+// timestamp or a block level) when not empty.
+func (c *Client) ListBakerDelegators(
+	ctx context.Context,
+	address, asOf string,
+	page Page,
+) ([]*model.Delegator, error) {
+	params := page.values()
+	setString(params, "as_of", asOf)
+
+	delegators := []*model.Delegator{}
+
+	_, err := send(
+		c.request(ctx, map[string]string{"address": address}, params),
+		http.MethodGet,
+		bakerDelegatorsResource,
+		&delegators,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return delegators, nil
+}
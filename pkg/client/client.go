@@ -0,0 +1,82 @@
+// Package client is the Go client of the delegation API.
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/imroc/req/v3"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/http"
+)
+
+// Config defines the delegation API client configuration, the base URL being the API root URL.
+type Config struct {
+	HTTP http.ClientConfig `mapstructure:",squash"`
+	// APIKey is the bearer token of the webhooks requests, which are unauthorized without it.
+	APIKey string
+}
+
+// Client represents the delegation API client.
+type Client struct {
+	http.Client
+	cfg *Config
+}
+
+// NewClient creates a new delegation API client.
+func NewClient(cfg *Config, options ...http.Option) API {
+	return &Client{
+		Client: http.NewClient(&cfg.HTTP, options...),
+		cfg:    cfg,
+	}
+}
+
+// Init initializes the delegation API client.
+func (c *Client) Init() {
+	c.Client.Init()
+
+	if c.cfg.APIKey != "" {
+		c.C().SetCommonBearerAuthToken(c.cfg.APIKey)
+	}
+}
+
+// request creates a request of the resource, its {name} path parameters being replaced by pathParams.
+func (c *Client) request(ctx context.Context, pathParams map[string]string, query url.Values) *req.Request {
+	r := c.C().R().
+		SetContext(ctx).
+		SetPathParams(pathParams)
+
+	for name, values := range query {
+		for _, value := range values {
+			r.AddQueryParam(name, value)
+		}
+	}
+
+	return r
+}
+
+// send sends the request, decoding the response body into result when not nil. Error responses are returned
+// as an *Error.
+func send(r *req.Request, method, resource string, result any) (*req.Response, error) {
+	if result != nil {
+		r.SetSuccessResult(result)
+	}
+
+	resp, err := r.Send(method, resource)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't %s %s: %w", method, resource, err)
+	}
+
+	if resp.IsErrorState() {
+		// the body isn't read yet when the response is streamed
+		body, err := resp.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %s %s error response: %w", method, resource, err)
+		}
+
+		return nil, newError(resp.StatusCode, body)
+	}
+
+	return resp, nil
+}
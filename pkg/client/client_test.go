@@ -0,0 +1,211 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/client"
+	pkghttp "github.com/guillaumedebavelaere/tezos-delegation/pkg/http"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/terrs"
+)
+
+type underTest struct {
+	client        client.API
+	mockTransport *httpmock.MockTransport
+}
+
+func setupTest(t *testing.T) *underTest {
+	t.Helper()
+
+	ut := &underTest{}
+
+	ut.mockTransport = httpmock.NewMockTransport()
+
+	ut.client = client.NewClient(&client.Config{
+		HTTP: pkghttp.ClientConfig{
+			BaseURL: "https://api.delegation.test",
+			Timeout: 5 * time.Second,
+		},
+	},
+		pkghttp.WithTransport(ut.mockTransport),
+	)
+
+	ut.client.Init()
+
+	return ut
+}
+
+func TestClient_NewClient(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, client.NewClient(&client.Config{}))
+}
+
+func TestClient_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		responder httpmock.Responder
+		wantErr   error
+		wantIs    error
+		// wantTransportErr is true when the transport error is returned
+		wantTransportErr bool
+	}{
+		{
+			name: "Problem",
+			responder: httpmock.NewStringResponder(
+				http.StatusNotFound,
+				`{"type":"about:blank","title":"Not Found","status":404,"detail":"baker not found","code":"not_found"}`,
+			).HeaderSet(http.Header{"Content-Type": {"application/problem+json"}}),
+			wantErr: &client.Error{StatusCode: 404, Title: "Not Found", Detail: "baker not found", Code: "not_found"},
+			wantIs:  client.ErrNotFound,
+		},
+		{
+			name:      "Not a problem",
+			responder: httpmock.NewStringResponder(http.StatusBadGateway, "bad gateway"),
+			wantErr:   &client.Error{StatusCode: 502, Title: "Bad Gateway", Detail: "bad gateway"},
+		},
+		{
+			name:             "Transport error",
+			responder:        httpmock.NewErrorResponder(terrs.NewTestError()),
+			wantTransportErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			ut.mockTransport.RegisterResponder(
+				http.MethodGet,
+				"https://api.delegation.test/xtz/bakers/tz1unknown",
+				c.responder,
+			)
+
+			baker, err := ut.client.GetBaker(context.Background(), "tz1unknown")
+			require.Error(t, err)
+			assert.Nil(t, baker)
+
+			if c.wantErr != nil {
+				assert.Equal(t, c.wantErr, err)
+			}
+
+			if c.wantIs != nil {
+				assert.ErrorIs(t, err, c.wantIs)
+			}
+
+			if c.wantTransportErr {
+				assert.ErrorAs(t, err, new(*terrs.TestError))
+			}
+
+			assert.False(t, errors.Is(err, client.ErrInternalError))
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(
+		t,
+		"delegation api error 400: Bad Request: invalid year",
+		(&client.Error{StatusCode: 400, Title: "Bad Request", Detail: "invalid year"}).Error(),
+	)
+	assert.Equal(
+		t,
+		"delegation api error 502: Bad Gateway",
+		(&client.Error{StatusCode: 502, Title: "Bad Gateway"}).Error(),
+	)
+}
+
+func TestClient_APIKey(t *testing.T) {
+	t.Parallel()
+
+	mockTransport := httpmock.NewMockTransport()
+	mockTransport.RegisterResponder(
+		http.MethodGet,
+		"https://api.delegation.test/xtz/webhooks",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer key1" {
+				return httpmock.NewStringResponse(http.StatusUnauthorized, `{"code":"unauthorized"}`), nil
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, `[]`), nil
+		},
+	)
+
+	apiClient := client.NewClient(&client.Config{
+		HTTP: pkghttp.ClientConfig{
+			BaseURL: "https://api.delegation.test",
+			Timeout: 5 * time.Second,
+		},
+		APIKey: "key1",
+	},
+		pkghttp.WithTransport(mockTransport),
+	)
+	apiClient.Init()
+
+	webhooks, err := apiClient.ListWebhooks(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestClient_ListDelegationsAmount(t *testing.T) {
+	t.Parallel()
+
+	amount := func(amount int64) *int64 {
+		return &amount
+	}
+
+	cases := []struct {
+		name   string
+		filter client.DelegationsFilter
+		want   string
+	}{
+		{name: "No amount", filter: client.DelegationsFilter{}, want: ""},
+		{name: "Zero amount", filter: client.DelegationsFilter{AmountGt: amount(0)}, want: "amount.gt=0"},
+		{
+			name:   "Amount range",
+			filter: client.DelegationsFilter{AmountGt: amount(10), AmountLt: amount(100)},
+			want:   "amount.gt=10&amount.lt=100",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+
+			var query string
+
+			ut.mockTransport.RegisterResponder(
+				http.MethodGet,
+				"https://api.delegation.test/v1/delegations",
+				func(req *http.Request) (*http.Response, error) {
+					query = req.URL.RawQuery
+
+					return httpmock.NewStringResponse(http.StatusOK, `{"data":[]}`), nil
+				},
+			)
+
+			_, err := ut.client.ListDelegations(
+				context.Background(),
+				&client.DelegationsQuery{DelegationsFilter: c.filter},
+			)
+			require.NoError(t, err)
+			assert.Equal(t, c.want, query)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	delegationsResource        = "v1/delegations"
+	delegationsChangesResource = "v1/delegations/changes"
+	delegationsExportResource  = "v1/delegations/export"
+)
+
+// ExportFormat is the file format of a delegations export.
+type ExportFormat string
+
+// Delegations export formats.
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportNDJSON  ExportFormat = "ndjson"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// DelegationsFilter filters the delegations, zero values and nil amounts not filtering.
+type DelegationsFilter struct {
+	Year int
+	// From is the inclusive start of the delegations timestamp range.
+	From time.Time
+	// To is the exclusive end of the delegations timestamp range.
+	To        time.Time
+	Delegator string
+	Baker     string
+	Block     string
+	Level     int64
+	// Kind is delegation, undelegation or redelegation.
+	Kind string
+	// Status is applied, failed, backtracked or skipped.
+	Status string
+	// AmountGt keeps the delegations with an amount strictly greater than AmountGt.
+	AmountGt *int64
+	// AmountLt keeps the delegations with an amount strictly lower than AmountLt.
+	AmountLt *int64
+}
+
+// DelegationsQuery is a delegations request: its filter, sort, selected fields and page.
+type DelegationsQuery struct {
+	DelegationsFilter
+	// Sort is timestamp, amount or level, prefixed by - for a descending order. Cursors require the default sort.
+	Sort string
+	// Select are the returned fields, all of them when empty.
+	Select []string
+	// Page is the page number, from 1.
+	Page int
+	Size int
+	// Cursor is the cursor of the page, from a previous page next cursor.
+	Cursor string
+}
+
+// Pagination is the pagination of a delegations page.
+type Pagination struct {
+	TotalCount int `json:"totalCount"`
+	TotalPages int `json:"totalPages"`
+	Page       int `json:"page"`
+	Size       int `json:"size"`
+	// NextCursor is the cursor of the next page in cursor mode, empty for the last page.
+	NextCursor string `json:"nextCursor"`
+}
+
+// Links are the links of a delegations page.
+type Links struct {
+	Self string `json:"self"`
+	Next string `json:"next"`
+}
+
+// DelegationsPage is a page of delegations.
+type DelegationsPage struct {
+	Pagination Pagination          `json:"pagination"`
+	Links      Links               `json:"links"`
+	Data       []*model.Delegation `json:"data"`
+}
+
+// DelegationsChanges is a page of the delegations change log.
+type DelegationsChanges struct {
+	Changes []*model.DelegationChange `json:"changes"`
+	// Next is the token to resume reading the changes after this page.
+	Next string `json:"next"`
+}
+
+// ListDelegations returns a page of delegations, a cursor page when the query cursor is set.
+func (c *Client) ListDelegations(ctx context.Context, query *DelegationsQuery) (*DelegationsPage, error) {
+	params := query.values()
+	if query.Cursor != "" {
+		params.Set("cursor", query.Cursor)
+	}
+
+	return c.listDelegations(ctx, params)
+}
+
+func (c *Client) listDelegations(ctx context.Context, params url.Values) (*DelegationsPage, error) {
+	page := &DelegationsPage{}
+
+	if _, err := send(c.request(ctx, nil, params), http.MethodGet, delegationsResource, page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Delegations returns an iterator over all the delegations of the query, paginating with cursors from its
+// first page (the query page and cursor being ignored).
+func (c *Client) Delegations(query *DelegationsQuery) *DelegationsIterator {
+	return &DelegationsIterator{
+		client: c,
+		params: query.values(),
+	}
+}
+
+// ListDelegationsChanges returns at most size delegations changes after the since token, from the start of
+// the change log when empty.
+func (c *Client) ListDelegationsChanges(ctx context.Context, since string, size int) (*DelegationsChanges, error) {
+	params := url.Values{}
+	if since != "" {
+		params.Set("since", since)
+	}
+
+	if size != 0 {
+		params.Set("size", strconv.Itoa(size))
+	}
+
+	changes := &DelegationsChanges{}
+
+	if _, err := send(c.request(ctx, nil, params), http.MethodGet, delegationsChangesResource, changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// ExportDelegations exports all the delegations of the query (its page being ignored) in format, the
+// returned export being streamed until closed. The export is read within the client timeout.
+func (c *Client) ExportDelegations(
+	ctx context.Context,
+	query *DelegationsQuery,
+	format ExportFormat,
+) (io.ReadCloser, error) {
+	params := query.values()
+	params.Del("page")
+	params.Del("size")
+	params.Set("format", string(format))
+
+	resp, err := send(
+		c.request(ctx, nil, params).DisableAutoReadResponse(),
+		http.MethodGet,
+		delegationsExportResource,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// values returns the query parameters of the query, but its cursor.
+func (q *DelegationsQuery) values() url.Values {
+	params := q.DelegationsFilter.values()
+	setString(params, "sort", q.Sort)
+	setString(params, "select", strings.Join(q.Select, ","))
+	setInt(params, "page", int64(q.Page))
+	setInt(params, "size", int64(q.Size))
+
+	return params
+}
+
+// values returns the query parameters of the filter.
+func (f *DelegationsFilter) values() url.Values {
+	params := url.Values{}
+	setInt(params, "year", int64(f.Year))
+	setTime(params, "from", f.From)
+	setTime(params, "to", f.To)
+	setString(params, "delegator", f.Delegator)
+	setString(params, "baker", f.Baker)
+	setString(params, "block", f.Block)
+	setInt(params, "level", f.Level)
+	setString(params, "kind", f.Kind)
+	setString(params, "status", f.Status)
+	setIntPtr(params, "amount.gt", f.AmountGt)
+	setIntPtr(params, "amount.lt", f.AmountLt)
+
+	return params
+}
+
+// DelegationsIterator iterates over delegations, fetching their pages as needed:
+//
+//	it := c.Delegations(query)
+//	for it.Next(ctx) {
+//		delegation := it.Delegation()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type DelegationsIterator struct {
+	client *Client
+	params url.Values
+	// page are the delegations left of the current page.
+	page       []*model.Delegation
+	delegation *model.Delegation
+	// cursor is the cursor of the next page, and done true once the last page is fetched.
+	cursor string
+	done   bool
+	err    error
+}
+
+// Next advances to the next delegation, fetching the next page when needed. It returns false when there are
+// no more delegations or on error.
+func (it *DelegationsIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			it.delegation = nil
+
+			return false
+		}
+
+		it.fetch(ctx)
+	}
+
+	it.delegation, it.page = it.page[0], it.page[1:]
+
+	return true
+}
+
+// fetch fetches the next page.
+func (it *DelegationsIterator) fetch(ctx context.Context) {
+	it.params.Set("cursor", it.cursor)
+
+	page, err := it.client.listDelegations(ctx, it.params)
+	if err != nil {
+		it.err = err
+
+		return
+	}
+
+	it.page = page.Data
+	it.cursor = page.Pagination.NextCursor
+	it.done = it.cursor == ""
+}
+
+// Delegation returns the current delegation.
+func (it *DelegationsIterator) Delegation() *model.Delegation {
+	return it.delegation
+}
+
+// Err returns the error which stopped the iteration, nil when all the delegations were iterated.
+func (it *DelegationsIterator) Err() error {
+	return it.err
+}
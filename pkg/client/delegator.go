@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	delegatorResource            = "xtz/delegators/{address}"
+	delegatorDelegationsResource = "xtz/delegators/{address}/delegations"
+)
+
+// TimelineDelegation is a delegation of a delegator, with how long it lasted.
+type TimelineDelegation struct {
+	*model.Delegation
+	// Until is the timestamp of the next delegation of the delegator, nil for the current delegation.
+	Until *time.Time `json:"until"`
+	// DurationSeconds is how long the delegation lasted, until now for the current delegation.
+	DurationSeconds int64 `json:"durationSeconds"`
+}
+
+// GetDelegator returns the current delegation of the delegator of address.
+func (c *Client) GetDelegator(ctx context.Context, address string) (*model.Delegator, error) {
+	delegator := &model.Delegator{}
+
+	_, err := send(
+		c.request(ctx, map[string]string{"address": address}, nil),
+		http.MethodGet,
+		delegatorResource,
+		delegator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return delegator, nil
+}
+
+// ListDelegatorDelegations returns the delegations of the delegator of address in chronological order.
+func (c *Client) ListDelegatorDelegations(ctx context.Context, address string) ([]*TimelineDelegation, error) {
+	delegations := []*TimelineDelegation{}
+
+	_, err := send(
+		c.request(ctx, map[string]string{"address": address}, nil),
+		http.MethodGet,
+		delegatorDelegationsResource,
+		&delegations,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
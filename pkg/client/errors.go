@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Codes of the API problems.
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnknownParameter = "unknown_parameter"
+	CodeInvalidBody      = "invalid_body"
+	CodeUnauthorized     = "unauthorized"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeNotAcceptable    = "not_acceptable"
+	CodeInternalError    = "internal_error"
+)
+
+// Errors matching the API errors of their code with errors.Is.
+var (
+	ErrInvalidParameter = &Error{Code: CodeInvalidParameter}
+	ErrUnknownParameter = &Error{Code: CodeUnknownParameter}
+	ErrInvalidBody      = &Error{Code: CodeInvalidBody}
+	ErrUnauthorized     = &Error{Code: CodeUnauthorized}
+	ErrNotFound         = &Error{Code: CodeNotFound}
+	ErrNotAcceptable    = &Error{Code: CodeNotAcceptable}
+	ErrInternalError    = &Error{Code: CodeInternalError}
+)
+
+// Error is an error response of the API, decoded from its RFC 7807 problem details.
+type Error struct {
+	StatusCode int    `json:"status"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	// Code is the stable code of the problem, empty when the response isn't a problem.
+	Code string `json:"code"`
+	// Param is the offending query parameter of an invalid or unknown parameter error.
+	Param string `json:"param"`
+	// Range is the allowed range of an invalid integer query parameter.
+	Range *Range `json:"range"`
+}
+
+// Range is the inclusive range of an integer query parameter, a nil bound being unbounded.
+type Range struct {
+	Min *int64 `json:"min"`
+	Max *int64 `json:"max"`
+}
+
+// newError creates the Error of an error response, its body being the response detail when it isn't a
+// problem.
+func newError(statusCode int, body []byte) *Error {
+	e := &Error{}
+	if err := json.Unmarshal(body, e); err != nil || e.Code == "" {
+		e = &Error{Title: http.StatusText(statusCode), Detail: string(body)}
+	}
+
+	e.StatusCode = statusCode
+
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("delegation api error %d: %s", e.StatusCode, e.Title)
+	}
+
+	return fmt.Sprintf("delegation api error %d: %s: %s", e.StatusCode, e.Title, e.Detail)
+}
+
+// Is reports whether target is an Error of the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code != "" && t.Code == e.Code
+}
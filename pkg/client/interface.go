@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/http"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// API describes the delegation API interface.
+type API interface {
+	http.Client
+	ListDelegations(ctx context.Context, query *DelegationsQuery) (*DelegationsPage, error)
+	Delegations(query *DelegationsQuery) *DelegationsIterator
+	ListDelegationsChanges(ctx context.Context, since string, size int) (*DelegationsChanges, error)
+	ExportDelegations(ctx context.Context, query *DelegationsQuery, format ExportFormat) (io.ReadCloser, error)
+	ListBakers(ctx context.Context, sort string, page Page) (*BakersPage, error)
+	GetBaker(ctx context.Context, address string) (*model.Baker, error)
+	ListBakerDelegators(ctx context.Context, address, asOf string, page Page) ([]*model.Delegator, error)
+	GetDelegator(ctx context.Context, address string) (*model.Delegator, error)
+	ListDelegatorDelegations(ctx context.Context, address string) ([]*TimelineDelegation, error)
+	GetDelegationsStats(ctx context.Context, query *StatsQuery) ([]*model.DelegationsStats, error)
+	ListWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *CreateWebhookRequest) (*model.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhookDeliveries(ctx context.Context, id string, page Page) ([]*model.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, id, deliveryID string) (*model.WebhookDelivery, error)
+}
@@ -0,0 +1,307 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/guillaumedebavelaere/tezos-delegation/pkg/client (interfaces: API)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./pkg/client/mock/client_mock.go -package=mock_client github.com/guillaumedebavelaere/tezos-delegation/pkg/client API
+//
+// Package mock_client is a generated GoMock package.
+package mock_client
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	client "github.com/guillaumedebavelaere/tezos-delegation/pkg/client"
+	model "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	req "github.com/imroc/req/v3"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPI is a mock of API interface.
+type MockAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIMockRecorder
+}
+
+// MockAPIMockRecorder is the mock recorder for MockAPI.
+type MockAPIMockRecorder struct {
+	mock *MockAPI
+}
+
+// NewMockAPI creates a new mock instance.
+func NewMockAPI(ctrl *gomock.Controller) *MockAPI {
+	mock := &MockAPI{ctrl: ctrl}
+	mock.recorder = &MockAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPI) EXPECT() *MockAPIMockRecorder {
+	return m.recorder
+}
+
+// C mocks base method.
+func (m *MockAPI) C() *req.Client {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "C")
+	ret0, _ := ret[0].(*req.Client)
+	return ret0
+}
+
+// C indicates an expected call of C.
+func (mr *MockAPIMockRecorder) C() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "C", reflect.TypeOf((*MockAPI)(nil).C))
+}
+
+// CreateWebhook mocks base method.
+func (m *MockAPI) CreateWebhook(arg0 context.Context, arg1 *client.CreateWebhookRequest) (*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockAPIMockRecorder) CreateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockAPI)(nil).CreateWebhook), arg0, arg1)
+}
+
+// Delegations mocks base method.
+func (m *MockAPI) Delegations(arg0 *client.DelegationsQuery) *client.DelegationsIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delegations", arg0)
+	ret0, _ := ret[0].(*client.DelegationsIterator)
+	return ret0
+}
+
+// Delegations indicates an expected call of Delegations.
+func (mr *MockAPIMockRecorder) Delegations(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delegations", reflect.TypeOf((*MockAPI)(nil).Delegations), arg0)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockAPI) DeleteWebhook(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockAPIMockRecorder) DeleteWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockAPI)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// ExportDelegations mocks base method.
+func (m *MockAPI) ExportDelegations(arg0 context.Context, arg1 *client.DelegationsQuery, arg2 client.ExportFormat) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportDelegations", arg0, arg1, arg2)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportDelegations indicates an expected call of ExportDelegations.
+func (mr *MockAPIMockRecorder) ExportDelegations(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDelegations", reflect.TypeOf((*MockAPI)(nil).ExportDelegations), arg0, arg1, arg2)
+}
+
+// GetBaker mocks base method.
+func (m *MockAPI) GetBaker(arg0 context.Context, arg1 string) (*model.Baker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBaker", arg0, arg1)
+	ret0, _ := ret[0].(*model.Baker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBaker indicates an expected call of GetBaker.
+func (mr *MockAPIMockRecorder) GetBaker(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBaker", reflect.TypeOf((*MockAPI)(nil).GetBaker), arg0, arg1)
+}
+
+// GetDelegationsStats mocks base method.
+func (m *MockAPI) GetDelegationsStats(arg0 context.Context, arg1 *client.StatsQuery) ([]*model.DelegationsStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegationsStats", arg0, arg1)
+	ret0, _ := ret[0].([]*model.DelegationsStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegationsStats indicates an expected call of GetDelegationsStats.
+func (mr *MockAPIMockRecorder) GetDelegationsStats(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegationsStats", reflect.TypeOf((*MockAPI)(nil).GetDelegationsStats), arg0, arg1)
+}
+
+// GetDelegator mocks base method.
+func (m *MockAPI) GetDelegator(arg0 context.Context, arg1 string) (*model.Delegator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegator", arg0, arg1)
+	ret0, _ := ret[0].(*model.Delegator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegator indicates an expected call of GetDelegator.
+func (mr *MockAPIMockRecorder) GetDelegator(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegator", reflect.TypeOf((*MockAPI)(nil).GetDelegator), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockAPI) GetWebhook(arg0 context.Context, arg1 string) (*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockAPIMockRecorder) GetWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockAPI)(nil).GetWebhook), arg0, arg1)
+}
+
+// Init mocks base method.
+func (m *MockAPI) Init() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Init")
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockAPIMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockAPI)(nil).Init))
+}
+
+// ListBakerDelegators mocks base method.
+func (m *MockAPI) ListBakerDelegators(arg0 context.Context, arg1, arg2 string, arg3 client.Page) ([]*model.Delegator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBakerDelegators", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*model.Delegator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBakerDelegators indicates an expected call of ListBakerDelegators.
+func (mr *MockAPIMockRecorder) ListBakerDelegators(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBakerDelegators", reflect.TypeOf((*MockAPI)(nil).ListBakerDelegators), arg0, arg1, arg2, arg3)
+}
+
+// ListBakers mocks base method.
+func (m *MockAPI) ListBakers(arg0 context.Context, arg1 string, arg2 client.Page) (*client.BakersPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBakers", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*client.BakersPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBakers indicates an expected call of ListBakers.
+func (mr *MockAPIMockRecorder) ListBakers(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBakers", reflect.TypeOf((*MockAPI)(nil).ListBakers), arg0, arg1, arg2)
+}
+
+// ListDelegations mocks base method.
+func (m *MockAPI) ListDelegations(arg0 context.Context, arg1 *client.DelegationsQuery) (*client.DelegationsPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDelegations", arg0, arg1)
+	ret0, _ := ret[0].(*client.DelegationsPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDelegations indicates an expected call of ListDelegations.
+func (mr *MockAPIMockRecorder) ListDelegations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegations", reflect.TypeOf((*MockAPI)(nil).ListDelegations), arg0, arg1)
+}
+
+// ListDelegationsChanges mocks base method.
+func (m *MockAPI) ListDelegationsChanges(arg0 context.Context, arg1 string, arg2 int) (*client.DelegationsChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDelegationsChanges", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*client.DelegationsChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDelegationsChanges indicates an expected call of ListDelegationsChanges.
+func (mr *MockAPIMockRecorder) ListDelegationsChanges(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegationsChanges", reflect.TypeOf((*MockAPI)(nil).ListDelegationsChanges), arg0, arg1, arg2)
+}
+
+// ListDelegatorDelegations mocks base method.
+func (m *MockAPI) ListDelegatorDelegations(arg0 context.Context, arg1 string) ([]*client.TimelineDelegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDelegatorDelegations", arg0, arg1)
+	ret0, _ := ret[0].([]*client.TimelineDelegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDelegatorDelegations indicates an expected call of ListDelegatorDelegations.
+func (mr *MockAPIMockRecorder) ListDelegatorDelegations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegatorDelegations", reflect.TypeOf((*MockAPI)(nil).ListDelegatorDelegations), arg0, arg1)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockAPI) ListWebhookDeliveries(arg0 context.Context, arg1 string, arg2 client.Page) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockAPIMockRecorder) ListWebhookDeliveries(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockAPI)(nil).ListWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockAPI) ListWebhooks(arg0 context.Context) ([]*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0)
+	ret0, _ := ret[0].([]*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockAPIMockRecorder) ListWebhooks(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockAPI)(nil).ListWebhooks), arg0)
+}
+
+// ReplayWebhookDelivery mocks base method.
+func (m *MockAPI) ReplayWebhookDelivery(arg0 context.Context, arg1, arg2 string) (*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery.
+func (mr *MockAPIMockRecorder) ReplayWebhookDelivery(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockAPI)(nil).ReplayWebhookDelivery), arg0, arg1, arg2)
+}
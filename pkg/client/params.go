@@ -0,0 +1,48 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Page is a page of a list, zero values being the API defaults.
+type Page struct {
+	// Number is the page number, from 1.
+	Number int
+	Size   int
+}
+
+// values returns the query parameters of the page.
+func (p Page) values() url.Values {
+	params := url.Values{}
+	setInt(params, "page", int64(p.Number))
+	setInt(params, "size", int64(p.Size))
+
+	return params
+}
+
+func setString(params url.Values, name, value string) {
+	if value != "" {
+		params.Set(name, value)
+	}
+}
+
+func setInt(params url.Values, name string, value int64) {
+	if value != 0 {
+		params.Set(name, strconv.FormatInt(value, 10))
+	}
+}
+
+// setIntPtr sets a parameter when its value is not nil, even when 0.
+func setIntPtr(params url.Values, name string, value *int64) {
+	if value != nil {
+		params.Set(name, strconv.FormatInt(*value, 10))
+	}
+}
+
+func setTime(params url.Values, name string, value time.Time) {
+	if !value.IsZero() {
+		params.Set(name, value.UTC().Format(time.RFC3339))
+	}
+}
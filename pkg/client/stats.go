@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const delegationsStatsResource = "xtz/stats/delegations"
+
+// StatsQuery is a delegations stats request, zero values being the API defaults.
+type StatsQuery struct {
+	// Interval is the stats bucket interval: day, week, month or year.
+	Interval string
+	// From is the inclusive start of the delegations timestamp range.
+	From time.Time
+	// To is the exclusive end of the delegations timestamp range.
+	To time.Time
+}
+
+// GetDelegationsStats returns the delegations volume by interval.
+func (c *Client) GetDelegationsStats(ctx context.Context, query *StatsQuery) ([]*model.DelegationsStats, error) {
+	params := url.Values{}
+	setString(params, "interval", query.Interval)
+	setTime(params, "from", query.From)
+	setTime(params, "to", query.To)
+
+	stats := []*model.DelegationsStats{}
+
+	if _, err := send(c.request(ctx, nil, params), http.MethodGet, delegationsStatsResource, &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
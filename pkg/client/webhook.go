@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	webhooksResource              = "xtz/webhooks"
+	webhookResource               = "xtz/webhooks/{id}"
+	webhookDeliveriesResource     = "xtz/webhooks/{id}/deliveries"
+	webhookDeliveryReplayResource = "xtz/webhooks/{id}/deliveries/{deliveryId}/replay"
+)
+
+// CreateWebhookRequest is a webhook creation request.
+type CreateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret is the key of the deliveries signature, generated when empty.
+	Secret string              `json:"secret,omitempty"`
+	Filter model.WebhookFilter `json:"filter"`
+}
+
+// ListWebhooks returns the webhooks.
+func (c *Client) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	webhooks := []*model.Webhook{}
+
+	if _, err := send(c.request(ctx, nil, nil), http.MethodGet, webhooksResource, &webhooks); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhook creates a webhook, returning it with its secret.
+func (c *Client) CreateWebhook(ctx context.Context, webhook *CreateWebhookRequest) (*model.Webhook, error) {
+	created := &model.Webhook{}
+
+	_, err := send(c.request(ctx, nil, nil).SetBodyJsonMarshal(webhook), http.MethodPost, webhooksResource, created)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetWebhook returns the webhook of id.
+func (c *Client) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+
+	_, err := send(c.request(ctx, map[string]string{"id": id}, nil), http.MethodGet, webhookResource, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes the webhook of id.
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := send(c.request(ctx, map[string]string{"id": id}, nil), http.MethodDelete, webhookResource, nil)
+
+	return err
+}
+
+// ListWebhookDeliveries returns a page of the deliveries of the webhook of id, the latest delegations first.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, page Page) ([]*model.WebhookDelivery, error) {
+	deliveries := []*model.WebhookDelivery{}
+
+	_, err := send(
+		c.request(ctx, map[string]string{"id": id}, page.values()),
+		http.MethodGet,
+		webhookDeliveriesResource,
+		&deliveries,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery schedules the delivery of deliveryID of the webhook of id to be sent again.
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, id, deliveryID string) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+
+	_, err := send(
+		c.request(ctx, map[string]string{"id": id, "deliveryId": deliveryID}, nil),
+		http.MethodPost,
+		webhookDeliveryReplayResource,
+		delivery,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
@@ -3,6 +3,8 @@ package gen
 const (
 	// Mock define mock generation type.
 	Mock = "MOCK"
+	// Proto define protobuf generation type, generating the messages and the gRPC service.
+	Proto = "PROTO"
 )
 
 // Type define generation type.
@@ -10,7 +12,8 @@ type Type string
 
 // File represents a file to generate.
 type File struct {
-	Name      string
+	Name string
+	// Path is the path of the proto file, relative to Dest.
 	Path      string
 	Type      Type
 	Dest      string
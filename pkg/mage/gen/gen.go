@@ -2,6 +2,7 @@ package gen
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/magefile/mage/sh"
@@ -9,6 +10,10 @@ import (
 
 // Gen generate protobuf, mocks, and others.
 func Gen(file *File) error {
+	if file.Type == Proto {
+		return genProto(file)
+	}
+
 	return genMock(file)
 }
 
@@ -20,3 +25,16 @@ func genMock(file *File) error {
 		strings.Join(file.Interface, ","),
 	)
 }
+
+// genProto generates the Go code of a proto file next to it, with protoc and the protoc-gen-go and
+// protoc-gen-go-grpc plugins.
+func genProto(file *File) error {
+	return sh.RunV("protoc",
+		fmt.Sprintf("--proto_path=%s", file.Dest),
+		fmt.Sprintf("--go_out=%s", file.Dest),
+		"--go_opt=paths=source_relative",
+		fmt.Sprintf("--go-grpc_out=%s", file.Dest),
+		"--go-grpc_opt=paths=source_relative",
+		filepath.Join(file.Dest, file.Path),
+	)
+}
@@ -0,0 +1,46 @@
+package datastore
+
+import (
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// AsOf is a point in the delegations history, either a timestamp or a block level.
+// Its zero value is now, the current state.
+type AsOf struct {
+	// Timestamp keeps the delegations with a timestamp lower than or equal to Timestamp.
+	Timestamp time.Time
+	// Level keeps the delegations with a level lower than or equal to Level.
+	Level int64
+}
+
+// IsZero reports whether as of is now.
+func (a AsOf) IsZero() bool {
+	return a.Timestamp.IsZero() && a.Level == 0
+}
+
+// Includes reports whether a delegation happened as of this point in history.
+func (a AsOf) Includes(delegation *model.Delegation) bool {
+	if !a.Timestamp.IsZero() && delegation.Timestamp.After(a.Timestamp) {
+		return false
+	}
+
+	if a.Level != 0 && delegation.Level > a.Level {
+		return false
+	}
+
+	return true
+}
+
+// NewDelegator returns the delegator state resulting from its delegation.
+func NewDelegator(delegation *model.Delegation) *model.Delegator {
+	return &model.Delegator{
+		Address:      delegation.Delegator,
+		Baker:        delegation.Baker,
+		Amount:       delegation.Amount,
+		Timestamp:    delegation.Timestamp,
+		Level:        delegation.Level,
+		DelegationID: delegation.ID,
+	}
+}
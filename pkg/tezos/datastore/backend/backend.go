@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/config"
+	mongosvc "github.com/guillaumedebavelaere/tezos-delegation/pkg/mongo"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mongo"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/sqlite"
+)
+
+const (
+	// Mongo is the mongo datastore driver.
+	Mongo = "mongo"
+	// SQLite is the embedded sqlite datastore driver.
+	SQLite = "sqlite"
+	// Memory is the in-memory datastore driver, optionally seeded, data are lost when the process stops.
+	Memory = "memory"
+)
+
+// Config describes the datastore configuration.
+// Only the configuration of the selected driver is validated.
+type Config struct {
+	Driver string          `validate:"required,oneof=mongo sqlite memory"`
+	Mongo  mongosvc.Config `validate:"-"`
+	SQLite sqlite.Config   `validate:"-"`
+	Memory memory.Config   `validate:"-"`
+}
+
+// Datastore describes a datastore backend with its lifecycle.
+type Datastore interface {
+	datastore.Datastorer
+	Init() error
+	Close() error
+}
+
+// New creates the datastore of the configured driver.
+func New(cfg *Config) (Datastore, error) {
+	switch cfg.Driver {
+	case Mongo:
+		if err := config.Validate(cfg.Mongo); err != nil {
+			return nil, err
+		}
+
+		return mongo.New(mongosvc.New(&cfg.Mongo)), nil
+	case SQLite:
+		if err := config.Validate(cfg.SQLite); err != nil {
+			return nil, err
+		}
+
+		return sqlite.New(&cfg.SQLite), nil
+	case Memory:
+		return memory.NewWithConfig(&cfg.Memory), nil
+	default:
+		return nil, fmt.Errorf("unknown datastore driver %s", cfg.Driver)
+	}
+}
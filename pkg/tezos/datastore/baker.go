@@ -0,0 +1,321 @@
+package datastore
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// ErrInvalidBakersSort is returned when parsing an unknown bakers sort.
+var ErrInvalidBakersSort = errors.New("invalid bakers sort")
+
+// Bakers sort fields.
+const (
+	BakersSortAddress         = "address"
+	BakersSortDelegators      = "delegators"
+	BakersSortDelegatedAmount = "delegatedAmount"
+	BakersSortInflows         = "inflows"
+	BakersSortOutflows        = "outflows"
+)
+
+// BakersSort describes the order of the listed bakers. Bakers with equal values are sorted by address.
+type BakersSort struct {
+	Field      string
+	Descending bool
+}
+
+// ParseBakersSort parses a bakers sort, a field prefixed by - for a descending order (e.g. -delegators).
+// An empty sort sorts bakers by number of delegators descending.
+func ParseBakersSort(value string) (BakersSort, error) {
+	if value == "" {
+		return BakersSort{Field: BakersSortDelegators, Descending: true}, nil
+	}
+
+	field, descending := strings.CutPrefix(value, "-")
+
+	switch field {
+	case BakersSortAddress, BakersSortDelegators, BakersSortDelegatedAmount, BakersSortInflows, BakersSortOutflows:
+		return BakersSort{Field: field, Descending: descending}, nil
+	default:
+		return BakersSort{}, ErrInvalidBakersSort
+	}
+}
+
+// ApplyDelegations updates the current delegation of the delegators with new delegations, and returns the
+// resulting changes of the bakers aggregates, by baker address, along with the updated delegators.
+//
+// delegators must contain the current delegation of the delegators of the delegations, when known.
+// Delegations must not have been applied before (e.g. already stored, unless their previous values were
+// reversed with RemoveDelegations), so aggregates are updated once.
+// A delegation older than the current delegation of its delegator only counts in the baker flows.
+func ApplyDelegations(
+	delegators map[string]*model.Delegator,
+	delegations []*model.Delegation,
+) (map[string]*model.Baker, []*model.Delegator) {
+	bakers := map[string]*model.Baker{}
+	baker := func(address string) *model.Baker {
+		if bakers[address] == nil {
+			bakers[address] = &model.Baker{Address: address}
+		}
+
+		return bakers[address]
+	}
+
+	// delegations are applied chronologically
+	sorted := make([]*model.Delegation, len(delegations))
+	copy(sorted, delegations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return chronological(sorted[i].Timestamp, sorted[i].ID, sorted[j].Timestamp, sorted[j].ID)
+	})
+
+	changed := map[string]*model.Delegator{}
+
+	for _, delegation := range sorted {
+		if delegation.Baker != "" {
+			baker(delegation.Baker).Inflows++
+			baker(delegation.Baker).InflowAmount += delegation.Amount
+		}
+
+		if delegation.PreviousBaker != "" {
+			baker(delegation.PreviousBaker).Outflows++
+			baker(delegation.PreviousBaker).OutflowAmount += delegation.Amount
+		}
+
+		current := delegators[delegation.Delegator]
+		if current != nil && !chronological(current.Timestamp, current.DelegationID, delegation.Timestamp, delegation.ID) {
+			continue
+		}
+
+		if current != nil && current.Baker != "" {
+			baker(current.Baker).Delegators--
+			baker(current.Baker).DelegatedAmount -= current.Amount
+		}
+
+		if delegation.Baker != "" {
+			baker(delegation.Baker).Delegators++
+			baker(delegation.Baker).DelegatedAmount += delegation.Amount
+		}
+
+		updated := NewDelegator(delegation)
+		delegators[delegation.Delegator] = updated
+		changed[delegation.Delegator] = updated
+	}
+
+	updated := make([]*model.Delegator, 0, len(changed))
+	for _, delegator := range changed {
+		updated = append(updated, delegator)
+	}
+
+	return bakers, updated
+}
+
+// rebuildBatchSize is the number of delegations applied at once by a BakersRebuild.
+const rebuildBatchSize = 1000
+
+// BakersRebuild recomputes the bakers aggregates and the current delegation of the delegators from scratch,
+// from the stored delegations added in chronological order.
+type BakersRebuild struct {
+	delegators map[string]*model.Delegator
+	bakers     map[string]*model.Baker
+	pending    []*model.Delegation
+}
+
+// NewBakersRebuild creates a new BakersRebuild, without any delegation.
+func NewBakersRebuild() *BakersRebuild {
+	return &BakersRebuild{
+		delegators: map[string]*model.Delegator{},
+		bakers:     map[string]*model.Baker{},
+	}
+}
+
+// Add adds the next delegation, which must follow the delegations already added chronologically.
+func (r *BakersRebuild) Add(delegation *model.Delegation) {
+	r.pending = append(r.pending, delegation)
+
+	if len(r.pending) >= rebuildBatchSize {
+		r.flush()
+	}
+}
+
+// Bakers returns the rebuilt bakers aggregates, by address.
+func (r *BakersRebuild) Bakers() map[string]*model.Baker {
+	r.flush()
+
+	return r.bakers
+}
+
+// Delegators returns the rebuilt current delegation of the delegators, sorted by address.
+func (r *BakersRebuild) Delegators() []*model.Delegator {
+	r.flush()
+
+	delegators := make([]*model.Delegator, 0, len(r.delegators))
+	for _, delegator := range r.delegators {
+		delegators = append(delegators, delegator)
+	}
+
+	sort.Slice(delegators, func(i, j int) bool {
+		return delegators[i].Address < delegators[j].Address
+	})
+
+	return delegators
+}
+
+// flush applies the pending delegations.
+func (r *BakersRebuild) flush() {
+	if len(r.pending) == 0 {
+		return
+	}
+
+	changes, _ := ApplyDelegations(r.delegators, r.pending)
+	r.pending = r.pending[:0]
+
+	for address, change := range changes {
+		baker := r.bakers[address]
+		if baker == nil {
+			baker = &model.Baker{Address: address}
+			r.bakers[address] = baker
+		}
+
+		baker.Delegators += change.Delegators
+		baker.DelegatedAmount += change.DelegatedAmount
+		baker.Inflows += change.Inflows
+		baker.InflowAmount += change.InflowAmount
+		baker.Outflows += change.Outflows
+		baker.OutflowAmount += change.OutflowAmount
+	}
+}
+
+// RemoveDelegations updates the current delegation of the delegators when deleting stored delegations, or
+// before applying the new values of updated ones, and returns the resulting changes of the bakers aggregates,
+// by baker address, along with the updated delegators and the addresses of the delegators left without any
+// delegation.
+//
+// delegators must contain the current delegation of the delegators of the deleted delegations, when known,
+// and latest their latest delegation remaining once deleted, by delegator address.
+func RemoveDelegations(
+	delegators map[string]*model.Delegator,
+	deleted []*model.Delegation,
+	latest map[string]*model.Delegation,
+) (map[string]*model.Baker, []*model.Delegator, []string) {
+	bakers := map[string]*model.Baker{}
+	baker := func(address string) *model.Baker {
+		if bakers[address] == nil {
+			bakers[address] = &model.Baker{Address: address}
+		}
+
+		return bakers[address]
+	}
+
+	var (
+		updated []*model.Delegator
+		removed []string
+	)
+
+	for _, delegation := range deleted {
+		if delegation.Baker != "" {
+			baker(delegation.Baker).Inflows--
+			baker(delegation.Baker).InflowAmount -= delegation.Amount
+		}
+
+		if delegation.PreviousBaker != "" {
+			baker(delegation.PreviousBaker).Outflows--
+			baker(delegation.PreviousBaker).OutflowAmount -= delegation.Amount
+		}
+
+		// only deleting the current delegation of a delegator moves it
+		current := delegators[delegation.Delegator]
+		if current == nil || current.DelegationID != delegation.ID {
+			continue
+		}
+
+		if current.Baker != "" {
+			baker(current.Baker).Delegators--
+			baker(current.Baker).DelegatedAmount -= current.Amount
+		}
+
+		remaining := latest[delegation.Delegator]
+		if remaining == nil {
+			delete(delegators, delegation.Delegator)
+			removed = append(removed, delegation.Delegator)
+
+			continue
+		}
+
+		if remaining.Baker != "" {
+			baker(remaining.Baker).Delegators++
+			baker(remaining.Baker).DelegatedAmount += remaining.Amount
+		}
+
+		delegators[delegation.Delegator] = NewDelegator(remaining)
+		updated = append(updated, delegators[delegation.Delegator])
+	}
+
+	return bakers, updated, removed
+}
+
+// chronological reports whether the delegation a happened before the delegation b.
+func chronological(aTimestamp time.Time, aID int64, bTimestamp time.Time, bID int64) bool {
+	if !aTimestamp.Equal(bTimestamp) {
+		return aTimestamp.Before(bTimestamp)
+	}
+
+	return aID < bID
+}
+
+// NewDelegations returns the delegations which aren't stored yet, given the delegations already stored
+// by id, without duplicates.
+func NewDelegations(stored map[int64]*model.Delegation, delegations []*model.Delegation) []*model.Delegation {
+	seen := make(map[int64]bool, len(delegations))
+	news := make([]*model.Delegation, 0, len(delegations))
+
+	for _, delegation := range delegations {
+		if _, found := stored[delegation.ID]; found || seen[delegation.ID] {
+			continue
+		}
+
+		seen[delegation.ID] = true
+
+		news = append(news, delegation)
+	}
+
+	return news
+}
+
+// UpdatedDelegations returns the stored delegations whose values change by storing delegations, along with
+// their new values, in the same order, given the delegations already stored by id. A delegation given several
+// times takes its last values, like when storing the delegations in order.
+func UpdatedDelegations(
+	stored map[int64]*model.Delegation,
+	delegations []*model.Delegation,
+) ([]*model.Delegation, []*model.Delegation) {
+	ids := make([]int64, 0, len(stored))
+	latest := make(map[int64]*model.Delegation, len(stored))
+
+	for _, delegation := range delegations {
+		if _, found := stored[delegation.ID]; !found {
+			continue
+		}
+
+		if _, seen := latest[delegation.ID]; !seen {
+			ids = append(ids, delegation.ID)
+		}
+
+		latest[delegation.ID] = delegation
+	}
+
+	var previous, updated []*model.Delegation
+
+	for _, id := range ids {
+		if sameDelegation(stored[id], latest[id]) {
+			continue
+		}
+
+		previous = append(previous, stored[id])
+		updated = append(updated, latest[id])
+	}
+
+	return previous, updated
+}
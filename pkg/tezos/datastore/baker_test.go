@@ -0,0 +1,116 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestParseBakersSort(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		value   string
+		want    datastore.BakersSort
+		wantErr error
+	}{
+		{
+			name:  "Success default",
+			value: "",
+			want:  datastore.BakersSort{Field: datastore.BakersSortDelegators, Descending: true},
+		},
+		{
+			name:  "Success ascending",
+			value: "delegatedAmount",
+			want:  datastore.BakersSort{Field: datastore.BakersSortDelegatedAmount},
+		},
+		{
+			name:  "Success descending",
+			value: "-outflows",
+			want:  datastore.BakersSort{Field: datastore.BakersSortOutflows, Descending: true},
+		},
+		{
+			name:    "Error unknown field",
+			value:   "-amount",
+			wantErr: datastore.ErrInvalidBakersSort,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := datastore.ParseBakersSort(c.value)
+			assert.ErrorIs(t, err, c.wantErr)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestBakersRebuild(t *testing.T) {
+	t.Parallel()
+
+	rebuild := datastore.NewBakersRebuild()
+
+	for _, delegation := range []*model.Delegation{
+		{ID: 1, Timestamp: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), Amount: 100, Delegator: "tz1a", Baker: "tz1x"},
+		{ID: 2, Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), Amount: 50, Delegator: "tz1b", Baker: "tz1x"},
+		{
+			ID: 3, Timestamp: time.Date(2023, 1, 3, 10, 0, 0, 0, time.UTC), Amount: 120, Delegator: "tz1a",
+			Baker: "tz1y", PreviousBaker: "tz1x",
+		},
+		{
+			ID: 4, Timestamp: time.Date(2023, 1, 4, 10, 0, 0, 0, time.UTC), Amount: 50, Delegator: "tz1b",
+			PreviousBaker: "tz1x",
+		},
+	} {
+		rebuild.Add(delegation)
+	}
+
+	assert.Equal(t, map[string]*model.Baker{
+		"tz1x": {Address: "tz1x", Inflows: 2, InflowAmount: 150, Outflows: 2, OutflowAmount: 170},
+		"tz1y": {Address: "tz1y", Delegators: 1, DelegatedAmount: 120, Inflows: 1, InflowAmount: 120},
+	}, rebuild.Bakers())
+	assert.Equal(t, []*model.Delegator{
+		{
+			Address: "tz1a", Baker: "tz1y", Amount: 120, DelegationID: 3,
+			Timestamp: time.Date(2023, 1, 3, 10, 0, 0, 0, time.UTC),
+		},
+		{Address: "tz1b", Amount: 50, DelegationID: 4, Timestamp: time.Date(2023, 1, 4, 10, 0, 0, 0, time.UTC)},
+	}, rebuild.Delegators())
+}
+
+func TestUpdatedDelegations(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	stored := map[int64]*model.Delegation{
+		1: {ID: 1, Timestamp: timestamp, Amount: 100, Delegator: "tz1a", Baker: "tz1x"},
+		2: {ID: 2, Timestamp: timestamp, Amount: 50, Delegator: "tz1b", Baker: "tz1x"},
+		3: {ID: 3, Timestamp: timestamp, Amount: 120, Delegator: "tz1c", Baker: "tz1x"},
+	}
+
+	moved := &model.Delegation{ID: 1, Timestamp: timestamp, Amount: 100, Delegator: "tz1a", Baker: "tz1y"}
+	amount := &model.Delegation{ID: 3, Timestamp: timestamp, Amount: 130, Delegator: "tz1c", Baker: "tz1x"}
+
+	previous, updated := datastore.UpdatedDelegations(stored, []*model.Delegation{
+		// updated twice, keeping its last values
+		{ID: 3, Timestamp: timestamp, Amount: 110, Delegator: "tz1c", Baker: "tz1x"},
+		// unchanged
+		{ID: 2, Timestamp: timestamp, Amount: 50, Delegator: "tz1b", Baker: "tz1x"},
+		moved,
+		// new
+		{ID: 4, Timestamp: timestamp, Amount: 10, Delegator: "tz1d", Baker: "tz1x"},
+		amount,
+	})
+
+	assert.Equal(t, []*model.Delegation{stored[3], stored[1]}, previous)
+	assert.Equal(t, []*model.Delegation{amount, moved}, updated)
+}
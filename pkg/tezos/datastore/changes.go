@@ -0,0 +1,92 @@
+package datastore
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// ErrInvalidChangeToken is returned when decoding a malformed change token.
+var ErrInvalidChangeToken = errors.New("invalid change token")
+
+// EncodeChangeToken encodes the sequence of the last read change to an opaque URL safe token,
+// used to resume reading the changes after it.
+func EncodeChangeToken(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(sequence, 10)))
+}
+
+// DecodeChangeToken decodes a token returned by EncodeChangeToken. An empty token is the start of the
+// change log.
+func DecodeChangeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidChangeToken
+	}
+
+	sequence, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, ErrInvalidChangeToken
+	}
+
+	return sequence, nil
+}
+
+// StoreChanges returns the changes made by storing delegations, in order, given the delegations already
+// stored by id. Storing again a delegation with the same values isn't a change, so re-ingesting
+// delegations doesn't grow the change log. Sequences are left to the datastore.
+func StoreChanges(stored map[int64]*model.Delegation, delegations []*model.Delegation) []*model.DelegationChange {
+	current := make(map[int64]*model.Delegation, len(stored))
+
+	for id, delegation := range stored {
+		current[id] = delegation
+	}
+
+	changes := make([]*model.DelegationChange, 0, len(delegations))
+
+	for _, delegation := range delegations {
+		previous, found := current[delegation.ID]
+		if found && sameDelegation(previous, delegation) {
+			continue
+		}
+
+		operation := model.ChangeInsert
+		if found {
+			operation = model.ChangeUpdate
+		}
+
+		changes = append(changes, &model.DelegationChange{Operation: operation, Delegation: delegation})
+		current[delegation.ID] = delegation
+	}
+
+	return changes
+}
+
+// DeleteChanges returns the changes made by deleting stored delegations.
+func DeleteChanges(deleted []*model.Delegation) []*model.DelegationChange {
+	changes := make([]*model.DelegationChange, len(deleted))
+
+	for i, delegation := range deleted {
+		changes[i] = &model.DelegationChange{Operation: model.ChangeDelete, Delegation: delegation}
+	}
+
+	return changes
+}
+
+// sameDelegation reports whether two delegations have the same stored values, timestamps being stored
+// with a millisecond precision.
+func sameDelegation(a, b *model.Delegation) bool {
+	return a.ID == b.ID &&
+		a.Timestamp.UnixMilli() == b.Timestamp.UnixMilli() &&
+		a.Amount == b.Amount &&
+		a.Delegator == b.Delegator &&
+		a.Block == b.Block &&
+		a.Level == b.Level &&
+		a.Baker == b.Baker &&
+		a.PreviousBaker == b.PreviousBaker
+}
@@ -0,0 +1,99 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestEncodeChangeToken(t *testing.T) {
+	t.Parallel()
+
+	got, err := datastore.DecodeChangeToken(datastore.EncodeChangeToken(42))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), got)
+
+	got, err = datastore.DecodeChangeToken("")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), got)
+}
+
+func TestDecodeChangeToken(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{name: "Error not base64", token: "not base64!"},
+		{name: "Error not a sequence", token: "YWJj"},
+		{name: "Error negative sequence", token: "LTE"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := datastore.DecodeChangeToken(c.token)
+			assert.ErrorIs(t, err, datastore.ErrInvalidChangeToken)
+		})
+	}
+}
+
+func TestStoreChanges(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC)
+	delegation := &model.Delegation{ID: 1, Timestamp: timestamp, Amount: 100, Delegator: "tz1"}
+	corrected := &model.Delegation{ID: 1, Timestamp: timestamp, Amount: 120, Delegator: "tz1"}
+
+	cases := []struct {
+		name        string
+		stored      map[int64]*model.Delegation
+		delegations []*model.Delegation
+		want        []*model.DelegationChange
+	}{
+		{
+			name:        "Success inserted",
+			delegations: []*model.Delegation{delegation},
+			want:        []*model.DelegationChange{{Operation: model.ChangeInsert, Delegation: delegation}},
+		},
+		{
+			name:        "Success stored again",
+			stored:      map[int64]*model.Delegation{1: delegation},
+			delegations: []*model.Delegation{delegation},
+			want:        []*model.DelegationChange{},
+		},
+		{
+			name:        "Success updated",
+			stored:      map[int64]*model.Delegation{1: delegation},
+			delegations: []*model.Delegation{corrected},
+			want:        []*model.DelegationChange{{Operation: model.ChangeUpdate, Delegation: corrected}},
+		},
+		{
+			name:        "Success inserted then updated in batch",
+			delegations: []*model.Delegation{delegation, delegation, corrected},
+			want: []*model.DelegationChange{
+				{Operation: model.ChangeInsert, Delegation: delegation},
+				{Operation: model.ChangeUpdate, Delegation: corrected},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, datastore.StoreChanges(c.stored, c.delegations))
+		})
+	}
+}
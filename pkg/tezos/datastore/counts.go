@@ -0,0 +1,164 @@
+package datastore
+
+import (
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Delegations counts are precomputed in counters, maintained by StoreDelegations, so counting delegations
+// doesn't require to scan them. There is a counter of all the delegations, and a counter per year and per day (UTC).
+const (
+	// TotalCountKey is the key of the counter of all the delegations.
+	TotalCountKey = "total"
+
+	yearCountKeyPrefix = "year:"
+	dayCountKeyPrefix  = "day:"
+	dayCountKeyLayout  = "2006-01-02"
+)
+
+// YearCountKey returns the key of the counter of the delegations of a year.
+func YearCountKey(year int) string {
+	return yearCountKeyPrefix + time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Format("2006")
+}
+
+// DayCountKey returns the key of the counter of the delegations of the day (UTC) of a timestamp.
+// Day keys sort in chronological order.
+func DayCountKey(timestamp time.Time) string {
+	return dayCountKeyPrefix + timestamp.UTC().Format(dayCountKeyLayout)
+}
+
+// CountKeys returns the keys of the counters counting a delegation with this timestamp.
+func CountKeys(timestamp time.Time) []string {
+	return []string{
+		TotalCountKey,
+		YearCountKey(timestamp.UTC().Year()),
+		DayCountKey(timestamp),
+	}
+}
+
+// CountDeltas returns the counters increments when storing delegations, given the delegations already
+// stored, by id. Storing again a delegation with the same timestamp doesn't change the counters,
+// so re-ingesting delegations is idempotent.
+func CountDeltas(stored map[int64]*model.Delegation, delegations []*model.Delegation) map[string]int {
+	deltas := map[string]int{}
+	timestamps := make(map[int64]time.Time, len(stored))
+
+	for id, delegation := range stored {
+		timestamps[id] = delegation.Timestamp
+	}
+
+	for _, delegation := range delegations {
+		previous, found := timestamps[delegation.ID]
+		if found {
+			if previous.Equal(delegation.Timestamp) {
+				continue
+			}
+
+			for _, key := range CountKeys(previous) {
+				deltas[key]--
+			}
+		}
+
+		for _, key := range CountKeys(delegation.Timestamp) {
+			deltas[key]++
+		}
+
+		timestamps[delegation.ID] = delegation.Timestamp
+	}
+
+	for key, delta := range deltas {
+		if delta == 0 {
+			delete(deltas, key)
+		}
+	}
+
+	return deltas
+}
+
+// DeleteCountDeltas returns the counters increments when deleting stored delegations.
+func DeleteCountDeltas(deleted []*model.Delegation) map[string]int {
+	deltas := map[string]int{}
+
+	for _, delegation := range deleted {
+		for _, key := range CountKeys(delegation.Timestamp) {
+			deltas[key]--
+		}
+	}
+
+	return deltas
+}
+
+// CountQuery describes how to get a filter count from the precomputed counters: it is either the value of
+// the counter Key, or the sum of the day counters with a key in [FromDay, ToDay).
+type CountQuery struct {
+	Key     string
+	FromDay string
+	ToDay   string
+}
+
+// CountQuery returns how to get the filter count from the precomputed counters.
+// ok is false when the filter can't be answered by the counters, when it doesn't only filter on timestamps
+// or when its bounds aren't UTC days.
+func (f Filter) CountQuery() (query CountQuery, ok bool) {
+	if !f.TimestampOnly() {
+		return CountQuery{}, false
+	}
+
+	if f.From.IsZero() && f.To.IsZero() {
+		if f.Year == 0 {
+			return CountQuery{Key: TotalCountKey}, true
+		}
+
+		return CountQuery{Key: YearCountKey(f.Year)}, true
+	}
+
+	from, to := f.TimestampRange()
+
+	if !isDay(from) || !isDay(to) {
+		return CountQuery{}, false
+	}
+
+	// day keys are prefixed, so unbounded ranges are bounded by the first and last possible day keys
+	query = CountQuery{
+		FromDay: dayCountKeyPrefix,
+		ToDay:   dayCountKeyPrefix + "~",
+	}
+
+	if !from.IsZero() {
+		query.FromDay = DayCountKey(from)
+	}
+
+	if !to.IsZero() {
+		query.ToDay = DayCountKey(to)
+	}
+
+	return query, true
+}
+
+// isDay reports whether a bound is a day start (UTC), the zero time being an unbounded bound.
+func isDay(t time.Time) bool {
+	if t.IsZero() {
+		return true
+	}
+
+	return t.UTC().Equal(t.UTC().Truncate(24 * time.Hour))
+}
+
+// RecountKeys returns the counters computed from scratch from the number of delegations per day (UTC).
+func RecountKeys(perDay map[string]int) map[string]int {
+	counts := map[string]int{}
+
+	for day, count := range perDay {
+		timestamp, err := time.Parse(dayCountKeyLayout, day)
+		if err != nil {
+			continue
+		}
+
+		for _, key := range CountKeys(timestamp) {
+			counts[key] += count
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,123 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestCountDeltas(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		stored      map[int64]*model.Delegation
+		delegations []*model.Delegation
+		want        map[string]int
+	}{
+		{
+			name:        "Success inserted",
+			delegations: []*model.Delegation{{ID: 1, Timestamp: timestamp}},
+			want:        map[string]int{"total": 1, "year:2023": 1, "day:2023-12-10": 1},
+		},
+		{
+			name:        "Success stored again",
+			stored:      map[int64]*model.Delegation{1: {ID: 1, Timestamp: timestamp}},
+			delegations: []*model.Delegation{{ID: 1, Timestamp: timestamp}},
+			want:        map[string]int{},
+		},
+		{
+			name:        "Success duplicated in batch",
+			delegations: []*model.Delegation{{ID: 1, Timestamp: timestamp}, {ID: 1, Timestamp: timestamp}},
+			want:        map[string]int{"total": 1, "year:2023": 1, "day:2023-12-10": 1},
+		},
+		{
+			name:        "Success moved to another day",
+			stored:      map[int64]*model.Delegation{1: {ID: 1, Timestamp: timestamp}},
+			delegations: []*model.Delegation{{ID: 1, Timestamp: timestamp.Add(24 * time.Hour)}},
+			want:        map[string]int{"day:2023-12-10": -1, "day:2023-12-11": 1},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, datastore.CountDeltas(c.stored, c.delegations))
+		})
+	}
+}
+
+func TestFilter_CountQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		filter datastore.Filter
+		want   datastore.CountQuery
+		wantOK bool
+	}{
+		{
+			name:   "Success all",
+			want:   datastore.CountQuery{Key: "total"},
+			wantOK: true,
+		},
+		{
+			name:   "Success year",
+			filter: datastore.Filter{Year: 2023},
+			want:   datastore.CountQuery{Key: "year:2023"},
+			wantOK: true,
+		},
+		{
+			name: "Success days range",
+			filter: datastore.Filter{
+				From: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 7, 1, 2, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60)),
+			},
+			want:   datastore.CountQuery{FromDay: "day:2023-06-01", ToDay: "day:2023-07-01"},
+			wantOK: true,
+		},
+		{
+			name:   "Success days range within year",
+			filter: datastore.Filter{Year: 2023, From: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+			want:   datastore.CountQuery{FromDay: "day:2023-06-01", ToDay: "day:2024-01-01"},
+			wantOK: true,
+		},
+		{
+			name:   "Success unbounded days range",
+			filter: datastore.Filter{To: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)},
+			want:   datastore.CountQuery{FromDay: "day:", ToDay: "day:2023-07-01"},
+			wantOK: true,
+		},
+		{
+			name:   "Not a days range",
+			filter: datastore.Filter{From: time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)},
+			wantOK: false,
+		},
+		{
+			name:   "Not only timestamps",
+			filter: datastore.Filter{Year: 2023, Baker: "tz1baker"},
+			wantOK: false,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := c.filter.CountQuery()
+			assert.Equal(t, c.wantOK, ok)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func testAlerts(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := factory(t)
+	ctx := context.Background()
+
+	alert := func(rule, key string, delegation *model.Delegation, firedAt time.Time) *model.Alert {
+		return &model.Alert{
+			Rule:       rule,
+			Key:        key,
+			Type:       "amount",
+			Message:    "large delegation",
+			Baker:      delegation.Baker,
+			Delegation: delegation,
+			FiredAt:    firedAt,
+		}
+	}
+
+	first := alert("whales", "delegation:21", bakersDelegations[0], time.Date(2023, 1, 1, 10, 0, 1, 0, time.UTC))
+	second := alert("whales", "delegation:23", bakersDelegations[2], time.Date(2023, 1, 3, 10, 0, 1, 0, time.UTC))
+	other := alert("other", "delegation:22", bakersDelegations[1], time.Date(2023, 1, 4, 10, 0, 1, 0, time.UTC))
+
+	// created out of order
+	for _, a := range []*model.Alert{second, first, other} {
+		created, err := d.CreateAlert(ctx, a)
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+
+	// an alert is emitted once per key
+	created, err := d.CreateAlert(ctx, alert("whales", "delegation:21", bakersDelegations[0], time.Now()))
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	latest, err := d.GetLatestAlert(ctx, "whales")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, second.Rule, latest.Rule)
+	assert.Equal(t, second.Key, latest.Key)
+	assert.Equal(t, second.Type, latest.Type)
+	assert.Equal(t, second.Message, latest.Message)
+	assert.Equal(t, second.Baker, latest.Baker)
+	assertDelegation(t, second.Delegation, latest.Delegation)
+	assert.True(t, second.FiredAt.Equal(latest.FiredAt), "want %s, got %s", second.FiredAt, latest.FiredAt)
+
+	latest, err = d.GetLatestAlert(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+}
@@ -0,0 +1,497 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	bakerA = "tz1bakerA"
+	bakerB = "tz1bakerB"
+	bakerC = "tz1bakerC"
+)
+
+// bakersDelegations are two delegators delegating to bakerA, then one redelegating to bakerB and the other
+// undelegating.
+var bakersDelegations = []*model.Delegation{
+	{
+		ID: 21, Timestamp: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1delegatorA", Block: "block21", Level: 3000001, Baker: bakerA,
+	},
+	{
+		ID: 22, Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), Amount: 50,
+		Delegator: "tz1delegatorB", Block: "block22", Level: 3000002, Baker: bakerA,
+	},
+	{
+		ID: 23, Timestamp: time.Date(2023, 1, 3, 10, 0, 0, 0, time.UTC), Amount: 120,
+		Delegator: "tz1delegatorA", Block: "block23", Level: 3000003, Baker: bakerB, PreviousBaker: bakerA,
+	},
+	{
+		ID: 24, Timestamp: time.Date(2023, 1, 4, 10, 0, 0, 0, time.UTC), Amount: 50,
+		Delegator: "tz1delegatorB", Block: "block24", Level: 3000004, PreviousBaker: bakerA,
+	},
+}
+
+//nolint:funlen
+func testBakers(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations[:2]...)
+	ctx := context.Background()
+
+	// stored out of order, with an already stored delegation
+	require.NoError(
+		t,
+		d.StoreDelegations(ctx, []*model.Delegation{bakersDelegations[3], bakersDelegations[2], bakersDelegations[0]}),
+	)
+
+	wantA := &model.Baker{Address: bakerA, Inflows: 2, InflowAmount: 150, Outflows: 2, OutflowAmount: 170}
+	wantB := &model.Baker{Address: bakerB, Delegators: 1, DelegatedAmount: 120, Inflows: 1, InflowAmount: 120}
+
+	t.Run("GetBaker", func(t *testing.T) {
+		got, err := d.GetBaker(ctx, bakerA)
+		require.NoError(t, err)
+		assert.Equal(t, wantA, got)
+
+		got, err = d.GetBaker(ctx, bakerB)
+		require.NoError(t, err)
+		assert.Equal(t, wantB, got)
+
+		got, err = d.GetBaker(ctx, "tz1unknown")
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("GetBakersByAddresses", func(t *testing.T) {
+		got, err := d.GetBakersByAddresses(ctx, []string{bakerB, "tz1unknown", bakerA, bakerB})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Baker{wantA, wantB}, got)
+
+		got, err = d.GetBakersByAddresses(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("GetBakers", func(t *testing.T) {
+		cases := []struct {
+			name string
+			sort datastore.BakersSort
+			page datastore.Page
+			want []*model.Baker
+		}{
+			{
+				name: "Success by delegators desc",
+				sort: datastore.BakersSort{Field: datastore.BakersSortDelegators, Descending: true},
+				page: datastore.Page{Number: 1, Size: 10},
+				want: []*model.Baker{wantB, wantA},
+			},
+			{
+				name: "Success by outflows desc",
+				sort: datastore.BakersSort{Field: datastore.BakersSortOutflows, Descending: true},
+				page: datastore.Page{Number: 1, Size: 10},
+				want: []*model.Baker{wantA, wantB},
+			},
+			{
+				name: "Success by address",
+				sort: datastore.BakersSort{Field: datastore.BakersSortAddress},
+				page: datastore.Page{Number: 1, Size: 10},
+				want: []*model.Baker{wantA, wantB},
+			},
+			{
+				name: "Success second page",
+				sort: datastore.BakersSort{Field: datastore.BakersSortDelegatedAmount, Descending: true},
+				page: datastore.Page{Number: 2, Size: 1},
+				want: []*model.Baker{wantA},
+			},
+			{
+				name: "Success page after the last one",
+				sort: datastore.BakersSort{Field: datastore.BakersSortDelegators, Descending: true},
+				page: datastore.Page{Number: 3, Size: 1},
+				want: nil,
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				got, err := d.GetBakers(ctx, c.sort, c.page)
+				require.NoError(t, err)
+				assert.Equal(t, c.want, got)
+			})
+		}
+
+		count, err := d.GetBakersCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("Stored again", func(t *testing.T) {
+		require.NoError(t, d.StoreDelegations(ctx, bakersDelegations))
+
+		got, err := d.GetBakers(ctx, datastore.BakersSort{Field: datastore.BakersSortAddress}, datastore.Page{})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Baker{wantA, wantB}, got)
+	})
+
+	t.Run("Older delegation", func(t *testing.T) {
+		older := &model.Delegation{
+			ID: 20, Timestamp: time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC), Amount: 80,
+			Delegator: "tz1delegatorA", Block: "block20", Baker: bakerC,
+		}
+		require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{older}))
+
+		got, err := d.GetBakers(ctx, datastore.BakersSort{Field: datastore.BakersSortAddress}, datastore.Page{})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Baker{wantA, wantB, {Address: bakerC, Inflows: 1, InflowAmount: 80}}, got)
+	})
+}
+
+//nolint:funlen
+func testUpdatedDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+	ctx := context.Background()
+
+	allBakers := func(t *testing.T) []*model.Baker {
+		t.Helper()
+
+		got, err := d.GetBakers(ctx, datastore.BakersSort{Field: datastore.BakersSortAddress}, datastore.Page{})
+		require.NoError(t, err)
+
+		return got
+	}
+
+	// the current delegation of delegatorA moves to bakerC with another amount, an older one changes amount
+	moved := *bakersDelegations[2]
+	moved.Baker, moved.Amount = bakerC, 200
+	older := *bakersDelegations[0]
+	older.Amount = 110
+
+	t.Run("Baker and amount", func(t *testing.T) {
+		require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{&moved, &older}))
+
+		assert.Equal(t, []*model.Baker{
+			{Address: bakerA, Inflows: 2, InflowAmount: 160, Outflows: 2, OutflowAmount: 250},
+			{Address: bakerB},
+			{Address: bakerC, Delegators: 1, DelegatedAmount: 200, Inflows: 1, InflowAmount: 200},
+		}, allBakers(t))
+
+		got, err := d.GetDelegator(ctx, "tz1delegatorA")
+		require.NoError(t, err)
+		assert.Equal(t, datastore.NewDelegator(&moved), got)
+
+		delegators, err := d.GetBakerDelegators(ctx, bakerB, datastore.AsOf{}, datastore.Page{Number: 1, Size: 10})
+		require.NoError(t, err)
+		assert.Empty(t, delegators)
+	})
+
+	t.Run("Timestamp", func(t *testing.T) {
+		// the moved delegation becomes the oldest one, the current delegation of delegatorA being the older one
+		moved.Timestamp = time.Date(2022, 12, 31, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{&moved}))
+
+		assert.Equal(t, []*model.Baker{
+			{
+				Address: bakerA, Delegators: 1, DelegatedAmount: 110,
+				Inflows: 2, InflowAmount: 160, Outflows: 2, OutflowAmount: 250,
+			},
+			{Address: bakerB},
+			{Address: bakerC, Inflows: 1, InflowAmount: 200},
+		}, allBakers(t))
+
+		got, err := d.GetDelegator(ctx, "tz1delegatorA")
+		require.NoError(t, err)
+		assert.Equal(t, datastore.NewDelegator(&older), got)
+	})
+}
+
+func testGetDelegatorDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	// stored in reverse chronological order
+	d := seed(
+		t,
+		factory,
+		delegation2023,
+		bakersDelegations[3],
+		bakersDelegations[2],
+		bakersDelegations[1],
+		bakersDelegations[0],
+	)
+
+	got, err := d.GetDelegatorDelegations(context.Background(), "tz1delegatorA")
+	require.NoError(t, err)
+	assertDelegations(t, []*model.Delegation{bakersDelegations[0], bakersDelegations[2]}, got)
+
+	got, err = d.GetDelegatorDelegations(context.Background(), "tz1unknown")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func testGetDelegatorsDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	// stored in reverse chronological order
+	d := seed(
+		t,
+		factory,
+		delegation2023,
+		bakersDelegations[3],
+		bakersDelegations[2],
+		bakersDelegations[1],
+		bakersDelegations[0],
+	)
+
+	delegators := []string{"tz1delegatorB", "tz1delegatorA", "tz1unknown"}
+
+	got, err := d.GetDelegatorsDelegations(context.Background(), delegators, 0)
+	require.NoError(t, err)
+	assertDelegations(t, bakersDelegations, got)
+
+	// the latest delegation of each delegator
+	got, err = d.GetDelegatorsDelegations(context.Background(), delegators, 1)
+	require.NoError(t, err)
+	assertDelegations(t, bakersDelegations[2:], got)
+
+	got, err = d.GetDelegatorsDelegations(context.Background(), delegators, 2)
+	require.NoError(t, err)
+	assertDelegations(t, bakersDelegations, got)
+
+	got, err = d.GetDelegatorsDelegations(context.Background(), nil, 1)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func testRebuildBakers(t *testing.T, factory Factory) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	// stored out of order, so the incremental aggregates are built from several batches
+	d := seed(t, factory, bakersDelegations[2:]...)
+	require.NoError(t, d.StoreDelegations(ctx, bakersDelegations[:2]))
+	require.NoError(t, d.RebuildBakers(ctx))
+
+	got, err := d.GetBakers(ctx, datastore.BakersSort{Field: datastore.BakersSortAddress}, datastore.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Baker{
+		{Address: bakerA, Inflows: 2, InflowAmount: 150, Outflows: 2, OutflowAmount: 170},
+		{Address: bakerB, Delegators: 1, DelegatedAmount: 120, Inflows: 1, InflowAmount: 120},
+	}, got)
+
+	delegator, err := d.GetDelegator(ctx, "tz1delegatorA")
+	require.NoError(t, err)
+	assertDelegator(t, datastore.NewDelegator(bakersDelegations[2]), delegator)
+
+	delegator, err = d.GetDelegator(ctx, "tz1delegatorB")
+	require.NoError(t, err)
+	assertDelegator(t, datastore.NewDelegator(bakersDelegations[3]), delegator)
+
+	delegators, err := d.GetBakerDelegators(ctx, bakerB, datastore.AsOf{}, datastore.Page{Number: 1, Size: 10})
+	require.NoError(t, err)
+	require.Len(t, delegators, 1)
+	assertDelegator(t, datastore.NewDelegator(bakersDelegations[2]), delegators[0])
+
+	// rebuilding an empty datastore leaves it empty
+	empty := seed(t, factory)
+	require.NoError(t, empty.RebuildBakers(ctx))
+
+	count, err := empty.GetBakersCount(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func testGetDelegator(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+
+	got, err := d.GetDelegator(context.Background(), "tz1delegatorA")
+	require.NoError(t, err)
+	assertDelegator(t, datastore.NewDelegator(bakersDelegations[2]), got)
+
+	// an undelegated delegator has no baker
+	got, err = d.GetDelegator(context.Background(), "tz1delegatorB")
+	require.NoError(t, err)
+	assertDelegator(t, datastore.NewDelegator(bakersDelegations[3]), got)
+
+	got, err = d.GetDelegator(context.Background(), "tz1unknown")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+//nolint:funlen
+func testGetBakerDelegators(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+
+	delegatorA := datastore.NewDelegator(bakersDelegations[0])
+	delegatorB := datastore.NewDelegator(bakersDelegations[1])
+
+	cases := []struct {
+		name  string
+		baker string
+		asOf  datastore.AsOf
+		page  datastore.Page
+		want  []*model.Delegator
+	}{
+		{
+			name:  "Success current",
+			baker: bakerB,
+			page:  datastore.Page{Number: 1, Size: 10},
+			want:  []*model.Delegator{datastore.NewDelegator(bakersDelegations[2])},
+		},
+		{
+			name:  "Success current without delegators",
+			baker: bakerA,
+			page:  datastore.Page{Number: 1, Size: 10},
+			want:  nil,
+		},
+		{
+			name:  "Success as of timestamp",
+			baker: bakerA,
+			asOf:  datastore.AsOf{Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)},
+			page:  datastore.Page{Number: 1, Size: 10},
+			want:  []*model.Delegator{delegatorA, delegatorB},
+		},
+		{
+			name:  "Success as of timestamp after a redelegation",
+			baker: bakerA,
+			asOf:  datastore.AsOf{Timestamp: time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC)},
+			page:  datastore.Page{Number: 1, Size: 10},
+			want:  []*model.Delegator{delegatorB},
+		},
+		{
+			name:  "Success as of level",
+			baker: bakerA,
+			asOf:  datastore.AsOf{Level: 3000002},
+			page:  datastore.Page{Number: 1, Size: 10},
+			want:  []*model.Delegator{delegatorA, delegatorB},
+		},
+		{
+			name:  "Success as of second page",
+			baker: bakerA,
+			asOf:  datastore.AsOf{Level: 3000002},
+			page:  datastore.Page{Number: 2, Size: 1},
+			want:  []*model.Delegator{delegatorB},
+		},
+		{
+			name:  "Success as of before any delegation",
+			baker: bakerA,
+			asOf:  datastore.AsOf{Timestamp: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+			page:  datastore.Page{Number: 1, Size: 10},
+			want:  nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetBakerDelegators(context.Background(), c.baker, c.asOf, c.page)
+			require.NoError(t, err)
+			require.Len(t, got, len(c.want))
+
+			for i := range c.want {
+				assertDelegator(t, c.want[i], got[i])
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func testGetBakersDelegators(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+
+	delegatorA := datastore.NewDelegator(bakersDelegations[0])
+	delegatorB := datastore.NewDelegator(bakersDelegations[1])
+	redelegatorA := datastore.NewDelegator(bakersDelegations[2])
+
+	bakers := []string{bakerB, bakerA, bakerC}
+
+	cases := []struct {
+		name   string
+		bakers []string
+		asOf   datastore.AsOf
+		page   datastore.Page
+		want   []*model.Delegator
+	}{
+		{
+			name:   "Success current",
+			bakers: bakers,
+			page:   datastore.Page{Number: 1, Size: 10},
+			want:   []*model.Delegator{redelegatorA},
+		},
+		{
+			name:   "Success as of timestamp, by baker then address",
+			bakers: bakers,
+			asOf:   datastore.AsOf{Timestamp: time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC)},
+			page:   datastore.Page{Number: 1, Size: 10},
+			want:   []*model.Delegator{delegatorB, redelegatorA},
+		},
+		{
+			name:   "Success as of level, a page by baker",
+			bakers: bakers,
+			asOf:   datastore.AsOf{Level: 3000002},
+			page:   datastore.Page{Number: 1, Size: 1},
+			want:   []*model.Delegator{delegatorA},
+		},
+		{
+			name:   "Success as of level, second page",
+			bakers: bakers,
+			asOf:   datastore.AsOf{Level: 3000002},
+			page:   datastore.Page{Number: 2, Size: 1},
+			want:   []*model.Delegator{delegatorB},
+		},
+		{
+			name:   "Success as of timestamp, a page of each baker",
+			bakers: bakers,
+			asOf:   datastore.AsOf{Timestamp: time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC)},
+			page:   datastore.Page{Number: 1, Size: 1},
+			want:   []*model.Delegator{delegatorB, redelegatorA},
+		},
+		{
+			name:   "Success without bakers",
+			bakers: nil,
+			page:   datastore.Page{Number: 1, Size: 10},
+			want:   nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetBakersDelegators(context.Background(), c.bakers, c.asOf, c.page)
+			require.NoError(t, err)
+			require.Len(t, got, len(c.want))
+
+			for i := range c.want {
+				assertDelegator(t, c.want[i], got[i])
+			}
+		})
+	}
+}
+
+func assertDelegator(t *testing.T, want, got *model.Delegator) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.Address, got.Address)
+	assert.Equal(t, want.Baker, got.Baker)
+	assert.Equal(t, want.Amount, got.Amount)
+	assert.True(
+		t,
+		want.Timestamp.Equal(got.Timestamp),
+		"timestamp: want %s, got %s", want.Timestamp, got.Timestamp,
+	)
+	assert.Equal(t, time.UTC, got.Timestamp.Location(), "timestamps must be returned in UTC")
+	assert.Equal(t, want.Level, got.Level)
+	assert.Equal(t, want.DelegationID, got.DelegationID)
+}
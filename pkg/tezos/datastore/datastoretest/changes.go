@@ -0,0 +1,141 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+//nolint:funlen
+func testDeleteDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+	ctx := context.Background()
+
+	// deleting the current delegation of delegatorA moves it back to bakerA, unknown ids are ignored
+	require.NoError(t, d.DeleteDelegations(ctx, []int64{23, 99}))
+
+	got, err := d.GetDelegations(ctx, datastore.Filter{}, datastore.DelegationsSort{}, nil, datastore.Page{})
+	require.NoError(t, err)
+	assertDelegations(
+		t,
+		[]*model.Delegation{bakersDelegations[3], bakersDelegations[1], bakersDelegations[0]},
+		got,
+	)
+
+	count, err := d.GetDelegationsCount(ctx, datastore.Filter{Year: 2023})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	delegator, err := d.GetDelegator(ctx, "tz1delegatorA")
+	require.NoError(t, err)
+	assertDelegator(t, datastore.NewDelegator(bakersDelegations[0]), delegator)
+
+	baker, err := d.GetBaker(ctx, bakerA)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&model.Baker{
+			Address: bakerA, Delegators: 1, DelegatedAmount: 100,
+			Inflows: 2, InflowAmount: 150, Outflows: 1, OutflowAmount: 50,
+		},
+		baker,
+	)
+
+	baker, err = d.GetBaker(ctx, bakerB)
+	require.NoError(t, err)
+	assert.Equal(t, &model.Baker{Address: bakerB}, baker)
+
+	// deleting the last delegation of delegatorA removes it
+	require.NoError(t, d.DeleteDelegations(ctx, []int64{21}))
+
+	delegator, err = d.GetDelegator(ctx, "tz1delegatorA")
+	require.NoError(t, err)
+	assert.Nil(t, delegator)
+
+	baker, err = d.GetBaker(ctx, bakerA)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&model.Baker{Address: bakerA, Inflows: 1, InflowAmount: 50, Outflows: 1, OutflowAmount: 50},
+		baker,
+	)
+
+	count, err = d.GetDelegationsCount(ctx, datastore.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+//nolint:funlen
+func testGetDelegationsChanges(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations[:2]...)
+	ctx := context.Background()
+
+	// storing the same delegations again isn't a change
+	require.NoError(t, d.StoreDelegations(ctx, bakersDelegations[:2]))
+
+	corrected := *bakersDelegations[1]
+	corrected.Amount = 60
+	require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{&corrected}))
+
+	require.NoError(t, d.DeleteDelegations(ctx, []int64{bakersDelegations[0].ID}))
+
+	want := []*model.DelegationChange{
+		{Operation: model.ChangeInsert, Delegation: bakersDelegations[0]},
+		{Operation: model.ChangeInsert, Delegation: bakersDelegations[1]},
+		{Operation: model.ChangeUpdate, Delegation: &corrected},
+		{Operation: model.ChangeDelete, Delegation: bakersDelegations[0]},
+	}
+
+	got, err := d.GetDelegationsChanges(ctx, 0, 0)
+	require.NoError(t, err)
+	assertChanges(t, want, got)
+
+	for i := 1; i < len(got); i++ {
+		assert.Greater(t, got[i].Sequence, got[i-1].Sequence, "sequences must be increasing")
+	}
+
+	latest, err := d.GetLatestDelegationsChange(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, got[3].Sequence, latest.Sequence)
+	assertChanges(t, want[3:], []*model.DelegationChange{latest})
+
+	t.Run("Since", func(t *testing.T) {
+		since, err := d.GetDelegationsChanges(ctx, got[1].Sequence, 0)
+		require.NoError(t, err)
+		assertChanges(t, want[2:], since)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		limited, err := d.GetDelegationsChanges(ctx, got[0].Sequence, 2)
+		require.NoError(t, err)
+		assertChanges(t, want[1:3], limited)
+	})
+
+	t.Run("Up to date", func(t *testing.T) {
+		none, err := d.GetDelegationsChanges(ctx, got[3].Sequence, 10)
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+}
+
+func assertChanges(t *testing.T, want, got []*model.DelegationChange) {
+	t.Helper()
+
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		assert.Positive(t, got[i].Sequence)
+		assert.Equal(t, want[i].Operation, got[i].Operation)
+		assertDelegation(t, want[i].Delegation, got[i].Delegation)
+	}
+}
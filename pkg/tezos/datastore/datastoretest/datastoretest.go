@@ -0,0 +1,55 @@
+// Package datastoretest provides a conformance test suite for datastore.Datastorer implementations.
+//
+// Every datastore backend runs the same suite so they can prove they behave identically:
+//
+//	func TestConformance(t *testing.T) {
+//		datastoretest.Run(t, func(t *testing.T) datastore.Datastorer {
+//			return newEmptyDatastore(t)
+//		})
+//	}
+package datastoretest
+
+import (
+	"testing"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+)
+
+// Factory returns a new empty datastore, ready to be used.
+// It is called once per test case, releasing resources is up to the factory (e.g. with t.Cleanup).
+type Factory func(t *testing.T) datastore.Datastorer
+
+// Run runs the whole conformance test suite against the datastores created by factory.
+// Test cases are run sequentially, so factories may reuse the same underlying storage once emptied.
+func Run(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("StoreDelegations", func(t *testing.T) { testStoreDelegations(t, factory) })
+	t.Run("GetLatestDelegation", func(t *testing.T) { testGetLatestDelegation(t, factory) })
+	t.Run("GetLegacyDelegations", func(t *testing.T) { testGetLegacyDelegations(t, factory) })
+	t.Run("GetDelegations", func(t *testing.T) { testGetDelegations(t, factory) })
+	t.Run("Filters", func(t *testing.T) { testFilters(t, factory) })
+	t.Run("Sort", func(t *testing.T) { testSort(t, factory) })
+	t.Run("Projection", func(t *testing.T) { testProjection(t, factory) })
+	t.Run("IterateDelegations", func(t *testing.T) { testIterateDelegations(t, factory) })
+	t.Run("GetDelegationsAfterCursor", func(t *testing.T) { testGetDelegationsAfterCursor(t, factory) })
+	t.Run("GetDelegationsCount", func(t *testing.T) { testGetDelegationsCount(t, factory) })
+	t.Run("Counts", func(t *testing.T) { testCounts(t, factory) })
+	t.Run("Bakers", func(t *testing.T) { testBakers(t, factory) })
+	t.Run("UpdatedDelegations", func(t *testing.T) { testUpdatedDelegations(t, factory) })
+	t.Run("RebuildBakers", func(t *testing.T) { testRebuildBakers(t, factory) })
+	t.Run("GetDelegator", func(t *testing.T) { testGetDelegator(t, factory) })
+	t.Run("GetBakerDelegators", func(t *testing.T) { testGetBakerDelegators(t, factory) })
+	t.Run("GetBakersDelegators", func(t *testing.T) { testGetBakersDelegators(t, factory) })
+	t.Run("GetDelegatorDelegations", func(t *testing.T) { testGetDelegatorDelegations(t, factory) })
+	t.Run("GetDelegatorsDelegations", func(t *testing.T) { testGetDelegatorsDelegations(t, factory) })
+	t.Run("GetDelegationsStats", func(t *testing.T) { testGetDelegationsStats(t, factory) })
+	t.Run("DeleteDelegations", func(t *testing.T) { testDeleteDelegations(t, factory) })
+	t.Run("GetDelegationsChanges", func(t *testing.T) { testGetDelegationsChanges(t, factory) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, factory) })
+	t.Run("WebhookDeliveries", func(t *testing.T) { testWebhookDeliveries(t, factory) })
+	t.Run("Alerts", func(t *testing.T) { testAlerts(t, factory) })
+	t.Run("YearBoundaries", func(t *testing.T) { testYearBoundaries(t, factory) })
+	t.Run("Empty", func(t *testing.T) { testEmpty(t, factory) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, factory) })
+}
@@ -0,0 +1,799 @@
+package datastoretest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+var (
+	delegation2023 = &model.Delegation{
+		ID:        3,
+		Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
+		Amount:    124428330,
+		Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
+		Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+	}
+	delegation2022 = &model.Delegation{
+		ID:        2,
+		Timestamp: time.Date(2022, 6, 1, 8, 30, 0, 0, time.UTC),
+		Amount:    1500,
+		Delegator: "tz1Kf25fX1VdmYGSEzwFy1wNmkbSEZ2V83sY",
+		Block:     "BLxQGrPcAPAwKaeCdivBVw45Choicesen6wrmdm3NBeGsCnkLKv",
+	}
+	delegation2021 = &model.Delegation{
+		ID:        1,
+		Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
+		Amount:    499836,
+		Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
+		Block:     "BKpbuK3tzwcdbmoV8RdKwkqyqQoR7k8DmDxvE1TaMfPrnzrJPhP",
+	}
+)
+
+// seed returns a new datastore from factory with the given delegations stored.
+func seed(t *testing.T, factory Factory, delegations ...*model.Delegation) datastore.Datastorer {
+	t.Helper()
+
+	d := factory(t)
+
+	if len(delegations) > 0 {
+		require.NoError(t, d.StoreDelegations(context.Background(), delegations))
+	}
+
+	return d
+}
+
+// assertDelegations asserts delegations are equal, comparing timestamps as instants.
+func assertDelegations(t *testing.T, want, got []*model.Delegation) {
+	t.Helper()
+
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		assertDelegation(t, want[i], got[i])
+	}
+}
+
+func assertDelegation(t *testing.T, want, got *model.Delegation) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.ID, got.ID)
+	assert.True(
+		t,
+		want.Timestamp.Equal(got.Timestamp),
+		"timestamp: want %s, got %s", want.Timestamp, got.Timestamp,
+	)
+	assert.Equal(t, time.UTC, got.Timestamp.Location(), "timestamps must be returned in UTC")
+	assert.Equal(t, want.Amount, got.Amount)
+	assert.Equal(t, want.Delegator, got.Delegator)
+	assert.Equal(t, want.Block, got.Block)
+	assert.Equal(t, want.Baker, got.Baker)
+	assert.Equal(t, want.PreviousBaker, got.PreviousBaker)
+	assert.Equal(t, want.Level, got.Level)
+}
+
+func testStoreDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("Success create", func(t *testing.T) {
+		d := seed(t, factory, delegation2023, delegation2021)
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Number: 1, Size: 10},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation2023, delegation2021}, got)
+	})
+
+	t.Run("Success update same identity", func(t *testing.T) {
+		d := seed(t, factory, delegation2023)
+
+		updated := *delegation2023
+		updated.Timestamp = updated.Timestamp.Add(time.Second)
+		updated.Amount = 42
+		updated.Block = "BLockUpdated"
+		require.NoError(t, d.StoreDelegations(context.Background(), []*model.Delegation{&updated}))
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Number: 1, Size: 10},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{&updated}, got)
+	})
+
+	t.Run("Success same timestamp different ids", func(t *testing.T) {
+		sameBlock := *delegation2023
+		sameBlock.ID = 4
+		sameBlock.Delegator = "tz1SameBlockDelegator"
+
+		d := seed(t, factory, delegation2023, &sameBlock)
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Number: 1, Size: 10},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{&sameBlock, delegation2023}, got)
+	})
+
+	t.Run("Success idempotent", func(t *testing.T) {
+		d := seed(t, factory, delegation2023, delegation2022, delegation2021)
+
+		require.NoError(t, d.StoreDelegations(
+			context.Background(),
+			[]*model.Delegation{delegation2023, delegation2022, delegation2021},
+		))
+
+		count, err := d.GetDelegationsCount(context.Background(), datastore.Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("Success does not keep references", func(t *testing.T) {
+		stored := *delegation2023
+		d := seed(t, factory, &stored)
+
+		stored.Amount = 0
+
+		got, err := d.GetLatestDelegation(context.Background())
+		require.NoError(t, err)
+		assertDelegation(t, delegation2023, got)
+	})
+
+	t.Run("Error empty delegations", func(t *testing.T) {
+		d := factory(t)
+
+		assert.Error(t, d.StoreDelegations(context.Background(), []*model.Delegation{}))
+	})
+}
+
+func testGetLatestDelegation(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, delegation2021, delegation2023, delegation2022)
+
+	got, err := d.GetLatestDelegation(context.Background())
+	require.NoError(t, err)
+	assertDelegation(t, delegation2023, got)
+}
+
+func testGetLegacyDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	legacy2022 := &model.Delegation{
+		ID:        -delegation2022.Timestamp.UnixMilli(),
+		Timestamp: delegation2022.Timestamp,
+		Amount:    delegation2022.Amount,
+		Delegator: delegation2022.Delegator,
+		Block:     delegation2022.Block,
+	}
+	legacy2021 := &model.Delegation{
+		ID:        -delegation2021.Timestamp.UnixMilli(),
+		Timestamp: delegation2021.Timestamp,
+		Amount:    delegation2021.Amount,
+		Delegator: delegation2021.Delegator,
+		Block:     delegation2021.Block,
+	}
+
+	d := seed(t, factory, delegation2023, legacy2022, delegation2022, legacy2021)
+
+	got, err := d.GetLegacyDelegations(context.Background(), 10)
+	require.NoError(t, err)
+	assertDelegations(t, []*model.Delegation{legacy2021, legacy2022}, got)
+
+	got, err = d.GetLegacyDelegations(context.Background(), 1)
+	require.NoError(t, err)
+	assertDelegations(t, []*model.Delegation{legacy2021}, got)
+
+	got, err = seed(t, factory, delegation2023).GetLegacyDelegations(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// the ingested delegations being applied, no delegation matches another status, legacy ones included
+	got, err = d.GetDelegations(
+		context.Background(),
+		datastore.Filter{Status: model.StatusFailed},
+		datastore.DelegationsSort{},
+		nil,
+		datastore.Page{Number: 1, Size: 10},
+	)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	count, err := d.GetDelegationsCount(context.Background(), datastore.Filter{Status: model.StatusFailed})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func testGetDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, delegation2021, delegation2023, delegation2022)
+
+	cases := []struct {
+		name                 string
+		filter               datastore.Filter
+		pageNumber, pageSize int
+		lookahead            bool
+		want                 []*model.Delegation
+	}{
+		{
+			name:       "Success sorted by timestamp desc",
+			pageNumber: 1,
+			pageSize:   10,
+			want:       []*model.Delegation{delegation2023, delegation2022, delegation2021},
+		},
+		{
+			name:       "Success first page",
+			pageNumber: 1,
+			pageSize:   2,
+			want:       []*model.Delegation{delegation2023, delegation2022},
+		},
+		{
+			name:       "Success partial last page",
+			pageNumber: 2,
+			pageSize:   2,
+			want:       []*model.Delegation{delegation2021},
+		},
+		{
+			name:       "Success page after the last one",
+			pageNumber: 3,
+			pageSize:   2,
+			want:       []*model.Delegation{},
+		},
+		{
+			name:       "Success first page with lookahead",
+			pageNumber: 1,
+			pageSize:   1,
+			lookahead:  true,
+			want:       []*model.Delegation{delegation2023, delegation2022},
+		},
+		{
+			name:       "Success last page with lookahead",
+			pageNumber: 3,
+			pageSize:   1,
+			lookahead:  true,
+			want:       []*model.Delegation{delegation2021},
+		},
+		{
+			name:       "Success exact page size",
+			pageNumber: 1,
+			pageSize:   3,
+			want:       []*model.Delegation{delegation2023, delegation2022, delegation2021},
+		},
+		{
+			name: "Success without page size returns everything",
+			want: []*model.Delegation{delegation2023, delegation2022, delegation2021},
+		},
+		{
+			name:       "Success with year",
+			pageNumber: 1,
+			pageSize:   10,
+			filter:     datastore.Filter{Year: 2022},
+			want:       []*model.Delegation{delegation2022},
+		},
+		{
+			name:       "Success with year without delegations",
+			pageNumber: 1,
+			pageSize:   10,
+			filter:     datastore.Filter{Year: 2020},
+			want:       []*model.Delegation{},
+		},
+		{
+			name:       "Success with from inclusive",
+			pageNumber: 1,
+			pageSize:   10,
+			filter:     datastore.Filter{From: delegation2022.Timestamp},
+			want:       []*model.Delegation{delegation2023, delegation2022},
+		},
+		{
+			name:       "Success with to exclusive",
+			pageNumber: 1,
+			pageSize:   10,
+			filter:     datastore.Filter{To: delegation2022.Timestamp},
+			want:       []*model.Delegation{delegation2021},
+		},
+		{
+			name:       "Success with from and to",
+			pageNumber: 1,
+			pageSize:   10,
+			filter: datastore.Filter{
+				From: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+			},
+			want: []*model.Delegation{delegation2023, delegation2022},
+		},
+		{
+			name:       "Success with year and from",
+			pageNumber: 1,
+			pageSize:   10,
+			filter: datastore.Filter{
+				Year: 2023,
+				From: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: []*model.Delegation{delegation2023},
+		},
+		{
+			name:       "Success with year outside from and to",
+			pageNumber: 1,
+			pageSize:   10,
+			filter: datastore.Filter{
+				Year: 2021,
+				From: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: []*model.Delegation{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetDelegations(
+				context.Background(),
+				c.filter,
+				datastore.DelegationsSort{},
+				nil,
+				datastore.Page{Number: c.pageNumber, Size: c.pageSize, Lookahead: c.lookahead},
+			)
+			require.NoError(t, err)
+			assertDelegations(t, c.want, got)
+		})
+	}
+}
+
+// testGetDelegationsAfterCursor checks keyset pagination, including delegations sharing the same timestamp
+// and delegations stored while paging.
+func testGetDelegationsAfterCursor(t *testing.T, factory Factory) {
+	t.Helper()
+
+	sameBlock := func(id int64) *model.Delegation {
+		return &model.Delegation{
+			ID:        id,
+			Timestamp: delegation2022.Timestamp,
+			Amount:    id,
+			Delegator: fmt.Sprintf("tz1SameBlock%d", id),
+			Block:     delegation2022.Block,
+		}
+	}
+
+	sameBlock10, sameBlock11, sameBlock12 := sameBlock(10), sameBlock(11), sameBlock(12)
+	all := []*model.Delegation{delegation2023, sameBlock12, sameBlock11, sameBlock10, delegation2022, delegation2021}
+
+	t.Run("Success pages through everything once", func(t *testing.T) {
+		d := seed(t, factory, delegation2021, delegation2022, sameBlock10, sameBlock11, sameBlock12, delegation2023)
+
+		var (
+			got   []*model.Delegation
+			after *datastore.Cursor
+		)
+
+		for pages := 0; pages < len(all); pages++ {
+			page, err := d.GetDelegations(
+				context.Background(),
+				datastore.Filter{},
+				datastore.DelegationsSort{},
+				nil,
+				datastore.Page{Size: 2, After: after},
+			)
+			require.NoError(t, err)
+
+			if len(page) == 0 {
+				break
+			}
+
+			got = append(got, page...)
+			after = datastore.NewCursor(page[len(page)-1])
+		}
+
+		assertDelegations(t, all, got)
+	})
+
+	t.Run("Success ignores page number", func(t *testing.T) {
+		d := seed(t, factory, delegation2021, delegation2022, sameBlock10, sameBlock11, sameBlock12, delegation2023)
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Number: 3, Size: 2, After: datastore.NewCursor(sameBlock11)},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{sameBlock10, delegation2022}, got)
+	})
+
+	t.Run("Success stable when delegations are stored while paging", func(t *testing.T) {
+		d := seed(t, factory, delegation2021, delegation2022, delegation2023)
+
+		first, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Size: 1},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation2023}, first)
+
+		newer := *delegation2023
+		newer.ID = 100
+		newer.Timestamp = newer.Timestamp.Add(time.Hour)
+		require.NoError(t, d.StoreDelegations(context.Background(), []*model.Delegation{&newer}))
+
+		second, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Size: 1, After: datastore.NewCursor(first[0])},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation2022}, second)
+	})
+
+	t.Run("Success with filter", func(t *testing.T) {
+		d := seed(t, factory, delegation2021, delegation2022, sameBlock10, sameBlock11, sameBlock12, delegation2023)
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{Year: 2022},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Size: 10, After: datastore.NewCursor(sameBlock11)},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{sameBlock10, delegation2022}, got)
+	})
+
+	t.Run("Success with lookahead", func(t *testing.T) {
+		d := seed(t, factory, delegation2021, delegation2022, delegation2023)
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Size: 1, After: datastore.NewCursor(delegation2023), Lookahead: true},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation2022, delegation2021}, got)
+	})
+
+	t.Run("Success after the last delegation", func(t *testing.T) {
+		d := seed(t, factory, delegation2021, delegation2022, delegation2023)
+
+		got, err := d.GetDelegations(
+			context.Background(),
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Size: 10, After: datastore.NewCursor(delegation2021)},
+		)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func testGetDelegationsCount(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, delegation2021, delegation2023, delegation2022)
+
+	cases := []struct {
+		name   string
+		filter datastore.Filter
+		want   int
+	}{
+		{name: "Success all", want: 3},
+		{name: "Success with year", filter: datastore.Filter{Year: 2023}, want: 1},
+		{name: "Success with year without delegations", filter: datastore.Filter{Year: 2020}, want: 0},
+		{name: "Success with from", filter: datastore.Filter{From: delegation2022.Timestamp}, want: 2},
+		{name: "Success with to", filter: datastore.Filter{To: delegation2022.Timestamp}, want: 1},
+		{
+			name: "Success with days range",
+			filter: datastore.Filter{
+				From: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC),
+			},
+			want: 1,
+		},
+		{
+			name:   "Success with days range and year",
+			filter: datastore.Filter{Year: 2023, From: time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)},
+			want:   1,
+		},
+		{name: "Success with from day", filter: datastore.Filter{From: time.Date(2021, 12, 11, 0, 0, 0, 0, time.UTC)}, want: 2},
+		{name: "Success with to day", filter: datastore.Filter{To: time.Date(2021, 12, 11, 0, 0, 0, 0, time.UTC)}, want: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetDelegationsCount(context.Background(), c.filter)
+			require.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+// testCounts checks the delegations counts are kept up to date when delegations are stored again.
+//
+//nolint:funlen
+func testCounts(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, delegation2021, delegation2023, delegation2022)
+	ctx := context.Background()
+
+	assertCounts := func(t *testing.T, want map[int]int) {
+		t.Helper()
+
+		total := 0
+
+		for year, wantCount := range want {
+			count, err := d.GetDelegationsCount(ctx, datastore.Filter{Year: year})
+			require.NoError(t, err)
+			assert.Equal(t, wantCount, count, "year %d", year)
+
+			total += wantCount
+		}
+
+		count, err := d.GetDelegationsCount(ctx, datastore.Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, total, count)
+
+		// day counters are checked against a real count
+		for year := range want {
+			dayRange := datastore.Filter{
+				From: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC),
+			}
+
+			days, err := d.GetDelegationsCount(ctx, dayRange)
+			require.NoError(t, err)
+
+			dayRange.To = dayRange.To.Add(time.Millisecond)
+			delegations, err := d.GetDelegations(ctx, dayRange, datastore.DelegationsSort{}, nil, datastore.Page{})
+			require.NoError(t, err)
+			assert.Len(t, delegations, days, "year %d", year)
+		}
+	}
+
+	t.Run("Seeded", func(t *testing.T) {
+		assertCounts(t, map[int]int{2021: 1, 2022: 1, 2023: 1})
+	})
+
+	t.Run("Re-ingested", func(t *testing.T) {
+		require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{delegation2023, delegation2022, delegation2022}))
+		assertCounts(t, map[int]int{2021: 1, 2022: 1, 2023: 1})
+	})
+
+	t.Run("Moved to another year", func(t *testing.T) {
+		moved := *delegation2021
+		moved.Timestamp = time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+		require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{&moved}))
+		assertCounts(t, map[int]int{2021: 0, 2022: 2, 2023: 1})
+	})
+
+	t.Run("Inserted", func(t *testing.T) {
+		inserted := *delegation2023
+		inserted.ID = 4
+
+		require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{&inserted, delegation2023}))
+		assertCounts(t, map[int]int{2021: 0, 2022: 2, 2023: 2})
+	})
+
+	t.Run("Rebuilt", func(t *testing.T) {
+		require.NoError(t, d.RebuildCounts(ctx))
+		assertCounts(t, map[int]int{2021: 0, 2022: 2, 2023: 2})
+	})
+}
+
+// testYearBoundaries checks years are computed on UTC timestamps, whatever the time zone they were stored with.
+func testYearBoundaries(t *testing.T, factory Factory) {
+	t.Helper()
+
+	paris := time.FixedZone("UTC+2", 2*60*60)
+	newYork := time.FixedZone("UTC-5", -5*60*60)
+
+	// 2022-12-31T22:30:00Z
+	lateIn2022 := &model.Delegation{
+		ID:        11,
+		Timestamp: time.Date(2023, 1, 1, 0, 30, 0, 0, paris),
+		Amount:    1,
+		Delegator: "tz1late2022",
+		Block:     "block1",
+	}
+	// 2022-12-31T23:59:59.999Z
+	lastMillisecondOf2022 := &model.Delegation{
+		ID:        12,
+		Timestamp: time.Date(2022, 12, 31, 23, 59, 59, int(999*time.Millisecond), time.UTC),
+		Amount:    2,
+		Delegator: "tz1last2022",
+		Block:     "block2",
+	}
+	// 2023-01-01T00:00:00Z
+	firstInstantOf2023 := &model.Delegation{
+		ID:        13,
+		Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Amount:    3,
+		Delegator: "tz1first2023",
+		Block:     "block3",
+	}
+	// 2023-01-01T01:00:00Z
+	earlyIn2023 := &model.Delegation{
+		ID:        14,
+		Timestamp: time.Date(2022, 12, 31, 20, 0, 0, 0, newYork),
+		Amount:    4,
+		Delegator: "tz1early2023",
+		Block:     "block4",
+	}
+
+	d := seed(t, factory, lateIn2022, lastMillisecondOf2022, firstInstantOf2023, earlyIn2023)
+
+	cases := []struct {
+		year int
+		want []*model.Delegation
+	}{
+		{year: 2022, want: []*model.Delegation{lastMillisecondOf2022, lateIn2022}},
+		{year: 2023, want: []*model.Delegation{earlyIn2023, firstInstantOf2023}},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("Year %d", c.year), func(t *testing.T) {
+			got, err := d.GetDelegations(
+				context.Background(),
+				datastore.Filter{Year: c.year},
+				datastore.DelegationsSort{},
+				nil,
+				datastore.Page{Number: 1, Size: 10},
+			)
+			require.NoError(t, err)
+			assertDelegations(t, c.want, got)
+
+			count, err := d.GetDelegationsCount(context.Background(), datastore.Filter{Year: c.year})
+			require.NoError(t, err)
+			assert.Equal(t, len(c.want), count)
+		})
+	}
+}
+
+func testEmpty(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := factory(t)
+	ctx := context.Background()
+
+	latest, err := d.GetLatestDelegation(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	delegations, err := d.GetDelegations(
+		ctx,
+		datastore.Filter{},
+		datastore.DelegationsSort{},
+		nil,
+		datastore.Page{Number: 1, Size: 10},
+	)
+	require.NoError(t, err)
+	assert.Empty(t, delegations)
+
+	count, err := d.GetDelegationsCount(ctx, datastore.Filter{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	require.NoError(t, d.RebuildCounts(ctx))
+
+	count, err = d.GetDelegationsCount(ctx, datastore.Filter{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	require.NoError(t, d.DeleteDelegations(ctx, []int64{1}))
+
+	changes, err := d.GetDelegationsChanges(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	latestChange, err := d.GetLatestDelegationsChange(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, latestChange)
+
+	webhooks, err := d.GetWebhooks(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	deliveries, err := d.GetDueWebhookDeliveries(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	alert, err := d.GetLatestAlert(ctx, "rule")
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+}
+
+// testConcurrency stores batches concurrently with readers, and checks nothing is lost.
+func testConcurrency(t *testing.T, factory Factory) {
+	t.Helper()
+
+	const (
+		writers   = 8
+		batchSize = 25
+	)
+
+	d := factory(t)
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 2*writers)
+
+	for w := 0; w < writers; w++ {
+		batch := make([]*model.Delegation, batchSize)
+		for i := range batch {
+			n := w*batchSize + i
+			batch[i] = &model.Delegation{
+				ID:        int64(n),
+				Timestamp: start.Add(time.Duration(n) * time.Minute),
+				Amount:    int64(n),
+				Delegator: fmt.Sprintf("tz1delegator%d", n),
+				Block:     fmt.Sprintf("block%d", n),
+			}
+		}
+
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			errs <- d.StoreDelegations(ctx, batch)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, err := d.GetDelegations(
+				ctx,
+				datastore.Filter{Year: 2023},
+				datastore.DelegationsSort{},
+				nil,
+				datastore.Page{Number: 1, Size: batchSize},
+			)
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	count, err := d.GetDelegationsCount(ctx, datastore.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, writers*batchSize, count)
+
+	latest, err := d.GetLatestDelegation(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(writers*batchSize-1), latest.Amount)
+}
@@ -0,0 +1,152 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+//nolint:funlen
+func testFilters(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+	ctx := context.Background()
+
+	amount := func(amount int64) *int64 {
+		return &amount
+	}
+
+	// delegations sorted by timestamp desc
+	delegation21, delegation22, delegation23, delegation24 := bakersDelegations[0], bakersDelegations[1],
+		bakersDelegations[2], bakersDelegations[3]
+
+	cases := []struct {
+		name   string
+		filter datastore.Filter
+		want   []*model.Delegation
+	}{
+		{
+			name:   "Delegator",
+			filter: datastore.Filter{Delegator: "tz1delegatorA"},
+			want:   []*model.Delegation{delegation23, delegation21},
+		},
+		{
+			name:   "Baker",
+			filter: datastore.Filter{Baker: bakerA},
+			want:   []*model.Delegation{delegation22, delegation21},
+		},
+		{
+			name:   "Previous baker",
+			filter: datastore.Filter{PreviousBaker: bakerA},
+			want:   []*model.Delegation{delegation24, delegation23},
+		},
+		{
+			name:   "Block",
+			filter: datastore.Filter{Block: "block23"},
+			want:   []*model.Delegation{delegation23},
+		},
+		{
+			name:   "Level",
+			filter: datastore.Filter{Level: 3000002},
+			want:   []*model.Delegation{delegation22},
+		},
+		{
+			name:   "Amount greater than, exclusive",
+			filter: datastore.Filter{AmountGt: amount(100)},
+			want:   []*model.Delegation{delegation23},
+		},
+		{
+			name:   "Amount lower than, exclusive",
+			filter: datastore.Filter{AmountLt: amount(100)},
+			want:   []*model.Delegation{delegation24, delegation22},
+		},
+		{
+			name:   "Amount range",
+			filter: datastore.Filter{AmountGt: amount(50), AmountLt: amount(120)},
+			want:   []*model.Delegation{delegation21},
+		},
+		{
+			name:   "Kind delegation",
+			filter: datastore.Filter{Kind: model.KindDelegation},
+			want:   []*model.Delegation{delegation22, delegation21},
+		},
+		{
+			name:   "Kind redelegation",
+			filter: datastore.Filter{Kind: model.KindRedelegation},
+			want:   []*model.Delegation{delegation23},
+		},
+		{
+			name:   "Kind undelegation",
+			filter: datastore.Filter{Kind: model.KindUndelegation},
+			want:   []*model.Delegation{delegation24},
+		},
+		{
+			name:   "Status applied",
+			filter: datastore.Filter{Status: model.StatusApplied},
+			want:   []*model.Delegation{delegation24, delegation23, delegation22, delegation21},
+		},
+		{
+			name:   "Status failed",
+			filter: datastore.Filter{Status: model.StatusFailed},
+			want:   []*model.Delegation{},
+		},
+		{
+			name: "Combined with a timestamp range",
+			filter: datastore.Filter{
+				Delegator: "tz1delegatorB",
+				From:      time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				To:        time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC),
+			},
+			want: []*model.Delegation{delegation22},
+		},
+		{
+			name:   "Combined without match",
+			filter: datastore.Filter{Delegator: "tz1delegatorB", Kind: model.KindRedelegation},
+			want:   []*model.Delegation{},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetDelegations(
+				ctx,
+				c.filter,
+				datastore.DelegationsSort{},
+				nil,
+				datastore.Page{Number: 1, Size: 10},
+			)
+			require.NoError(t, err)
+			assertDelegations(t, c.want, got)
+
+			count, err := d.GetDelegationsCount(ctx, c.filter)
+			require.NoError(t, err)
+			require.Equal(t, len(c.want), count)
+		})
+	}
+
+	t.Run("Cursor", func(t *testing.T) {
+		filter := datastore.Filter{Delegator: "tz1delegatorB"}
+
+		got, err := d.GetDelegations(ctx, filter, datastore.DelegationsSort{}, nil, datastore.Page{Size: 1})
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation24}, got)
+
+		got, err = d.GetDelegations(
+			ctx,
+			filter,
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{Size: 1, After: datastore.NewCursor(got[0])},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation22}, got)
+	})
+}
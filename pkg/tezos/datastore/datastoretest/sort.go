@@ -0,0 +1,217 @@
+package datastoretest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// errStop is returned by an iteration callback to stop the iteration.
+var errStop = errors.New("stop")
+
+//nolint:funlen
+func testSort(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+	ctx := context.Background()
+
+	// 21: amount 100, 22: amount 50, 23: amount 120, 24: amount 50, by increasing timestamp and level
+	delegation21, delegation22, delegation23, delegation24 := bakersDelegations[0], bakersDelegations[1],
+		bakersDelegations[2], bakersDelegations[3]
+
+	cases := []struct {
+		name string
+		sort datastore.DelegationsSort
+		page datastore.Page
+		want []*model.Delegation
+	}{
+		{
+			name: "Default",
+			want: []*model.Delegation{delegation24, delegation23, delegation22, delegation21},
+		},
+		{
+			name: "Timestamp ascending",
+			sort: datastore.DelegationsSort{Ascending: true},
+			want: []*model.Delegation{delegation21, delegation22, delegation23, delegation24},
+		},
+		{
+			name: "Amount descending, ties by timestamp descending",
+			sort: datastore.DelegationsSort{Field: datastore.DelegationsSortAmount},
+			want: []*model.Delegation{delegation23, delegation21, delegation24, delegation22},
+		},
+		{
+			name: "Amount ascending, ties by timestamp ascending",
+			sort: datastore.DelegationsSort{Field: datastore.DelegationsSortAmount, Ascending: true},
+			want: []*model.Delegation{delegation22, delegation24, delegation21, delegation23},
+		},
+		{
+			name: "Level ascending",
+			sort: datastore.DelegationsSort{Field: datastore.DelegationsSortLevel, Ascending: true},
+			want: []*model.Delegation{delegation21, delegation22, delegation23, delegation24},
+		},
+		{
+			name: "Amount descending second page",
+			sort: datastore.DelegationsSort{Field: datastore.DelegationsSortAmount},
+			page: datastore.Page{Number: 2, Size: 3},
+			want: []*model.Delegation{delegation22},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetDelegations(ctx, datastore.Filter{}, c.sort, nil, c.page)
+			require.NoError(t, err)
+			assertDelegations(t, c.want, got)
+		})
+	}
+
+	t.Run("Cursor requires the default sort", func(t *testing.T) {
+		_, err := d.GetDelegations(
+			ctx,
+			datastore.Filter{},
+			datastore.DelegationsSort{Field: datastore.DelegationsSortAmount},
+			nil,
+			datastore.Page{Size: 1, After: datastore.NewCursor(delegation23)},
+		)
+		assert.ErrorIs(t, err, datastore.ErrCursorSort)
+	})
+}
+
+func testProjection(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+	ctx := context.Background()
+
+	got, err := d.GetDelegations(
+		ctx,
+		datastore.Filter{Kind: model.KindRedelegation},
+		datastore.DelegationsSort{},
+		datastore.Projection{datastore.FieldAmount, datastore.FieldPreviousBaker},
+		datastore.Page{},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Delegation{{Amount: 120, PreviousBaker: bakerA}}, got)
+
+	got, err = d.GetDelegations(
+		ctx,
+		datastore.Filter{Kind: model.KindRedelegation},
+		datastore.DelegationsSort{},
+		datastore.Projection{datastore.FieldID, datastore.FieldTimestamp, datastore.FieldLevel, datastore.FieldBaker},
+		datastore.Page{},
+	)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, int64(23), got[0].ID)
+	assert.True(t, bakersDelegations[2].Timestamp.Equal(got[0].Timestamp))
+	assert.Equal(t, int64(3000003), got[0].Level)
+	assert.Equal(t, bakerB, got[0].Baker)
+	assert.Empty(t, got[0].Delegator)
+	assert.Zero(t, got[0].Amount)
+}
+
+//nolint:funlen
+func testIterateDelegations(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := seed(t, factory, bakersDelegations...)
+	ctx := context.Background()
+
+	// 21: amount 100, 22: amount 50, 23: amount 120, 24: amount 50, by increasing timestamp and level
+	delegation21, delegation22, delegation23 := bakersDelegations[0], bakersDelegations[1], bakersDelegations[2]
+	amount := int64(50)
+
+	collect := func(sort datastore.DelegationsSort, projection datastore.Projection) []*model.Delegation {
+		var got []*model.Delegation
+
+		err := d.IterateDelegations(
+			ctx,
+			datastore.Filter{AmountGt: &amount},
+			sort,
+			projection,
+			datastore.Page{},
+			func(delegation *model.Delegation) error {
+				got = append(got, delegation)
+
+				return nil
+			},
+		)
+		require.NoError(t, err)
+
+		return got
+	}
+
+	t.Run("Filtered and sorted", func(t *testing.T) {
+		got := collect(datastore.DelegationsSort{Ascending: true}, nil)
+		assertDelegations(t, []*model.Delegation{delegation21, delegation23}, got)
+	})
+
+	t.Run("Projected", func(t *testing.T) {
+		got := collect(
+			datastore.DelegationsSort{Field: datastore.DelegationsSortAmount},
+			datastore.Projection{datastore.FieldID, datastore.FieldAmount},
+		)
+		assert.Equal(t, []*model.Delegation{{ID: 23, Amount: 120}, {ID: 21, Amount: 100}}, got)
+	})
+
+	t.Run("Same page as GetDelegations", func(t *testing.T) {
+		page := datastore.Page{Size: 2, After: datastore.NewCursor(delegation23)}
+
+		var got []*model.Delegation
+
+		err := d.IterateDelegations(
+			ctx,
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			page,
+			func(delegation *model.Delegation) error {
+				got = append(got, delegation)
+
+				return nil
+			},
+		)
+		require.NoError(t, err)
+		assertDelegations(t, []*model.Delegation{delegation22, delegation21}, got)
+	})
+
+	t.Run("Stopped by the callback error", func(t *testing.T) {
+		calls := 0
+
+		err := d.IterateDelegations(
+			ctx,
+			datastore.Filter{},
+			datastore.DelegationsSort{},
+			nil,
+			datastore.Page{},
+			func(*model.Delegation) error {
+				calls++
+
+				return errStop
+			},
+		)
+		require.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Cursor requires the default sort", func(t *testing.T) {
+		err := d.IterateDelegations(
+			ctx,
+			datastore.Filter{},
+			datastore.DelegationsSort{Ascending: true},
+			nil,
+			datastore.Page{After: datastore.NewCursor(delegation23)},
+			func(*model.Delegation) error { return nil },
+		)
+		assert.ErrorIs(t, err, datastore.ErrCursorSort)
+	})
+}
@@ -0,0 +1,94 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+//nolint:funlen
+func testGetDelegationsStats(t *testing.T, factory Factory) {
+	t.Helper()
+
+	// bakersDelegations are from sunday 2023-01-01 to wednesday 2023-01-04
+	d := seed(t, factory, append([]*model.Delegation{delegation2022}, bakersDelegations...)...)
+
+	cases := []struct {
+		name     string
+		filter   datastore.Filter
+		interval datastore.Interval
+		want     []*model.DelegationsStats
+	}{
+		{
+			name:     "Success by day",
+			filter:   datastore.Filter{Year: 2023},
+			interval: datastore.IntervalDay,
+			want: []*model.DelegationsStats{
+				{Bucket: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Delegations: 1, Amount: 100, Delegators: 1},
+				{Bucket: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Delegations: 1, Amount: 50, Delegators: 1},
+				{Bucket: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Delegations: 1, Amount: 120, Delegators: 1},
+				{
+					Bucket:      time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC),
+					Delegations: 1, Amount: 50, Delegators: 1, Undelegations: 1,
+				},
+			},
+		},
+		{
+			name:     "Success by week",
+			filter:   datastore.Filter{Year: 2023},
+			interval: datastore.IntervalWeek,
+			want: []*model.DelegationsStats{
+				{Bucket: time.Date(2022, 12, 26, 0, 0, 0, 0, time.UTC), Delegations: 1, Amount: 100, Delegators: 1},
+				{
+					Bucket:      time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+					Delegations: 3, Amount: 220, Delegators: 2, Undelegations: 1,
+				},
+			},
+		},
+		{
+			name:     "Success by month with from",
+			filter:   datastore.Filter{From: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+			interval: datastore.IntervalMonth,
+			want: []*model.DelegationsStats{
+				{
+					Bucket:      time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+					Delegations: 3, Amount: 220, Delegators: 2, Undelegations: 1,
+				},
+			},
+		},
+		{
+			name:     "Success by year",
+			interval: datastore.IntervalYear,
+			want: []*model.DelegationsStats{
+				{
+					Bucket:      time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					Delegations: 1, Amount: delegation2022.Amount, Delegators: 1, Undelegations: 1,
+				},
+				{
+					Bucket:      time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+					Delegations: 4, Amount: 320, Delegators: 2, Undelegations: 1,
+				},
+			},
+		},
+		{
+			name:     "Success without delegations",
+			filter:   datastore.Filter{Year: 2020},
+			interval: datastore.IntervalDay,
+			want:     nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := d.GetDelegationsStats(context.Background(), c.filter, c.interval)
+			require.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
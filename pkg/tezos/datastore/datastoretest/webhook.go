@@ -0,0 +1,203 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// webhooks are a webhook called for every delegation, and one for the delegations to or away from bakerB.
+var webhooks = []*model.Webhook{
+	{
+		ID: "webhook1", Owner: "owner1", URL: "http://localhost/all", Secret: "secret1",
+		CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID: "webhook2", Owner: "owner2", URL: "http://localhost/bakerB", Secret: "secret2",
+		Filter: model.WebhookFilter{
+			Baker: bakerB, Delegator: "tz1delegatorA", MinAmount: 100, Kind: model.KindRedelegation,
+		},
+		CreatedAt: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func testWebhooks(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := factory(t)
+	ctx := context.Background()
+
+	// created out of order
+	require.NoError(t, d.CreateWebhook(ctx, webhooks[1]))
+	require.NoError(t, d.CreateWebhook(ctx, webhooks[0]))
+
+	got, err := d.GetWebhooks(ctx, "")
+	require.NoError(t, err)
+	assertWebhooks(t, webhooks, got)
+
+	got, err = d.GetWebhooks(ctx, webhooks[1].Owner)
+	require.NoError(t, err)
+	assertWebhooks(t, webhooks[1:], got)
+
+	got, err = d.GetWebhooks(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	webhook, err := d.GetWebhook(ctx, webhooks[1].ID)
+	require.NoError(t, err)
+	assertWebhooks(t, webhooks[1:], []*model.Webhook{webhook})
+
+	webhook, err = d.GetWebhook(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, webhook)
+
+	require.NoError(t, d.DeleteWebhook(ctx, webhooks[0].ID))
+	require.NoError(t, d.DeleteWebhook(ctx, "unknown"))
+
+	got, err = d.GetWebhooks(ctx, "")
+	require.NoError(t, err)
+	assertWebhooks(t, webhooks[1:], got)
+}
+
+//nolint:funlen
+func testWebhookDeliveries(t *testing.T, factory Factory) {
+	t.Helper()
+
+	d := factory(t)
+	ctx := context.Background()
+
+	for _, webhook := range webhooks {
+		require.NoError(t, d.CreateWebhook(ctx, webhook))
+	}
+
+	before := time.Now().Add(-time.Second)
+
+	require.NoError(t, d.StoreDelegations(ctx, bakersDelegations[:2]))
+	require.NoError(t, d.StoreDelegations(ctx, bakersDelegations[2:]))
+
+	// storing again or correcting a delegation isn't an ingestion
+	corrected := *bakersDelegations[2]
+	corrected.Amount = 130
+	require.NoError(t, d.StoreDelegations(ctx, []*model.Delegation{bakersDelegations[0], &corrected}))
+
+	changes, err := d.GetDelegationsChanges(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 5)
+
+	got, err := d.GetWebhookDeliveries(ctx, webhooks[0].ID, datastore.Page{})
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+
+	for i, delivery := range got {
+		change := changes[3-i]
+
+		assert.Equal(t, webhooks[0].ID, delivery.WebhookID)
+		assert.Equal(t, change.Sequence, delivery.Sequence)
+		assertDelegation(t, change.Delegation, delivery.Delegation)
+		assert.Equal(t, model.DeliveryPending, delivery.Status)
+		assert.Zero(t, delivery.Attempts)
+		assert.True(t, delivery.CreatedAt.After(before))
+		assert.True(t, delivery.NextAttemptAt.Equal(delivery.CreatedAt))
+	}
+
+	t.Run("Filtered", func(t *testing.T) {
+		got, err := d.GetWebhookDeliveries(ctx, webhooks[1].ID, datastore.Page{})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assertDelegation(t, bakersDelegations[2], got[0].Delegation)
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		page, err := d.GetWebhookDeliveries(ctx, webhooks[0].ID, datastore.Page{Number: 2, Size: 3})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, got[3].ID, page[0].ID)
+
+		page, err = d.GetWebhookDeliveries(ctx, "unknown", datastore.Page{Number: 1, Size: 3})
+		require.NoError(t, err)
+		assert.Empty(t, page)
+	})
+
+	t.Run("Due", func(t *testing.T) {
+		due, err := d.GetDueWebhookDeliveries(ctx, time.Now().Add(time.Second), 0)
+		require.NoError(t, err)
+		assert.Len(t, due, 5)
+
+		due, err = d.GetDueWebhookDeliveries(ctx, time.Now().Add(time.Second), 2)
+		require.NoError(t, err)
+		assert.Len(t, due, 2)
+
+		due, err = d.GetDueWebhookDeliveries(ctx, before, 0)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		next := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+		failed := *got[0]
+		failed.Attempts = 1
+		failed.StatusCode = 500
+		failed.Error = "500 Internal Server Error"
+		failed.NextAttemptAt = next
+		require.NoError(t, d.UpdateWebhookDelivery(ctx, &failed))
+
+		succeeded := *got[1]
+		succeeded.Status = model.DeliverySucceeded
+		succeeded.Attempts = 1
+		succeeded.StatusCode = 200
+		require.NoError(t, d.UpdateWebhookDelivery(ctx, &succeeded))
+
+		delivery, err := d.GetWebhookDelivery(ctx, failed.ID)
+		require.NoError(t, err)
+		require.NotNil(t, delivery)
+		assert.Equal(t, model.DeliveryPending, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, 500, delivery.StatusCode)
+		assert.Equal(t, failed.Error, delivery.Error)
+		assert.True(t, next.Equal(delivery.NextAttemptAt))
+
+		due, err := d.GetDueWebhookDeliveries(ctx, time.Now().Add(time.Second), 0)
+		require.NoError(t, err)
+		assert.Len(t, due, 3)
+
+		delivery, err = d.GetWebhookDelivery(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, delivery)
+	})
+
+	t.Run("DeleteWebhook", func(t *testing.T) {
+		require.NoError(t, d.DeleteWebhook(ctx, webhooks[0].ID))
+
+		deliveries, err := d.GetWebhookDeliveries(ctx, webhooks[0].ID, datastore.Page{})
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+
+		due, err := d.GetDueWebhookDeliveries(ctx, time.Now().Add(time.Second), 0)
+		require.NoError(t, err)
+		assert.Len(t, due, 1)
+	})
+}
+
+// assertWebhooks asserts webhooks are equal, comparing creation times as instants.
+func assertWebhooks(t *testing.T, want, got []*model.Webhook) {
+	t.Helper()
+
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		require.NotNil(t, got[i])
+		assert.Equal(t, want[i].ID, got[i].ID)
+		assert.Equal(t, want[i].Owner, got[i].Owner)
+		assert.Equal(t, want[i].URL, got[i].URL)
+		assert.Equal(t, want[i].Secret, got[i].Secret)
+		assert.Equal(t, want[i].Filter, got[i].Filter)
+		assert.True(t, want[i].CreatedAt.Equal(got[i].CreatedAt), "want %s, got %s", want[i].CreatedAt, got[i].CreatedAt)
+	}
+}
@@ -0,0 +1,126 @@
+package datastore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// ErrInvalidDelegationStatus is returned when parsing an unknown delegation status.
+var ErrInvalidDelegationStatus = errors.New("invalid delegation status")
+
+// Filter describes the filters applied when listing or counting delegations.
+// Its zero value matches every delegation, and each set field narrows the matched delegations.
+type Filter struct {
+	// Year keeps the delegations of a year (UTC), 0 means any year.
+	Year int
+	// From keeps the delegations with a timestamp greater than or equal to From, zero means no lower bound.
+	From time.Time
+	// To keeps the delegations with a timestamp strictly lower than To, zero means no upper bound.
+	To time.Time
+	// Delegator keeps the delegations of a delegator address.
+	Delegator string
+	// Baker keeps the delegations to a baker address.
+	Baker string
+	// PreviousBaker keeps the delegations from a previous baker address, redelegated or undelegated.
+	PreviousBaker string
+	// Block keeps the delegations of a block hash.
+	Block string
+	// Level keeps the delegations of a block level, 0 means any level.
+	Level int64
+	// AmountGt keeps the delegations with an amount strictly greater than AmountGt, nil means no lower bound.
+	AmountGt *int64
+	// AmountLt keeps the delegations with an amount strictly lower than AmountLt, nil means no upper bound.
+	AmountLt *int64
+	// Kind keeps the delegations of a kind (see DelegationKind): delegation, redelegation or undelegation.
+	Kind string
+	// Status keeps the delegations of an operation status. Only applied delegations are ingested, so any other
+	// status matches no delegation.
+	Status string
+}
+
+// ParseDelegationStatus parses a delegation status filter, empty meaning any status.
+func ParseDelegationStatus(status string) (string, error) {
+	switch status {
+	case "", model.StatusApplied, model.StatusFailed, model.StatusBacktracked, model.StatusSkipped:
+		return status, nil
+	default:
+		return "", ErrInvalidDelegationStatus
+	}
+}
+
+// TimestampOnly reports whether the filter only filters on timestamps, which precomputed counters and
+// timestamp indexes can answer alone.
+func (f Filter) TimestampOnly() bool {
+	return f.Delegator == "" && f.Baker == "" && f.PreviousBaker == "" && f.Block == "" && f.Level == 0 &&
+		f.AmountGt == nil && f.AmountLt == nil && f.Kind == "" && f.Status == ""
+}
+
+// MatchesNone reports whether the filter matches no ingested delegation, whatever the stored delegations.
+func (f Filter) MatchesNone() bool {
+	return f.Status != "" && f.Status != model.StatusApplied
+}
+
+// TimestampRange returns the timestamp range [from, to) matched by the filter, combining the year with
+// the from and to bounds, so datastores can filter on an indexed timestamp range.
+// A zero bound means the range is unbounded on that side.
+func (f Filter) TimestampRange() (from, to time.Time) {
+	from, to = f.From, f.To
+
+	if f.Year != 0 {
+		yearStart := time.Date(f.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		yearEnd := yearStart.AddDate(1, 0, 0)
+
+		if from.IsZero() || from.Before(yearStart) {
+			from = yearStart
+		}
+
+		if to.IsZero() || to.After(yearEnd) {
+			to = yearEnd
+		}
+	}
+
+	return from, to
+}
+
+// Matches reports whether a delegation matches the filter.
+func (f Filter) Matches(delegation *model.Delegation) bool {
+	from, to := f.TimestampRange()
+
+	if !from.IsZero() && delegation.Timestamp.Before(from) {
+		return false
+	}
+
+	if !to.IsZero() && !delegation.Timestamp.Before(to) {
+		return false
+	}
+
+	return f.matchesFields(delegation)
+}
+
+// matchesFields reports whether a delegation matches the filter fields other than its timestamp.
+//
+//nolint:cyclop
+func (f Filter) matchesFields(delegation *model.Delegation) bool {
+	switch {
+	case f.MatchesNone():
+		return false
+	case f.Delegator != "" && f.Delegator != delegation.Delegator:
+		return false
+	case f.Baker != "" && f.Baker != delegation.Baker:
+		return false
+	case f.PreviousBaker != "" && f.PreviousBaker != delegation.PreviousBaker:
+		return false
+	case f.Block != "" && f.Block != delegation.Block:
+		return false
+	case f.Level != 0 && f.Level != delegation.Level:
+		return false
+	case f.AmountGt != nil && delegation.Amount <= *f.AmountGt:
+		return false
+	case f.AmountLt != nil && delegation.Amount >= *f.AmountLt:
+		return false
+	default:
+		return f.Kind == "" || f.Kind == DelegationKind(delegation)
+	}
+}
@@ -0,0 +1,77 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestParseDelegationStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []string{"", model.StatusApplied, model.StatusFailed, model.StatusBacktracked} {
+		got, err := datastore.ParseDelegationStatus(status)
+		require.NoError(t, err)
+		assert.Equal(t, status, got)
+	}
+
+	_, err := datastore.ParseDelegationStatus("pending")
+	assert.ErrorIs(t, err, datastore.ErrInvalidDelegationStatus)
+}
+
+func TestFilter_Matches(t *testing.T) {
+	t.Parallel()
+
+	delegation := &model.Delegation{
+		ID: 1, Timestamp: time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1", Block: "block1", Level: 10, Baker: "baker2", PreviousBaker: "baker1",
+	}
+
+	amount := func(amount int64) *int64 {
+		return &amount
+	}
+
+	cases := []struct {
+		name   string
+		filter datastore.Filter
+		want   bool
+	}{
+		{name: "Empty filter", filter: datastore.Filter{}, want: true},
+		{name: "Year", filter: datastore.Filter{Year: 2023}, want: true},
+		{name: "Other year", filter: datastore.Filter{Year: 2022}, want: false},
+		{name: "To exclusive", filter: datastore.Filter{To: delegation.Timestamp}, want: false},
+		{name: "Delegator", filter: datastore.Filter{Delegator: "tz1"}, want: true},
+		{name: "Other delegator", filter: datastore.Filter{Delegator: "tz2"}, want: false},
+		{name: "Baker", filter: datastore.Filter{Baker: "baker2"}, want: true},
+		{name: "Previous baker", filter: datastore.Filter{Baker: "baker1"}, want: false},
+		{name: "Previous baker filter", filter: datastore.Filter{PreviousBaker: "baker1"}, want: true},
+		{name: "Other previous baker", filter: datastore.Filter{PreviousBaker: "baker2"}, want: false},
+		{name: "Block", filter: datastore.Filter{Block: "block1"}, want: true},
+		{name: "Other block", filter: datastore.Filter{Block: "block2"}, want: false},
+		{name: "Level", filter: datastore.Filter{Level: 10}, want: true},
+		{name: "Other level", filter: datastore.Filter{Level: 11}, want: false},
+		{name: "Amount greater than", filter: datastore.Filter{AmountGt: amount(99)}, want: true},
+		{name: "Amount not greater than", filter: datastore.Filter{AmountGt: amount(100)}, want: false},
+		{name: "Amount lower than", filter: datastore.Filter{AmountLt: amount(101)}, want: true},
+		{name: "Amount not lower than", filter: datastore.Filter{AmountLt: amount(100)}, want: false},
+		{name: "Kind", filter: datastore.Filter{Kind: model.KindRedelegation}, want: true},
+		{name: "Other kind", filter: datastore.Filter{Kind: model.KindUndelegation}, want: false},
+		{name: "Status applied", filter: datastore.Filter{Status: model.StatusApplied}, want: true},
+		{name: "Status skipped", filter: datastore.Filter{Status: model.StatusSkipped}, want: false},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, c.filter.Matches(delegation))
+		})
+	}
+}
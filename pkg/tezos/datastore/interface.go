@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"context"
+	"time"
 
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
 )
@@ -10,9 +11,46 @@ import (
 type Datastorer interface {
 	StoreDelegations(ctx context.Context, delegations []*model.Delegation) error
 	GetLatestDelegation(ctx context.Context) (*model.Delegation, error)
+	GetLegacyDelegations(ctx context.Context, limit int) ([]*model.Delegation, error)
 	GetDelegations(
 		ctx context.Context,
-		pageNumber, pageSize, year int,
+		filter Filter,
+		sort DelegationsSort,
+		projection Projection,
+		page Page,
 	) ([]*model.Delegation, error)
-	GetDelegationsCount(ctx context.Context, year int) (int, error)
+	IterateDelegations(
+		ctx context.Context,
+		filter Filter,
+		sort DelegationsSort,
+		projection Projection,
+		page Page,
+		fn func(delegation *model.Delegation) error,
+	) error
+	GetDelegationsCount(ctx context.Context, filter Filter) (int, error)
+	RebuildCounts(ctx context.Context) error
+	RebuildBakers(ctx context.Context) error
+	GetBakers(ctx context.Context, sort BakersSort, page Page) ([]*model.Baker, error)
+	GetBaker(ctx context.Context, address string) (*model.Baker, error)
+	GetBakersByAddresses(ctx context.Context, addresses []string) ([]*model.Baker, error)
+	GetBakersCount(ctx context.Context) (int, error)
+	GetDelegator(ctx context.Context, address string) (*model.Delegator, error)
+	GetBakerDelegators(ctx context.Context, baker string, asOf AsOf, page Page) ([]*model.Delegator, error)
+	GetBakersDelegators(ctx context.Context, bakers []string, asOf AsOf, page Page) ([]*model.Delegator, error)
+	GetDelegatorDelegations(ctx context.Context, delegator string) ([]*model.Delegation, error)
+	GetDelegatorsDelegations(ctx context.Context, delegators []string, limit int) ([]*model.Delegation, error)
+	GetDelegationsStats(ctx context.Context, filter Filter, interval Interval) ([]*model.DelegationsStats, error)
+	DeleteDelegations(ctx context.Context, ids []int64) error
+	GetDelegationsChanges(ctx context.Context, since int64, limit int) ([]*model.DelegationChange, error)
+	GetLatestDelegationsChange(ctx context.Context) (*model.DelegationChange, error)
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	GetWebhooks(ctx context.Context, owner string) ([]*model.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	GetWebhookDeliveries(ctx context.Context, webhookID string, page Page) ([]*model.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	CreateAlert(ctx context.Context, alert *model.Alert) (bool, error)
+	GetLatestAlert(ctx context.Context, rule string) (*model.Alert, error)
 }
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// CreateAlert store an alert, unless an alert with the same rule and key is already stored.
+// It reports whether the alert was stored.
+func (d *Datastore) CreateAlert(_ context.Context, alert *model.Alert) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, stored := range d.alerts {
+		if stored.Rule == alert.Rule && stored.Key == alert.Key {
+			return false, nil
+		}
+	}
+
+	stored := *alert
+	stored.Delegation = normalize(alert.Delegation)
+	stored.FiredAt = stored.FiredAt.UTC().Truncate(time.Millisecond)
+	d.alerts = append(d.alerts, &stored)
+
+	return true, nil
+}
+
+// GetLatestAlert get the latest alert emitted by a rule, nil if none.
+func (d *Datastore) GetLatestAlert(_ context.Context, rule string) (*model.Alert, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var latest *model.Alert
+
+	for _, alert := range d.alerts {
+		if alert.Rule == rule && (latest == nil || !alert.FiredAt.Before(latest.FiredAt)) {
+			latest = alert
+		}
+	}
+
+	if latest == nil {
+		return nil, nil
+	}
+
+	result := *latest
+	delegation := *latest.Delegation
+	result.Delegation = &delegation
+
+	return &result, nil
+}
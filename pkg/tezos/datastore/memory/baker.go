@@ -0,0 +1,302 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// GetBakers get a page of bakers aggregates.
+func (d *Datastore) GetBakers(
+	_ context.Context,
+	bakersSort datastore.BakersSort,
+	page datastore.Page,
+) ([]*model.Baker, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	bakers := make([]*model.Baker, 0, len(d.bakers))
+	for _, baker := range d.bakers {
+		bakers = append(bakers, baker)
+	}
+
+	sort.Slice(bakers, func(i, j int) bool {
+		return bakerBefore(bakersSort, bakers[i], bakers[j])
+	})
+
+	skip := 0
+	if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	if skip >= len(bakers) {
+		return nil, nil
+	}
+
+	bakers = bakers[skip:]
+
+	if page.Size > 0 && page.Size < len(bakers) {
+		bakers = bakers[:page.Size]
+	}
+
+	results := make([]*model.Baker, len(bakers))
+
+	for i, baker := range bakers {
+		result := *baker
+		results[i] = &result
+	}
+
+	return results, nil
+}
+
+// GetBaker get the aggregate of a baker, nil if the baker is unknown.
+func (d *Datastore) GetBaker(_ context.Context, address string) (*model.Baker, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	baker, found := d.bakers[address]
+	if !found {
+		return nil, nil
+	}
+
+	result := *baker
+
+	return &result, nil
+}
+
+// GetBakersByAddresses get the aggregates of the bakers of addresses, sorted by address.
+// Unknown bakers are skipped.
+func (d *Datastore) GetBakersByAddresses(_ context.Context, addresses []string) ([]*model.Baker, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	found := make(map[string]*model.Baker, len(addresses))
+
+	for _, address := range addresses {
+		if baker, ok := d.bakers[address]; ok {
+			result := *baker
+			found[address] = &result
+		}
+	}
+
+	results := make([]*model.Baker, 0, len(found))
+	for _, baker := range found {
+		results = append(results, baker)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Address < results[j].Address
+	})
+
+	return results, nil
+}
+
+// GetBakersCount get the number of bakers.
+func (d *Datastore) GetBakersCount(_ context.Context) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return len(d.bakers), nil
+}
+
+// RebuildBakers recompute the bakers aggregates and the current delegation of the delegators from the
+// stored delegations, e.g. for the delegations stored before they were maintained.
+func (d *Datastore) RebuildBakers(_ context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delegations := make([]*model.Delegation, 0, len(d.delegations))
+	for _, delegation := range d.delegations {
+		delegations = append(delegations, delegation)
+	}
+
+	// chronological order is the reverse of the timestamp then id desc order
+	sort.Slice(delegations, func(i, j int) bool {
+		return before(delegations[j], delegations[i])
+	})
+
+	rebuild := datastore.NewBakersRebuild()
+	for _, delegation := range delegations {
+		rebuild.Add(delegation)
+	}
+
+	d.bakers = rebuild.Bakers()
+	d.delegators = map[string]*model.Delegator{}
+
+	for _, delegator := range rebuild.Delegators() {
+		d.delegators[delegator.Address] = delegator
+	}
+
+	return nil
+}
+
+// applyDelegations updates the bakers aggregates and the delegators with new delegations.
+// The caller must hold the lock.
+func (d *Datastore) applyDelegations(delegations []*model.Delegation) {
+	changes, _ := datastore.ApplyDelegations(d.delegators, delegations)
+	d.applyBakersChanges(changes)
+}
+
+// applyBakersChanges adds changes to the bakers aggregates, by baker address.
+// The caller must hold the lock.
+func (d *Datastore) applyBakersChanges(changes map[string]*model.Baker) {
+	for address, change := range changes {
+		baker, found := d.bakers[address]
+		if !found {
+			baker = &model.Baker{Address: address}
+			d.bakers[address] = baker
+		}
+
+		baker.Delegators += change.Delegators
+		baker.DelegatedAmount += change.DelegatedAmount
+		baker.Inflows += change.Inflows
+		baker.InflowAmount += change.InflowAmount
+		baker.Outflows += change.Outflows
+		baker.OutflowAmount += change.OutflowAmount
+	}
+}
+
+// bakerBefore reports whether a comes before b when sorting bakers.
+func bakerBefore(bakersSort datastore.BakersSort, a, b *model.Baker) bool {
+	var diff int64
+
+	switch bakersSort.Field {
+	case datastore.BakersSortDelegators:
+		diff = int64(a.Delegators - b.Delegators)
+	case datastore.BakersSortDelegatedAmount:
+		diff = a.DelegatedAmount - b.DelegatedAmount
+	case datastore.BakersSortInflows:
+		diff = int64(a.Inflows - b.Inflows)
+	case datastore.BakersSortOutflows:
+		diff = int64(a.Outflows - b.Outflows)
+	}
+
+	if diff == 0 {
+		if bakersSort.Field == datastore.BakersSortAddress && bakersSort.Descending {
+			return a.Address > b.Address
+		}
+
+		return a.Address < b.Address
+	}
+
+	if bakersSort.Descending {
+		return diff > 0
+	}
+
+	return diff < 0
+}
+
+// GetDelegator get the current delegation of a delegator, nil if the delegator is unknown.
+func (d *Datastore) GetDelegator(_ context.Context, address string) (*model.Delegator, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	delegator, found := d.delegators[address]
+	if !found {
+		return nil, nil
+	}
+
+	result := *delegator
+
+	return &result, nil
+}
+
+// GetBakerDelegators get a page of the delegators of a baker as of a point in history, sorted by address.
+// The current delegators are read from the delegators current delegation, past ones are reconstructed
+// from the delegations history.
+func (d *Datastore) GetBakerDelegators(
+	ctx context.Context,
+	baker string,
+	asOf datastore.AsOf,
+	page datastore.Page,
+) ([]*model.Delegator, error) {
+	return d.GetBakersDelegators(ctx, []string{baker}, asOf, page)
+}
+
+// GetBakersDelegators get a page of the delegators of each of the bakers as of a point in history, sorted
+// by baker then address, the page applying to the delegators of each baker.
+func (d *Datastore) GetBakersDelegators(
+	_ context.Context,
+	bakers []string,
+	asOf datastore.AsOf,
+	page datastore.Page,
+) ([]*model.Delegator, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	delegators := d.delegators
+
+	if !asOf.IsZero() {
+		latest := map[string]*model.Delegation{}
+
+		for _, delegation := range d.delegations {
+			if !asOf.Includes(delegation) {
+				continue
+			}
+
+			if current := latest[delegation.Delegator]; current == nil || before(delegation, current) {
+				latest[delegation.Delegator] = delegation
+			}
+		}
+
+		delegators = make(map[string]*model.Delegator, len(latest))
+		for address, delegation := range latest {
+			delegators[address] = datastore.NewDelegator(delegation)
+		}
+	}
+
+	byBaker := make(map[string][]*model.Delegator, len(bakers))
+	for _, baker := range bakers {
+		byBaker[baker] = nil
+	}
+
+	for _, delegator := range delegators {
+		if _, found := byBaker[delegator.Baker]; found {
+			result := *delegator
+			byBaker[delegator.Baker] = append(byBaker[delegator.Baker], &result)
+		}
+	}
+
+	skip := 0
+	if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	var results []*model.Delegator
+
+	for _, baker := range sortedKeys(byBaker) {
+		matching := byBaker[baker]
+
+		sort.Slice(matching, func(i, j int) bool {
+			return matching[i].Address < matching[j].Address
+		})
+
+		if skip >= len(matching) {
+			continue
+		}
+
+		matching = matching[skip:]
+
+		if page.Size > 0 && page.Size < len(matching) {
+			matching = matching[:page.Size]
+		}
+
+		results = append(results, matching...)
+	}
+
+	return results, nil
+}
+
+// sortedKeys returns the keys of a map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// DeleteDelegations delete stored delegations, unknown ids being ignored. Counters, bakers aggregates and
+// delegators current delegation are updated accordingly.
+func (d *Datastore) DeleteDelegations(_ context.Context, ids []int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deleted := make([]*model.Delegation, 0, len(ids))
+
+	for _, id := range ids {
+		delegation, found := d.delegations[id]
+		if !found {
+			continue
+		}
+
+		deleted = append(deleted, delegation)
+		delete(d.delegations, id)
+	}
+
+	for key, delta := range datastore.DeleteCountDeltas(deleted) {
+		d.counts[key] += delta
+	}
+
+	d.removeDelegations(deleted)
+	d.appendChanges(datastore.DeleteChanges(deleted))
+
+	return nil
+}
+
+// removeDelegations updates the bakers aggregates and the delegators with delegations removed from the
+// stored delegations.
+// The caller must hold the lock.
+func (d *Datastore) removeDelegations(deleted []*model.Delegation) {
+	if len(deleted) == 0 {
+		return
+	}
+
+	// latest delegation remaining of the delegators of the deleted delegations
+	latest := map[string]*model.Delegation{}
+
+	for _, delegation := range deleted {
+		latest[delegation.Delegator] = nil
+	}
+
+	for _, delegation := range d.delegations {
+		current, found := latest[delegation.Delegator]
+		if found && (current == nil || before(delegation, current)) {
+			latest[delegation.Delegator] = delegation
+		}
+	}
+
+	changes, _, _ := datastore.RemoveDelegations(d.delegators, deleted, latest)
+	d.applyBakersChanges(changes)
+}
+
+// GetDelegationsChanges get the delegations changes with a sequence greater than since, in sequence order.
+// A limit of 0 means no limit.
+func (d *Datastore) GetDelegationsChanges(
+	_ context.Context,
+	since int64,
+	limit int,
+) ([]*model.DelegationChange, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var results []*model.DelegationChange
+
+	// sequences start at 1 and have no gaps, the change of sequence n is at index n-1
+	for i := int(since); i >= 0 && i < len(d.changes); i++ {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+
+		change := *d.changes[i]
+		delegation := *change.Delegation
+		change.Delegation = &delegation
+		results = append(results, &change)
+	}
+
+	return results, nil
+}
+
+// GetLatestDelegationsChange get the last change of the delegations change log, nil if it is empty.
+func (d *Datastore) GetLatestDelegationsChange(_ context.Context) (*model.DelegationChange, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(d.changes) == 0 {
+		return nil, nil
+	}
+
+	change := *d.changes[len(d.changes)-1]
+	delegation := *change.Delegation
+	change.Delegation = &delegation
+
+	return &change, nil
+}
+
+// appendChanges appends changes to the change log, assigning their sequence.
+// The caller must hold the lock.
+func (d *Datastore) appendChanges(changes []*model.DelegationChange) {
+	for _, change := range changes {
+		change.Sequence = int64(len(d.changes) + 1)
+		d.changes = append(d.changes, change)
+	}
+}
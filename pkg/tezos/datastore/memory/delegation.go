@@ -0,0 +1,337 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// errEmptyDelegations mirrors the mongo bulk write error when no delegation is given.
+var errEmptyDelegations = errors.New("must provide at least one element in input slice")
+
+// StoreDelegations store delegations in memory.
+func (d *Datastore) StoreDelegations(_ context.Context, delegations []*model.Delegation) error {
+	if len(delegations) == 0 {
+		return errEmptyDelegations
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored := make(map[int64]*model.Delegation, len(delegations))
+	normalized := make([]*model.Delegation, len(delegations))
+
+	for i, delegation := range delegations {
+		normalized[i] = normalize(delegation)
+
+		if previous, found := d.delegations[delegation.ID]; found {
+			stored[delegation.ID] = previous
+		}
+	}
+
+	for key, delta := range datastore.CountDeltas(stored, normalized) {
+		d.counts[key] += delta
+	}
+
+	// the previous values of the updated delegations are reversed before their new values are applied
+	previous, updated := datastore.UpdatedDelegations(stored, normalized)
+	for _, delegation := range previous {
+		delete(d.delegations, delegation.ID)
+	}
+
+	d.removeDelegations(previous)
+	d.applyDelegations(append(datastore.NewDelegations(stored, normalized), updated...))
+
+	changes := datastore.StoreChanges(stored, normalized)
+	d.appendChanges(changes)
+	d.createDeliveries(changes)
+
+	for _, delegation := range normalized {
+		d.delegations[delegation.ID] = delegation
+	}
+
+	return nil
+}
+
+// GetLatestDelegation get the latest delegation in memory (with the more recent timestamp).
+func (d *Datastore) GetLatestDelegation(_ context.Context) (*model.Delegation, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var latest *model.Delegation
+
+	for _, delegation := range d.delegations {
+		if latest == nil || before(delegation, latest) {
+			latest = delegation
+		}
+	}
+
+	if latest == nil {
+		return nil, nil
+	}
+
+	result := *latest
+
+	return &result, nil
+}
+
+// GetLegacyDelegations get the oldest delegations stored before delegations were identified by their
+// operation id, which have a provisional negative id, in chronological order.
+func (d *Datastore) GetLegacyDelegations(_ context.Context, limit int) ([]*model.Delegation, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var results []*model.Delegation
+
+	for _, delegation := range d.delegations {
+		if delegation.ID < 0 {
+			result := *delegation
+			results = append(results, &result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return before(results[j], results[i])
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// GetDelegations get a page of delegations matching the filter, sorted, with the projected fields.
+func (d *Datastore) GetDelegations(
+	_ context.Context,
+	filter datastore.Filter,
+	order datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+) ([]*model.Delegation, error) {
+	return d.page(filter, order, projection, page)
+}
+
+// IterateDelegations calls fn on each delegation of a page matching the filter, sorted, with the projected
+// fields. The page is copied before the iteration, so fn doesn't hold the lock. An error returned by fn stops
+// the iteration.
+func (d *Datastore) IterateDelegations(
+	_ context.Context,
+	filter datastore.Filter,
+	order datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+	fn func(delegation *model.Delegation) error,
+) error {
+	delegations, err := d.page(filter, order, projection, page)
+	if err != nil {
+		return err
+	}
+
+	for _, delegation := range delegations {
+		if err := fn(delegation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// page returns copies of a page of delegations matching the filter, sorted, with the projected fields.
+func (d *Datastore) page(
+	filter datastore.Filter,
+	order datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+) ([]*model.Delegation, error) {
+	if page.After != nil && !order.IsDefault() {
+		return nil, datastore.ErrCursorSort
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	matching := d.filter(filter)
+
+	datastore.SortDelegations(matching, order)
+
+	skip := 0
+
+	if page.After != nil {
+		// keyset pagination: delegations strictly after the cursor in the sort order
+		cursor := &model.Delegation{Timestamp: page.After.Timestamp, ID: page.After.ID}
+		skip = sort.Search(len(matching), func(i int) bool {
+			return before(cursor, matching[i])
+		})
+	} else if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	if skip >= len(matching) {
+		return nil, nil
+	}
+
+	matching = matching[skip:]
+
+	if limit := page.Limit(); limit > 0 && limit < len(matching) {
+		matching = matching[:limit]
+	}
+
+	results := make([]*model.Delegation, len(matching))
+
+	for i, delegation := range matching {
+		results[i] = projection.Project(delegation)
+	}
+
+	return results, nil
+}
+
+// GetDelegationsCount get the number of delegations matching the filter,
+// from the precomputed counters when the filter allows it.
+func (d *Datastore) GetDelegationsCount(_ context.Context, filter datastore.Filter) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query, ok := filter.CountQuery()
+	if !ok {
+		return len(d.filter(filter)), nil
+	}
+
+	if query.Key != "" {
+		return d.counts[query.Key], nil
+	}
+
+	count := 0
+
+	for key, value := range d.counts {
+		if key >= query.FromDay && key < query.ToDay {
+			count += value
+		}
+	}
+
+	return count, nil
+}
+
+// RebuildCounts recompute the precomputed delegations counters from the stored delegations.
+func (d *Datastore) RebuildCounts(_ context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	counts := map[string]int{}
+
+	for _, delegation := range d.delegations {
+		for _, key := range datastore.CountKeys(delegation.Timestamp) {
+			counts[key]++
+		}
+	}
+
+	d.counts = counts
+
+	return nil
+}
+
+// GetDelegatorDelegations get all the delegations of a delegator, in chronological order.
+func (d *Datastore) GetDelegatorDelegations(_ context.Context, delegator string) ([]*model.Delegation, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var results []*model.Delegation
+
+	for _, delegation := range d.delegations {
+		if delegation.Delegator == delegator {
+			result := *delegation
+			results = append(results, &result)
+		}
+	}
+
+	// chronological order is the reverse of the timestamp then id desc order
+	sort.Slice(results, func(i, j int) bool {
+		return before(results[j], results[i])
+	})
+
+	return results, nil
+}
+
+// GetDelegatorsDelegations get the latest delegations of several delegators, at most limit by delegator,
+// in chronological order. A limit of 0 means no limit.
+func (d *Datastore) GetDelegatorsDelegations(
+	_ context.Context,
+	delegators []string,
+	limit int,
+) ([]*model.Delegation, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	addresses := make(map[string]bool, len(delegators))
+	for _, delegator := range delegators {
+		addresses[delegator] = true
+	}
+
+	var results []*model.Delegation
+
+	for _, delegation := range d.delegations {
+		if addresses[delegation.Delegator] {
+			result := *delegation
+			results = append(results, &result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return before(results[j], results[i])
+	})
+
+	if limit <= 0 {
+		return results, nil
+	}
+
+	// the latest delegations of each delegator are kept, from the end of the chronological order
+	counts := make(map[string]int, len(delegators))
+	latest := make([]*model.Delegation, 0, len(results))
+
+	for i := len(results) - 1; i >= 0; i-- {
+		if counts[results[i].Delegator] < limit {
+			counts[results[i].Delegator]++
+			latest = append(latest, results[i])
+		}
+	}
+
+	slices.Reverse(latest)
+
+	return latest, nil
+}
+
+// filter returns the delegations matching the filter.
+// The caller must hold the lock.
+func (d *Datastore) filter(filter datastore.Filter) []*model.Delegation {
+	matching := make([]*model.Delegation, 0, len(d.delegations))
+
+	for _, delegation := range d.delegations {
+		if filter.Matches(delegation) {
+			matching = append(matching, delegation)
+		}
+	}
+
+	return matching
+}
+
+// before reports whether a comes before b when sorting by timestamp then id descending.
+func before(a, b *model.Delegation) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+
+	return a.ID > b.ID
+}
+
+// normalize copies a delegation the way mongo stores it: UTC timestamp with millisecond precision.
+func normalize(delegation *model.Delegation) *model.Delegation {
+	stored := *delegation
+	stored.Timestamp = time.UnixMilli(delegation.Timestamp.UnixMilli()).UTC()
+
+	return &stored
+}
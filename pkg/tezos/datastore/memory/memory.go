@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Config describes the in-memory datastore configuration.
+type Config struct {
+	// Seed is the path of a JSON array of delegations stored at initialization, empty to start empty.
+	Seed string
+}
+
+// Datastore represents an in-memory implementation of the datastore, safe for concurrent use.
+// It is meant for tests, demos and as the reference implementation of the datastore behaviour.
+type Datastore struct {
+	cfg *Config
+	mu  sync.RWMutex
+	// delegations are indexed by their id, which is the upsert identity.
+	delegations map[int64]*model.Delegation
+	// counts are the precomputed delegations counters, by key.
+	counts map[string]int
+	// bakers are the bakers aggregates, by address.
+	bakers map[string]*model.Baker
+	// delegators are the current delegation of the delegators, by address.
+	delegators map[string]*model.Delegator
+	// changes is the delegations change log, in sequence order.
+	changes []*model.DelegationChange
+	// webhooks are the webhooks, by id.
+	webhooks map[string]*model.Webhook
+	// deliveries are the webhooks deliveries, by id.
+	deliveries map[string]*model.WebhookDelivery
+	// alerts are the emitted alerts, in emission order.
+	alerts []*model.Alert
+}
+
+// New create a new in-memory datastore, starting empty.
+func New() *Datastore {
+	return NewWithConfig(&Config{})
+}
+
+// NewWithConfig create a new in-memory datastore, seeded at initialization as configured.
+func NewWithConfig(cfg *Config) *Datastore {
+	return &Datastore{
+		cfg:         cfg,
+		delegations: map[int64]*model.Delegation{},
+		counts:      map[string]int{},
+		bakers:      map[string]*model.Baker{},
+		delegators:  map[string]*model.Delegator{},
+		webhooks:    map[string]*model.Webhook{},
+		deliveries:  map[string]*model.WebhookDelivery{},
+	}
+}
+
+// Init initialize in-memory datastore, storing the seed delegations if any.
+func (d *Datastore) Init() error {
+	if d.cfg.Seed == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(d.cfg.Seed)
+	if err != nil {
+		return fmt.Errorf("couldn't read seed delegations: %w", err)
+	}
+
+	var delegations []*model.Delegation
+	if err := json.Unmarshal(data, &delegations); err != nil {
+		return fmt.Errorf("couldn't decode seed delegations %s: %w", d.cfg.Seed, err)
+	}
+
+	if len(delegations) == 0 {
+		return nil
+	}
+
+	return d.StoreDelegations(context.Background(), delegations)
+}
+
+// Close close in-memory datastore.
+func (d *Datastore) Close() error {
+	return nil
+}
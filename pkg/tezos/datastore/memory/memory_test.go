@@ -0,0 +1,62 @@
+package memory_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/datastoretest"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+)
+
+func TestDatastore_Conformance(t *testing.T) {
+	t.Parallel()
+
+	datastoretest.Run(t, func(t *testing.T) datastore.Datastorer {
+		t.Helper()
+
+		return memory.New()
+	})
+}
+
+// TestDatastore_InitSeeds checks the seed delegations are stored at init, with their aggregates.
+func TestDatastore_InitSeeds(t *testing.T) {
+	t.Parallel()
+
+	seed := filepath.Join(t.TempDir(), "delegations.json")
+	require.NoError(t, os.WriteFile(seed, []byte(`[
+	{"id": 1, "timestamp": "2023-12-10T11:01:01Z", "amount": 100, "delegator": "tz1a", "block": "b1", "baker": "tz1b"},
+	{"id": 2, "timestamp": "2023-12-11T11:01:01Z", "amount": 200, "delegator": "tz1c", "block": "b2", "baker": "tz1b"}
+]`), 0o600))
+
+	memorySvc := memory.NewWithConfig(&memory.Config{Seed: seed})
+	require.NoError(t, memorySvc.Init())
+
+	count, err := memorySvc.GetDelegationsCount(context.Background(), datastore.Filter{Year: 2023})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	baker, err := memorySvc.GetBaker(context.Background(), "tz1b")
+	require.NoError(t, err)
+	require.NotNil(t, baker)
+	assert.Equal(t, 2, baker.Delegators)
+	assert.Equal(t, int64(300), baker.DelegatedAmount)
+}
+
+func TestDatastore_InitInvalidSeed(t *testing.T) {
+	t.Parallel()
+
+	missing := memory.NewWithConfig(&memory.Config{Seed: filepath.Join(t.TempDir(), "missing.json")})
+	require.Error(t, missing.Init())
+
+	seed := filepath.Join(t.TempDir(), "delegations.json")
+	require.NoError(t, os.WriteFile(seed, []byte(`{"id": 1}`), 0o600))
+
+	invalid := memory.NewWithConfig(&memory.Config{Seed: seed})
+	require.Error(t, invalid.Init())
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// GetDelegationsStats get the stats of the delegations matching the filter, by bucket of interval,
+// in chronological order.
+func (d *Datastore) GetDelegationsStats(
+	_ context.Context,
+	filter datastore.Filter,
+	interval datastore.Interval,
+) ([]*model.DelegationsStats, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	buckets := map[int64]*model.DelegationsStats{}
+	delegators := map[int64]map[string]bool{}
+
+	for _, delegation := range d.filter(filter) {
+		bucket := interval.Truncate(delegation.Timestamp)
+		key := bucket.Unix()
+
+		stats, found := buckets[key]
+		if !found {
+			stats = &model.DelegationsStats{Bucket: bucket}
+			buckets[key] = stats
+			delegators[key] = map[string]bool{}
+		}
+
+		stats.Delegations++
+		stats.Amount += delegation.Amount
+		delegators[key][delegation.Delegator] = true
+
+		if delegation.Baker == "" {
+			stats.Undelegations++
+		}
+	}
+
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	results := make([]*model.DelegationsStats, 0, len(buckets))
+
+	for key, stats := range buckets {
+		stats.Delegators = len(delegators[key])
+		results = append(results, stats)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Bucket.Before(results[j].Bucket)
+	})
+
+	return results, nil
+}
@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// CreateWebhook store a new webhook.
+func (d *Datastore) CreateWebhook(_ context.Context, webhook *model.Webhook) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored := *webhook
+	stored.CreatedAt = stored.CreatedAt.UTC().Truncate(time.Millisecond)
+	d.webhooks[webhook.ID] = &stored
+
+	return nil
+}
+
+// GetWebhooks get the webhooks of an owner, every webhook when empty, sorted by creation.
+func (d *Datastore) GetWebhooks(_ context.Context, owner string) ([]*model.Webhook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var results []*model.Webhook
+
+	for _, webhook := range d.sortedWebhooks() {
+		if owner == "" || webhook.Owner == owner {
+			results = append(results, webhook)
+		}
+	}
+
+	return results, nil
+}
+
+// GetWebhook get a webhook by id, nil if not found.
+func (d *Datastore) GetWebhook(_ context.Context, id string) (*model.Webhook, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	webhook, found := d.webhooks[id]
+	if !found {
+		return nil, nil
+	}
+
+	result := *webhook
+
+	return &result, nil
+}
+
+// DeleteWebhook delete a webhook along with its deliveries, an unknown id being ignored.
+func (d *Datastore) DeleteWebhook(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.webhooks, id)
+
+	for deliveryID, delivery := range d.deliveries {
+		if delivery.WebhookID == id {
+			delete(d.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries get a page of the deliveries of a webhook, the latest delegations first.
+func (d *Datastore) GetWebhookDeliveries(
+	_ context.Context,
+	webhookID string,
+	page datastore.Page,
+) ([]*model.WebhookDelivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var results []*model.WebhookDelivery
+
+	for _, delivery := range d.deliveries {
+		if delivery.WebhookID == webhookID {
+			results = append(results, copyDelivery(delivery))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Sequence > results[j].Sequence
+	})
+
+	skip := 0
+	if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	if skip >= len(results) {
+		return nil, nil
+	}
+
+	results = results[skip:]
+
+	if page.Size > 0 && page.Size < len(results) {
+		results = results[:page.Size]
+	}
+
+	return results, nil
+}
+
+// GetWebhookDelivery get a webhook delivery by id, nil if not found.
+func (d *Datastore) GetWebhookDelivery(_ context.Context, id string) (*model.WebhookDelivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	delivery, found := d.deliveries[id]
+	if !found {
+		return nil, nil
+	}
+
+	return copyDelivery(delivery), nil
+}
+
+// GetDueWebhookDeliveries get the pending deliveries due at now, the earliest due first.
+// A limit of 0 means no limit.
+func (d *Datastore) GetDueWebhookDeliveries(
+	_ context.Context,
+	now time.Time,
+	limit int,
+) ([]*model.WebhookDelivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var results []*model.WebhookDelivery
+
+	for _, delivery := range d.deliveries {
+		if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			results = append(results, copyDelivery(delivery))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].NextAttemptAt.Equal(results[j].NextAttemptAt) {
+			return results[i].NextAttemptAt.Before(results[j].NextAttemptAt)
+		}
+
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// UpdateWebhookDelivery update the status of a stored webhook delivery, an unknown delivery being ignored.
+func (d *Datastore) UpdateWebhookDelivery(_ context.Context, delivery *model.WebhookDelivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored, found := d.deliveries[delivery.ID]
+	if !found {
+		return nil
+	}
+
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.StatusCode = delivery.StatusCode
+	stored.Error = delivery.Error
+	stored.NextAttemptAt = delivery.NextAttemptAt.UTC().Truncate(time.Millisecond)
+
+	return nil
+}
+
+// createDeliveries creates the deliveries of the delegations inserted by changes to the webhooks.
+// The caller must hold the lock.
+func (d *Datastore) createDeliveries(changes []*model.DelegationChange) {
+	if len(d.webhooks) == 0 {
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	for _, delivery := range datastore.WebhookDeliveries(d.sortedWebhooks(), changes, now) {
+		d.deliveries[delivery.ID] = copyDelivery(delivery)
+	}
+}
+
+// sortedWebhooks returns copies of the webhooks sorted by creation. The caller must hold the lock.
+func (d *Datastore) sortedWebhooks() []*model.Webhook {
+	results := make([]*model.Webhook, 0, len(d.webhooks))
+
+	for _, webhook := range d.webhooks {
+		result := *webhook
+		results = append(results, &result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+
+		return results[i].ID < results[j].ID
+	})
+
+	return results
+}
+
+// copyDelivery returns a deep copy of a delivery.
+func copyDelivery(delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	result := *delivery
+	delegation := *delivery.Delegation
+	result.Delegation = &delegation
+
+	return &result
+}
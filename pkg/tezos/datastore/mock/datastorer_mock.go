@@ -11,7 +11,9 @@ package mock_datastorer
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	datastore "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
 	model "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -39,23 +41,185 @@ func (m *MockDatastorer) EXPECT() *MockDatastorerMockRecorder {
 	return m.recorder
 }
 
+// CreateAlert mocks base method.
+func (m *MockDatastorer) CreateAlert(arg0 context.Context, arg1 *model.Alert) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAlert", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAlert indicates an expected call of CreateAlert.
+func (mr *MockDatastorerMockRecorder) CreateAlert(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAlert", reflect.TypeOf((*MockDatastorer)(nil).CreateAlert), arg0, arg1)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockDatastorer) CreateWebhook(arg0 context.Context, arg1 *model.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockDatastorerMockRecorder) CreateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockDatastorer)(nil).CreateWebhook), arg0, arg1)
+}
+
+// DeleteDelegations mocks base method.
+func (m *MockDatastorer) DeleteDelegations(arg0 context.Context, arg1 []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDelegations", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDelegations indicates an expected call of DeleteDelegations.
+func (mr *MockDatastorerMockRecorder) DeleteDelegations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDelegations", reflect.TypeOf((*MockDatastorer)(nil).DeleteDelegations), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockDatastorer) DeleteWebhook(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockDatastorerMockRecorder) DeleteWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockDatastorer)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// GetBaker mocks base method.
+func (m *MockDatastorer) GetBaker(arg0 context.Context, arg1 string) (*model.Baker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBaker", arg0, arg1)
+	ret0, _ := ret[0].(*model.Baker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBaker indicates an expected call of GetBaker.
+func (mr *MockDatastorerMockRecorder) GetBaker(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBaker", reflect.TypeOf((*MockDatastorer)(nil).GetBaker), arg0, arg1)
+}
+
+// GetBakerDelegators mocks base method.
+func (m *MockDatastorer) GetBakerDelegators(arg0 context.Context, arg1 string, arg2 datastore.AsOf, arg3 datastore.Page) ([]*model.Delegator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBakerDelegators", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*model.Delegator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBakerDelegators indicates an expected call of GetBakerDelegators.
+func (mr *MockDatastorerMockRecorder) GetBakerDelegators(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBakerDelegators", reflect.TypeOf((*MockDatastorer)(nil).GetBakerDelegators), arg0, arg1, arg2, arg3)
+}
+
+// GetBakers mocks base method.
+func (m *MockDatastorer) GetBakers(arg0 context.Context, arg1 datastore.BakersSort, arg2 datastore.Page) ([]*model.Baker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBakers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.Baker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBakers indicates an expected call of GetBakers.
+func (mr *MockDatastorerMockRecorder) GetBakers(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBakers", reflect.TypeOf((*MockDatastorer)(nil).GetBakers), arg0, arg1, arg2)
+}
+
+// GetBakersByAddresses mocks base method.
+func (m *MockDatastorer) GetBakersByAddresses(arg0 context.Context, arg1 []string) ([]*model.Baker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBakersByAddresses", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Baker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBakersByAddresses indicates an expected call of GetBakersByAddresses.
+func (mr *MockDatastorerMockRecorder) GetBakersByAddresses(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBakersByAddresses", reflect.TypeOf((*MockDatastorer)(nil).GetBakersByAddresses), arg0, arg1)
+}
+
+// GetBakersCount mocks base method.
+func (m *MockDatastorer) GetBakersCount(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBakersCount", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBakersCount indicates an expected call of GetBakersCount.
+func (mr *MockDatastorerMockRecorder) GetBakersCount(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBakersCount", reflect.TypeOf((*MockDatastorer)(nil).GetBakersCount), arg0)
+}
+
+// GetBakersDelegators mocks base method.
+func (m *MockDatastorer) GetBakersDelegators(arg0 context.Context, arg1 []string, arg2 datastore.AsOf, arg3 datastore.Page) ([]*model.Delegator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBakersDelegators", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*model.Delegator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBakersDelegators indicates an expected call of GetBakersDelegators.
+func (mr *MockDatastorerMockRecorder) GetBakersDelegators(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBakersDelegators", reflect.TypeOf((*MockDatastorer)(nil).GetBakersDelegators), arg0, arg1, arg2, arg3)
+}
+
 // GetDelegations mocks base method.
-func (m *MockDatastorer) GetDelegations(arg0 context.Context, arg1, arg2, arg3 int) ([]*model.Delegation, error) {
+func (m *MockDatastorer) GetDelegations(arg0 context.Context, arg1 datastore.Filter, arg2 datastore.DelegationsSort, arg3 datastore.Projection, arg4 datastore.Page) ([]*model.Delegation, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDelegations", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "GetDelegations", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].([]*model.Delegation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDelegations indicates an expected call of GetDelegations.
-func (mr *MockDatastorerMockRecorder) GetDelegations(arg0, arg1, arg2, arg3 any) *gomock.Call {
+func (mr *MockDatastorerMockRecorder) GetDelegations(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegations", reflect.TypeOf((*MockDatastorer)(nil).GetDelegations), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegations", reflect.TypeOf((*MockDatastorer)(nil).GetDelegations), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetDelegationsChanges mocks base method.
+func (m *MockDatastorer) GetDelegationsChanges(arg0 context.Context, arg1 int64, arg2 int) ([]*model.DelegationChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegationsChanges", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.DelegationChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegationsChanges indicates an expected call of GetDelegationsChanges.
+func (mr *MockDatastorerMockRecorder) GetDelegationsChanges(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegationsChanges", reflect.TypeOf((*MockDatastorer)(nil).GetDelegationsChanges), arg0, arg1, arg2)
 }
 
 // GetDelegationsCount mocks base method.
-func (m *MockDatastorer) GetDelegationsCount(arg0 context.Context, arg1 int) (int, error) {
+func (m *MockDatastorer) GetDelegationsCount(arg0 context.Context, arg1 datastore.Filter) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDelegationsCount", arg0, arg1)
 	ret0, _ := ret[0].(int)
@@ -69,6 +233,96 @@ func (mr *MockDatastorerMockRecorder) GetDelegationsCount(arg0, arg1 any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegationsCount", reflect.TypeOf((*MockDatastorer)(nil).GetDelegationsCount), arg0, arg1)
 }
 
+// GetDelegationsStats mocks base method.
+func (m *MockDatastorer) GetDelegationsStats(arg0 context.Context, arg1 datastore.Filter, arg2 datastore.Interval) ([]*model.DelegationsStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegationsStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.DelegationsStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegationsStats indicates an expected call of GetDelegationsStats.
+func (mr *MockDatastorerMockRecorder) GetDelegationsStats(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegationsStats", reflect.TypeOf((*MockDatastorer)(nil).GetDelegationsStats), arg0, arg1, arg2)
+}
+
+// GetDelegator mocks base method.
+func (m *MockDatastorer) GetDelegator(arg0 context.Context, arg1 string) (*model.Delegator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegator", arg0, arg1)
+	ret0, _ := ret[0].(*model.Delegator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegator indicates an expected call of GetDelegator.
+func (mr *MockDatastorerMockRecorder) GetDelegator(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegator", reflect.TypeOf((*MockDatastorer)(nil).GetDelegator), arg0, arg1)
+}
+
+// GetDelegatorDelegations mocks base method.
+func (m *MockDatastorer) GetDelegatorDelegations(arg0 context.Context, arg1 string) ([]*model.Delegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegatorDelegations", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Delegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegatorDelegations indicates an expected call of GetDelegatorDelegations.
+func (mr *MockDatastorerMockRecorder) GetDelegatorDelegations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegatorDelegations", reflect.TypeOf((*MockDatastorer)(nil).GetDelegatorDelegations), arg0, arg1)
+}
+
+// GetDelegatorsDelegations mocks base method.
+func (m *MockDatastorer) GetDelegatorsDelegations(arg0 context.Context, arg1 []string, arg2 int) ([]*model.Delegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelegatorsDelegations", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.Delegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelegatorsDelegations indicates an expected call of GetDelegatorsDelegations.
+func (mr *MockDatastorerMockRecorder) GetDelegatorsDelegations(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelegatorsDelegations", reflect.TypeOf((*MockDatastorer)(nil).GetDelegatorsDelegations), arg0, arg1, arg2)
+}
+
+// GetDueWebhookDeliveries mocks base method.
+func (m *MockDatastorer) GetDueWebhookDeliveries(arg0 context.Context, arg1 time.Time, arg2 int) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueWebhookDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueWebhookDeliveries indicates an expected call of GetDueWebhookDeliveries.
+func (mr *MockDatastorerMockRecorder) GetDueWebhookDeliveries(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueWebhookDeliveries", reflect.TypeOf((*MockDatastorer)(nil).GetDueWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// GetLatestAlert mocks base method.
+func (m *MockDatastorer) GetLatestAlert(arg0 context.Context, arg1 string) (*model.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestAlert", arg0, arg1)
+	ret0, _ := ret[0].(*model.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestAlert indicates an expected call of GetLatestAlert.
+func (mr *MockDatastorerMockRecorder) GetLatestAlert(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestAlert", reflect.TypeOf((*MockDatastorer)(nil).GetLatestAlert), arg0, arg1)
+}
+
 // GetLatestDelegation mocks base method.
 func (m *MockDatastorer) GetLatestDelegation(arg0 context.Context) (*model.Delegation, error) {
 	m.ctrl.T.Helper()
@@ -84,6 +338,138 @@ func (mr *MockDatastorerMockRecorder) GetLatestDelegation(arg0 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestDelegation", reflect.TypeOf((*MockDatastorer)(nil).GetLatestDelegation), arg0)
 }
 
+// GetLatestDelegationsChange mocks base method.
+func (m *MockDatastorer) GetLatestDelegationsChange(arg0 context.Context) (*model.DelegationChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestDelegationsChange", arg0)
+	ret0, _ := ret[0].(*model.DelegationChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestDelegationsChange indicates an expected call of GetLatestDelegationsChange.
+func (mr *MockDatastorerMockRecorder) GetLatestDelegationsChange(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestDelegationsChange", reflect.TypeOf((*MockDatastorer)(nil).GetLatestDelegationsChange), arg0)
+}
+
+// GetLegacyDelegations mocks base method.
+func (m *MockDatastorer) GetLegacyDelegations(arg0 context.Context, arg1 int) ([]*model.Delegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLegacyDelegations", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Delegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLegacyDelegations indicates an expected call of GetLegacyDelegations.
+func (mr *MockDatastorerMockRecorder) GetLegacyDelegations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLegacyDelegations", reflect.TypeOf((*MockDatastorer)(nil).GetLegacyDelegations), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockDatastorer) GetWebhook(arg0 context.Context, arg1 string) (*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockDatastorerMockRecorder) GetWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockDatastorer)(nil).GetWebhook), arg0, arg1)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockDatastorer) GetWebhookDeliveries(arg0 context.Context, arg1 string, arg2 datastore.Page) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockDatastorerMockRecorder) GetWebhookDeliveries(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockDatastorer)(nil).GetWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockDatastorer) GetWebhookDelivery(arg0 context.Context, arg1 string) (*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockDatastorerMockRecorder) GetWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockDatastorer)(nil).GetWebhookDelivery), arg0, arg1)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockDatastorer) GetWebhooks(arg0 context.Context, arg1 string) ([]*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockDatastorerMockRecorder) GetWebhooks(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockDatastorer)(nil).GetWebhooks), arg0, arg1)
+}
+
+// IterateDelegations mocks base method.
+func (m *MockDatastorer) IterateDelegations(arg0 context.Context, arg1 datastore.Filter, arg2 datastore.DelegationsSort, arg3 datastore.Projection, arg4 datastore.Page, arg5 func(*model.Delegation) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateDelegations", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateDelegations indicates an expected call of IterateDelegations.
+func (mr *MockDatastorerMockRecorder) IterateDelegations(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateDelegations", reflect.TypeOf((*MockDatastorer)(nil).IterateDelegations), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// RebuildBakers mocks base method.
+func (m *MockDatastorer) RebuildBakers(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildBakers", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebuildBakers indicates an expected call of RebuildBakers.
+func (mr *MockDatastorerMockRecorder) RebuildBakers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildBakers", reflect.TypeOf((*MockDatastorer)(nil).RebuildBakers), arg0)
+}
+
+// RebuildCounts mocks base method.
+func (m *MockDatastorer) RebuildCounts(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildCounts", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebuildCounts indicates an expected call of RebuildCounts.
+func (mr *MockDatastorerMockRecorder) RebuildCounts(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildCounts", reflect.TypeOf((*MockDatastorer)(nil).RebuildCounts), arg0)
+}
+
 // StoreDelegations mocks base method.
 func (m *MockDatastorer) StoreDelegations(arg0 context.Context, arg1 []*model.Delegation) error {
 	m.ctrl.T.Helper()
@@ -97,3 +483,17 @@ func (mr *MockDatastorerMockRecorder) StoreDelegations(arg0, arg1 any) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreDelegations", reflect.TypeOf((*MockDatastorer)(nil).StoreDelegations), arg0, arg1)
 }
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockDatastorer) UpdateWebhookDelivery(arg0 context.Context, arg1 *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockDatastorerMockRecorder) UpdateWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockDatastorer)(nil).UpdateWebhookDelivery), arg0, arg1)
+}
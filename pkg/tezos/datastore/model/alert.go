@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Alert represents an alert emitted by an alert rule on an ingested delegation.
+type Alert struct {
+	// Rule is the name of the rule emitting the alert.
+	Rule string `json:"rule"`
+	// Key identifies the alert within its rule, an alert being emitted once per key.
+	Key string `json:"key"`
+	// Type is the type of the rule: amount, bakerOutflow or watchedAddress.
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	// Baker is the address of the baker the alert is about, for baker alerts.
+	Baker string `json:"baker,omitempty"`
+	// Delegation is the delegation triggering the alert.
+	Delegation *Delegation `json:"delegation"`
+	FiredAt    time.Time   `json:"firedAt"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Baker represents the delegations aggregate of a baker.
+type Baker struct {
+	Address string `json:"address"`
+	// Delegators is the number of addresses currently delegating to the baker.
+	Delegators int `json:"delegators"`
+	// DelegatedAmount is the sum of the amounts of the current delegations to the baker.
+	DelegatedAmount int64 `json:"delegatedAmount"`
+	// Inflows is the number of delegations to the baker, InflowAmount the sum of their amounts.
+	Inflows      int   `json:"inflows"`
+	InflowAmount int64 `json:"inflowAmount"`
+	// Outflows is the number of delegations away from the baker, OutflowAmount the sum of their amounts.
+	Outflows      int   `json:"outflows"`
+	OutflowAmount int64 `json:"outflowAmount"`
+}
+
+// Delegator represents the current delegation of an address, which is its latest delegation.
+type Delegator struct {
+	Address string `json:"address"`
+	// Baker is the address of the baker currently delegated to, empty when undelegated.
+	Baker        string    `json:"baker"`
+	Amount       int64     `json:"amount"`
+	Timestamp    time.Time `json:"timestamp"`
+	Level        int64     `json:"level,omitempty"`
+	DelegationID int64     `json:"delegationId"`
+}
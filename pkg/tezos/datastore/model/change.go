@@ -0,0 +1,21 @@
+package model
+
+// Delegations change operations.
+const (
+	// ChangeInsert is a delegation stored for the first time.
+	ChangeInsert = "insert"
+	// ChangeUpdate is a stored delegation stored again with different values (e.g. a reorg correction).
+	ChangeUpdate = "update"
+	// ChangeDelete is a stored delegation deleted (e.g. an operation removed by a reorg).
+	ChangeDelete = "delete"
+)
+
+// DelegationChange represents a change of the stored delegations, in the delegations change log.
+type DelegationChange struct {
+	// Sequence orders the changes, it is strictly increasing.
+	Sequence int64 `json:"sequence"`
+	// Operation is the change operation: insert, update or delete.
+	Operation string `json:"operation"`
+	// Delegation is the delegation as stored by the change, or as it was before being deleted.
+	Delegation *Delegation `json:"delegation"`
+}
@@ -2,10 +2,26 @@ package model
 
 import "time"
 
+// Delegation operation statuses. Only applied delegations are ingested.
+const (
+	StatusApplied     = "applied"
+	StatusFailed      = "failed"
+	StatusBacktracked = "backtracked"
+	StatusSkipped     = "skipped"
+)
+
 // Delegation represents a delegation model in our datastore.
 type Delegation struct {
+	// ID is the tezos operation id, which identifies a delegation.
+	ID        int64 `json:"id"`
 	Timestamp time.Time
 	Amount    int64  `json:"amount"`
 	Delegator string `json:"delegator"`
 	Block     string `json:"block"`
+	// Level is the level of the block, 0 when unknown.
+	Level int64 `json:"level,omitempty"`
+	// Baker is the address of the baker delegated to, empty when the delegator undelegates.
+	Baker string `json:"baker,omitempty"`
+	// PreviousBaker is the address of the baker the delegator delegated to before, empty if none.
+	PreviousBaker string `json:"previousBaker,omitempty"`
 }
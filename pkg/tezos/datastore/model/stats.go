@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// DelegationsStats represents the delegations stats of a time bucket.
+type DelegationsStats struct {
+	// Bucket is the start of the bucket (UTC).
+	Bucket time.Time `json:"bucket"`
+	// Delegations is the number of delegations, Amount the sum of their amounts.
+	Delegations int   `json:"delegations"`
+	Amount      int64 `json:"amount"`
+	// Delegators is the number of unique delegators.
+	Delegators int `json:"delegators"`
+	// Undelegations is the number of delegations removing a delegation (without baker).
+	Undelegations int `json:"undelegations"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// Delegation kinds.
+const (
+	// KindDelegation is a delegation to a baker from an undelegated address.
+	KindDelegation = "delegation"
+	// KindRedelegation is a delegation moving from a baker to another.
+	KindRedelegation = "redelegation"
+	// KindUndelegation is a delegation removed, without baker.
+	KindUndelegation = "undelegation"
+)
+
+// Webhook deliveries status.
+const (
+	// DeliveryPending is a delivery waiting for its next attempt.
+	DeliveryPending = "pending"
+	// DeliverySucceeded is a delivery acknowledged by a 2xx response.
+	DeliverySucceeded = "succeeded"
+	// DeliveryFailed is a delivery given up after its last attempt.
+	DeliveryFailed = "failed"
+)
+
+// WebhookFilter describes the delegations a webhook is called for. Its zero value matches every delegation.
+type WebhookFilter struct {
+	// Baker keeps the delegations to or away from a baker.
+	Baker string `json:"baker,omitempty"`
+	// Delegator keeps the delegations of a delegator.
+	Delegator string `json:"delegator,omitempty"`
+	// MinAmount keeps the delegations with an amount greater than or equal to MinAmount.
+	MinAmount int64 `json:"minAmount,omitempty"`
+	// Kind keeps the delegations of a kind: delegation, redelegation or undelegation.
+	Kind string `json:"kind,omitempty"`
+}
+
+// Webhook represents a subscription to the ingested delegations matching its filter.
+type Webhook struct {
+	ID string `json:"id"`
+	// Owner identifies the API client which created the webhook, the only one managing it.
+	Owner string `json:"-"`
+	// URL is called with a POST request for each matching delegation.
+	URL string `json:"url"`
+	// Secret is the key of the HMAC signature of the deliveries.
+	Secret    string        `json:"secret,omitempty"`
+	Filter    WebhookFilter `json:"filter"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// WebhookDelivery represents the delivery of an ingested delegation to a webhook.
+type WebhookDelivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhookId"`
+	// Sequence is the sequence of the delegation insert in the delegations change log.
+	Sequence   int64       `json:"sequence"`
+	Delegation *Delegation `json:"delegation"`
+	// Status is the delivery status: pending, succeeded or failed.
+	Status string `json:"status"`
+	// Attempts is the number of delivery attempts.
+	Attempts int `json:"attempts"`
+	// StatusCode is the response status code of the last attempt, 0 if it got no response.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Error describes why the last attempt failed.
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// NextAttemptAt is when a pending delivery is attempted next.
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+}
@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// CreateAlert store an alert, unless an alert with the same rule and key is already stored.
+// It reports whether the alert was stored.
+func (d *Datastore) CreateAlert(ctx context.Context, alert *model.Alert) (bool, error) {
+	_, err := d.alerts.InsertOne(ctx, alert)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetLatestAlert get the latest alert emitted by a rule, nil if none.
+func (d *Datastore) GetLatestAlert(ctx context.Context, rule string) (*model.Alert, error) {
+	var result *model.Alert
+
+	err := d.alerts.FindOne(
+		ctx,
+		bson.M{"rule": rule},
+		options.FindOne().SetSort(bson.D{{Key: "firedat", Value: -1}, {Key: "_id", Value: -1}}),
+	).Decode(&result)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
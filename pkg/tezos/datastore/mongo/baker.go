@@ -0,0 +1,448 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// GetBakers get a page of bakers aggregates.
+func (d *Datastore) GetBakers(
+	ctx context.Context,
+	bakersSort datastore.BakersSort,
+	page datastore.Page,
+) ([]*model.Baker, error) {
+	skip := 0
+	if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	opts := options.Find().
+		SetSort(bakersSortFields(bakersSort)).
+		SetSkip(int64(skip)).
+		SetLimit(int64(page.Size))
+
+	cursor, err := d.bakers.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Baker
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetBaker get the aggregate of a baker, nil if the baker is unknown.
+func (d *Datastore) GetBaker(ctx context.Context, address string) (*model.Baker, error) {
+	var result *model.Baker
+
+	err := d.bakers.FindOne(ctx, bson.M{"address": address}).Decode(&result)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// GetBakersByAddresses get the aggregates of the bakers of addresses, sorted by address.
+// Unknown bakers are skipped.
+func (d *Datastore) GetBakersByAddresses(ctx context.Context, addresses []string) ([]*model.Baker, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	sort := options.Find().SetSort(bson.D{primitive.E{Key: "address", Value: 1}})
+
+	cursor, err := d.bakers.Find(ctx, bson.M{"address": bson.M{"$in": addresses}}, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Baker
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetBakersCount get the number of bakers.
+func (d *Datastore) GetBakersCount(ctx context.Context) (int, error) {
+	count, err := d.bakers.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// RebuildBakers recompute the bakers aggregates and the current delegation of the delegators from the
+// stored delegations, e.g. for the delegations stored before they were maintained.
+func (d *Datastore) RebuildBakers(ctx context.Context) error {
+	sort := options.Find().SetSort(bson.D{
+		primitive.E{Key: "timestamp", Value: 1},
+		primitive.E{Key: "id", Value: 1},
+	})
+
+	cursor, err := d.delegations.Find(ctx, bson.M{}, sort)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // the iteration error is the one worth returning
+	defer cursor.Close(ctx)
+
+	rebuild := datastore.NewBakersRebuild()
+
+	for cursor.Next(ctx) {
+		var delegation model.Delegation
+
+		if err := cursor.Decode(&delegation); err != nil {
+			return err
+		}
+
+		rebuild.Add(&delegation)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if _, err := d.bakers.DeleteMany(ctx, bson.M{}); err != nil {
+		return err
+	}
+
+	if _, err := d.delegators.DeleteMany(ctx, bson.M{}); err != nil {
+		return err
+	}
+
+	if err := d.upsertDelegators(ctx, rebuild.Delegators()); err != nil {
+		return err
+	}
+
+	return d.incrementBakers(ctx, rebuild.Bakers())
+}
+
+// applyDelegations updates the bakers aggregates and the delegators with new delegations.
+func (d *Datastore) applyDelegations(ctx context.Context, delegations []*model.Delegation) error {
+	if len(delegations) == 0 {
+		return nil
+	}
+
+	delegators, err := d.currentDelegators(ctx, delegations)
+	if err != nil {
+		return err
+	}
+
+	changes, updated := datastore.ApplyDelegations(delegators, delegations)
+
+	if err := d.upsertDelegators(ctx, updated); err != nil {
+		return err
+	}
+
+	return d.incrementBakers(ctx, changes)
+}
+
+// upsertDelegators stores the current delegation of delegators.
+func (d *Datastore) upsertDelegators(ctx context.Context, delegators []*model.Delegator) error {
+	if len(delegators) == 0 {
+		return nil
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(delegators))
+
+	for _, delegator := range delegators {
+		upsert := mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"address": delegator.Address}).
+			SetReplacement(delegator).
+			SetUpsert(true)
+		writeModels = append(writeModels, upsert)
+	}
+
+	_, err := d.delegators.BulkWrite(ctx, writeModels)
+
+	return err
+}
+
+// incrementBakers adds changes to the bakers aggregates.
+func (d *Datastore) incrementBakers(ctx context.Context, changes map[string]*model.Baker) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(changes))
+
+	for _, change := range changes {
+		increment := mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"address": change.Address}).
+			SetUpdate(bson.M{"$inc": bson.M{
+				"delegators":      change.Delegators,
+				"delegatedamount": change.DelegatedAmount,
+				"inflows":         change.Inflows,
+				"inflowamount":    change.InflowAmount,
+				"outflows":        change.Outflows,
+				"outflowamount":   change.OutflowAmount,
+			}}).
+			SetUpsert(true)
+		writeModels = append(writeModels, increment)
+	}
+
+	_, err := d.bakers.BulkWrite(ctx, writeModels)
+
+	return err
+}
+
+// currentDelegators returns the current delegation of the delegators of the delegations, by address.
+func (d *Datastore) currentDelegators(
+	ctx context.Context,
+	delegations []*model.Delegation,
+) (map[string]*model.Delegator, error) {
+	addresses := make(bson.A, 0, len(delegations))
+
+	for _, delegation := range delegations {
+		addresses = append(addresses, delegation.Delegator)
+	}
+
+	cursor, err := d.delegators.Find(ctx, bson.M{"address": bson.M{"$in": addresses}})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Delegator
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	delegators := make(map[string]*model.Delegator, len(results))
+
+	for _, result := range results {
+		delegators[result.Address] = result
+	}
+
+	return delegators, nil
+}
+
+// bakersSortFields returns the sort of a bakers sort, ties being sorted by address.
+func bakersSortFields(bakersSort datastore.BakersSort) bson.D {
+	direction := 1
+	if bakersSort.Descending {
+		direction = -1
+	}
+
+	fields := map[string]string{
+		datastore.BakersSortDelegators:      "delegators",
+		datastore.BakersSortDelegatedAmount: "delegatedamount",
+		datastore.BakersSortInflows:         "inflows",
+		datastore.BakersSortOutflows:        "outflows",
+	}
+
+	field, found := fields[bakersSort.Field]
+	if !found {
+		return bson.D{primitive.E{Key: "address", Value: direction}}
+	}
+
+	return bson.D{
+		primitive.E{Key: field, Value: direction},
+		primitive.E{Key: "address", Value: 1},
+	}
+}
+
+// GetDelegator get the current delegation of a delegator, nil if the delegator is unknown.
+func (d *Datastore) GetDelegator(ctx context.Context, address string) (*model.Delegator, error) {
+	var result *model.Delegator
+
+	err := d.delegators.FindOne(ctx, bson.M{"address": address}).Decode(&result)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// GetBakerDelegators get a page of the delegators of a baker as of a point in history, sorted by address.
+// The current delegators are read from the delegators current delegation, past ones are reconstructed
+// from the delegations history.
+func (d *Datastore) GetBakerDelegators(
+	ctx context.Context,
+	baker string,
+	asOf datastore.AsOf,
+	page datastore.Page,
+) ([]*model.Delegator, error) {
+	skip := 0
+	if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	if !asOf.IsZero() {
+		return d.bakersDelegatorsAsOf(ctx, []string{baker}, asOf, skip, page.Size)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{primitive.E{Key: "address", Value: 1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(page.Size))
+
+	cursor, err := d.delegators.Find(ctx, bson.M{"baker": baker}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Delegator
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetBakersDelegators get a page of the delegators of each of the bakers as of a point in history, sorted
+// by baker then address, the page applying to the delegators of each baker.
+func (d *Datastore) GetBakersDelegators(
+	ctx context.Context,
+	bakers []string,
+	asOf datastore.AsOf,
+	page datastore.Page,
+) ([]*model.Delegator, error) {
+	if len(bakers) == 0 {
+		return nil, nil
+	}
+
+	skip := 0
+	if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	if !asOf.IsZero() {
+		return d.bakersDelegatorsAsOf(ctx, bakers, asOf, skip, page.Size)
+	}
+
+	pipeline := append(
+		mongo.Pipeline{{{Key: "$match", Value: bson.M{"baker": bson.M{"$in": bakers}}}}},
+		pagesByBaker("baker", "address", skip, page.Size)...,
+	)
+
+	cursor, err := d.delegators.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Delegator
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// bakersDelegatorsAsOf reconstructs the delegators of bakers from the delegations history: the delegators
+// whose latest delegation as of a point in history is to one of the bakers.
+func (d *Datastore) bakersDelegatorsAsOf(
+	ctx context.Context,
+	bakers []string,
+	asOf datastore.AsOf,
+	skip, limit int,
+) ([]*model.Delegator, error) {
+	match := asOfFilter(asOf)
+	match["baker"] = bson.M{"$in": bakers}
+
+	// only the delegators which delegated to the bakers can be their delegators
+	delegators, err := d.delegations.Distinct(ctx, "delegator", match)
+	if err != nil {
+		return nil, err
+	}
+
+	match = asOfFilter(asOf)
+	match["delegator"] = bson.M{"$in": delegators}
+
+	pipeline := append(mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{
+			primitive.E{Key: "delegator", Value: 1},
+			primitive.E{Key: "timestamp", Value: -1},
+			primitive.E{Key: "id", Value: -1},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$delegator", "latest": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$match", Value: bson.M{"latest.baker": bson.M{"$in": bakers}}}},
+	}, pagesByBaker("latest.baker", "_id", skip, limit)...)
+
+	cursor, err := d.delegations.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var latests []struct {
+		Latest *model.Delegation `bson:"latest"`
+	}
+
+	err = cursor.All(ctx, &latests)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Delegator
+
+	for _, latest := range latests {
+		results = append(results, datastore.NewDelegator(latest.Latest))
+	}
+
+	return results, nil
+}
+
+// pagesByBaker returns the stages keeping a page of the delegators of each baker, ranked by address, sorted by
+// baker then address. A limit of 0 means no limit.
+func pagesByBaker(baker, address string, skip, limit int) mongo.Pipeline {
+	position := bson.M{"$gt": skip}
+	if limit > 0 {
+		position["$lte"] = skip + limit
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$setWindowFields", Value: bson.M{
+			"partitionBy": "$" + baker,
+			"sortBy":      bson.D{primitive.E{Key: address, Value: 1}},
+			"output":      bson.M{"position": bson.M{"$documentNumber": bson.M{}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"position": position}}},
+		{{Key: "$unset", Value: "position"}},
+		{{Key: "$sort", Value: bson.D{primitive.E{Key: baker, Value: 1}, primitive.E{Key: address, Value: 1}}}},
+	}
+}
+
+// asOfFilter returns the filter keeping the delegations as of a point in history.
+func asOfFilter(asOf datastore.AsOf) bson.M {
+	filter := bson.M{}
+
+	if !asOf.Timestamp.IsZero() {
+		filter["timestamp"] = bson.M{"$lte": asOf.Timestamp}
+	}
+
+	if asOf.Level != 0 {
+		filter["level"] = bson.M{"$lte": asOf.Level}
+	}
+
+	return filter
+}
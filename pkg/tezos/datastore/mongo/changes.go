@@ -0,0 +1,203 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// changesSequence is the name of the delegations change log sequence.
+const changesSequence = "delegation_changes"
+
+// DeleteDelegations delete stored delegations, unknown ids being ignored. Counters, bakers aggregates and
+// delegators current delegation are updated accordingly, in the same transaction when the deployment supports
+// transactions, before the delegations are deleted otherwise.
+func (d *Datastore) DeleteDelegations(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return d.withTransaction(ctx, func(ctx context.Context) error {
+		return d.deleteDelegations(ctx, ids)
+	})
+}
+
+// deleteDelegations writes the data derived from the deleted delegations, then deletes them.
+func (d *Datastore) deleteDelegations(ctx context.Context, ids []int64) error {
+	toDelete := make([]*model.Delegation, len(ids))
+	for i, id := range ids {
+		toDelete[i] = &model.Delegation{ID: id}
+	}
+
+	stored, err := d.storedDelegations(ctx, toDelete)
+	if err != nil {
+		return err
+	}
+
+	// deleted in the given order
+	deleted := make([]*model.Delegation, 0, len(stored))
+
+	for _, id := range ids {
+		if delegation, found := stored[id]; found {
+			deleted = append(deleted, delegation)
+			delete(stored, id)
+		}
+	}
+
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	err = d.incrementCounts(ctx, datastore.DeleteCountDeltas(deleted))
+	if err != nil {
+		return err
+	}
+
+	err = d.removeDelegations(ctx, deleted)
+	if err != nil {
+		return err
+	}
+
+	err = d.insertChanges(ctx, datastore.DeleteChanges(deleted))
+	if err != nil {
+		return err
+	}
+
+	_, err = d.delegations.DeleteMany(ctx, bson.M{"id": bson.M{"$in": ids}})
+
+	return err
+}
+
+// removeDelegations updates the bakers aggregates and the delegators with delegations removed from the stored
+// delegations, whether they are still stored or not.
+func (d *Datastore) removeDelegations(ctx context.Context, deleted []*model.Delegation) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	delegators, err := d.currentDelegators(ctx, deleted)
+	if err != nil {
+		return err
+	}
+
+	ids := make(bson.A, len(deleted))
+	for i, delegation := range deleted {
+		ids[i] = delegation.ID
+	}
+
+	latest := map[string]*model.Delegation{}
+
+	for _, delegation := range deleted {
+		var remaining *model.Delegation
+
+		err := d.delegations.FindOne(
+			ctx,
+			bson.M{"delegator": delegation.Delegator, "id": bson.M{"$nin": ids}},
+			options.FindOne().SetSort(delegationsSort),
+		).Decode(&remaining)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+
+			return err
+		}
+
+		latest[delegation.Delegator] = remaining
+	}
+
+	changes, updated, removed := datastore.RemoveDelegations(delegators, deleted, latest)
+
+	if err := d.upsertDelegators(ctx, updated); err != nil {
+		return err
+	}
+
+	if len(removed) > 0 {
+		_, err := d.delegators.DeleteMany(ctx, bson.M{"address": bson.M{"$in": removed}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.incrementBakers(ctx, changes)
+}
+
+// GetDelegationsChanges get the delegations changes with a sequence greater than since, in sequence order.
+// A limit of 0 means no limit.
+func (d *Datastore) GetDelegationsChanges(
+	ctx context.Context,
+	since int64,
+	limit int,
+) ([]*model.DelegationChange, error) {
+	opts := options.Find().
+		SetSort(bson.M{"sequence": 1}).
+		SetLimit(int64(limit))
+
+	cursor, err := d.changes.Find(ctx, bson.M{"sequence": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.DelegationChange
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetLatestDelegationsChange get the last change of the delegations change log, nil if it is empty.
+func (d *Datastore) GetLatestDelegationsChange(ctx context.Context) (*model.DelegationChange, error) {
+	var result *model.DelegationChange
+
+	err := d.changes.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"sequence": -1})).Decode(&result)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// insertChanges appends changes to the change log, allocating their sequences from the sequences collection.
+// Sequences are allocated atomically, but a change may become visible after a change with a greater sequence
+// when delegations are stored concurrently: the change log expects a single writer, the cron.
+func (d *Datastore) insertChanges(ctx context.Context, changes []*model.DelegationChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var sequence struct {
+		Value int64 `bson:"value"`
+	}
+
+	err := d.sequences.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": changesSequence},
+		bson.M{"$inc": bson.M{"value": len(changes)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&sequence)
+	if err != nil {
+		return err
+	}
+
+	documents := make([]any, len(changes))
+
+	for i, change := range changes {
+		change.Sequence = sequence.Value - int64(len(changes)-1-i)
+		documents[i] = change
+	}
+
+	_, err = d.changes.InsertMany(ctx, documents)
+
+	return err
+}
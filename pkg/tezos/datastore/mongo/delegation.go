@@ -3,35 +3,133 @@ package mongo
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
 )
 
-// StoreDelegations store delegations in database.
+// StoreDelegations store delegations in database. The counters, bakers aggregates, delegators, change log
+// and webhooks deliveries are updated in the same transaction when the deployment supports transactions.
+// Otherwise they are written before the delegations, so that storing the delegations again after a failure
+// writes them again, instead of losing them, the delegations being already stored.
 func (d *Datastore) StoreDelegations(ctx context.Context, delegations []*model.Delegation) error {
+	return d.withTransaction(ctx, func(ctx context.Context) error {
+		return d.storeDelegations(ctx, delegations)
+	})
+}
+
+// storeDelegations writes the data derived from delegations, then the delegations.
+func (d *Datastore) storeDelegations(ctx context.Context, delegations []*model.Delegation) error {
+	// counters, bakers aggregates and change log are updated from the delegations stored before the upsert
+	stored, err := d.storedDelegations(ctx, delegations)
+	if err != nil {
+		return err
+	}
+
+	err = d.incrementCounts(ctx, datastore.CountDeltas(stored, delegations))
+	if err != nil {
+		return err
+	}
+
+	// the previous values of the updated delegations are reversed before their new values are applied
+	previous, updated := datastore.UpdatedDelegations(stored, delegations)
+
+	err = d.removeDelegations(ctx, previous)
+	if err != nil {
+		return err
+	}
+
+	err = d.applyDelegations(ctx, append(datastore.NewDelegations(stored, delegations), updated...))
+	if err != nil {
+		return err
+	}
+
+	changes := datastore.StoreChanges(stored, delegations)
+
+	err = d.insertChanges(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	err = d.createDeliveries(ctx, changes)
+	if err != nil {
+		return err
+	}
+
 	// Create a slice of WriteModels for the bulk write
 	writeModels := make([]mongo.WriteModel, 0, len(delegations))
 
 	for _, delegation := range delegations {
 		upsert := mongo.NewUpdateOneModel().
-			SetFilter(bson.M{"timestamp": delegation.Timestamp}).
+			SetFilter(bson.M{"id": delegation.ID}).
 			SetUpdate(bson.D{primitive.E{Key: "$set", Value: delegation}}).
 			SetUpsert(true)
 		writeModels = append(writeModels, upsert)
 	}
 
 	// Execute the bulk write
-	_, err := d.delegations.BulkWrite(ctx, writeModels)
+	_, err = d.delegations.BulkWrite(ctx, writeModels)
+
+	return err
+}
+
+// storedDelegations returns the delegations already stored, by id.
+func (d *Datastore) storedDelegations(
+	ctx context.Context,
+	delegations []*model.Delegation,
+) (map[int64]*model.Delegation, error) {
+	ids := make(bson.A, 0, len(delegations))
+
+	for _, delegation := range delegations {
+		ids = append(ids, delegation.ID)
+	}
+
+	cursor, err := d.delegations.Find(ctx, bson.M{"id": bson.M{"$in": ids}})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var results []*model.Delegation
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	stored := make(map[int64]*model.Delegation, len(results))
+
+	for _, result := range results {
+		stored[result.ID] = result
+	}
+
+	return stored, nil
+}
+
+// incrementCounts applies the counters increments.
+func (d *Datastore) incrementCounts(ctx context.Context, deltas map[string]int) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(deltas))
+
+	for key, delta := range deltas {
+		increment := mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": key}).
+			SetUpdate(bson.M{"$inc": bson.M{"count": delta}}).
+			SetUpsert(true)
+		writeModels = append(writeModels, increment)
+	}
+
+	_, err := d.counts.BulkWrite(ctx, writeModels)
+
+	return err
 }
 
 // GetLatestDelegation get the latest delegation in database (with the more recent timestamp).
@@ -39,7 +137,7 @@ func (d *Datastore) GetLatestDelegation(ctx context.Context) (*model.Delegation,
 	// An empty filter matches all documents
 	filter := bson.D{{}}
 	// sort to find the document with the latest timestamp
-	sort := options.FindOne().SetSort(bson.D{primitive.E{Key: "timestamp", Value: -1}})
+	sort := options.FindOne().SetSort(delegationsSort)
 
 	var result *model.Delegation
 
@@ -53,33 +151,138 @@ func (d *Datastore) GetLatestDelegation(ctx context.Context) (*model.Delegation,
 	return result, nil
 }
 
-// GetDelegations get delegations for a specific year.
+// GetLegacyDelegations get the oldest delegations stored before delegations were identified by their
+// operation id, which have a provisional negative id, in chronological order.
+func (d *Datastore) GetLegacyDelegations(ctx context.Context, limit int) ([]*model.Delegation, error) {
+	sort := options.Find().
+		SetSort(bson.D{
+			primitive.E{Key: "timestamp", Value: 1},
+			primitive.E{Key: "id", Value: 1},
+		}).
+		SetLimit(int64(limit))
+
+	cursor, err := d.delegations.Find(ctx, bson.M{"id": bson.M{"$lt": 0}}, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Delegation
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetDelegations get a page of delegations matching the filter, sorted, with the projected fields.
 func (d *Datastore) GetDelegations(
 	ctx context.Context,
-	pageNumber, pageSize, year int,
+	filter datastore.Filter,
+	sort datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
 ) ([]*model.Delegation, error) {
-	filter := bson.M{}
-
-	if year != 0 {
-		filter = bson.M{
-			"$expr": bson.M{
-				"$eq": []interface{}{
-					bson.M{"$year": "$timestamp"},
-					year,
-				},
-			},
+	var results []*model.Delegation
+
+	err := d.IterateDelegations(ctx, filter, sort, projection, page, func(delegation *model.Delegation) error {
+		results = append(results, delegation)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// IterateDelegations calls fn on each delegation of a page matching the filter, sorted, with the projected
+// fields, decoding the documents one at a time from the cursor. An error returned by fn stops the iteration.
+func (d *Datastore) IterateDelegations(
+	ctx context.Context,
+	filter datastore.Filter,
+	sort datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+	fn func(delegation *model.Delegation) error,
+) error {
+	cursor, err := d.findDelegations(ctx, filter, sort, projection, page)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // the iteration error is the one worth returning
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var delegation model.Delegation
+
+		if err := cursor.Decode(&delegation); err != nil {
+			return err
+		}
+
+		if err := fn(&delegation); err != nil {
+			return err
 		}
 	}
 
-	skip := (pageNumber - 1) * pageSize
+	return cursor.Err()
+}
 
-	// sort by timestamp desc and paginate
-	sort := options.Find().
-		SetSort(bson.D{primitive.E{Key: "timestamp", Value: -1}}).
+// findDelegations finds a page of delegations matching the filter, sorted, with the projected fields.
+func (d *Datastore) findDelegations(
+	ctx context.Context,
+	filter datastore.Filter,
+	sort datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+) (*mongo.Cursor, error) {
+	if page.After != nil && !sort.IsDefault() {
+		return nil, datastore.ErrCursorSort
+	}
+
+	query := delegationsFilter(filter)
+
+	skip := 0
+
+	if page.After != nil {
+		// keyset pagination: delegations strictly after the cursor in the sort order
+		query["$or"] = bson.A{
+			bson.M{"timestamp": bson.M{"$lt": page.After.Timestamp}},
+			bson.M{"timestamp": page.After.Timestamp, "id": bson.M{"$lt": page.After.ID}},
+		}
+	} else if page.Number > 1 {
+		skip = (page.Number - 1) * page.Size
+	}
+
+	// sort and paginate
+	opts := options.Find().
+		SetSort(delegationsOrder(sort)).
 		SetSkip(int64(skip)).
-		SetLimit(int64(pageSize))
+		SetLimit(int64(page.Limit()))
+
+	if projection != nil {
+		fields := bson.M{"_id": 0}
+		for _, field := range projection {
+			fields[strings.ToLower(field)] = 1
+		}
+
+		opts.SetProjection(fields)
+	}
 
-	cursor, err := d.delegations.Find(ctx, filter, sort)
+	return d.delegations.Find(ctx, query, opts)
+}
+
+// GetDelegatorDelegations get all the delegations of a delegator, in chronological order.
+func (d *Datastore) GetDelegatorDelegations(ctx context.Context, delegator string) ([]*model.Delegation, error) {
+	sort := options.Find().SetSort(bson.D{
+		primitive.E{Key: "timestamp", Value: 1},
+		primitive.E{Key: "id", Value: 1},
+	})
+
+	cursor, err := d.delegations.Find(ctx, bson.M{"delegator": delegator}, sort)
 	if err != nil {
 		return nil, err
 	}
@@ -94,25 +297,236 @@ func (d *Datastore) GetDelegations(
 	return results, nil
 }
 
-// GetDelegationsCount get the number of delegations for a specific year.
-func (d *Datastore) GetDelegationsCount(ctx context.Context, year int) (int, error) {
-	filter := bson.M{}
+// GetDelegatorsDelegations get the latest delegations of several delegators, at most limit by delegator,
+// in chronological order. A limit of 0 means no limit.
+func (d *Datastore) GetDelegatorsDelegations(
+	ctx context.Context,
+	delegators []string,
+	limit int,
+) ([]*model.Delegation, error) {
+	if len(delegators) == 0 {
+		return nil, nil
+	}
+
+	chronological := bson.D{primitive.E{Key: "timestamp", Value: 1}, primitive.E{Key: "id", Value: 1}}
+	match := bson.M{"delegator": bson.M{"$in": delegators}}
+
+	var (
+		cursor *mongo.Cursor
+		err    error
+	)
+
+	if limit <= 0 {
+		cursor, err = d.delegations.Find(ctx, match, options.Find().SetSort(chronological))
+	} else {
+		// the delegations of each delegator are ranked from the latest, keeping the limit first ones
+		cursor, err = d.delegations.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$setWindowFields", Value: bson.M{
+				"partitionBy": "$delegator",
+				"sortBy":      bson.D{primitive.E{Key: "timestamp", Value: -1}, primitive.E{Key: "id", Value: -1}},
+				"output":      bson.M{"rank": bson.M{"$documentNumber": bson.M{}}},
+			}}},
+			{{Key: "$match", Value: bson.M{"rank": bson.M{"$lte": limit}}}},
+			{{Key: "$unset", Value: "rank"}},
+			{{Key: "$sort", Value: chronological}},
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Delegation
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
 
-	if year != 0 {
-		filter = bson.M{
-			"$expr": bson.M{
-				"$eq": []interface{}{
-					bson.M{"$year": "$timestamp"},
-					year,
-				},
-			},
+// GetDelegationsCount get the number of delegations matching the filter,
+// from the precomputed counters when the filter allows it.
+func (d *Datastore) GetDelegationsCount(ctx context.Context, filter datastore.Filter) (int, error) {
+	query, ok := filter.CountQuery()
+	if !ok {
+		count, err := d.delegations.CountDocuments(ctx, delegationsFilter(filter))
+		if err != nil {
+			return 0, err
 		}
+
+		return int(count), nil
+	}
+
+	match := bson.M{"_id": query.Key}
+	if query.Key == "" {
+		match = bson.M{"_id": bson.M{"$gte": query.FromDay, "$lt": query.ToDay}}
 	}
 
-	count, err := d.delegations.CountDocuments(ctx, filter)
+	cursor, err := d.counts.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": "$count"}}}},
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return int(count), nil
+	var results []struct {
+		Count int `bson:"count"`
+	}
+
+	err = cursor.All(ctx, &results)
+	if err != nil || len(results) == 0 {
+		return 0, err
+	}
+
+	return results[0].Count, nil
+}
+
+// RebuildCounts recompute the precomputed delegations counters from the stored delegations.
+func (d *Datastore) RebuildCounts(ctx context.Context) error {
+	cursor, err := d.delegations.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$timestamp"}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var results []struct {
+		Day   string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return err
+	}
+
+	perDay := make(map[string]int, len(results))
+
+	for _, result := range results {
+		perDay[result.Day] = result.Count
+	}
+
+	_, err = d.counts.DeleteMany(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	documents := make([]any, 0, len(perDay))
+
+	for key, count := range datastore.RecountKeys(perDay) {
+		documents = append(documents, bson.M{"_id": key, "count": count})
+	}
+
+	if len(documents) == 0 {
+		return nil
+	}
+
+	_, err = d.counts.InsertMany(ctx, documents)
+
+	return err
+}
+
+// delegationsSort sorts delegations by timestamp then id descending, the id making the order total.
+var delegationsSort = bson.D{
+	primitive.E{Key: "timestamp", Value: -1},
+	primitive.E{Key: "id", Value: -1},
+}
+
+// delegationsOrder returns the sort document of a delegations sort, ties being sorted by timestamp then id.
+func delegationsOrder(sort datastore.DelegationsSort) bson.D {
+	if sort.IsDefault() {
+		return delegationsSort
+	}
+
+	direction := -1
+	if sort.Ascending {
+		direction = 1
+	}
+
+	order := bson.D{}
+	if sort.Field != "" && sort.Field != datastore.DelegationsSortTimestamp {
+		order = append(order, primitive.E{Key: sort.Field, Value: direction})
+	}
+
+	return append(order, primitive.E{Key: "timestamp", Value: direction}, primitive.E{Key: "id", Value: direction})
+}
+
+// delegationsFilter translates a filter into a query. Timestamps are filtered on a range, which can use the
+// timestamp index (unlike a $year expression which has to be evaluated on every document).
+//
+//nolint:cyclop
+func delegationsFilter(filter datastore.Filter) bson.M {
+	if filter.MatchesNone() {
+		// never true, the legacy delegations having negative ids too
+		return bson.M{"$expr": false}
+	}
+
+	from, to := filter.TimestampRange()
+
+	query := bson.M{}
+	timestamp := bson.M{}
+
+	if !from.IsZero() {
+		timestamp["$gte"] = from
+	}
+
+	if !to.IsZero() {
+		timestamp["$lt"] = to
+	}
+
+	if len(timestamp) != 0 {
+		query["timestamp"] = timestamp
+	}
+
+	if filter.Delegator != "" {
+		query["delegator"] = filter.Delegator
+	}
+
+	if filter.Baker != "" {
+		query["baker"] = filter.Baker
+	}
+
+	if filter.PreviousBaker != "" {
+		query["previousbaker"] = filter.PreviousBaker
+	}
+
+	if filter.Block != "" {
+		query["block"] = filter.Block
+	}
+
+	if filter.Level != 0 {
+		query["level"] = filter.Level
+	}
+
+	amount := bson.M{}
+
+	if filter.AmountGt != nil {
+		amount["$gt"] = *filter.AmountGt
+	}
+
+	if filter.AmountLt != nil {
+		amount["$lt"] = *filter.AmountLt
+	}
+
+	if len(amount) != 0 {
+		query["amount"] = amount
+	}
+
+	switch filter.Kind {
+	case model.KindDelegation:
+		query["$and"] = bson.A{bson.M{"baker": bson.M{"$ne": ""}}, bson.M{"previousbaker": ""}}
+	case model.KindRedelegation:
+		query["$and"] = bson.A{bson.M{"baker": bson.M{"$ne": ""}}, bson.M{"previousbaker": bson.M{"$ne": ""}}}
+	case model.KindUndelegation:
+		query["$and"] = bson.A{bson.M{"baker": ""}}
+	}
+
+	return query
 }
@@ -3,9 +3,14 @@ package mongo_test
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	mongosvc "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mongo"
 )
 
 var errBulkWrite = errors.New("must provide at least one element in input slice")
@@ -22,6 +27,7 @@ func (suite *MongoTestSuite) TestDatastore_StoreDelegations() {
 			init: func(ctx context.Context) {},
 			want: []*model.Delegation{
 				{
+					ID:        2,
 					Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 					Amount:    124428330,
 					Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
@@ -34,12 +40,14 @@ func (suite *MongoTestSuite) TestDatastore_StoreDelegations() {
 			init: func(ctx context.Context) {
 				err := suite.mongoSvc.StoreDelegations(ctx, []*model.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 						Amount:    124428330,
 						Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 						Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2023, 12, 10, 11, 0, 1, 0, time.UTC),
 						Amount:    499836,
 						Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -50,6 +58,7 @@ func (suite *MongoTestSuite) TestDatastore_StoreDelegations() {
 			},
 			want: []*model.Delegation{
 				{
+					ID:        2,
 					Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 					Amount:    124428330,
 					Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
@@ -105,12 +114,14 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegations() {
 			init: func(ctx context.Context) {
 				err := suite.mongoSvc.StoreDelegations(ctx, []*model.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 						Amount:    124428330,
 						Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 						Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
 						Amount:    499836,
 						Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -121,6 +132,7 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegations() {
 			},
 			want: []*model.Delegation{
 				{
+					ID:        2,
 					Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 					Amount:    124428330,
 					Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
@@ -135,12 +147,14 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegations() {
 			init: func(ctx context.Context) {
 				err := suite.mongoSvc.StoreDelegations(ctx, []*model.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 						Amount:    124428330,
 						Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 						Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
 						Amount:    499836,
 						Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -152,6 +166,7 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegations() {
 			year: 2021,
 			want: []*model.Delegation{
 				{
+					ID:        1,
 					Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
 					Amount:    499836,
 					Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -170,7 +185,13 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegations() {
 
 			c.init(ctx)
 
-			result, err := suite.mongoSvc.GetDelegations(ctx, c.pageNumber, c.pageSize, c.year)
+			result, err := suite.mongoSvc.GetDelegations(
+				ctx,
+				datastore.Filter{Year: c.year},
+				datastore.DelegationsSort{},
+				nil,
+				datastore.Page{Number: c.pageNumber, Size: c.pageSize},
+			)
 			suite.Require().Equal(c.want, result)
 			suite.Require().Nil(err)
 		})
@@ -188,12 +209,14 @@ func (suite *MongoTestSuite) TestDatastore_GetLatestDelegation() {
 			init: func(ctx context.Context) {
 				err := suite.mongoSvc.StoreDelegations(ctx, []*model.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 						Amount:    124428330,
 						Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 						Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
 						Amount:    499836,
 						Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -203,6 +226,7 @@ func (suite *MongoTestSuite) TestDatastore_GetLatestDelegation() {
 				suite.Require().Nil(err)
 			},
 			want: &model.Delegation{
+				ID:        2,
 				Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 				Amount:    124428330,
 				Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
@@ -239,12 +263,14 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegationsCount() {
 			init: func(ctx context.Context) {
 				err := suite.mongoSvc.StoreDelegations(ctx, []*model.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 						Amount:    124428330,
 						Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 						Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
 						Amount:    499836,
 						Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -260,12 +286,14 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegationsCount() {
 			init: func(ctx context.Context) {
 				err := suite.mongoSvc.StoreDelegations(ctx, []*model.Delegation{
 					{
+						ID:        2,
 						Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
 						Amount:    124428330,
 						Delegator: "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
 						Block:     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
 					},
 					{
+						ID:        1,
 						Timestamp: time.Date(2021, 12, 10, 11, 0, 1, 0, time.UTC),
 						Amount:    499836,
 						Delegator: "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
@@ -288,9 +316,175 @@ func (suite *MongoTestSuite) TestDatastore_GetDelegationsCount() {
 
 			c.init(ctx)
 
-			result, err := suite.mongoSvc.GetDelegationsCount(ctx, c.year)
+			result, err := suite.mongoSvc.GetDelegationsCount(ctx, datastore.Filter{Year: c.year})
 			suite.Require().Equal(c.want, result)
 			suite.Require().Nil(err)
 		})
 	}
 }
+
+func (suite *MongoTestSuite) TestDatastore_DelegationsFilterUsesTimestampIndex() {
+	cases := []struct {
+		name   string
+		filter datastore.Filter
+	}{
+		{
+			name:   "Year",
+			filter: datastore.Filter{Year: 2023},
+		},
+		{
+			name:   "From",
+			filter: datastore.Filter{From: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "To",
+			filter: datastore.Filter{To: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "Year from and to",
+			filter: datastore.Filter{
+				Year: 2023,
+				From: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		suite.Run(c.name, func() {
+			ctx := context.Background()
+
+			var find bson.M
+
+			err := suite.database.RunCommand(ctx, bson.D{
+				{Key: "explain", Value: bson.D{
+					{Key: "find", Value: "delegations"},
+					{Key: "filter", Value: mongosvc.DelegationsFilter(c.filter)},
+					{Key: "sort", Value: bson.D{{Key: "timestamp", Value: -1}}},
+				}},
+				{Key: "verbosity", Value: "queryPlanner"},
+			}).Decode(&find)
+			suite.Require().NoError(err)
+
+			var count bson.M
+
+			err = suite.database.RunCommand(ctx, bson.D{
+				{Key: "explain", Value: bson.D{
+					{Key: "count", Value: "delegations"},
+					{Key: "query", Value: mongosvc.DelegationsFilter(c.filter)},
+				}},
+				{Key: "verbosity", Value: "queryPlanner"},
+			}).Decode(&count)
+			suite.Require().NoError(err)
+
+			for _, explain := range []bson.M{find, count} {
+				queryPlanner, ok := explain["queryPlanner"].(bson.M)
+				suite.Require().True(ok)
+
+				// counts on an index range may be answered by a COUNT_SCAN, which is an index scan too
+				stages := planStages(queryPlanner["winningPlan"])
+				suite.Require().True(
+					slices.Contains(stages, "IXSCAN") || slices.Contains(stages, "COUNT_SCAN"),
+					"no index scan in plan stages %v", stages,
+				)
+				suite.Require().NotContains(stages, "COLLSCAN")
+			}
+		})
+	}
+}
+
+// planStages returns every stage of an explained query plan.
+func planStages(plan any) []string {
+	var stages []string
+
+	switch p := plan.(type) {
+	case bson.M:
+		if stage, ok := p["stage"].(string); ok {
+			stages = append(stages, stage)
+		}
+
+		for _, value := range p {
+			stages = append(stages, planStages(value)...)
+		}
+	case bson.A:
+		for _, value := range p {
+			stages = append(stages, planStages(value)...)
+		}
+	}
+
+	return stages
+}
+
+// TestDatastore_MigrateLegacyIDs checks the delegations stored when they were keyed by timestamp get a
+// provisional id, while the identified delegations are left unchanged.
+func (suite *MongoTestSuite) TestDatastore_MigrateLegacyIDs() {
+	ctx := context.Background()
+
+	defer suite.TearDownTest()
+
+	timestamp := time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC)
+
+	_, err := suite.collection.InsertMany(ctx, []any{
+		bson.M{
+			"timestamp": timestamp,
+			"amount":    124428330,
+			"delegator": "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx",
+			"block":     "BMWE6vssezoqBCSSJd9M24jmExjLRyVrAr4f7sWFywGKjD3TeSG",
+		},
+		bson.M{
+			"id":        2,
+			"timestamp": timestamp.Add(time.Minute),
+			"amount":    499836,
+			"delegator": "tz1NqVXDBf8fZNomacychFPSK1trQbi14PvA",
+			"block":     "BLxQGrPcAPAwKaeCdivBVw45Choicesen6wrmdm3NBeGsCnkLKv",
+		},
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.mongoSvc.MigrateLegacyIDs(ctx))
+
+	legacy, err := suite.mongoSvc.GetLegacyDelegations(ctx, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(legacy, 1)
+	suite.Equal(-timestamp.UnixMilli(), legacy[0].ID)
+	suite.Equal("tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx", legacy[0].Delegator)
+
+	latest, err := suite.mongoSvc.GetLatestDelegation(ctx)
+	suite.Require().NoError(err)
+	suite.Equal(int64(2), latest.ID)
+}
+
+// TestDatastore_InitBakers checks the delegators of delegations stored before they were maintained are computed.
+func (suite *MongoTestSuite) TestDatastore_InitBakers() {
+	ctx := context.Background()
+
+	defer suite.TearDownTest()
+
+	_, err := suite.collection.InsertMany(ctx, []any{
+		bson.M{
+			"id":        1,
+			"timestamp": time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
+			"amount":    100,
+			"delegator": "tz1delegator",
+			"block":     "block1",
+			"baker":     "tz1baker1",
+		},
+		bson.M{
+			"id":        2,
+			"timestamp": time.Date(2023, 12, 11, 11, 1, 1, 0, time.UTC),
+			"amount":    100,
+			"delegator": "tz1delegator",
+			"block":     "block2",
+			"baker":     "tz1baker2",
+		},
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.mongoSvc.InitBakers(ctx))
+
+	delegator, err := suite.mongoSvc.GetDelegator(ctx, "tz1delegator")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(delegator)
+	suite.Equal("tz1baker2", delegator.Baker)
+	suite.Equal(int64(2), delegator.DelegationID)
+}
@@ -0,0 +1,16 @@
+package mongo
+
+import "context"
+
+// DelegationsFilter exposes delegationsFilter to the tests.
+var DelegationsFilter = delegationsFilter
+
+// MigrateLegacyIDs exposes migrateLegacyIDs to the tests.
+func (d *Datastore) MigrateLegacyIDs(ctx context.Context) error {
+	return d.migrateLegacyIDs(ctx)
+}
+
+// InitBakers exposes initBakers to the tests.
+func (d *Datastore) InitBakers(ctx context.Context) error {
+	return d.initBakers(ctx)
+}
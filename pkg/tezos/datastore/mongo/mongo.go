@@ -1,7 +1,11 @@
 package mongo
 
 import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	mongosvc "github.com/guillaumedebavelaere/tezos-delegation/pkg/mongo"
 )
@@ -9,12 +13,41 @@ import (
 const (
 	database              = "tezos_delegation"
 	collectionDelegations = "delegations"
+	collectionCounts      = "delegation_counts"
+	collectionBakers      = "bakers"
+	collectionDelegators  = "delegators"
+	collectionChanges     = "delegation_changes"
+	collectionSequences   = "sequences"
+	collectionWebhooks    = "webhooks"
+	collectionDeliveries  = "webhook_deliveries"
+	collectionAlerts      = "alerts"
 )
 
+// idIndex is the name of the index of the delegations ids.
+const idIndex = "id_1"
+
 // Datastore represents the implementation of the datastore with mongo.
 type Datastore struct {
 	client      mongosvc.Client
 	delegations *mongo.Collection
+	// counts stores the precomputed delegations counters, as {_id: key, count: value} documents.
+	counts *mongo.Collection
+	// bakers stores the bakers aggregates.
+	bakers *mongo.Collection
+	// delegators stores the current delegation of the delegators.
+	delegators *mongo.Collection
+	// changes stores the delegations change log.
+	changes *mongo.Collection
+	// sequences stores the last allocated sequences, as {_id: name, value: sequence} documents.
+	sequences *mongo.Collection
+	// webhooks stores the webhooks.
+	webhooks *mongo.Collection
+	// deliveries stores the webhooks deliveries.
+	deliveries *mongo.Collection
+	// alerts stores the emitted alerts.
+	alerts *mongo.Collection
+	// transactions is true when the deployment supports transactions, being a replica set or a sharded cluster.
+	transactions bool
 }
 
 // New create a new mongo datastore.
@@ -32,10 +65,210 @@ func (d *Datastore) Init() error {
 	}
 
 	d.delegations = d.client.C().Database(database).Collection(collectionDelegations)
+	d.counts = d.client.C().Database(database).Collection(collectionCounts)
+	d.bakers = d.client.C().Database(database).Collection(collectionBakers)
+	d.delegators = d.client.C().Database(database).Collection(collectionDelegators)
+	d.changes = d.client.C().Database(database).Collection(collectionChanges)
+	d.sequences = d.client.C().Database(database).Collection(collectionSequences)
+	d.webhooks = d.client.C().Database(database).Collection(collectionWebhooks)
+	d.deliveries = d.client.C().Database(database).Collection(collectionDeliveries)
+	d.alerts = d.client.C().Database(database).Collection(collectionAlerts)
+
+	d.transactions, err = d.supportsTransactions(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if err := d.migrateLegacyIDs(context.Background()); err != nil {
+		return err
+	}
+
+	if err := d.createIndexes(context.Background()); err != nil {
+		return err
+	}
+
+	if err := d.initCounts(context.Background()); err != nil {
+		return err
+	}
+
+	return d.initBakers(context.Background())
+}
+
+// supportsTransactions reports whether the deployment supports transactions, a standalone server not
+// supporting them.
+func (d *Datastore) supportsTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+		// SetName is the name of the replica set of a replica set member.
+		SetName string `bson:"setName"`
+		// Msg is isdbgrid for a mongos of a sharded cluster.
+		Msg string `bson:"msg"`
+	}
+
+	err := d.client.C().Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
+// withTransaction runs fn in a transaction when the deployment supports transactions, and directly otherwise.
+// fn may be run several times, the transaction being retried on transient errors.
+func (d *Datastore) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !d.transactions {
+		return fn(ctx)
+	}
+
+	session, err := d.client.C().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return nil, fn(ctx)
+	})
+
+	return err
+}
+
+// createIndexes creates the indexes used by the datastore queries, if they don't exist yet.
+func (d *Datastore) createIndexes(ctx context.Context) error {
+	_, err := d.delegations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// timestamp range filters and (timestamp, id) keyset pagination
+		{Keys: bson.D{{Key: "timestamp", Value: -1}, {Key: "id", Value: -1}}},
+		// upserts, a delegation being stored once
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetName(idIndex).SetUnique(true)},
+		// delegator timeline
+		{Keys: bson.D{{Key: "delegator", Value: 1}, {Key: "timestamp", Value: 1}, {Key: "id", Value: 1}}},
+		// baker delegators history
+		{Keys: bson.D{{Key: "baker", Value: 1}}},
+		// baker outflows within a window
+		{Keys: bson.D{{Key: "previousbaker", Value: 1}, {Key: "timestamp", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.bakers.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "address", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// default bakers sort
+		{Keys: bson.D{{Key: "delegators", Value: -1}, {Key: "address", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.delegators.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "address", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "baker", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.changes.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sequence", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.webhooks.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// webhook deliveries, latest first
+		{Keys: bson.D{{Key: "webhookid", Value: 1}, {Key: "sequence", Value: -1}}},
+		// due deliveries
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextattemptat", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.alerts.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// an alert is emitted once per key
+		{Keys: bson.D{{Key: "rule", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// latest alert of a rule
+		{Keys: bson.D{{Key: "rule", Value: 1}, {Key: "firedat", Value: -1}}},
+	})
+
+	return err
+}
+
+// migrateLegacyIDs identifies the delegations stored when they were keyed by timestamp, without id. Their
+// operation id being unknown, they get a provisional negative id derived from their timestamp, which was
+// unique, until they are backfilled. The non unique id index of previous versions is dropped, to be
+// recreated unique.
+func (d *Datastore) migrateLegacyIDs(ctx context.Context) error {
+	_, err := d.delegations.UpdateMany(
+		ctx,
+		bson.M{"id": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"id": bson.M{"$multiply": bson.A{bson.M{"$toLong": "$timestamp"}, -1}},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	specifications, err := d.delegations.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, specification := range specifications {
+		if specification.Name != idIndex || (specification.Unique != nil && *specification.Unique) {
+			continue
+		}
+
+		if _, err := d.delegations.Indexes().DropOne(ctx, idIndex); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// initCounts computes the delegations counters of a database created before they were maintained.
+func (d *Datastore) initCounts(ctx context.Context) error {
+	counts, err := d.counts.EstimatedDocumentCount(ctx)
+	if err != nil || counts > 0 {
+		return err
+	}
+
+	delegations, err := d.delegations.EstimatedDocumentCount(ctx)
+	if err != nil || delegations == 0 {
+		return err
+	}
+
+	return d.RebuildCounts(ctx)
+}
+
+// initBakers computes the bakers aggregates and the delegators of a database created before they were
+// maintained, a datastore with delegations always having delegators.
+func (d *Datastore) initBakers(ctx context.Context) error {
+	delegators, err := d.delegators.EstimatedDocumentCount(ctx)
+	if err != nil || delegators > 0 {
+		return err
+	}
+
+	delegations, err := d.delegations.EstimatedDocumentCount(ctx)
+	if err != nil || delegations == 0 {
+		return err
+	}
+
+	return d.RebuildBakers(ctx)
+}
+
 // Close close mongo datastore.
 func (d *Datastore) Close() error {
 	return d.client.Close()
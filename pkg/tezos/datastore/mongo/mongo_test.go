@@ -5,11 +5,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
 	gomongo "go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/mongo"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/test/docker"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/datastoretest"
 	mongosvc "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mongo"
 )
 
@@ -44,18 +48,47 @@ func (suite *MongoTestSuite) SetupSuite() {
 }
 
 func (suite *MongoTestSuite) SetupTest() {
-	suite.database = suite.mongoClient.C().Database("tezos-delegations")
+	suite.database = suite.mongoClient.C().Database("tezos_delegation")
 	suite.collection = suite.database.Collection("delegations")
 }
 
+// TearDownTest empties every collection, keeping the indexes created by the datastore.
 func (suite *MongoTestSuite) TearDownTest() {
-	suite.Require().NoError(suite.database.Drop(context.Background()))
+	suite.Require().NoError(suite.emptyCollections())
+}
+
+// emptyCollections empties every collection: delegations and the data maintained from them.
+func (suite *MongoTestSuite) emptyCollections() error {
+	ctx := context.Background()
+
+	collections, err := suite.database.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range collections {
+		if _, err := suite.database.Collection(collection).DeleteMany(ctx, bson.D{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (suite *MongoTestSuite) TearDownSuite() {
 	suite.Require().NoError(suite.dockerTest.Stop())
 }
 
+func (suite *MongoTestSuite) TestDatastore_Conformance() {
+	datastoretest.Run(suite.T(), func(t *testing.T) datastore.Datastorer {
+		t.Helper()
+
+		require.NoError(t, suite.emptyCollections())
+
+		return suite.mongoSvc
+	})
+}
+
 func TestMongoTestSuite(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,83 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// GetDelegationsStats get the stats of the delegations matching the filter, by bucket of interval,
+// in chronological order.
+func (d *Datastore) GetDelegationsStats(
+	ctx context.Context,
+	filter datastore.Filter,
+	interval datastore.Interval,
+) ([]*model.DelegationsStats, error) {
+	bucket := bson.M{"date": "$timestamp", "unit": string(interval), "timezone": "UTC"}
+	if interval == datastore.IntervalWeek {
+		bucket["startOfWeek"] = "monday"
+	}
+
+	// delegations are grouped by bucket and delegator first, to count the unique delegators of the buckets
+	// without accumulating them in memory
+	cursor, err := d.delegations.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: delegationsFilter(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         bson.M{"bucket": bson.M{"$dateTrunc": bucket}, "delegator": "$delegator"},
+			"delegations": bson.M{"$sum": 1},
+			"amount":      bson.M{"$sum": "$amount"},
+			// delegations stored without baker are counted as undelegations, like in the other datastores
+			"undelegations": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$baker", ""}}, ""}}, 1, 0},
+			}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           "$_id.bucket",
+			"delegations":   bson.M{"$sum": "$delegations"},
+			"amount":        bson.M{"$sum": "$amount"},
+			"delegators":    bson.M{"$sum": 1},
+			"undelegations": bson.M{"$sum": "$undelegations"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []struct {
+		Bucket        time.Time `bson:"_id"`
+		Delegations   int       `bson:"delegations"`
+		Amount        int64     `bson:"amount"`
+		Delegators    int       `bson:"delegators"`
+		Undelegations int       `bson:"undelegations"`
+	}
+
+	err = cursor.All(ctx, &buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	results := make([]*model.DelegationsStats, len(buckets))
+
+	for i, bucket := range buckets {
+		results[i] = &model.DelegationsStats{
+			Bucket:        bucket.Bucket.UTC(),
+			Delegations:   bucket.Delegations,
+			Amount:        bucket.Amount,
+			Delegators:    bucket.Delegators,
+			Undelegations: bucket.Undelegations,
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,181 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// webhooksSort sorts webhooks by creation.
+var webhooksSort = bson.D{{Key: "createdat", Value: 1}, {Key: "id", Value: 1}}
+
+// CreateWebhook store a new webhook.
+func (d *Datastore) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	_, err := d.webhooks.InsertOne(ctx, webhook)
+
+	return err
+}
+
+// GetWebhooks get the webhooks of an owner, every webhook when empty, sorted by creation.
+func (d *Datastore) GetWebhooks(ctx context.Context, owner string) ([]*model.Webhook, error) {
+	filter := bson.M{}
+	if owner != "" {
+		filter["owner"] = owner
+	}
+
+	cursor, err := d.webhooks.Find(ctx, filter, options.Find().SetSort(webhooksSort))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Webhook
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetWebhook get a webhook by id, nil if not found.
+func (d *Datastore) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	var result *model.Webhook
+
+	err := d.webhooks.FindOne(ctx, bson.M{"id": id}).Decode(&result)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteWebhook delete a webhook along with its deliveries, an unknown id being ignored.
+func (d *Datastore) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := d.webhooks.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.deliveries.DeleteMany(ctx, bson.M{"webhookid": id})
+
+	return err
+}
+
+// GetWebhookDeliveries get a page of the deliveries of a webhook, the latest delegations first.
+func (d *Datastore) GetWebhookDeliveries(
+	ctx context.Context,
+	webhookID string,
+	page datastore.Page,
+) ([]*model.WebhookDelivery, error) {
+	opts := options.Find().
+		SetSort(bson.M{"sequence": -1}).
+		SetLimit(int64(page.Size))
+
+	if page.Number > 1 {
+		opts.SetSkip(int64((page.Number - 1) * page.Size))
+	}
+
+	return d.findDeliveries(ctx, bson.M{"webhookid": webhookID}, opts)
+}
+
+// GetWebhookDelivery get a webhook delivery by id, nil if not found.
+func (d *Datastore) GetWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	var result *model.WebhookDelivery
+
+	err := d.deliveries.FindOne(ctx, bson.M{"id": id}).Decode(&result)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// GetDueWebhookDeliveries get the pending deliveries due at now, the earliest due first.
+// A limit of 0 means no limit.
+func (d *Datastore) GetDueWebhookDeliveries(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*model.WebhookDelivery, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "nextattemptat", Value: 1}, {Key: "id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	return d.findDeliveries(
+		ctx,
+		bson.M{"status": model.DeliveryPending, "nextattemptat": bson.M{"$lte": now}},
+		opts,
+	)
+}
+
+// UpdateWebhookDelivery update the status of a stored webhook delivery, an unknown delivery being ignored.
+func (d *Datastore) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	_, err := d.deliveries.UpdateOne(ctx, bson.M{"id": delivery.ID}, bson.M{"$set": bson.M{
+		"status":        delivery.Status,
+		"attempts":      delivery.Attempts,
+		"statuscode":    delivery.StatusCode,
+		"error":         delivery.Error,
+		"nextattemptat": delivery.NextAttemptAt,
+	}})
+
+	return err
+}
+
+// createDeliveries creates the deliveries of the delegations inserted by changes to the webhooks.
+func (d *Datastore) createDeliveries(ctx context.Context, changes []*model.DelegationChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	webhooks, err := d.GetWebhooks(ctx, "")
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	deliveries := datastore.WebhookDeliveries(webhooks, changes, time.Now())
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	documents := make([]any, len(deliveries))
+	for i, delivery := range deliveries {
+		documents[i] = delivery
+	}
+
+	_, err = d.deliveries.InsertMany(ctx, documents)
+
+	return err
+}
+
+// findDeliveries returns the deliveries matching a filter.
+func (d *Datastore) findDeliveries(
+	ctx context.Context,
+	filter bson.M,
+	opts *options.FindOptions,
+) ([]*model.WebhookDelivery, error) {
+	cursor, err := d.deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.WebhookDelivery
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
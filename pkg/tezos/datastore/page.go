@@ -0,0 +1,86 @@
+package datastore
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// ErrInvalidCursor is returned when decoding a malformed cursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page describes the requested page of delegations, sorted by timestamp then id descending.
+type Page struct {
+	// Number is the page number starting at 1, ignored when After is set.
+	Number int
+	// Size is the maximum number of delegations of the page, 0 means no limit.
+	Size int
+	// After is the cursor of the last delegation of the previous page, keyset pagination is used when set.
+	// Unlike page numbers, cursors keep pages stable when new delegations are stored while paging.
+	After *Cursor
+	// Lookahead reads one more delegation after the page, telling whether more delegations follow it.
+	Lookahead bool
+}
+
+// Limit returns the maximum number of delegations read for the page, 0 means no limit.
+func (p Page) Limit() int {
+	if p.Size > 0 && p.Lookahead {
+		return p.Size + 1
+	}
+
+	return p.Size
+}
+
+// Cursor identifies the position of a delegation in the delegations sorted by timestamp then id descending.
+type Cursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// NewCursor returns the cursor positioned on a delegation.
+func NewCursor(delegation *model.Delegation) *Cursor {
+	return &Cursor{
+		Timestamp: delegation.Timestamp,
+		ID:        delegation.ID,
+	}
+}
+
+// Encode encodes the cursor to an opaque URL safe token.
+func (c *Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf("%d:%d", c.Timestamp.UnixMilli(), c.ID)),
+	)
+}
+
+// DecodeCursor decodes a token returned by Cursor.Encode.
+func DecodeCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	timestamp, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parsedID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{
+		Timestamp: time.UnixMilli(millis).UTC(),
+		ID:        parsedID,
+	}, nil
+}
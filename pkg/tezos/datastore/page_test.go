@@ -0,0 +1,50 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestCursor_Encode(t *testing.T) {
+	t.Parallel()
+
+	cursor := datastore.NewCursor(&model.Delegation{
+		ID:        1098907648,
+		Timestamp: time.Date(2023, 12, 10, 11, 1, 1, int(250*time.Millisecond), time.UTC),
+	})
+
+	got, err := datastore.DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, got)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{name: "Error not base64", token: "not base64!"},
+		{name: "Error missing separator", token: "MTIz"},
+		{name: "Error invalid timestamp", token: "YWJjOjE"},
+		{name: "Error invalid id", token: "MTIzOmFiYw"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := datastore.DecodeCursor(c.token)
+			assert.ErrorIs(t, err, datastore.ErrInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package datastore
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// ErrInvalidProjection is returned when parsing a projection with an unknown field.
+var ErrInvalidProjection = errors.New("invalid projection")
+
+// Delegation fields, which can be projected.
+const (
+	FieldID            = "id"
+	FieldTimestamp     = "timestamp"
+	FieldAmount        = "amount"
+	FieldDelegator     = "delegator"
+	FieldBlock         = "block"
+	FieldLevel         = "level"
+	FieldBaker         = "baker"
+	FieldPreviousBaker = "previousBaker"
+)
+
+// Fields are the delegation fields, in the order of the model.
+var Fields = []string{
+	FieldID, FieldTimestamp, FieldAmount, FieldDelegator, FieldBlock, FieldLevel, FieldBaker, FieldPreviousBaker,
+}
+
+// Projection is the list of the delegation fields read from the datastore, the other fields being left empty.
+// A nil projection reads every field.
+type Projection []string
+
+// ParseProjection parses a comma separated list of fields, without duplicates. An empty list reads every field.
+func ParseProjection(value string) (Projection, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	projection := Projection{}
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+
+		if !isField(field) {
+			return nil, ErrInvalidProjection
+		}
+
+		if !projection.Has(field) {
+			projection = append(projection, field)
+		}
+	}
+
+	return projection, nil
+}
+
+// Has reports whether the projection reads a field.
+func (p Projection) Has(field string) bool {
+	if p == nil {
+		return isField(field)
+	}
+
+	for _, f := range p {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// With returns the projection also reading fields, nil when reading every field.
+func (p Projection) With(fields ...string) Projection {
+	if p == nil {
+		return nil
+	}
+
+	with := append(Projection{}, p...)
+
+	for _, field := range fields {
+		if !with.Has(field) {
+			with = append(with, field)
+		}
+	}
+
+	return with
+}
+
+// Project returns a copy of a delegation with only the projected fields.
+func (p Projection) Project(delegation *model.Delegation) *model.Delegation {
+	if p == nil {
+		projected := *delegation
+
+		return &projected
+	}
+
+	projected := &model.Delegation{}
+
+	for _, field := range p {
+		switch field {
+		case FieldID:
+			projected.ID = delegation.ID
+		case FieldTimestamp:
+			projected.Timestamp = delegation.Timestamp
+		case FieldAmount:
+			projected.Amount = delegation.Amount
+		case FieldDelegator:
+			projected.Delegator = delegation.Delegator
+		case FieldBlock:
+			projected.Block = delegation.Block
+		case FieldLevel:
+			projected.Level = delegation.Level
+		case FieldBaker:
+			projected.Baker = delegation.Baker
+		case FieldPreviousBaker:
+			projected.PreviousBaker = delegation.PreviousBaker
+		}
+	}
+
+	return projected
+}
+
+// isField reports whether field is a delegation field.
+func isField(field string) bool {
+	for _, f := range Fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
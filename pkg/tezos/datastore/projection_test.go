@@ -0,0 +1,67 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestParseProjection(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		value   string
+		want    datastore.Projection
+		wantErr error
+	}{
+		{name: "Success every field", value: ""},
+		{name: "Success fields", value: "amount,baker", want: datastore.Projection{"amount", "baker"}},
+		{name: "Success without duplicates", value: "id, id,level", want: datastore.Projection{"id", "level"}},
+		{name: "Error unknown field", value: "amount,status", wantErr: datastore.ErrInvalidProjection},
+		{name: "Error empty field", value: "amount,", wantErr: datastore.ErrInvalidProjection},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := datastore.ParseProjection(c.value)
+			assert.ErrorIs(t, err, c.wantErr)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestProjection(t *testing.T) {
+	t.Parallel()
+
+	delegation := &model.Delegation{
+		ID: 1, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1", Block: "block1", Level: 10, Baker: "baker2", PreviousBaker: "baker1",
+	}
+
+	var all datastore.Projection
+
+	assert.True(t, all.Has(datastore.FieldBlock))
+	assert.Nil(t, all.With(datastore.FieldID))
+	assert.Equal(t, delegation, all.Project(delegation))
+	assert.NotSame(t, delegation, all.Project(delegation))
+
+	projection := datastore.Projection{datastore.FieldAmount, datastore.FieldBaker}
+
+	assert.True(t, projection.Has(datastore.FieldAmount))
+	assert.False(t, projection.Has(datastore.FieldID))
+	assert.Equal(
+		t,
+		datastore.Projection{datastore.FieldAmount, datastore.FieldBaker, datastore.FieldID},
+		projection.With(datastore.FieldID, datastore.FieldAmount),
+	)
+	assert.Equal(t, &model.Delegation{Amount: 100, Baker: "baker2"}, projection.Project(delegation))
+}
@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+var (
+	// ErrInvalidDelegationsSort is returned when parsing an unknown delegations sort.
+	ErrInvalidDelegationsSort = errors.New("invalid delegations sort")
+	// ErrCursorSort is returned when paginating delegations with a cursor in another order than the default one.
+	ErrCursorSort = errors.New("cursor pagination requires the default delegations sort")
+)
+
+// Delegations sort fields.
+const (
+	DelegationsSortTimestamp = "timestamp"
+	DelegationsSortAmount    = "amount"
+	DelegationsSortLevel     = "level"
+)
+
+// DelegationsSort describes the order of the listed delegations. Delegations with equal values are sorted by
+// timestamp then id, in the same direction. Its zero value is the default order: by timestamp descending.
+type DelegationsSort struct {
+	// Field is the sort field, empty meaning timestamp.
+	Field     string
+	Ascending bool
+}
+
+// ParseDelegationsSort parses a delegations sort, a field prefixed by - for a descending order (e.g. -amount).
+// An empty sort is the default order, by timestamp descending.
+func ParseDelegationsSort(value string) (DelegationsSort, error) {
+	if value == "" {
+		return DelegationsSort{}, nil
+	}
+
+	field, descending := strings.CutPrefix(value, "-")
+
+	switch field {
+	case DelegationsSortTimestamp:
+		if descending {
+			return DelegationsSort{}, nil
+		}
+
+		return DelegationsSort{Ascending: true}, nil
+	case DelegationsSortAmount, DelegationsSortLevel:
+		return DelegationsSort{Field: field, Ascending: !descending}, nil
+	default:
+		return DelegationsSort{}, ErrInvalidDelegationsSort
+	}
+}
+
+// IsDefault reports whether the sort is the default order, by timestamp descending, which cursors are
+// positioned in.
+func (s DelegationsSort) IsDefault() bool {
+	return s == DelegationsSort{}
+}
+
+// SortDelegations sorts delegations in place.
+func SortDelegations(delegations []*model.Delegation, s DelegationsSort) {
+	sort.Slice(delegations, func(i, j int) bool {
+		a, b := delegations[i], delegations[j]
+
+		if s.Ascending {
+			a, b = b, a
+		}
+
+		// a comes first in the descending order
+		switch {
+		case s.Field == DelegationsSortAmount && a.Amount != b.Amount:
+			return a.Amount > b.Amount
+		case s.Field == DelegationsSortLevel && a.Level != b.Level:
+			return a.Level > b.Level
+		default:
+			return chronological(b.Timestamp, b.ID, a.Timestamp, a.ID)
+		}
+	})
+}
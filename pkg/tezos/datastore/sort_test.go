@@ -0,0 +1,71 @@
+package datastore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+)
+
+func TestParseDelegationsSort(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		value   string
+		want    datastore.DelegationsSort
+		wantErr error
+	}{
+		{
+			name:  "Success default",
+			value: "",
+			want:  datastore.DelegationsSort{},
+		},
+		{
+			name:  "Success timestamp descending is the default",
+			value: "-timestamp",
+			want:  datastore.DelegationsSort{},
+		},
+		{
+			name:  "Success timestamp ascending",
+			value: "timestamp",
+			want:  datastore.DelegationsSort{Ascending: true},
+		},
+		{
+			name:  "Success ascending",
+			value: "amount",
+			want:  datastore.DelegationsSort{Field: datastore.DelegationsSortAmount, Ascending: true},
+		},
+		{
+			name:  "Success descending",
+			value: "-level",
+			want:  datastore.DelegationsSort{Field: datastore.DelegationsSortLevel},
+		},
+		{
+			name:    "Error unknown field",
+			value:   "-delegator",
+			wantErr: datastore.ErrInvalidDelegationsSort,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := datastore.ParseDelegationsSort(c.value)
+			assert.ErrorIs(t, err, c.wantErr)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestDelegationsSort_IsDefault(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, datastore.DelegationsSort{}.IsDefault())
+	assert.False(t, datastore.DelegationsSort{Ascending: true}.IsDefault())
+	assert.False(t, datastore.DelegationsSort{Field: datastore.DelegationsSortAmount}.IsDefault())
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	insertAlert = `
+INSERT INTO alerts (
+	rule, key, type, message, alert_baker,
+	delegation_id, timestamp, amount, delegator, block, baker, previous_baker, level, fired_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (rule, key) DO NOTHING`
+
+	selectAlerts = `
+SELECT rule, key, type, message, alert_baker,
+	delegation_id, timestamp, amount, delegator, block, baker, previous_baker, level, fired_at
+FROM alerts`
+)
+
+// CreateAlert store an alert, unless an alert with the same rule and key is already stored.
+// It reports whether the alert was stored.
+func (d *Datastore) CreateAlert(ctx context.Context, alert *model.Alert) (bool, error) {
+	res, err := d.db.ExecContext(
+		ctx,
+		insertAlert,
+		alert.Rule,
+		alert.Key,
+		alert.Type,
+		alert.Message,
+		alert.Baker,
+		alert.Delegation.ID,
+		alert.Delegation.Timestamp.UnixMilli(),
+		alert.Delegation.Amount,
+		alert.Delegation.Delegator,
+		alert.Delegation.Block,
+		alert.Delegation.Baker,
+		alert.Delegation.PreviousBaker,
+		alert.Delegation.Level,
+		alert.FiredAt.UnixMilli(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted > 0, nil
+}
+
+// GetLatestAlert get the latest alert emitted by a rule, nil if none.
+func (d *Datastore) GetLatestAlert(ctx context.Context, rule string) (*model.Alert, error) {
+	row := d.db.QueryRowContext(ctx, selectAlerts+` WHERE rule = ? ORDER BY fired_at DESC, rowid DESC LIMIT 1`, rule)
+
+	result, err := scanAlert(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanAlert scans an alert selected with selectAlerts.
+func scanAlert(s scanner) (*model.Alert, error) {
+	var (
+		alert              model.Alert
+		delegation         model.Delegation
+		timestamp, firedAt int64
+	)
+
+	err := s.Scan(
+		&alert.Rule,
+		&alert.Key,
+		&alert.Type,
+		&alert.Message,
+		&alert.Baker,
+		&delegation.ID,
+		&timestamp,
+		&delegation.Amount,
+		&delegation.Delegator,
+		&delegation.Block,
+		&delegation.Baker,
+		&delegation.PreviousBaker,
+		&delegation.Level,
+		&firedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation.Timestamp = time.UnixMilli(timestamp).UTC()
+	alert.Delegation = &delegation
+	alert.FiredAt = time.UnixMilli(firedAt).UTC()
+
+	return &alert, nil
+}
@@ -0,0 +1,550 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	selectBakers = `
+SELECT address, delegators, delegated_amount, inflows, inflow_amount, outflows, outflow_amount FROM bakers`
+
+	selectDelegators = `SELECT address, baker, amount, timestamp, level, delegation_id FROM delegators`
+
+	upsertDelegator = `
+INSERT INTO delegators (address, baker, amount, timestamp, level, delegation_id)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (address) DO UPDATE SET
+	baker         = excluded.baker,
+	amount        = excluded.amount,
+	timestamp     = excluded.timestamp,
+	level         = excluded.level,
+	delegation_id = excluded.delegation_id`
+
+	// selectDelegatorsAsOf reconstructs the delegators of a baker from the delegations history: the delegators
+	// whose latest delegation as of a point in history is to the baker.
+	selectDelegatorsAsOf = `
+SELECT delegator, baker, amount, timestamp, level, id FROM (
+	SELECT *, ROW_NUMBER() OVER (PARTITION BY delegator ORDER BY timestamp DESC, id DESC) AS rank
+	FROM delegations
+	WHERE delegator IN (SELECT delegator FROM delegations WHERE baker = :baker AND %[1]s) AND %[1]s
+)
+WHERE rank = 1 AND baker = :baker
+ORDER BY delegator
+LIMIT :limit OFFSET :offset`
+
+	// selectBakersDelegators ranks the delegators of each baker by address, keeping a page by baker.
+	selectBakersDelegators = `
+SELECT address, baker, amount, timestamp, level, delegation_id FROM (
+	SELECT *, ROW_NUMBER() OVER (PARTITION BY baker ORDER BY address) AS position
+	FROM delegators
+	WHERE %s
+)
+WHERE position > :offset AND (:limit < 0 OR position <= :offset + :limit)
+ORDER BY baker, address`
+
+	// selectBakersDelegatorsAsOf reconstructs the delegators of bakers from the delegations history, like
+	// selectDelegatorsAsOf, ranking the delegators of each baker by address to keep a page by baker.
+	selectBakersDelegatorsAsOf = `
+SELECT delegator, baker, amount, timestamp, level, id FROM (
+	SELECT *, ROW_NUMBER() OVER (PARTITION BY baker ORDER BY delegator) AS position FROM (
+		SELECT *, ROW_NUMBER() OVER (PARTITION BY delegator ORDER BY timestamp DESC, id DESC) AS rank
+		FROM delegations
+		WHERE delegator IN (SELECT delegator FROM delegations WHERE %[1]s AND %[2]s) AND %[2]s
+	)
+	WHERE rank = 1 AND %[1]s
+)
+WHERE position > :offset AND (:limit < 0 OR position <= :offset + :limit)
+ORDER BY baker, delegator`
+
+	incrementBaker = `
+INSERT INTO bakers (address, delegators, delegated_amount, inflows, inflow_amount, outflows, outflow_amount)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (address) DO UPDATE SET
+	delegators       = delegators + excluded.delegators,
+	delegated_amount = delegated_amount + excluded.delegated_amount,
+	inflows          = inflows + excluded.inflows,
+	inflow_amount    = inflow_amount + excluded.inflow_amount,
+	outflows         = outflows + excluded.outflows,
+	outflow_amount   = outflow_amount + excluded.outflow_amount`
+)
+
+// GetBakers get a page of bakers aggregates.
+func (d *Datastore) GetBakers(
+	ctx context.Context,
+	bakersSort datastore.BakersSort,
+	page datastore.Page,
+) ([]*model.Baker, error) {
+	offset := 0
+	if page.Number > 1 {
+		offset = (page.Number - 1) * page.Size
+	}
+
+	// a negative limit means no limit in sqlite
+	limit := -1
+	if page.Size > 0 {
+		limit = page.Size
+	}
+
+	rows, err := d.db.QueryContext(ctx, selectBakers+orderBakers(bakersSort)+` LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.Baker
+
+	for rows.Next() {
+		baker, err := scanBaker(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, baker)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetBaker get the aggregate of a baker, nil if the baker is unknown.
+func (d *Datastore) GetBaker(ctx context.Context, address string) (*model.Baker, error) {
+	baker, err := scanBaker(d.db.QueryRowContext(ctx, selectBakers+` WHERE address = ?`, address))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return baker, nil
+}
+
+// GetBakersByAddresses get the aggregates of the bakers of addresses, sorted by address.
+// Unknown bakers are skipped.
+func (d *Datastore) GetBakersByAddresses(ctx context.Context, addresses []string) ([]*model.Baker, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	condition, args := in("address", addresses)
+
+	rows, err := d.db.QueryContext(ctx, selectBakers+` WHERE `+condition+` ORDER BY address`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.Baker
+
+	for rows.Next() {
+		baker, err := scanBaker(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, baker)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetBakersCount get the number of bakers.
+func (d *Datastore) GetBakersCount(ctx context.Context) (int, error) {
+	var count int
+
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM bakers`).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RebuildBakers recompute the bakers aggregates and the current delegation of the delegators from the
+// stored delegations, e.g. for the delegations stored before they were maintained.
+func (d *Datastore) RebuildBakers(ctx context.Context) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	rebuild, err := rebuildBakers(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bakers; DELETE FROM delegators`); err != nil {
+		return err
+	}
+
+	if err := upsertDelegators(ctx, tx, rebuild.Delegators()); err != nil {
+		return err
+	}
+
+	if err := incrementBakers(ctx, tx, rebuild.Bakers()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rebuildBakers applies the stored delegations chronologically to a new bakers rebuild.
+func rebuildBakers(ctx context.Context, tx *sql.Tx) (*datastore.BakersRebuild, error) {
+	rows, err := tx.QueryContext(ctx, selectDelegations+` ORDER BY timestamp, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rebuild := datastore.NewBakersRebuild()
+
+	for rows.Next() {
+		delegation, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		rebuild.Add(delegation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rebuild, nil
+}
+
+// GetDelegator get the current delegation of a delegator, nil if the delegator is unknown.
+func (d *Datastore) GetDelegator(ctx context.Context, address string) (*model.Delegator, error) {
+	delegator, err := scanDelegator(d.db.QueryRowContext(ctx, selectDelegators+` WHERE address = ?`, address))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return delegator, nil
+}
+
+// GetBakerDelegators get a page of the delegators of a baker as of a point in history, sorted by address.
+// The current delegators are read from the delegators current delegation, past ones are reconstructed
+// from the delegations history.
+func (d *Datastore) GetBakerDelegators(
+	ctx context.Context,
+	baker string,
+	asOf datastore.AsOf,
+	page datastore.Page,
+) ([]*model.Delegator, error) {
+	offset := 0
+	if page.Number > 1 {
+		offset = (page.Number - 1) * page.Size
+	}
+
+	// a negative limit means no limit in sqlite
+	limit := -1
+	if page.Size > 0 {
+		limit = page.Size
+	}
+
+	query := selectDelegators + ` WHERE baker = :baker ORDER BY address LIMIT :limit OFFSET :offset`
+	args := []any{sql.Named("baker", baker), sql.Named("limit", limit), sql.Named("offset", offset)}
+
+	if !asOf.IsZero() {
+		condition, asOfArgs := asOfCondition(asOf)
+		query = fmt.Sprintf(selectDelegatorsAsOf, condition)
+		args = append(args, asOfArgs...)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.Delegator
+
+	for rows.Next() {
+		delegator, err := scanDelegator(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, delegator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetBakersDelegators get a page of the delegators of each of the bakers as of a point in history, sorted
+// by baker then address, the page applying to the delegators of each baker.
+func (d *Datastore) GetBakersDelegators(
+	ctx context.Context,
+	bakers []string,
+	asOf datastore.AsOf,
+	page datastore.Page,
+) ([]*model.Delegator, error) {
+	if len(bakers) == 0 {
+		return nil, nil
+	}
+
+	offset := 0
+	if page.Number > 1 {
+		offset = (page.Number - 1) * page.Size
+	}
+
+	// a negative limit means no limit
+	limit := -1
+	if page.Size > 0 {
+		limit = page.Size
+	}
+
+	condition, args := namedIn("baker", bakers)
+	query := fmt.Sprintf(selectBakersDelegators, condition)
+	args = append(args, sql.Named("limit", limit), sql.Named("offset", offset))
+
+	if !asOf.IsZero() {
+		asOfCondition, asOfArgs := asOfCondition(asOf)
+		query = fmt.Sprintf(selectBakersDelegatorsAsOf, condition, asOfCondition)
+		args = append(args, asOfArgs...)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.Delegator
+
+	for rows.Next() {
+		delegator, err := scanDelegator(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, delegator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// namedIn returns the condition matching a column against a list of values, and its named arguments, so that
+// the condition can be repeated in a query.
+func namedIn(column string, values []string) (string, []any) {
+	names := make([]string, len(values))
+	args := make([]any, len(values))
+
+	for i, value := range values {
+		names[i] = fmt.Sprintf(":%s%d", column, i)
+		args[i] = sql.Named(fmt.Sprintf("%s%d", column, i), value)
+	}
+
+	return column + ` IN (` + strings.Join(names, ", ") + `)`, args
+}
+
+// asOfCondition returns the condition and its named arguments keeping the delegations as of a point in history.
+func asOfCondition(asOf datastore.AsOf) (string, []any) {
+	conditions := []string{"TRUE"}
+
+	var args []any
+
+	if !asOf.Timestamp.IsZero() {
+		conditions = append(conditions, "timestamp <= :timestamp")
+		args = append(args, sql.Named("timestamp", asOf.Timestamp.UnixMilli()))
+	}
+
+	if asOf.Level != 0 {
+		conditions = append(conditions, "level <= :level")
+		args = append(args, sql.Named("level", asOf.Level))
+	}
+
+	return "(" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// applyDelegations updates the bakers aggregates and the delegators with new delegations.
+func applyDelegations(ctx context.Context, tx *sql.Tx, delegations []*model.Delegation) error {
+	if len(delegations) == 0 {
+		return nil
+	}
+
+	delegators, err := currentDelegators(ctx, tx, delegations)
+	if err != nil {
+		return err
+	}
+
+	changes, updated := datastore.ApplyDelegations(delegators, delegations)
+
+	if err := upsertDelegators(ctx, tx, updated); err != nil {
+		return err
+	}
+
+	return incrementBakers(ctx, tx, changes)
+}
+
+// upsertDelegators stores the current delegation of delegators.
+func upsertDelegators(ctx context.Context, tx *sql.Tx, delegators []*model.Delegator) error {
+	for _, delegator := range delegators {
+		_, err := tx.ExecContext(
+			ctx,
+			upsertDelegator,
+			delegator.Address,
+			delegator.Baker,
+			delegator.Amount,
+			delegator.Timestamp.UnixMilli(),
+			delegator.Level,
+			delegator.DelegationID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// incrementBakers adds changes to the bakers aggregates.
+func incrementBakers(ctx context.Context, tx *sql.Tx, changes map[string]*model.Baker) error {
+	for _, change := range changes {
+		_, err := tx.ExecContext(
+			ctx,
+			incrementBaker,
+			change.Address,
+			change.Delegators,
+			change.DelegatedAmount,
+			change.Inflows,
+			change.InflowAmount,
+			change.Outflows,
+			change.OutflowAmount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// currentDelegators returns the current delegation of the delegators of the delegations, by address.
+func currentDelegators(
+	ctx context.Context,
+	tx *sql.Tx,
+	delegations []*model.Delegation,
+) (map[string]*model.Delegator, error) {
+	stmt, err := tx.PrepareContext(ctx, selectDelegators+` WHERE address = ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	delegators := map[string]*model.Delegator{}
+
+	for _, delegation := range delegations {
+		if _, found := delegators[delegation.Delegator]; found {
+			continue
+		}
+
+		delegator, err := scanDelegator(stmt.QueryRowContext(ctx, delegation.Delegator))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		delegators[delegation.Delegator] = delegator
+	}
+
+	return delegators, nil
+}
+
+// orderBakers returns the order by clause of a bakers sort, ties being sorted by address.
+func orderBakers(bakersSort datastore.BakersSort) string {
+	direction := " ASC"
+	if bakersSort.Descending {
+		direction = " DESC"
+	}
+
+	switch bakersSort.Field {
+	case datastore.BakersSortDelegators:
+		return ` ORDER BY delegators` + direction + `, address`
+	case datastore.BakersSortDelegatedAmount:
+		return ` ORDER BY delegated_amount` + direction + `, address`
+	case datastore.BakersSortInflows:
+		return ` ORDER BY inflows` + direction + `, address`
+	case datastore.BakersSortOutflows:
+		return ` ORDER BY outflows` + direction + `, address`
+	default:
+		return ` ORDER BY address` + direction
+	}
+}
+
+func scanDelegator(s scanner) (*model.Delegator, error) {
+	var (
+		timestamp int64
+		delegator model.Delegator
+	)
+
+	err := s.Scan(
+		&delegator.Address,
+		&delegator.Baker,
+		&delegator.Amount,
+		&timestamp,
+		&delegator.Level,
+		&delegator.DelegationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delegator.Timestamp = time.UnixMilli(timestamp).UTC()
+
+	return &delegator, nil
+}
+
+func scanBaker(s scanner) (*model.Baker, error) {
+	var baker model.Baker
+
+	err := s.Scan(
+		&baker.Address,
+		&baker.Delegators,
+		&baker.DelegatedAmount,
+		&baker.Inflows,
+		&baker.InflowAmount,
+		&baker.Outflows,
+		&baker.OutflowAmount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &baker, nil
+}
@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	insertChange = `
+INSERT INTO delegation_changes (operation, id, timestamp, amount, delegator, block, baker, previous_baker, level)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	selectChanges = `
+SELECT sequence, operation, id, timestamp, amount, delegator, block, baker, previous_baker, level
+FROM delegation_changes`
+
+	deleteDelegator = `DELETE FROM delegators WHERE address = ?`
+)
+
+// DeleteDelegations delete stored delegations, unknown ids being ignored. Counters, bakers aggregates and
+// delegators current delegation are updated accordingly, in the same transaction.
+//
+//nolint:funlen
+func (d *Datastore) DeleteDelegations(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	toDelete := make([]*model.Delegation, len(ids))
+	for i, id := range ids {
+		toDelete[i] = &model.Delegation{ID: id}
+	}
+
+	stored, err := storedDelegations(ctx, tx, toDelete)
+	if err != nil {
+		return err
+	}
+
+	deleted := make([]*model.Delegation, 0, len(stored))
+
+	for _, id := range ids {
+		delegation, found := stored[id]
+		if !found {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteDelegation, id); err != nil {
+			return err
+		}
+
+		delete(stored, id)
+
+		deleted = append(deleted, delegation)
+	}
+
+	for name, delta := range datastore.DeleteCountDeltas(deleted) {
+		if _, err := tx.ExecContext(ctx, incrementCount, name, delta); err != nil {
+			return err
+		}
+	}
+
+	if err := removeDelegations(ctx, tx, deleted); err != nil {
+		return err
+	}
+
+	if err := insertChanges(ctx, tx, datastore.DeleteChanges(deleted)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// removeDelegations updates the bakers aggregates and the delegators with deleted delegations.
+func removeDelegations(ctx context.Context, tx *sql.Tx, deleted []*model.Delegation) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	delegators, err := currentDelegators(ctx, tx, deleted)
+	if err != nil {
+		return err
+	}
+
+	latest := map[string]*model.Delegation{}
+
+	for _, delegation := range deleted {
+		remaining, err := scanDelegation(tx.QueryRowContext(
+			ctx,
+			selectDelegations+` WHERE delegator = ?`+orderDelegations+` LIMIT 1`,
+			delegation.Delegator,
+		))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return err
+		}
+
+		latest[delegation.Delegator] = remaining
+	}
+
+	changes, updated, removed := datastore.RemoveDelegations(delegators, deleted, latest)
+
+	if err := upsertDelegators(ctx, tx, updated); err != nil {
+		return err
+	}
+
+	for _, address := range removed {
+		if _, err := tx.ExecContext(ctx, deleteDelegator, address); err != nil {
+			return err
+		}
+	}
+
+	return incrementBakers(ctx, tx, changes)
+}
+
+// GetDelegationsChanges get the delegations changes with a sequence greater than since, in sequence order.
+// A limit of 0 means no limit.
+func (d *Datastore) GetDelegationsChanges(
+	ctx context.Context,
+	since int64,
+	limit int,
+) ([]*model.DelegationChange, error) {
+	// a negative limit means no limit in sqlite
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := d.db.QueryContext(ctx, selectChanges+` WHERE sequence > ? ORDER BY sequence LIMIT ?`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.DelegationChange
+
+	for rows.Next() {
+		change, err := scanChange(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetLatestDelegationsChange get the last change of the delegations change log, nil if it is empty.
+func (d *Datastore) GetLatestDelegationsChange(ctx context.Context) (*model.DelegationChange, error) {
+	row := d.db.QueryRowContext(ctx, selectChanges+` ORDER BY sequence DESC LIMIT 1`)
+
+	result, err := scanChange(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanChange scans a change selected with selectChanges.
+func scanChange(s scanner) (*model.DelegationChange, error) {
+	var (
+		change     model.DelegationChange
+		delegation model.Delegation
+		timestamp  int64
+	)
+
+	err := s.Scan(
+		&change.Sequence,
+		&change.Operation,
+		&delegation.ID,
+		&timestamp,
+		&delegation.Amount,
+		&delegation.Delegator,
+		&delegation.Block,
+		&delegation.Baker,
+		&delegation.PreviousBaker,
+		&delegation.Level,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation.Timestamp = time.UnixMilli(timestamp).UTC()
+	change.Delegation = &delegation
+
+	return &change, nil
+}
+
+// insertChanges appends changes to the change log, their sequences being assigned by the table.
+func insertChanges(ctx context.Context, tx *sql.Tx, changes []*model.DelegationChange) error {
+	for _, change := range changes {
+		res, err := tx.ExecContext(
+			ctx,
+			insertChange,
+			change.Operation,
+			change.Delegation.ID,
+			change.Delegation.Timestamp.UnixMilli(),
+			change.Delegation.Amount,
+			change.Delegation.Delegator,
+			change.Delegation.Block,
+			change.Delegation.Baker,
+			change.Delegation.PreviousBaker,
+			change.Delegation.Level,
+		)
+		if err != nil {
+			return err
+		}
+
+		change.Sequence, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,613 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	upsertDelegation = `
+INSERT INTO delegations (id, timestamp, amount, delegator, block, baker, previous_baker, level)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	timestamp      = excluded.timestamp,
+	amount         = excluded.amount,
+	delegator      = excluded.delegator,
+	block          = excluded.block,
+	baker          = excluded.baker,
+	previous_baker = excluded.previous_baker,
+	level          = excluded.level`
+
+	selectDelegations = `
+SELECT id, timestamp, amount, delegator, block, baker, previous_baker, level FROM delegations`
+
+	// selectLatestDelegations selects the latest delegations of each delegator matching a condition, at most a
+	// limit by delegator, in chronological order.
+	selectLatestDelegations = `
+SELECT id, timestamp, amount, delegator, block, baker, previous_baker, level FROM (
+	SELECT *, ROW_NUMBER() OVER (PARTITION BY delegator ORDER BY timestamp DESC, id DESC) AS rank
+	FROM delegations
+	WHERE %s
+)
+WHERE rank <= ?
+ORDER BY timestamp, id`
+
+	deleteDelegation = `DELETE FROM delegations WHERE id = ?`
+
+	incrementCount = `
+INSERT INTO delegation_counts (name, count)
+VALUES (?, ?)
+ON CONFLICT (name) DO UPDATE SET count = count + excluded.count`
+
+	// orderDelegations sorts delegations by timestamp then id descending, the id making the order total.
+	orderDelegations = ` ORDER BY timestamp DESC, id DESC`
+)
+
+// StoreDelegations store delegations in database.
+//
+//nolint:funlen
+func (d *Datastore) StoreDelegations(ctx context.Context, delegations []*model.Delegation) error {
+	if len(delegations) == 0 {
+		return errEmptyDelegations
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	// counters and bakers aggregates are updated in the same transaction, from the delegations stored before
+	stored, err := storedDelegations(ctx, tx, delegations)
+	if err != nil {
+		return err
+	}
+
+	// the previous values of the updated delegations are reversed before their new values are applied
+	previous, updated := datastore.UpdatedDelegations(stored, delegations)
+
+	for _, delegation := range previous {
+		if _, err := tx.ExecContext(ctx, deleteDelegation, delegation.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := removeDelegations(ctx, tx, previous); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, upsertDelegation)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, delegation := range delegations {
+		_, err := stmt.ExecContext(
+			ctx,
+			delegation.ID,
+			delegation.Timestamp.UnixMilli(),
+			delegation.Amount,
+			delegation.Delegator,
+			delegation.Block,
+			delegation.Baker,
+			delegation.PreviousBaker,
+			delegation.Level,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, delta := range datastore.CountDeltas(stored, delegations) {
+		if _, err := tx.ExecContext(ctx, incrementCount, name, delta); err != nil {
+			return err
+		}
+	}
+
+	if err := applyDelegations(ctx, tx, append(datastore.NewDelegations(stored, delegations), updated...)); err != nil {
+		return err
+	}
+
+	changes := datastore.StoreChanges(stored, delegations)
+
+	if err := insertChanges(ctx, tx, changes); err != nil {
+		return err
+	}
+
+	if err := createDeliveries(ctx, tx, changes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// storedDelegations returns the delegations already stored, by id.
+func storedDelegations(
+	ctx context.Context,
+	tx *sql.Tx,
+	delegations []*model.Delegation,
+) (map[int64]*model.Delegation, error) {
+	stmt, err := tx.PrepareContext(ctx, selectDelegations+` WHERE id = ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	stored := map[int64]*model.Delegation{}
+
+	for _, delegation := range delegations {
+		previous, err := scanDelegation(stmt.QueryRowContext(ctx, delegation.ID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		stored[delegation.ID] = previous
+	}
+
+	return stored, nil
+}
+
+// GetLatestDelegation get the latest delegation in database (with the more recent timestamp).
+func (d *Datastore) GetLatestDelegation(ctx context.Context) (*model.Delegation, error) {
+	row := d.db.QueryRowContext(ctx, selectDelegations+orderDelegations+` LIMIT 1`)
+
+	result, err := scanDelegation(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLegacyDelegations get the oldest delegations stored before delegations were identified by their
+// operation id, which have a provisional negative id, in chronological order.
+func (d *Datastore) GetLegacyDelegations(ctx context.Context, limit int) ([]*model.Delegation, error) {
+	return d.getDelegations(ctx, selectDelegations+` WHERE id < 0 ORDER BY timestamp, id LIMIT ?`, limit)
+}
+
+// GetDelegations get a page of delegations matching the filter, sorted, with the projected fields.
+func (d *Datastore) GetDelegations(
+	ctx context.Context,
+	filter datastore.Filter,
+	sort datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+) ([]*model.Delegation, error) {
+	var results []*model.Delegation
+
+	err := d.IterateDelegations(ctx, filter, sort, projection, page, func(delegation *model.Delegation) error {
+		results = append(results, delegation)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// IterateDelegations calls fn on each delegation of a page matching the filter, sorted, with the projected
+// fields, scanning the rows one at a time. An error returned by fn stops the iteration.
+func (d *Datastore) IterateDelegations(
+	ctx context.Context,
+	filter datastore.Filter,
+	sort datastore.DelegationsSort,
+	projection datastore.Projection,
+	page datastore.Page,
+	fn func(delegation *model.Delegation) error,
+) error {
+	if page.After != nil && !sort.IsDefault() {
+		return datastore.ErrCursorSort
+	}
+
+	conditions, args := delegationsConditions(filter)
+
+	offset := 0
+
+	if page.After != nil {
+		// keyset pagination: delegations strictly after the cursor in the sort order
+		conditions = append(conditions, "(timestamp < ? OR (timestamp = ? AND id < ?))")
+		after := page.After.Timestamp.UnixMilli()
+		args = append(args, after, after, page.After.ID)
+	} else if page.Number > 1 {
+		offset = (page.Number - 1) * page.Size
+	}
+
+	// a negative limit means no limit in sqlite
+	limit := -1
+	if page.Size > 0 {
+		limit = page.Limit()
+	}
+
+	args = append(args, limit, offset)
+
+	rows, err := d.db.QueryContext(
+		ctx,
+		selectProjected(projection)+where(conditions)+delegationsOrder(sort)+` LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delegation, err := scanProjected(rows, projection)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(delegation); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetDelegatorDelegations get all the delegations of a delegator, in chronological order.
+func (d *Datastore) GetDelegatorDelegations(ctx context.Context, delegator string) ([]*model.Delegation, error) {
+	return d.getDelegations(ctx, selectDelegations+` WHERE delegator = ? ORDER BY timestamp, id`, delegator)
+}
+
+// GetDelegatorsDelegations get the latest delegations of several delegators, at most limit by delegator,
+// in chronological order. A limit of 0 means no limit.
+func (d *Datastore) GetDelegatorsDelegations(
+	ctx context.Context,
+	delegators []string,
+	limit int,
+) ([]*model.Delegation, error) {
+	if len(delegators) == 0 {
+		return nil, nil
+	}
+
+	condition, args := in("delegator", delegators)
+
+	if limit <= 0 {
+		return d.getDelegations(ctx, selectDelegations+` WHERE `+condition+` ORDER BY timestamp, id`, args...)
+	}
+
+	return d.getDelegations(ctx, fmt.Sprintf(selectLatestDelegations, condition), append(args, limit)...)
+}
+
+// getDelegations returns all the delegations selected by a query.
+func (d *Datastore) getDelegations(ctx context.Context, query string, args ...any) ([]*model.Delegation, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.Delegation
+
+	for rows.Next() {
+		delegation, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, delegation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetDelegationsCount get the number of delegations matching the filter,
+// from the precomputed counters when the filter allows it.
+func (d *Datastore) GetDelegationsCount(ctx context.Context, filter datastore.Filter) (int, error) {
+	query, ok := filter.CountQuery()
+
+	var row *sql.Row
+
+	switch {
+	case !ok:
+		conditions, args := delegationsConditions(filter)
+		row = d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM delegations`+where(conditions), args...)
+	case query.Key != "":
+		row = d.db.QueryRowContext(
+			ctx,
+			`SELECT COALESCE(SUM(count), 0) FROM delegation_counts WHERE name = ?`,
+			query.Key,
+		)
+	default:
+		row = d.db.QueryRowContext(
+			ctx,
+			`SELECT COALESCE(SUM(count), 0) FROM delegation_counts WHERE name >= ? AND name < ?`,
+			query.FromDay,
+			query.ToDay,
+		)
+	}
+
+	var count int
+
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RebuildCounts recompute the precomputed delegations counters from the stored delegations.
+func (d *Datastore) RebuildCounts(ctx context.Context) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT strftime('%Y-%m-%d', timestamp / 1000, 'unixepoch') AS day, COUNT(*) FROM delegations GROUP BY day`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	perDay := map[string]int{}
+
+	for rows.Next() {
+		var (
+			day   string
+			count int
+		)
+
+		if err := rows.Scan(&day, &count); err != nil {
+			return err
+		}
+
+		perDay[day] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM delegation_counts`); err != nil {
+		return err
+	}
+
+	for name, count := range datastore.RecountKeys(perDay) {
+		if _, err := tx.ExecContext(ctx, incrementCount, name, count); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// delegationsConditions returns the where conditions and their arguments to filter delegations.
+// Filtering on a timestamp range allows sqlite to use the timestamp index.
+//
+//nolint:cyclop
+func delegationsConditions(filter datastore.Filter) ([]string, []any) {
+	from, to := filter.TimestampRange()
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.MatchesNone() {
+		return []string{"0"}, nil
+	}
+
+	if !from.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, from.UnixMilli())
+	}
+
+	if !to.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, to.UnixMilli())
+	}
+
+	if filter.Delegator != "" {
+		conditions = append(conditions, "delegator = ?")
+		args = append(args, filter.Delegator)
+	}
+
+	if filter.Baker != "" {
+		conditions = append(conditions, "baker = ?")
+		args = append(args, filter.Baker)
+	}
+
+	if filter.PreviousBaker != "" {
+		conditions = append(conditions, "previous_baker = ?")
+		args = append(args, filter.PreviousBaker)
+	}
+
+	if filter.Block != "" {
+		conditions = append(conditions, "block = ?")
+		args = append(args, filter.Block)
+	}
+
+	if filter.Level != 0 {
+		conditions = append(conditions, "level = ?")
+		args = append(args, filter.Level)
+	}
+
+	if filter.AmountGt != nil {
+		conditions = append(conditions, "amount > ?")
+		args = append(args, *filter.AmountGt)
+	}
+
+	if filter.AmountLt != nil {
+		conditions = append(conditions, "amount < ?")
+		args = append(args, *filter.AmountLt)
+	}
+
+	switch filter.Kind {
+	case model.KindDelegation:
+		conditions = append(conditions, "baker != ''", "previous_baker = ''")
+	case model.KindRedelegation:
+		conditions = append(conditions, "baker != ''", "previous_baker != ''")
+	case model.KindUndelegation:
+		conditions = append(conditions, "baker = ''")
+	}
+
+	return conditions, args
+}
+
+// where returns the where clause joining conditions.
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// in returns the condition matching a column against a list of values, and its arguments.
+func in(column string, values []string) (string, []any) {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return column + ` IN (?` + strings.Repeat(`, ?`, len(values)-1) + `)`, args
+}
+
+// delegationColumns are the columns of the delegation fields.
+var delegationColumns = map[string]string{
+	datastore.FieldID:            "id",
+	datastore.FieldTimestamp:     "timestamp",
+	datastore.FieldAmount:        "amount",
+	datastore.FieldDelegator:     "delegator",
+	datastore.FieldBlock:         "block",
+	datastore.FieldLevel:         "level",
+	datastore.FieldBaker:         "baker",
+	datastore.FieldPreviousBaker: "previous_baker",
+}
+
+// delegationsOrder returns the order clause of a delegations sort, ties being sorted by timestamp then id.
+func delegationsOrder(sort datastore.DelegationsSort) string {
+	if sort.IsDefault() {
+		return orderDelegations
+	}
+
+	direction := " DESC"
+	if sort.Ascending {
+		direction = " ASC"
+	}
+
+	order := " ORDER BY "
+	if sort.Field != "" && sort.Field != datastore.DelegationsSortTimestamp {
+		order += delegationColumns[sort.Field] + direction + ", "
+	}
+
+	return order + "timestamp" + direction + ", id" + direction
+}
+
+// selectProjected returns the select statement of the projected delegation fields.
+func selectProjected(projection datastore.Projection) string {
+	if projection == nil {
+		return selectDelegations
+	}
+
+	columns := make([]string, len(projection))
+	for i, field := range projection {
+		columns[i] = delegationColumns[field]
+	}
+
+	return `SELECT ` + strings.Join(columns, ", ") + ` FROM delegations`
+}
+
+// scanProjected scans a delegation selected with selectProjected.
+func scanProjected(s scanner, projection datastore.Projection) (*model.Delegation, error) {
+	if projection == nil {
+		return scanDelegation(s)
+	}
+
+	var (
+		timestamp  int64
+		delegation model.Delegation
+	)
+
+	dest := make([]any, len(projection))
+
+	for i, field := range projection {
+		switch field {
+		case datastore.FieldID:
+			dest[i] = &delegation.ID
+		case datastore.FieldTimestamp:
+			dest[i] = &timestamp
+		case datastore.FieldAmount:
+			dest[i] = &delegation.Amount
+		case datastore.FieldDelegator:
+			dest[i] = &delegation.Delegator
+		case datastore.FieldBlock:
+			dest[i] = &delegation.Block
+		case datastore.FieldLevel:
+			dest[i] = &delegation.Level
+		case datastore.FieldBaker:
+			dest[i] = &delegation.Baker
+		case datastore.FieldPreviousBaker:
+			dest[i] = &delegation.PreviousBaker
+		}
+	}
+
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if projection.Has(datastore.FieldTimestamp) {
+		delegation.Timestamp = time.UnixMilli(timestamp).UTC()
+	}
+
+	return &delegation, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDelegation(s scanner) (*model.Delegation, error) {
+	var (
+		timestamp  int64
+		delegation model.Delegation
+	)
+
+	err := s.Scan(
+		&delegation.ID,
+		&timestamp,
+		&delegation.Amount,
+		&delegation.Delegator,
+		&delegation.Block,
+		&delegation.Baker,
+		&delegation.PreviousBaker,
+		&delegation.Level,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation.Timestamp = time.UnixMilli(timestamp).UTC()
+
+	return &delegation, nil
+}
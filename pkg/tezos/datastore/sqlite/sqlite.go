@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	// register the pure go sqlite driver.
+	_ "modernc.org/sqlite"
+)
+
+const (
+	driverName  = "sqlite"
+	busyTimeout = 5000
+)
+
+// errEmptyDelegations mirrors the mongo bulk write error when no delegation is given.
+var errEmptyDelegations = errors.New("must provide at least one element in input slice")
+
+// schema creates the datastore tables if they don't exist yet.
+const schema = `
+CREATE TABLE IF NOT EXISTS delegations (
+	id             INTEGER NOT NULL PRIMARY KEY,
+	timestamp      INTEGER NOT NULL,
+	amount         INTEGER NOT NULL,
+	delegator      TEXT    NOT NULL,
+	block          TEXT    NOT NULL,
+	baker          TEXT    NOT NULL DEFAULT '',
+	previous_baker TEXT    NOT NULL DEFAULT '',
+	level          INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS delegations_timestamp_id ON delegations (timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS delegations_delegator ON delegations (delegator, timestamp, id);
+CREATE TABLE IF NOT EXISTS delegation_counts (
+	name  TEXT    NOT NULL PRIMARY KEY,
+	count INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bakers (
+	address          TEXT    NOT NULL PRIMARY KEY,
+	delegators       INTEGER NOT NULL DEFAULT 0,
+	delegated_amount INTEGER NOT NULL DEFAULT 0,
+	inflows          INTEGER NOT NULL DEFAULT 0,
+	inflow_amount    INTEGER NOT NULL DEFAULT 0,
+	outflows         INTEGER NOT NULL DEFAULT 0,
+	outflow_amount   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS bakers_delegators ON bakers (delegators DESC, address);
+CREATE TABLE IF NOT EXISTS delegators (
+	address       TEXT    NOT NULL PRIMARY KEY,
+	baker         TEXT    NOT NULL,
+	amount        INTEGER NOT NULL,
+	timestamp     INTEGER NOT NULL,
+	level         INTEGER NOT NULL DEFAULT 0,
+	delegation_id INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS delegators_baker ON delegators (baker);
+CREATE TABLE IF NOT EXISTS delegation_changes (
+	sequence       INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	operation      TEXT    NOT NULL,
+	id             INTEGER NOT NULL,
+	timestamp      INTEGER NOT NULL,
+	amount         INTEGER NOT NULL,
+	delegator      TEXT    NOT NULL,
+	block          TEXT    NOT NULL,
+	baker          TEXT    NOT NULL,
+	previous_baker TEXT    NOT NULL,
+	level          INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhooks (
+	id         TEXT    NOT NULL PRIMARY KEY,
+	owner      TEXT    NOT NULL,
+	url        TEXT    NOT NULL,
+	secret     TEXT    NOT NULL,
+	baker      TEXT    NOT NULL,
+	delegator  TEXT    NOT NULL,
+	min_amount INTEGER NOT NULL,
+	kind       TEXT    NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id              TEXT    NOT NULL PRIMARY KEY,
+	webhook_id      TEXT    NOT NULL,
+	sequence        INTEGER NOT NULL,
+	delegation_id   INTEGER NOT NULL,
+	timestamp       INTEGER NOT NULL,
+	amount          INTEGER NOT NULL,
+	delegator       TEXT    NOT NULL,
+	block           TEXT    NOT NULL,
+	baker           TEXT    NOT NULL,
+	previous_baker  TEXT    NOT NULL,
+	level           INTEGER NOT NULL,
+	status          TEXT    NOT NULL,
+	attempts        INTEGER NOT NULL,
+	status_code     INTEGER NOT NULL,
+	error           TEXT    NOT NULL,
+	created_at      INTEGER NOT NULL,
+	next_attempt_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries (webhook_id, sequence DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE TABLE IF NOT EXISTS alerts (
+	rule           TEXT    NOT NULL,
+	key            TEXT    NOT NULL,
+	type           TEXT    NOT NULL,
+	message        TEXT    NOT NULL,
+	alert_baker    TEXT    NOT NULL,
+	delegation_id  INTEGER NOT NULL,
+	timestamp      INTEGER NOT NULL,
+	amount         INTEGER NOT NULL,
+	delegator      TEXT    NOT NULL,
+	block          TEXT    NOT NULL,
+	baker          TEXT    NOT NULL,
+	previous_baker TEXT    NOT NULL,
+	level          INTEGER NOT NULL,
+	fired_at       INTEGER NOT NULL,
+	PRIMARY KEY (rule, key)
+);
+CREATE INDEX IF NOT EXISTS alerts_rule_fired_at ON alerts (rule, fired_at DESC);
+`
+
+// migrations creates the indexes on the columns added by migrate.
+const migrations = `
+CREATE INDEX IF NOT EXISTS delegations_baker ON delegations (baker);
+CREATE INDEX IF NOT EXISTS delegations_previous_baker ON delegations (previous_baker, timestamp);
+`
+
+// column is a column added to a table after its creation, which is added to the tables of existing databases.
+type column struct {
+	table      string
+	name       string
+	definition string
+}
+
+func addedColumns() []column {
+	return []column{
+		{table: "delegations", name: "baker", definition: "TEXT NOT NULL DEFAULT ''"},
+		{table: "delegations", name: "previous_baker", definition: "TEXT NOT NULL DEFAULT ''"},
+		{table: "delegations", name: "level", definition: "INTEGER NOT NULL DEFAULT 0"},
+		{table: "delegators", name: "level", definition: "INTEGER NOT NULL DEFAULT 0"},
+		{table: "webhooks", name: "owner", definition: "TEXT NOT NULL DEFAULT ''"},
+	}
+}
+
+// Config describes the sqlite datastore configuration.
+type Config struct {
+	Path string `validate:"required"`
+}
+
+// Datastore represents the implementation of the datastore with sqlite.
+type Datastore struct {
+	cfg *Config
+	db  *sql.DB
+}
+
+// New create a new sqlite datastore.
+func New(cfg *Config) *Datastore {
+	return &Datastore{
+		cfg: cfg,
+	}
+}
+
+// Init initialize sqlite datastore, opening the database file and creating the schema.
+// Transactions take the write lock immediately, as they read the stored delegations before writing.
+func (d *Datastore) Init() error {
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_txlock=immediate",
+		d.cfg.Path,
+		busyTimeout,
+	)
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateLegacyKey(context.Background(), db); err != nil {
+		_ = db.Close()
+
+		return err
+	}
+
+	if _, err := db.ExecContext(context.Background(), schema); err != nil {
+		_ = db.Close()
+
+		return err
+	}
+
+	d.db = db
+
+	if err := d.initData(context.Background()); err != nil {
+		_ = db.Close()
+		d.db = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// initData migrates the stored data, then initializes the delegations counts and the bakers aggregates.
+func (d *Datastore) initData(ctx context.Context) error {
+	if err := d.migrate(ctx); err != nil {
+		return err
+	}
+
+	if err := d.initCounts(ctx); err != nil {
+		return err
+	}
+
+	return d.initBakers(ctx)
+}
+
+// migrateLegacyKey moves the delegations of a database created when they were keyed by timestamp, without
+// id, to the delegations table keyed by id. Their operation id being unknown, they get a provisional negative
+// id derived from their timestamp, which was unique, until they are backfilled.
+func migrateLegacyKey(ctx context.Context, db *sql.DB) error {
+	var legacy bool
+
+	err := db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM pragma_table_info('delegations'))
+		AND NOT EXISTS (SELECT 1 FROM pragma_table_info('delegations') WHERE name = 'id')`,
+	).Scan(&legacy)
+	if err != nil || !legacy {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `ALTER TABLE delegations RENAME TO legacy_delegations`)
+	if err != nil {
+		return err
+	}
+
+	// the other tables and indexes are created along with the new delegations table
+	if _, err := tx.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO delegations (id, timestamp, amount, delegator, block)
+SELECT -timestamp, timestamp, amount, delegator, block FROM legacy_delegations;
+DROP TABLE legacy_delegations;`)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrate adds the missing columns to the tables of a database created by a previous version.
+func (d *Datastore) migrate(ctx context.Context) error {
+	for _, column := range addedColumns() {
+		var exists bool
+
+		err := d.db.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM pragma_table_info(?) WHERE name = ?)`,
+			column.table,
+			column.name,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			continue
+		}
+
+		_, err = d.db.ExecContext(
+			ctx,
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, column.table, column.name, column.definition),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := d.db.ExecContext(ctx, migrations)
+
+	return err
+}
+
+// initCounts computes the delegations counters of a database created before they were maintained.
+func (d *Datastore) initCounts(ctx context.Context) error {
+	var missing bool
+
+	err := d.db.QueryRowContext(
+		ctx,
+		`SELECT NOT EXISTS (SELECT 1 FROM delegation_counts) AND EXISTS (SELECT 1 FROM delegations)`,
+	).Scan(&missing)
+	if err != nil || !missing {
+		return err
+	}
+
+	return d.RebuildCounts(ctx)
+}
+
+// initBakers computes the bakers aggregates and the delegators of a database created before they were
+// maintained, a datastore with delegations always having delegators.
+func (d *Datastore) initBakers(ctx context.Context) error {
+	var missing bool
+
+	err := d.db.QueryRowContext(
+		ctx,
+		`SELECT NOT EXISTS (SELECT 1 FROM delegators) AND EXISTS (SELECT 1 FROM delegations)`,
+	).Scan(&missing)
+	if err != nil || !missing {
+		return err
+	}
+
+	return d.RebuildBakers(ctx)
+}
+
+// Close close sqlite datastore.
+func (d *Datastore) Close() error {
+	return d.db.Close()
+}
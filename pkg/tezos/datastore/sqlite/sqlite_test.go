@@ -0,0 +1,221 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/datastoretest"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/sqlite"
+)
+
+func TestDatastore_Conformance(t *testing.T) {
+	t.Parallel()
+
+	datastoretest.Run(t, func(t *testing.T) datastore.Datastorer {
+		t.Helper()
+
+		sqliteSvc := sqlite.New(&sqlite.Config{
+			Path: filepath.Join(t.TempDir(), "tezos_delegation.db"),
+		})
+		require.NoError(t, sqliteSvc.Init())
+
+		t.Cleanup(func() {
+			require.NoError(t, sqliteSvc.Close())
+		})
+
+		return sqliteSvc
+	})
+}
+
+// TestDatastore_InitMigrates checks a database created by a previous version is migrated on init,
+// keeping its delegations and computing their counts.
+func TestDatastore_InitMigrates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tezos_delegation.db")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+CREATE TABLE delegations (
+	id        INTEGER NOT NULL PRIMARY KEY,
+	timestamp INTEGER NOT NULL,
+	amount    INTEGER NOT NULL,
+	delegator TEXT    NOT NULL,
+	block     TEXT    NOT NULL
+);
+INSERT INTO delegations VALUES (1, 1702206061000, 124428330, 'tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx', 'block1');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	sqliteSvc := sqlite.New(&sqlite.Config{Path: path})
+	require.NoError(t, sqliteSvc.Init())
+
+	t.Cleanup(func() {
+		require.NoError(t, sqliteSvc.Close())
+	})
+
+	latest, err := sqliteSvc.GetLatestDelegation(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx", latest.Delegator)
+	assert.Empty(t, latest.Baker)
+
+	count, err := sqliteSvc.GetDelegationsCount(context.Background(), datastore.Filter{Year: 2023})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+// TestDatastore_InitMigratesLegacyKey checks a database created when delegations were keyed by timestamp is
+// migrated on init, its delegations getting a provisional id until they are backfilled.
+func TestDatastore_InitMigratesLegacyKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tezos_delegation.db")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+CREATE TABLE delegations (
+	timestamp INTEGER NOT NULL PRIMARY KEY,
+	amount    INTEGER NOT NULL,
+	delegator TEXT    NOT NULL,
+	block     TEXT    NOT NULL
+);
+INSERT INTO delegations VALUES (1702206061000, 124428330, 'tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx', 'block1');
+INSERT INTO delegations VALUES (1702206121000, 2000, 'tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6', 'block2');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	sqliteSvc := sqlite.New(&sqlite.Config{Path: path})
+	require.NoError(t, sqliteSvc.Init())
+
+	t.Cleanup(func() {
+		require.NoError(t, sqliteSvc.Close())
+	})
+
+	legacy, err := sqliteSvc.GetLegacyDelegations(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, legacy, 2)
+	assert.Equal(t, int64(-1702206061000), legacy[0].ID)
+	assert.Equal(t, "block1", legacy[0].Block)
+	assert.Equal(t, int64(-1702206121000), legacy[1].ID)
+
+	count, err := sqliteSvc.GetDelegationsCount(context.Background(), datastore.Filter{Year: 2023})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// the migrated database is reopened as is
+	require.NoError(t, sqliteSvc.Close())
+	require.NoError(t, sqliteSvc.Init())
+}
+
+// TestDatastore_InitFails checks a datastore failing to initialize its database, which is then closed, is
+// initialized again once the database is fixed.
+func TestDatastore_InitFails(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tezos_delegation.db")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+
+	// the delegations counts can't be computed in a counts table without count column
+	_, err = db.Exec(`
+CREATE TABLE delegation_counts (name TEXT NOT NULL PRIMARY KEY);
+CREATE TABLE delegations (
+	id        INTEGER NOT NULL PRIMARY KEY,
+	timestamp INTEGER NOT NULL,
+	amount    INTEGER NOT NULL,
+	delegator TEXT    NOT NULL,
+	block     TEXT    NOT NULL
+);
+INSERT INTO delegations VALUES (1, 1702206061000, 124428330, 'tz1eZsUhWxawxDn5U24LGKiozLapYvAbw2yx', 'block1');`)
+	require.NoError(t, err)
+
+	sqliteSvc := sqlite.New(&sqlite.Config{Path: path})
+	require.Error(t, sqliteSvc.Init())
+
+	_, err = db.Exec(`DROP TABLE delegation_counts`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	require.NoError(t, sqliteSvc.Init())
+
+	t.Cleanup(func() {
+		require.NoError(t, sqliteSvc.Close())
+	})
+
+	count, err := sqliteSvc.GetDelegationsCount(context.Background(), datastore.Filter{Year: 2023})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+// TestDatastore_InitBuildsBakers checks the bakers and the delegators of a database created before they were
+// maintained are computed on init from its delegations.
+func TestDatastore_InitBuildsBakers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tezos_delegation.db")
+
+	sqliteSvc := sqlite.New(&sqlite.Config{Path: path})
+	require.NoError(t, sqliteSvc.Init())
+
+	t.Cleanup(func() {
+		require.NoError(t, sqliteSvc.Close())
+	})
+
+	require.NoError(t, sqliteSvc.StoreDelegations(ctx, []*model.Delegation{
+		{
+			ID:        1,
+			Timestamp: time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC),
+			Amount:    100,
+			Delegator: "tz1delegator",
+			Block:     "block1",
+			Baker:     "tz1baker1",
+		},
+		{
+			ID:        2,
+			Timestamp: time.Date(2023, 12, 11, 11, 1, 1, 0, time.UTC),
+			Amount:    100,
+			Delegator: "tz1delegator",
+			Block:     "block2",
+			Baker:     "tz1baker2",
+		},
+	}))
+	require.NoError(t, sqliteSvc.Close())
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`DELETE FROM bakers; DELETE FROM delegators;`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	require.NoError(t, sqliteSvc.Init())
+
+	delegator, err := sqliteSvc.GetDelegator(ctx, "tz1delegator")
+	require.NoError(t, err)
+	require.NotNil(t, delegator)
+	assert.Equal(t, "tz1baker2", delegator.Baker)
+	assert.Equal(t, int64(2), delegator.DelegationID)
+
+	bakers, err := sqliteSvc.GetBakers(ctx, datastore.BakersSort{Field: datastore.BakersSortAddress}, datastore.Page{})
+	require.NoError(t, err)
+	require.Len(t, bakers, 2)
+	assert.Equal(t, "tz1baker1", bakers[0].Address)
+	assert.Zero(t, bakers[0].Delegators)
+	assert.Equal(t, "tz1baker2", bakers[1].Address)
+	assert.Equal(t, 1, bakers[1].Delegators)
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// bucketDateLayout is the layout of the buckets dates computed by sqlite.
+const bucketDateLayout = "2006-01-02"
+
+// GetDelegationsStats get the stats of the delegations matching the filter, by bucket of interval,
+// in chronological order.
+func (d *Datastore) GetDelegationsStats(
+	ctx context.Context,
+	filter datastore.Filter,
+	interval datastore.Interval,
+) ([]*model.DelegationsStats, error) {
+	conditions, args := delegationsConditions(filter)
+
+	rows, err := d.db.QueryContext(
+		ctx,
+		`SELECT `+bucketDate(interval)+` AS bucket, COUNT(*), SUM(amount), COUNT(DISTINCT delegator), SUM(baker = '')
+FROM delegations`+where(conditions)+`
+GROUP BY bucket
+ORDER BY bucket`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.DelegationsStats
+
+	for rows.Next() {
+		var (
+			bucket string
+			stats  model.DelegationsStats
+		)
+
+		err := rows.Scan(&bucket, &stats.Delegations, &stats.Amount, &stats.Delegators, &stats.Undelegations)
+		if err != nil {
+			return nil, err
+		}
+
+		stats.Bucket, err = time.Parse(bucketDateLayout, bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, &stats)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// bucketDate returns the expression computing the date of the bucket of a delegation.
+func bucketDate(interval datastore.Interval) string {
+	const date = `timestamp / 1000, 'unixepoch'`
+
+	switch interval {
+	case datastore.IntervalWeek:
+		// the monday on or before the date
+		return `date(` + date + `, '-6 days', 'weekday 1')`
+	case datastore.IntervalMonth:
+		return `strftime('%Y-%m-01', ` + date + `)`
+	case datastore.IntervalYear:
+		return `strftime('%Y-01-01', ` + date + `)`
+	default:
+		return `date(` + date + `)`
+	}
+}
@@ -0,0 +1,344 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+const (
+	insertWebhook = `
+INSERT INTO webhooks (id, owner, url, secret, baker, delegator, min_amount, kind, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	selectWebhooks = `
+SELECT id, owner, url, secret, baker, delegator, min_amount, kind, created_at FROM webhooks`
+
+	insertDelivery = `
+INSERT INTO webhook_deliveries (
+	id, webhook_id, sequence, delegation_id, timestamp, amount, delegator, block, baker, previous_baker, level,
+	status, attempts, status_code, error, created_at, next_attempt_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO NOTHING`
+
+	selectDeliveries = `
+SELECT id, webhook_id, sequence, delegation_id, timestamp, amount, delegator, block, baker, previous_baker, level,
+	status, attempts, status_code, error, created_at, next_attempt_at
+FROM webhook_deliveries`
+
+	updateDelivery = `
+UPDATE webhook_deliveries SET status = ?, attempts = ?, status_code = ?, error = ?, next_attempt_at = ?
+WHERE id = ?`
+)
+
+// CreateWebhook store a new webhook.
+func (d *Datastore) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	_, err := d.db.ExecContext(
+		ctx,
+		insertWebhook,
+		webhook.ID,
+		webhook.Owner,
+		webhook.URL,
+		webhook.Secret,
+		webhook.Filter.Baker,
+		webhook.Filter.Delegator,
+		webhook.Filter.MinAmount,
+		webhook.Filter.Kind,
+		webhook.CreatedAt.UnixMilli(),
+	)
+
+	return err
+}
+
+// GetWebhooks get the webhooks of an owner, every webhook when empty, sorted by creation.
+func (d *Datastore) GetWebhooks(ctx context.Context, owner string) ([]*model.Webhook, error) {
+	return queryWebhooks(ctx, d.db, owner)
+}
+
+// GetWebhook get a webhook by id, nil if not found.
+func (d *Datastore) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	result, err := scanWebhook(d.db.QueryRowContext(ctx, selectWebhooks+` WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteWebhook delete a webhook along with its deliveries, an unknown id being ignored.
+func (d *Datastore) DeleteWebhook(ctx context.Context, id string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck // rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetWebhookDeliveries get a page of the deliveries of a webhook, the latest delegations first.
+func (d *Datastore) GetWebhookDeliveries(
+	ctx context.Context,
+	webhookID string,
+	page datastore.Page,
+) ([]*model.WebhookDelivery, error) {
+	// a negative limit means no limit in sqlite
+	limit := -1
+	if page.Size > 0 {
+		limit = page.Size
+	}
+
+	offset := 0
+	if page.Number > 1 {
+		offset = (page.Number - 1) * page.Size
+	}
+
+	return queryDeliveries(
+		ctx,
+		d.db,
+		selectDeliveries+` WHERE webhook_id = ? ORDER BY sequence DESC LIMIT ? OFFSET ?`,
+		webhookID,
+		limit,
+		offset,
+	)
+}
+
+// GetWebhookDelivery get a webhook delivery by id, nil if not found.
+func (d *Datastore) GetWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	result, err := scanDelivery(d.db.QueryRowContext(ctx, selectDeliveries+` WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetDueWebhookDeliveries get the pending deliveries due at now, the earliest due first.
+// A limit of 0 means no limit.
+func (d *Datastore) GetDueWebhookDeliveries(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*model.WebhookDelivery, error) {
+	// a negative limit means no limit in sqlite
+	if limit <= 0 {
+		limit = -1
+	}
+
+	return queryDeliveries(
+		ctx,
+		d.db,
+		selectDeliveries+` WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?`,
+		model.DeliveryPending,
+		now.UnixMilli(),
+		limit,
+	)
+}
+
+// UpdateWebhookDelivery update the status of a stored webhook delivery, an unknown delivery being ignored.
+func (d *Datastore) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	_, err := d.db.ExecContext(
+		ctx,
+		updateDelivery,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.NextAttemptAt.UnixMilli(),
+		delivery.ID,
+	)
+
+	return err
+}
+
+// createDeliveries creates the deliveries of the delegations inserted by changes to the webhooks.
+func createDeliveries(ctx context.Context, tx *sql.Tx, changes []*model.DelegationChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	webhooks, err := queryWebhooks(ctx, tx, "")
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	for _, delivery := range datastore.WebhookDeliveries(webhooks, changes, time.Now()) {
+		_, err := tx.ExecContext(
+			ctx,
+			insertDelivery,
+			delivery.ID,
+			delivery.WebhookID,
+			delivery.Sequence,
+			delivery.Delegation.ID,
+			delivery.Delegation.Timestamp.UnixMilli(),
+			delivery.Delegation.Amount,
+			delivery.Delegation.Delegator,
+			delivery.Delegation.Block,
+			delivery.Delegation.Baker,
+			delivery.Delegation.PreviousBaker,
+			delivery.Delegation.Level,
+			delivery.Status,
+			delivery.Attempts,
+			delivery.StatusCode,
+			delivery.Error,
+			delivery.CreatedAt.UnixMilli(),
+			delivery.NextAttemptAt.UnixMilli(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryWebhooks returns the webhooks of an owner, every webhook when empty, sorted by creation.
+func queryWebhooks(ctx context.Context, q querier, owner string) ([]*model.Webhook, error) {
+	rows, err := q.QueryContext(
+		ctx,
+		selectWebhooks+` WHERE ? = '' OR owner = ? ORDER BY created_at, id`,
+		owner,
+		owner,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.Webhook
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// queryDeliveries returns the deliveries selected by a selectDeliveries query.
+func queryDeliveries(ctx context.Context, q querier, query string, args ...any) ([]*model.WebhookDelivery, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// scanWebhook scans a webhook selected with selectWebhooks.
+func scanWebhook(s scanner) (*model.Webhook, error) {
+	var (
+		webhook   model.Webhook
+		createdAt int64
+	)
+
+	err := s.Scan(
+		&webhook.ID,
+		&webhook.Owner,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.Filter.Baker,
+		&webhook.Filter.Delegator,
+		&webhook.Filter.MinAmount,
+		&webhook.Filter.Kind,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.CreatedAt = time.UnixMilli(createdAt).UTC()
+
+	return &webhook, nil
+}
+
+// scanDelivery scans a delivery selected with selectDeliveries.
+func scanDelivery(s scanner) (*model.WebhookDelivery, error) {
+	var (
+		delivery                          model.WebhookDelivery
+		delegation                        model.Delegation
+		timestamp, createdAt, nextAttempt int64
+	)
+
+	err := s.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Sequence,
+		&delegation.ID,
+		&timestamp,
+		&delegation.Amount,
+		&delegation.Delegator,
+		&delegation.Block,
+		&delegation.Baker,
+		&delegation.PreviousBaker,
+		&delegation.Level,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.StatusCode,
+		&delivery.Error,
+		&createdAt,
+		&nextAttempt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation.Timestamp = time.UnixMilli(timestamp).UTC()
+	delivery.Delegation = &delegation
+	delivery.CreatedAt = time.UnixMilli(createdAt).UTC()
+	delivery.NextAttemptAt = time.UnixMilli(nextAttempt).UTC()
+
+	return &delivery, nil
+}
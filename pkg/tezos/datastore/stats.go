@@ -0,0 +1,50 @@
+package datastore
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidInterval is returned when parsing an unknown stats interval.
+var ErrInvalidInterval = errors.New("invalid interval")
+
+// Interval is the duration of the buckets delegations stats are computed on.
+type Interval string
+
+// Stats intervals, buckets start at the beginning of a UTC day, ISO week (on monday), month or year.
+const (
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+	IntervalYear  Interval = "year"
+)
+
+// ParseInterval parses a stats interval.
+func ParseInterval(value string) (Interval, error) {
+	switch interval := Interval(value); interval {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return interval, nil
+	default:
+		return "", ErrInvalidInterval
+	}
+}
+
+// Truncate returns the start of the bucket of a timestamp.
+func (i Interval) Truncate(timestamp time.Time) time.Time {
+	timestamp = timestamp.UTC()
+	year, month, day := timestamp.Date()
+
+	switch i {
+	case IntervalWeek:
+		// weeks start on monday
+		daysSinceMonday := (int(timestamp.Weekday()) + 6) % 7
+
+		return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case IntervalMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case IntervalYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
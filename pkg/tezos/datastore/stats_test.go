@@ -0,0 +1,48 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+)
+
+func TestParseInterval(t *testing.T) {
+	t.Parallel()
+
+	got, err := datastore.ParseInterval("week")
+	assert.NoError(t, err)
+	assert.Equal(t, datastore.IntervalWeek, got)
+
+	_, err = datastore.ParseInterval("hour")
+	assert.ErrorIs(t, err, datastore.ErrInvalidInterval)
+}
+
+func TestInterval_Truncate(t *testing.T) {
+	t.Parallel()
+
+	// sunday 2023-01-01T00:30:00Z
+	timestamp := time.Date(2023, 1, 1, 2, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+
+	cases := []struct {
+		interval datastore.Interval
+		want     time.Time
+	}{
+		{interval: datastore.IntervalDay, want: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{interval: datastore.IntervalWeek, want: time.Date(2022, 12, 26, 0, 0, 0, 0, time.UTC)},
+		{interval: datastore.IntervalMonth, want: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{interval: datastore.IntervalYear, want: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(string(c.interval), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, c.interval.Truncate(timestamp))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package datastore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// ErrInvalidDelegationKind is returned when parsing an unknown delegation kind.
+var ErrInvalidDelegationKind = errors.New("invalid delegation kind")
+
+// webhookIDSize is the number of random bytes of a webhook id.
+const webhookIDSize = 16
+
+// NewWebhookID returns a new random webhook id.
+func NewWebhookID() (string, error) {
+	id := make([]byte, webhookIDSize)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// ParseDelegationKind parses a delegation kind: delegation, redelegation or undelegation. An empty kind is
+// any kind.
+func ParseDelegationKind(value string) (string, error) {
+	switch value {
+	case "", model.KindDelegation, model.KindRedelegation, model.KindUndelegation:
+		return value, nil
+	default:
+		return "", ErrInvalidDelegationKind
+	}
+}
+
+// DelegationKind returns the kind of a delegation.
+func DelegationKind(delegation *model.Delegation) string {
+	switch {
+	case delegation.Baker == "":
+		return model.KindUndelegation
+	case delegation.PreviousBaker != "":
+		return model.KindRedelegation
+	default:
+		return model.KindDelegation
+	}
+}
+
+// WebhookMatches reports whether a delegation matches a webhook filter.
+func WebhookMatches(filter model.WebhookFilter, delegation *model.Delegation) bool {
+	if filter.Baker != "" && filter.Baker != delegation.Baker && filter.Baker != delegation.PreviousBaker {
+		return false
+	}
+
+	if filter.Delegator != "" && filter.Delegator != delegation.Delegator {
+		return false
+	}
+
+	if delegation.Amount < filter.MinAmount {
+		return false
+	}
+
+	return filter.Kind == "" || filter.Kind == DelegationKind(delegation)
+}
+
+// WebhookDeliveries returns the pending deliveries of the delegations inserted by changes to the webhooks
+// they match, due now. Changes must have their sequence assigned: a delivery is identified by its webhook
+// and the sequence of its change.
+func WebhookDeliveries(
+	webhooks []*model.Webhook,
+	changes []*model.DelegationChange,
+	now time.Time,
+) []*model.WebhookDelivery {
+	var deliveries []*model.WebhookDelivery
+
+	for _, change := range changes {
+		if change.Operation != model.ChangeInsert {
+			continue
+		}
+
+		for _, webhook := range webhooks {
+			if !WebhookMatches(webhook.Filter, change.Delegation) {
+				continue
+			}
+
+			deliveries = append(deliveries, &model.WebhookDelivery{
+				ID:            fmt.Sprintf("%s-%d", webhook.ID, change.Sequence),
+				WebhookID:     webhook.ID,
+				Sequence:      change.Sequence,
+				Delegation:    change.Delegation,
+				Status:        model.DeliveryPending,
+				CreatedAt:     now,
+				NextAttemptAt: now,
+			})
+		}
+	}
+
+	return deliveries
+}
@@ -0,0 +1,132 @@
+package datastore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestNewWebhookID(t *testing.T) {
+	t.Parallel()
+
+	id, err := datastore.NewWebhookID()
+	require.NoError(t, err)
+	assert.Len(t, id, 32)
+
+	other, err := datastore.NewWebhookID()
+	require.NoError(t, err)
+	assert.NotEqual(t, id, other)
+}
+
+func TestParseDelegationKind(t *testing.T) {
+	t.Parallel()
+
+	for _, kind := range []string{"", model.KindDelegation, model.KindRedelegation, model.KindUndelegation} {
+		got, err := datastore.ParseDelegationKind(kind)
+		require.NoError(t, err)
+		assert.Equal(t, kind, got)
+	}
+
+	_, err := datastore.ParseDelegationKind("transfer")
+	assert.ErrorIs(t, err, datastore.ErrInvalidDelegationKind)
+}
+
+func TestWebhookMatches(t *testing.T) {
+	t.Parallel()
+
+	delegation := &model.Delegation{ID: 1, Amount: 100, Delegator: "tz1", Baker: "baker2", PreviousBaker: "baker1"}
+
+	cases := []struct {
+		name   string
+		filter model.WebhookFilter
+		want   bool
+	}{
+		{name: "Empty filter", filter: model.WebhookFilter{}, want: true},
+		{name: "Baker", filter: model.WebhookFilter{Baker: "baker2"}, want: true},
+		{name: "Previous baker", filter: model.WebhookFilter{Baker: "baker1"}, want: true},
+		{name: "Other baker", filter: model.WebhookFilter{Baker: "baker3"}, want: false},
+		{name: "Delegator", filter: model.WebhookFilter{Delegator: "tz1"}, want: true},
+		{name: "Other delegator", filter: model.WebhookFilter{Delegator: "tz2"}, want: false},
+		{name: "Min amount", filter: model.WebhookFilter{MinAmount: 100}, want: true},
+		{name: "Amount too low", filter: model.WebhookFilter{MinAmount: 101}, want: false},
+		{name: "Kind", filter: model.WebhookFilter{Kind: model.KindRedelegation}, want: true},
+		{name: "Other kind", filter: model.WebhookFilter{Kind: model.KindDelegation}, want: false},
+		{
+			name:   "All fields",
+			filter: model.WebhookFilter{Baker: "baker2", Delegator: "tz1", MinAmount: 50, Kind: model.KindRedelegation},
+			want:   true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, datastore.WebhookMatches(c.filter, delegation))
+		})
+	}
+}
+
+func TestDelegationKind(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, model.KindDelegation, datastore.DelegationKind(&model.Delegation{Baker: "baker1"}))
+	assert.Equal(
+		t,
+		model.KindRedelegation,
+		datastore.DelegationKind(&model.Delegation{Baker: "baker2", PreviousBaker: "baker1"}),
+	)
+	assert.Equal(t, model.KindUndelegation, datastore.DelegationKind(&model.Delegation{PreviousBaker: "baker1"}))
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 12, 10, 11, 1, 1, 0, time.UTC)
+	all := &model.Webhook{ID: "all"}
+	big := &model.Webhook{ID: "big", Filter: model.WebhookFilter{MinAmount: 1000}}
+
+	small := &model.Delegation{ID: 1, Amount: 100, Delegator: "tz1", Baker: "baker1"}
+	large := &model.Delegation{ID: 2, Amount: 2000, Delegator: "tz2", Baker: "baker1"}
+
+	changes := []*model.DelegationChange{
+		{Sequence: 1, Operation: model.ChangeInsert, Delegation: small},
+		{Sequence: 2, Operation: model.ChangeInsert, Delegation: large},
+		{Sequence: 3, Operation: model.ChangeUpdate, Delegation: large},
+		{Sequence: 4, Operation: model.ChangeDelete, Delegation: large},
+	}
+
+	got := datastore.WebhookDeliveries([]*model.Webhook{all, big}, changes, now)
+
+	delivery := func(
+		webhook *model.Webhook,
+		id string,
+		sequence int64,
+		delegation *model.Delegation,
+	) *model.WebhookDelivery {
+		return &model.WebhookDelivery{
+			ID:            id,
+			WebhookID:     webhook.ID,
+			Sequence:      sequence,
+			Delegation:    delegation,
+			Status:        model.DeliveryPending,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		}
+	}
+
+	assert.Equal(t, []*model.WebhookDelivery{
+		delivery(all, "all-1", 1, small),
+		delivery(all, "all-2", 2, large),
+		delivery(big, "big-2", 2, large),
+	}, got)
+
+	assert.Empty(t, datastore.WebhookDeliveries(nil, changes, now))
+}
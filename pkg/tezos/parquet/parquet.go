@@ -0,0 +1,232 @@
+// Package parquet exports delegations from any datastore to Parquet files, for the analytics warehouse.
+package parquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	parquetgo "github.com/parquet-go/parquet-go"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Partitions of the exported files.
+const (
+	// PartitionNone exports a single file.
+	PartitionNone = ""
+	// PartitionYear exports a file per year, in year=YYYY directories.
+	PartitionYear = "year"
+	// PartitionMonth exports a file per month, in year=YYYY/month=MM directories.
+	PartitionMonth = "month"
+)
+
+const (
+	// FileName is the name of the exported files.
+	FileName = "delegations.parquet"
+	// ContentType is the media type of a Parquet file.
+	ContentType = "application/vnd.apache.parquet"
+	// rowGroupRows is the number of rows of a row group, buffered in memory before being written.
+	rowGroupRows = 64 << 10
+	// dirPermissions are the permissions of the partitions directories.
+	dirPermissions = 0o755
+)
+
+// ErrInvalidPartition is returned when parsing an unknown partition.
+var ErrInvalidPartition = errors.New("invalid parquet partition")
+
+// ParsePartition parses a partition: empty, year or month.
+func ParsePartition(value string) (string, error) {
+	switch value {
+	case PartitionNone, PartitionYear, PartitionMonth:
+		return value, nil
+	default:
+		return "", ErrInvalidPartition
+	}
+}
+
+// Writer writes delegations to an uncompressed Parquet file. Rows are buffered in memory up to a row group, the
+// file metadata is written by Close.
+type Writer struct {
+	w *parquetgo.GenericWriter[row]
+	// rows is the row being written, reused to avoid an allocation per delegation
+	rows [1]row
+}
+
+// NewWriter creates a Writer writing to w, which doesn't need to be seekable.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: parquetgo.NewGenericWriter[row](w, parquetgo.MaxRowsPerRowGroup(rowGroupRows)),
+	}
+}
+
+// Write writes a delegation.
+func (w *Writer) Write(delegation *model.Delegation) error {
+	w.rows[0] = newRow(delegation)
+	_, err := w.w.Write(w.rows[:])
+
+	return err
+}
+
+// Close writes the buffered rows and the file metadata. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	return w.w.Close()
+}
+
+// Export writes the delegations matching the filter to w, in chronological order, returning the number of
+// exported delegations. The delegations are streamed from the datastore.
+func Export(ctx context.Context, ds datastore.Datastorer, w io.Writer, filter datastore.Filter) (int, error) {
+	pw := NewWriter(w)
+	count := 0
+
+	err := ds.IterateDelegations(
+		ctx,
+		filter,
+		datastore.DelegationsSort{Ascending: true},
+		nil,
+		datastore.Page{},
+		func(delegation *model.Delegation) error {
+			count++
+
+			return pw.Write(delegation)
+		},
+	)
+	if err != nil {
+		return count, err
+	}
+
+	return count, pw.Close()
+}
+
+// ExportFiles writes the delegations matching the filter to Parquet files in dir, partitioned by year or
+// month in Hive style directories (e.g. year=2023/month=01/delegations.parquet), returning the paths of the
+// written files. The delegations are streamed from the datastore in chronological order, so a single file
+// is open at once. No file is written when no delegation matches, unless not partitioned.
+func ExportFiles(
+	ctx context.Context,
+	ds datastore.Datastorer,
+	dir string,
+	filter datastore.Filter,
+	partition string,
+) ([]string, error) {
+	e := &fileExporter{dir: dir, partition: partition}
+
+	if partition == PartitionNone {
+		if err := e.open(filepath.Join(dir, FileName)); err != nil {
+			return nil, err
+		}
+	}
+
+	err := ds.IterateDelegations(
+		ctx,
+		filter,
+		datastore.DelegationsSort{Ascending: true},
+		nil,
+		datastore.Page{},
+		e.write,
+	)
+	if err != nil {
+		e.abort()
+
+		return nil, err
+	}
+
+	if err := e.close(); err != nil {
+		return nil, err
+	}
+
+	return e.paths, nil
+}
+
+// fileExporter writes delegations to the file of their partition.
+type fileExporter struct {
+	dir       string
+	partition string
+	// path is the path of the open file, empty when no file is open
+	path   string
+	file   *os.File
+	writer *Writer
+	paths  []string
+}
+
+// write writes a delegation to the file of its partition, opening it when the partition changes.
+func (e *fileExporter) write(delegation *model.Delegation) error {
+	if path := e.partitionPath(delegation.Timestamp); path != e.path {
+		if err := e.close(); err != nil {
+			return err
+		}
+
+		if err := e.open(path); err != nil {
+			return err
+		}
+	}
+
+	return e.writer.Write(delegation)
+}
+
+// partitionPath returns the path of the file of a timestamp partition.
+func (e *fileExporter) partitionPath(timestamp time.Time) string {
+	timestamp = timestamp.UTC()
+
+	switch e.partition {
+	case PartitionYear:
+		return filepath.Join(e.dir, fmt.Sprintf("year=%d", timestamp.Year()), FileName)
+	case PartitionMonth:
+		return filepath.Join(
+			e.dir,
+			fmt.Sprintf("year=%d", timestamp.Year()),
+			fmt.Sprintf("month=%02d", timestamp.Month()),
+			FileName,
+		)
+	default:
+		return e.path
+	}
+}
+
+// open creates a file and its directory.
+func (e *fileExporter) open(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirPermissions); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	e.path, e.file, e.writer = path, file, NewWriter(file)
+	e.paths = append(e.paths, path)
+
+	return nil
+}
+
+// close closes the open file, if any.
+func (e *fileExporter) close() error {
+	if e.file == nil {
+		return nil
+	}
+
+	file := e.file
+	e.file = nil
+
+	if err := e.writer.Close(); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// abort closes the open file after a failed export.
+func (e *fileExporter) abort() {
+	if e.file != nil {
+		_ = e.file.Close()
+		e.file = nil
+	}
+}
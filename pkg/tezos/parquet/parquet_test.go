@@ -0,0 +1,278 @@
+package parquet_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	parquetgo "github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/parquet"
+)
+
+var delegations = []*model.Delegation{
+	{
+		ID: 1, Timestamp: time.Date(2022, 12, 31, 23, 59, 59, 999000000, time.UTC), Amount: 100,
+		Delegator: "tz1delegator1", Block: "block1", Level: 1, Baker: "tz1bakerA",
+	},
+	{
+		ID: 2, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 200,
+		Delegator: "tz1delegator2", Block: "block2", Level: 2, Baker: "tz1bakerB", PreviousBaker: "tz1bakerA",
+	},
+	{
+		ID: 3, Timestamp: time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC), Amount: 300,
+		Delegator: "tz1delegator1", Block: "block3", Level: 3, PreviousBaker: "tz1bakerA",
+	},
+	{
+		ID: 4, Timestamp: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Amount: 400,
+		Delegator: "tz1delegator3", Block: "block4", Level: 4, Baker: "tz1bakerB",
+	},
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	// more rows than a row group
+	rows := make([]*model.Delegation, 70000)
+	for i := range rows {
+		rows[i] = &model.Delegation{
+			ID:        int64(i),
+			Timestamp: time.UnixMilli(int64(i) * 1000).UTC(),
+			Amount:    int64(i) * 10,
+			Delegator: "tz1delegator",
+			Block:     "block",
+			Level:     int64(i),
+		}
+	}
+
+	rows = append(rows, delegations...)
+
+	var buf bytes.Buffer
+
+	w := parquet.NewWriter(&buf)
+
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+
+	require.NoError(t, w.Close())
+
+	file := readFile(t, buf.Bytes())
+	assert.Equal(t, 2, file.rowGroups)
+	assert.Equal(t, rows, file.delegations)
+}
+
+func TestWriter_Schema(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, parquet.NewWriter(&buf).Close())
+
+	file := readFile(t, buf.Bytes())
+	assert.Empty(t, file.delegations)
+	assert.Zero(t, file.rowGroups)
+
+	fields := file.schema.Fields()
+	names := make([]string, len(fields))
+
+	for i, field := range fields {
+		names[i] = field.Name()
+		assert.True(t, field.Required(), field.Name())
+	}
+
+	assert.Equal(
+		t,
+		[]string{"id", "timestamp", "amount", "delegator", "block", "level", "baker", "previous_baker"},
+		names,
+	)
+
+	// timestamp is a UTC TIMESTAMP_MILLIS logical type
+	timestamp := fields[1].Type()
+	assert.Equal(t, parquetgo.Int64Type.Kind(), timestamp.Kind())
+	require.NotNil(t, timestamp.LogicalType().Timestamp)
+	assert.True(t, timestamp.LogicalType().Timestamp.IsAdjustedToUTC)
+	assert.NotNil(t, timestamp.LogicalType().Timestamp.Unit.Millis)
+
+	// delegator is a STRING logical type
+	delegator := fields[3].Type()
+	assert.Equal(t, parquetgo.ByteArrayType.Kind(), delegator.Kind())
+	assert.NotNil(t, delegator.LogicalType().UTF8)
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	d := seed(t)
+
+	var buf bytes.Buffer
+
+	count, err := parquet.Export(context.Background(), d, &buf, datastore.Filter{Year: 2023})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// in chronological order
+	assert.Equal(t, delegations[1:], readFile(t, buf.Bytes()).delegations)
+}
+
+//nolint:funlen
+func TestExportFiles(t *testing.T) {
+	t.Parallel()
+
+	d := seed(t)
+
+	cases := []struct {
+		name      string
+		filter    datastore.Filter
+		partition string
+		want      map[string][]*model.Delegation
+	}{
+		{
+			name: "Single file",
+			want: map[string][]*model.Delegation{"delegations.parquet": delegations},
+		},
+		{
+			name:   "Single empty file",
+			filter: datastore.Filter{Year: 2020},
+			want:   map[string][]*model.Delegation{"delegations.parquet": nil},
+		},
+		{
+			name:      "Partitioned by year",
+			partition: parquet.PartitionYear,
+			want: map[string][]*model.Delegation{
+				"year=2022/delegations.parquet": delegations[:1],
+				"year=2023/delegations.parquet": delegations[1:],
+			},
+		},
+		{
+			name:      "Partitioned by month within a range",
+			filter:    datastore.Filter{From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			partition: parquet.PartitionMonth,
+			want: map[string][]*model.Delegation{
+				"year=2023/month=01/delegations.parquet": delegations[1:3],
+				"year=2023/month=02/delegations.parquet": delegations[3:],
+			},
+		},
+		{
+			name:      "No partition file",
+			filter:    datastore.Filter{Year: 2020},
+			partition: parquet.PartitionMonth,
+			want:      map[string][]*model.Delegation{},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			paths, err := parquet.ExportFiles(context.Background(), d, dir, c.filter, c.partition)
+			require.NoError(t, err)
+
+			got := map[string][]*model.Delegation{}
+
+			for _, path := range paths {
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+
+				relative, err := filepath.Rel(dir, path)
+				require.NoError(t, err)
+
+				got[filepath.ToSlash(relative)] = readFile(t, data).delegations
+			}
+
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestParsePartition(t *testing.T) {
+	t.Parallel()
+
+	for _, value := range []string{"", "year", "month"} {
+		partition, err := parquet.ParsePartition(value)
+		require.NoError(t, err)
+		assert.Equal(t, value, partition)
+	}
+
+	_, err := parquet.ParsePartition("day")
+	assert.ErrorIs(t, err, parquet.ErrInvalidPartition)
+}
+
+func seed(t *testing.T) datastore.Datastorer {
+	t.Helper()
+
+	d := memory.New()
+	require.NoError(t, d.StoreDelegations(context.Background(), delegations))
+
+	return d
+}
+
+// file is a decoded Parquet file.
+type file struct {
+	schema      *parquetgo.Schema
+	rowGroups   int
+	delegations []*model.Delegation
+}
+
+// delegation is a decoded delegation row.
+type delegation struct {
+	ID            int64     `parquet:"id"`
+	Timestamp     time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	Amount        int64     `parquet:"amount"`
+	Delegator     string    `parquet:"delegator"`
+	Block         string    `parquet:"block"`
+	Level         int64     `parquet:"level"`
+	Baker         string    `parquet:"baker"`
+	PreviousBaker string    `parquet:"previous_baker"`
+}
+
+// readFile decodes a Parquet file.
+func readFile(t *testing.T, data []byte) *file {
+	t.Helper()
+
+	pf, err := parquetgo.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	f := &file{schema: pf.Schema(), rowGroups: len(pf.RowGroups())}
+
+	reader := parquetgo.NewGenericReader[delegation](pf)
+	defer reader.Close()
+
+	rows := make([]delegation, pf.NumRows())
+
+	n, err := reader.Read(rows)
+	if !errors.Is(err, io.EOF) {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, len(rows), n)
+
+	for _, row := range rows {
+		f.delegations = append(f.delegations, &model.Delegation{
+			ID:            row.ID,
+			Timestamp:     row.Timestamp.UTC(),
+			Amount:        row.Amount,
+			Delegator:     row.Delegator,
+			Block:         row.Block,
+			Level:         row.Level,
+			Baker:         row.Baker,
+			PreviousBaker: row.PreviousBaker,
+		})
+	}
+
+	return f
+}
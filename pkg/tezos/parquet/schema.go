@@ -0,0 +1,34 @@
+package parquet
+
+import (
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// row is the Parquet schema of a delegation, matching model.Delegation, all columns being required. Empty bakers
+// are exported as empty strings, as they are stored.
+type row struct {
+	ID            int64     `parquet:"id"`
+	Timestamp     time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	Amount        int64     `parquet:"amount"`
+	Delegator     string    `parquet:"delegator"`
+	Block         string    `parquet:"block"`
+	Level         int64     `parquet:"level"`
+	Baker         string    `parquet:"baker"`
+	PreviousBaker string    `parquet:"previous_baker"`
+}
+
+// newRow returns the row of a delegation.
+func newRow(delegation *model.Delegation) row {
+	return row{
+		ID:            delegation.ID,
+		Timestamp:     delegation.Timestamp,
+		Amount:        delegation.Amount,
+		Delegator:     delegation.Delegator,
+		Block:         delegation.Block,
+		Level:         delegation.Level,
+		Baker:         delegation.Baker,
+		PreviousBaker: delegation.PreviousBaker,
+	}
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned when a webhook host is, or resolves to, a non public address.
+var ErrNonPublicAddress = errors.New("non public address")
+
+// IsPublic reports whether an address is a public unicast address, which webhooks can be called on.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	return !isReserved(addr)
+}
+
+// isReserved reports whether an address is in a non public range which isn't loopback, private, link local nor
+// multicast: this network, shared (carrier-grade NAT), IETF protocol assignments, documentation, benchmarking,
+// future use and the NAT64 translations, which can reach private IPv4 addresses.
+func isReserved(addr netip.Addr) bool {
+	for _, prefix := range []string{
+		"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "192.0.2.0/24", "198.18.0.0/15", "198.51.100.0/24",
+		"203.0.113.0/24", "240.0.0.0/4", "64:ff9b::/96", "64:ff9b:1::/48", "2001:db8::/32",
+	} {
+		if netip.MustParsePrefix(prefix).Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckHost resolves the host of a webhook URL, returning an ErrNonPublicAddress error when any of its
+// addresses isn't public.
+func CheckHost(ctx context.Context, resolver *net.Resolver, host string) error {
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("couldn't resolve host %s: %w", host, err)
+	}
+
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+
+		if !IsPublic(addr) {
+			return fmt.Errorf("host %s resolves to %s: %w", host, addr, ErrNonPublicAddress)
+		}
+	}
+
+	return nil
+}
+
+// publicControl rejects the connections to non public addresses. The address being the resolved one, a host
+// resolving to another address than when the webhook was created is rejected too.
+func publicControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if addr := addrPort.Addr().Unmap(); !IsPublic(addr) {
+		return fmt.Errorf("couldn't connect to %s: %w", addr, ErrNonPublicAddress)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package webhook_test
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/webhook"
+)
+
+func TestIsPublic(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "::ffff:93.184.216.34", want: true},
+		{addr: "0.0.0.0"},
+		{addr: "127.0.0.1"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "100.64.0.1"},
+		{addr: "198.18.0.1"},
+		{addr: "224.0.0.1"},
+		{addr: "255.255.255.255"},
+		{addr: "::"},
+		{addr: "::1"},
+		{addr: "fc00::1"},
+		{addr: "fe80::1"},
+		{addr: "ff02::1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "64:ff9b::a00:1"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.addr, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, webhook.IsPublic(netip.MustParseAddr(c.addr)))
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	require.NoError(t, webhook.CheckHost(ctx, net.DefaultResolver, "93.184.216.34"))
+
+	err := webhook.CheckHost(ctx, net.DefaultResolver, "localhost")
+	require.ErrorIs(t, err, webhook.ErrNonPublicAddress)
+
+	err = webhook.CheckHost(ctx, net.DefaultResolver, "::1")
+	require.ErrorIs(t, err, webhook.ErrNonPublicAddress)
+	assert.EqualError(t, err, "host ::1 resolves to ::1: non public address")
+}
@@ -0,0 +1,226 @@
+// Package webhook delivers the ingested delegations to the webhooks they match.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Delivery request headers.
+const (
+	HeaderWebhookID  = "X-Webhook-Id"
+	HeaderDeliveryID = "X-Webhook-Delivery"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+const (
+	// batchSize is the maximum number of due deliveries read from the datastore at once.
+	batchSize = 100
+	// maxResponseSize is the maximum number of bytes of a response body read before closing it, so the
+	// connection can be reused.
+	maxResponseSize = 64 << 10
+)
+
+// Config describes the webhooks deliveries configuration.
+type Config struct {
+	// Timeout is the timeout of a delivery request.
+	Timeout time.Duration `validate:"required"`
+	// MaxAttempts is the number of attempts before giving up a delivery.
+	MaxAttempts int `validate:"required"`
+	// Backoff is the delay before the second attempt, doubled after each failed attempt up to MaxBackoff.
+	Backoff    time.Duration `validate:"required"`
+	MaxBackoff time.Duration `validate:"required"`
+	// AllowPrivateNetworks allows calling webhooks on non public addresses, for tests and development.
+	AllowPrivateNetworks bool
+}
+
+// Payload is the JSON body of a delivery request.
+type Payload struct {
+	DeliveryID string `json:"deliveryId"`
+	WebhookID  string `json:"webhookId"`
+	// Kind is the delegation kind: delegation, redelegation or undelegation.
+	Kind       string            `json:"kind"`
+	Delegation *model.Delegation `json:"delegation"`
+}
+
+// Dispatcher sends the due webhooks deliveries, recording their status.
+//
+// Each delivery is a POST request of a Payload, signed with the webhook secret (see Sign). A 2xx response
+// acknowledges the delivery, otherwise it is retried with an exponential backoff until MaxAttempts.
+type Dispatcher struct {
+	cfg       *Config
+	datastore datastore.Datastorer
+	client    *http.Client
+}
+
+// NewDispatcher creates a new Dispatcher.
+func NewDispatcher(cfg *Config, datastore datastore.Datastorer) *Dispatcher {
+	return &Dispatcher{
+		cfg:       cfg,
+		datastore: datastore,
+		client:    newClient(cfg),
+	}
+}
+
+// newClient creates the deliveries HTTP client. Unless private networks are allowed, it only connects to public
+// addresses, checked once resolved. Requests aren't proxied, the proxy connecting on its own, and redirects aren't
+// followed, so a webhook can't reach another address than its own.
+func newClient(cfg *Config) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = publicControl
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:       dialer.DialContext,
+			ForceAttemptHTTP2: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Dispatch sends the deliveries due now. Deliveries failing again are due later, so they aren't retried
+// by the same dispatch.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	now := time.Now()
+	webhooks := map[string]*model.Webhook{}
+
+	for {
+		deliveries, err := d.datastore.GetDueWebhookDeliveries(ctx, now, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			webhook, found := webhooks[delivery.WebhookID]
+			if !found {
+				webhook, err = d.datastore.GetWebhook(ctx, delivery.WebhookID)
+				if err != nil {
+					return err
+				}
+
+				webhooks[delivery.WebhookID] = webhook
+			}
+
+			d.deliver(ctx, webhook, delivery)
+
+			if err := d.datastore.UpdateWebhookDelivery(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < batchSize {
+			return nil
+		}
+	}
+}
+
+// deliver attempts a delivery, updating its status.
+func (d *Dispatcher) deliver(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+
+	if webhook == nil {
+		// the webhook was deleted after the delivery was read
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = "webhook deleted"
+
+		return
+	}
+
+	statusCode, err := d.send(ctx, webhook, delivery)
+
+	delivery.StatusCode = statusCode
+
+	if err == nil && statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices {
+		delivery.Status = model.DeliverySucceeded
+		delivery.Error = ""
+
+		return
+	}
+
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		delivery.Error = http.StatusText(statusCode)
+	}
+
+	zap.L().Warn(
+		"webhook delivery failed",
+		zap.String("webhookID", webhook.ID),
+		zap.String("deliveryID", delivery.ID),
+		zap.Int("attempts", delivery.Attempts),
+		zap.Int("statusCode", statusCode),
+		zap.Error(err),
+	)
+
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status = model.DeliveryFailed
+
+		return
+	}
+
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+}
+
+// send sends a delivery request, returning the response status code.
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(Payload{
+		DeliveryID: delivery.ID,
+		WebhookID:  webhook.ID,
+		Kind:       datastore.DelegationKind(delivery.Delegation),
+		Delegation: delivery.Delegation,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, webhook.ID)
+	req.Header.Set(HeaderDeliveryID, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt of a delivery attempted attempts times.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.Backoff
+
+	for i := 1; i < attempts && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.cfg.MaxBackoff)
+}
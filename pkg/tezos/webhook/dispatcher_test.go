@@ -0,0 +1,248 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/webhook"
+)
+
+const secret = "secret"
+
+type underTest struct {
+	datastore  *memory.Datastore
+	dispatcher *webhook.Dispatcher
+	server     *httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+// setupTest creates a dispatcher with a webhook calling a server responding with status, redirecting to
+// /redirected.
+func setupTest(t *testing.T, status int) *underTest {
+	t.Helper()
+
+	return setupTestWithConfig(t, &webhook.Config{
+		Timeout:              time.Second,
+		MaxAttempts:          2,
+		Backoff:              time.Millisecond,
+		MaxBackoff:           time.Hour,
+		AllowPrivateNetworks: true,
+	}, status)
+}
+
+func setupTestWithConfig(t *testing.T, cfg *webhook.Config, status int) *underTest {
+	t.Helper()
+
+	ut := &underTest{datastore: memory.New(), status: status}
+
+	ut.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		ut.mu.Lock()
+		defer ut.mu.Unlock()
+
+		ut.requests = append(ut.requests, r)
+		ut.bodies = append(ut.bodies, body)
+		w.Header().Set("Location", "/redirected")
+		w.WriteHeader(ut.status)
+	}))
+	t.Cleanup(ut.server.Close)
+
+	ut.dispatcher = webhook.NewDispatcher(cfg, ut.datastore)
+
+	require.NoError(t, ut.datastore.CreateWebhook(context.Background(), &model.Webhook{
+		ID:        "webhook1",
+		URL:       ut.server.URL,
+		Secret:    secret,
+		Filter:    model.WebhookFilter{MinAmount: 100},
+		CreatedAt: time.Now(),
+	}))
+
+	return ut
+}
+
+func (ut *underTest) delivery(t *testing.T, id string) *model.WebhookDelivery {
+	t.Helper()
+
+	delivery, err := ut.datastore.GetWebhookDelivery(context.Background(), id)
+	require.NoError(t, err)
+	require.NotNil(t, delivery)
+
+	return delivery
+}
+
+var delegations = []*model.Delegation{
+	{
+		ID: 1, Timestamp: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1delegator", Block: "block1", Baker: "tz1baker",
+	},
+	// filtered out by the minimum amount
+	{
+		ID: 2, Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), Amount: 50,
+		Delegator: "tz1delegator", Block: "block2", PreviousBaker: "tz1baker",
+	},
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, http.StatusNoContent)
+	ctx := context.Background()
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+
+	require.Len(t, ut.requests, 1)
+
+	req, body := ut.requests[0], ut.bodies[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "webhook1", req.Header.Get(webhook.HeaderWebhookID))
+	assert.Equal(t, "webhook1-1", req.Header.Get(webhook.HeaderDeliveryID))
+
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, webhook.Verify(secret, timestamp, body, req.Header.Get(webhook.HeaderSignature)))
+
+	var payload webhook.Payload
+
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, webhook.Payload{
+		DeliveryID: "webhook1-1",
+		WebhookID:  "webhook1",
+		Kind:       model.KindDelegation,
+		Delegation: delegations[0],
+	}, payload)
+
+	delivery := ut.delivery(t, "webhook1-1")
+	assert.Equal(t, model.DeliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.StatusCode)
+	assert.Empty(t, delivery.Error)
+
+	// delivered once
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+	assert.Len(t, ut.requests, 1)
+}
+
+func TestDispatcher_DispatchRetry(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, http.StatusInternalServerError)
+	ctx := context.Background()
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+
+	delivery := ut.delivery(t, "webhook1-1")
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.StatusCode)
+	assert.Equal(t, "Internal Server Error", delivery.Error)
+	assert.True(t, delivery.NextAttemptAt.After(delivery.CreatedAt))
+
+	// the last attempt fails the delivery
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+
+	delivery = ut.delivery(t, "webhook1-1")
+	assert.Equal(t, model.DeliveryFailed, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+	assert.Len(t, ut.requests, 2)
+}
+
+func TestDispatcher_DispatchUnreachable(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, http.StatusOK)
+	ut.server.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+
+	delivery := ut.delivery(t, "webhook1-1")
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Zero(t, delivery.StatusCode)
+	assert.NotEmpty(t, delivery.Error)
+}
+
+func TestDispatcher_DispatchRedirect(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t, http.StatusFound)
+	ctx := context.Background()
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+
+	// the redirect isn't followed
+	require.Len(t, ut.requests, 1)
+	assert.Equal(t, "/", ut.requests[0].URL.Path)
+
+	delivery := ut.delivery(t, "webhook1-1")
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusFound, delivery.StatusCode)
+}
+
+func TestDispatcher_DispatchNonPublic(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTestWithConfig(t, &webhook.Config{
+		Timeout:     time.Second,
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Hour,
+	}, http.StatusOK)
+	ctx := context.Background()
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+	require.NoError(t, ut.dispatcher.Dispatch(ctx))
+
+	// the test server listens on a loopback address
+	assert.Empty(t, ut.requests)
+
+	delivery := ut.delivery(t, "webhook1-1")
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Zero(t, delivery.StatusCode)
+	assert.Contains(t, delivery.Error, "couldn't connect to 127.0.0.1: non public address")
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	t.Parallel()
+
+	dispatcher := webhook.NewDispatcher(&webhook.Config{
+		Timeout:     time.Second,
+		MaxAttempts: 10,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+	}, nil)
+
+	assert.Equal(t, time.Second, dispatcher.Backoff(1))
+	assert.Equal(t, 2*time.Second, dispatcher.Backoff(2))
+	assert.Equal(t, 32*time.Second, dispatcher.Backoff(6))
+	assert.Equal(t, time.Minute, dispatcher.Backoff(7))
+	assert.Equal(t, time.Minute, dispatcher.Backoff(100))
+}
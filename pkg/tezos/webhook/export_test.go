@@ -0,0 +1,8 @@
+package webhook
+
+import "time"
+
+// Backoff returns the delay before the next attempt of a delivery attempted attempts times.
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	return d.backoff(attempts)
+}
@@ -0,0 +1,52 @@
+package webhook_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/webhook"
+)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"deliveryId":"webhook1-1"}`)
+
+	// echo -n '1702206061.{"deliveryId":"webhook1-1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(
+		t,
+		"sha256=0cdcfb0f680af7a5922f663de093fc7971defe682ef09d97e1b32b246b74b302",
+		webhook.Sign("secret", 1702206061, body),
+	)
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"deliveryId":"webhook1-1"}`)
+	signature := webhook.Sign("secret", 1702206061, body)
+
+	cases := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		want      bool
+	}{
+		{name: "Valid", secret: "secret", timestamp: 1702206061, body: body, want: true},
+		{name: "Other secret", secret: "other", timestamp: 1702206061, body: body, want: false},
+		{name: "Other timestamp", secret: "secret", timestamp: 1702206062, body: body, want: false},
+		{name: "Other body", secret: "secret", timestamp: 1702206061, body: []byte(`{}`), want: false},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, webhook.Verify(c.secret, c.timestamp, c.body, signature))
+		})
+	}
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"net"
 	"net/http"
 	"os"
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/config"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/log"
-	mongosvc "github.com/guillaumedebavelaere/tezos-delegation/pkg/mongo"
-	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mongo"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/backend"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/api"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/baker"
 	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegation"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegator"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/gql"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/rpc"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stats"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stream"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/webhook"
+	delegationv1 "github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/proto/delegation/v1"
 )
 
 const appName = "delegation_api"
@@ -22,19 +34,59 @@ func main() {
 	log.SetDefaultZap()
 
 	var cfg struct {
-		Debug     bool
-		Addr      string
-		Datastore struct {
-			Mongo mongosvc.Config
+		Debug       bool
+		Addr        string
+		Datastore   backend.Config
+		Delegations struct {
+			// MaxPageSize is the maximum size of a page of delegations, and of the other paginated lists.
+			MaxPageSize int `validate:"required"`
+		}
+		Params struct {
+			// RejectUnknown rejects the requests with query parameters unsupported by their endpoint.
+			RejectUnknown bool
+		}
+		Stats struct {
+			// CacheTTL is the duration computed stats are cached for, 0 disables the cache.
+			CacheTTL time.Duration
+		}
+		Stream struct {
+			// PollInterval is the interval the change log is polled at for new delegations.
+			PollInterval time.Duration `validate:"required"`
+			// HeartbeatInterval is the interval heartbeats are sent at to the stream clients.
+			HeartbeatInterval time.Duration `validate:"required"`
+			// BufferSize is the number of delegations buffered per client, slower clients are disconnected.
+			BufferSize int `validate:"required"`
+		}
+		GRPC struct {
+			// Addr is the address of the gRPC server, empty disables it.
+			Addr string
+		}
+		Webhooks webhook.Config
+		GraphQL  struct {
+			// MaxDepth is the maximum depth of the fields of a GraphQL query.
+			MaxDepth int `validate:"required"`
+			// MaxCost is the maximum cost of a GraphQL query, the estimated number of objects it resolves.
+			MaxCost int `validate:"required"`
 		}
 	}
 
+	datastoreDriver := flag.String(
+		"datastore",
+		"",
+		"datastore driver overriding the configuration (mongo, sqlite or memory)",
+	)
+	flag.Parse()
+
 	// parse yaml config
 	if err := config.Parse(appName, &cfg); err != nil {
 		zap.L().Error("couldn't parse config", zap.Error(err))
 		os.Exit(1)
 	}
 
+	if *datastoreDriver != "" {
+		cfg.Datastore.Driver = *datastoreDriver
+	}
+
 	if err := config.Validate(cfg); err != nil {
 		zap.L().Error("invalid config", zap.Error(err))
 		os.Exit(1)
@@ -42,8 +94,11 @@ func main() {
 
 	log.Configure(cfg.Debug)
 
-	mongoClient := mongosvc.New(&cfg.Datastore.Mongo)
-	datastore := mongo.New(mongoClient)
+	datastore, err := backend.New(&cfg.Datastore)
+	if err != nil {
+		zap.L().Error("invalid datastore config", zap.Error(err))
+		os.Exit(1)
+	}
 
 	if err := datastore.Init(); err != nil {
 		zap.L().Error(
@@ -54,7 +109,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	defer func(datastore *mongo.Datastore) {
+	defer func(datastore backend.Datastore) {
 		if err := datastore.Close(); err != nil {
 			zap.L().Error(
 				"couldn't close datastore",
@@ -63,23 +118,75 @@ func main() {
 		}
 	}(datastore)
 
-	apiDelegationHandler := delegation.New(datastore)
+	apiDelegationHandler := delegation.New(datastore, cfg.Delegations.MaxPageSize)
+	apiBakerHandler := baker.New(datastore, cfg.Delegations.MaxPageSize)
+	apiDelegatorHandler := delegator.New(datastore)
+	apiStatsHandler := stats.New(datastore, cfg.Stats.CacheTTL)
+	apiWebhookHandler := webhook.New(&cfg.Webhooks, datastore, cfg.Delegations.MaxPageSize)
+	apiGraphQLHandler := gql.New(
+		datastore,
+		cfg.Delegations.MaxPageSize,
+		gql.Limits{MaxDepth: cfg.GraphQL.MaxDepth, MaxCost: cfg.GraphQL.MaxCost},
+	)
+
+	broker := stream.NewBroker(datastore, cfg.Stream.PollInterval, cfg.Stream.BufferSize)
+	if err := broker.Init(context.Background()); err != nil {
+		zap.L().Error("couldn't initialize delegations stream", zap.Error(err))
+		os.Exit(1) //nolint:gocritic // the datastore is closed by the process exit
+	}
+
+	go broker.Run(context.Background())
+
+	apiStreamHandler := stream.New(datastore, broker, cfg.Stream.HeartbeatInterval)
+
+	if cfg.GRPC.Addr != "" {
+		listener, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			zap.L().Error("couldn't listen for grpc server", zap.String("addr", cfg.GRPC.Addr), zap.Error(err))
+			os.Exit(1)
+		}
+
+		grpcServer := grpc.NewServer()
+		delegationv1.RegisterDelegationServiceServer(
+			grpcServer,
+			rpc.New(datastore, apiStreamHandler, cfg.Delegations.MaxPageSize),
+		)
+
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				zap.L().Error("grpc server stopped", zap.Error(err))
+			}
+		}()
+
+		zap.L().Info("grpc server started and listening", zap.String("addr", cfg.GRPC.Addr))
+	}
 
-	http.HandleFunc("/xtz/delegations", apiDelegationHandler.GetDelegationsHandler)
+	apiRouter := api.NewRouter(
+		api.Handlers{
+			Delegation: apiDelegationHandler,
+			Baker:      apiBakerHandler,
+			Delegator:  apiDelegatorHandler,
+			Stats:      apiStatsHandler,
+			Webhook:    apiWebhookHandler,
+			Stream:     apiStreamHandler,
+			GraphQL:    apiGraphQLHandler,
+		},
+		cfg.Params.RejectUnknown,
+	)
 
 	zap.L().Info("server started and listening", zap.String("addr", cfg.Addr))
 
 	// Create a new HTTP server with custom timeouts
 	server := &http.Server{
 		Addr:         cfg.Addr,
-		Handler:      nil,
+		Handler:      apiRouter,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
 	// Start the server
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if errors.Is(err, http.ErrServerClosed) {
 		zap.L().Error("server closed")
 	} else if err != nil {
@@ -0,0 +1,161 @@
+// Package api routes the API requests to their handlers.
+package api
+
+import (
+	"net/http"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/baker"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegation"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegator"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/gql"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/openapi"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/router"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stats"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stream"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/webhook"
+)
+
+// Handlers are the handlers of the API endpoints.
+type Handlers struct {
+	Delegation *delegation.APIHandler
+	Baker      *baker.APIHandler
+	Delegator  *delegator.APIHandler
+	Stats      *stats.APIHandler
+	Webhook    *webhook.APIHandler
+	Stream     *stream.APIHandler
+	GraphQL    *gql.APIHandler
+}
+
+// Route is an API route.
+type Route struct {
+	Methods []string
+	// Pattern is the route path, a pattern ending with a / matching its whole subtree.
+	Pattern string
+	Handler http.HandlerFunc
+	// Params are the query parameters of the route.
+	Params []string
+}
+
+// Routes returns the API routes: the v1 and legacy routes, the GraphQL route and the documentation routes.
+//
+//nolint:funlen
+func Routes(handlers Handlers) []Route {
+	bakersSubpathHandler := http.StripPrefix("/xtz/bakers/", http.HandlerFunc(handlers.Baker.SubpathHandler))
+	delegatorsSubpathHandler := http.StripPrefix(
+		"/xtz/delegators/",
+		http.HandlerFunc(handlers.Delegator.SubpathHandler),
+	)
+	webhooksSubpathHandler := http.StripPrefix("/xtz/webhooks/", http.HandlerFunc(handlers.Webhook.SubpathHandler))
+	bakersSubpathV1Handler := http.StripPrefix("/v1/bakers/", http.HandlerFunc(handlers.Baker.SubpathV1Handler))
+	delegatorsSubpathV1Handler := http.StripPrefix(
+		"/v1/delegators/",
+		http.HandlerFunc(handlers.Delegator.SubpathV1Handler),
+	)
+	webhooksSubpathV1Handler := http.StripPrefix(
+		"/v1/webhooks/",
+		http.HandlerFunc(handlers.Webhook.SubpathV1Handler),
+	)
+
+	return []Route{
+		// v1 routes, the responses being returned in a JSON envelope with their data, and the pagination and
+		// links of the lists
+		{
+			[]string{http.MethodGet}, "/v1/delegations",
+			handlers.Delegation.GetDelegationsV1Handler, delegation.ListParams,
+		},
+		{
+			[]string{http.MethodGet}, "/v1/delegations/changes",
+			handlers.Delegation.GetDelegationsChangesHandler, delegation.ChangesParams,
+		},
+		{
+			[]string{http.MethodGet}, "/v1/delegations/export",
+			handlers.Delegation.GetDelegationsExportHandler, delegation.ExportParams,
+		},
+		{
+			[]string{http.MethodGet}, "/v1/delegations/stream",
+			handlers.Stream.GetDelegationsStreamHandler, stream.Params,
+		},
+		{[]string{http.MethodGet}, "/v1/bakers", handlers.Baker.GetBakersV1Handler, baker.ListParams},
+		{[]string{http.MethodGet}, "/v1/bakers/", bakersSubpathV1Handler.ServeHTTP, baker.SubpathParams},
+		{[]string{http.MethodGet}, "/v1/delegators/", delegatorsSubpathV1Handler.ServeHTTP, nil},
+		{
+			[]string{http.MethodGet}, "/v1/stats/delegations",
+			handlers.Stats.GetDelegationsStatsV1Handler, stats.Params,
+		},
+		{[]string{http.MethodGet, http.MethodPost}, "/v1/webhooks", handlers.Webhook.WebhooksV1Handler, nil},
+		// the webhooks sub paths check their methods
+		{
+			[]string{http.MethodGet, http.MethodPost, http.MethodDelete}, "/v1/webhooks/",
+			webhooksSubpathV1Handler.ServeHTTP, webhook.SubpathParams,
+		},
+		// legacy routes
+		{
+			[]string{http.MethodGet}, "/xtz/delegations",
+			handlers.Delegation.GetDelegationsHandler, delegation.ListParams,
+		},
+		{
+			[]string{http.MethodGet}, "/xtz/delegations/changes",
+			handlers.Delegation.GetDelegationsChangesHandler, delegation.ChangesParams,
+		},
+		{
+			[]string{http.MethodGet}, "/xtz/delegations/export",
+			handlers.Delegation.GetDelegationsExportHandler, delegation.ExportParams,
+		},
+		{
+			[]string{http.MethodGet}, "/xtz/delegations/stream",
+			handlers.Stream.GetDelegationsStreamHandler, stream.Params,
+		},
+		{[]string{http.MethodGet}, "/xtz/bakers", handlers.Baker.GetBakersHandler, baker.ListParams},
+		{[]string{http.MethodGet}, "/xtz/bakers/", bakersSubpathHandler.ServeHTTP, baker.SubpathParams},
+		{[]string{http.MethodGet}, "/xtz/delegators/", delegatorsSubpathHandler.ServeHTTP, nil},
+		{[]string{http.MethodGet}, "/xtz/stats/delegations", handlers.Stats.GetDelegationsStatsHandler, stats.Params},
+		{[]string{http.MethodGet, http.MethodPost}, "/xtz/webhooks", handlers.Webhook.WebhooksHandler, nil},
+		// the webhooks sub paths check their methods
+		{
+			[]string{http.MethodGet, http.MethodPost, http.MethodDelete}, "/xtz/webhooks/",
+			webhooksSubpathHandler.ServeHTTP, webhook.SubpathParams,
+		},
+		// the GraphQL queries are read from the query parameters of GET requests, and from the POST bodies
+		{[]string{http.MethodGet}, "/graphql", handlers.GraphQL.GraphQLHandler, gql.Params},
+		{[]string{http.MethodPost}, "/graphql", handlers.GraphQL.GraphQLHandler, nil},
+		// documentation routes
+		{[]string{http.MethodGet}, "/openapi.json", openapi.SpecHandler, nil},
+		{[]string{http.MethodGet}, "/docs", openapi.DocsHandler, nil},
+		{[]string{http.MethodGet}, "/docs/", openapi.AssetsHandler, nil},
+	}
+}
+
+// NewRouter returns the router of the API routes. When rejectUnknown is true, the requests with query
+// parameters unsupported by their route are rejected with an unknown parameter problem.
+func NewRouter(handlers Handlers, rejectUnknown bool) *router.Router {
+	apiRouter := router.New()
+
+	for _, route := range Routes(handlers) {
+		var handler http.Handler = route.Handler
+		if rejectUnknown {
+			handler = rejectUnknownParams(route.Handler, route.Params)
+		}
+
+		for _, method := range route.Methods {
+			apiRouter.Handle(method, route.Pattern, handler)
+		}
+	}
+
+	return apiRouter
+}
+
+// rejectUnknownParams wraps a handler, answering the requests with query parameters other than the allowed
+// ones with an unknown parameter problem.
+func rejectUnknownParams(handler http.Handler, allowed []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := param.Check(r.URL.Query(), allowed...); err != nil {
+			problem.BadRequest(w, err)
+
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
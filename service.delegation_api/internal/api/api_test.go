@@ -0,0 +1,363 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/api"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/baker"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegation"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegator"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/gql"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/openapi/openapitest"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stats"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stream"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/webhook"
+)
+
+const (
+	bakerAddress     = "tz1aRoaRhSpRYvFdyvgWLL6TGyRoGF51wDjM"
+	delegatorAddress = "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6"
+	webhookID        = "webhook-1"
+	// apiKey authenticates the owner of the webhook.
+	apiKey = "key"
+)
+
+// setupTest returns the API handlers over a memory datastore storing a webhook, then 2 delegations to a baker
+// and the deliveries of the first one, along with the id of that delivery.
+func setupTest(t *testing.T) (api.Handlers, string) {
+	t.Helper()
+
+	ds := memory.New()
+	ctx := context.Background()
+
+	require.NoError(t, ds.CreateWebhook(ctx, &model.Webhook{
+		ID:        webhookID,
+		Owner:     "owner",
+		URL:       "http://localhost/hook",
+		Secret:    "secret",
+		Filter:    model.WebhookFilter{Baker: bakerAddress, MinAmount: 100000},
+		CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	require.NoError(t, ds.StoreDelegations(ctx, []*model.Delegation{
+		{
+			ID:        1,
+			Timestamp: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			Amount:    157800,
+			Delegator: delegatorAddress,
+			Block:     "BLockA",
+			Level:     4000000,
+			Baker:     bakerAddress,
+		},
+		{
+			ID:            2,
+			Timestamp:     time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC),
+			Amount:        57800,
+			Delegator:     "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
+			Block:         "BLockB",
+			Level:         4000001,
+			Baker:         bakerAddress,
+			PreviousBaker: "tz1previousBaker",
+		},
+	}))
+
+	deliveries, err := ds.GetWebhookDeliveries(ctx, webhookID, datastore.Page{Number: 1, Size: 1})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	broker := stream.NewBroker(ds, time.Hour, 16)
+	require.NoError(t, broker.Init(ctx))
+
+	return api.Handlers{
+		Delegation: delegation.New(ds, 1000),
+		Baker:      baker.New(ds, 1000),
+		Delegator:  delegator.New(ds),
+		Stats:      stats.New(ds, 0),
+		Webhook: webhook.New(
+			&webhook.Config{APIKeys: []webhook.APIKey{{Owner: "owner", Key: apiKey}}},
+			ds,
+			1000,
+		),
+		Stream:  stream.New(ds, broker, time.Hour),
+		GraphQL: gql.New(ds, 1000, gql.Limits{MaxDepth: 10, MaxCost: 10000}),
+	}, deliveries[0].ID
+}
+
+// TestAPI_OpenAPI serves requests of every endpoint, failing when the handlers drift from the OpenAPI
+// specification.
+//
+//nolint:funlen
+func TestAPI_OpenAPI(t *testing.T) {
+	t.Parallel()
+
+	handlers, deliveryID := setupTest(t)
+	validator := openapitest.New(t)
+	apiRouter := validator.Middleware(api.NewRouter(handlers, true))
+
+	cases := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		accept         string
+		apiKey         string
+		wantStatusCode int
+	}{
+		{name: "Delegations", url: "/v1/delegations", wantStatusCode: http.StatusOK},
+		{
+			name: "Delegations filtered, sorted and selected",
+			url: "/v1/delegations?year=2023&amount.gt=1&kind=delegation&sort=-amount&select=id,amount&size=1" +
+				"&page=1",
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "Delegations cursor", url: "/v1/delegations?cursor=&size=1", wantStatusCode: http.StatusOK},
+		{name: "Delegations invalid size", url: "/v1/delegations?size=0", wantStatusCode: http.StatusBadRequest},
+		{name: "Delegations unknown parameter", url: "/v1/delegations?limit=1", wantStatusCode: http.StatusBadRequest},
+		{name: "Delegations unknown operator", url: "/v1/delegations?year.gt=1", wantStatusCode: http.StatusBadRequest},
+		{name: "Delegations operator field", url: "/v1/delegations?amount=1", wantStatusCode: http.StatusBadRequest},
+		{name: "Delegations method not allowed", method: http.MethodPost, url: "/v1/delegations", wantStatusCode: 405},
+		{name: "Delegations changes", url: "/v1/delegations/changes?size=1", wantStatusCode: http.StatusOK},
+		{name: "Delegations changes invalid", url: "/v1/delegations/changes?since=!", wantStatusCode: 400},
+		{name: "Delegations export CSV", url: "/v1/delegations/export?year=2023", wantStatusCode: http.StatusOK},
+		{name: "Delegations export NDJSON", url: "/v1/delegations/export?format=ndjson", wantStatusCode: 200},
+		{
+			name:           "Delegations export Parquet",
+			url:            "/v1/delegations/export",
+			accept:         "application/vnd.apache.parquet",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Delegations export not acceptable",
+			url:            "/v1/delegations/export",
+			accept:         "image/png",
+			wantStatusCode: http.StatusNotAcceptable,
+		},
+		{name: "Delegations stream invalid", url: "/v1/delegations/stream?year=2000", wantStatusCode: 400},
+		{name: "Bakers", url: "/v1/bakers?sort=-delegatedAmount&size=1", wantStatusCode: http.StatusOK},
+		{name: "Bakers invalid sort", url: "/v1/bakers?sort=name", wantStatusCode: http.StatusBadRequest},
+		{name: "Baker", url: "/v1/bakers/" + bakerAddress, wantStatusCode: http.StatusOK},
+		{name: "Baker not found", url: "/v1/bakers/tz1unknown", wantStatusCode: http.StatusNotFound},
+		{
+			name:           "Baker delegators",
+			url:            "/v1/bakers/" + bakerAddress + "/delegators?as_of=4000000&size=1",
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "Delegator", url: "/v1/delegators/" + delegatorAddress, wantStatusCode: http.StatusOK},
+		{
+			name:           "Delegator delegations",
+			url:            "/v1/delegators/" + delegatorAddress + "/delegations",
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "Delegator not found", url: "/v1/delegators/tz1unknown", wantStatusCode: http.StatusNotFound},
+		{name: "Stats", url: "/v1/stats/delegations?interval=month", wantStatusCode: http.StatusOK},
+		{name: "Stats invalid interval", url: "/v1/stats/delegations?interval=hour", wantStatusCode: 400},
+		{name: "Webhooks", url: "/v1/webhooks", wantStatusCode: http.StatusOK},
+		{
+			name:           "Create webhook",
+			method:         http.MethodPost,
+			url:            "/v1/webhooks",
+			body:           `{"url":"https://93.184.216.34/hook","filter":{"kind":"undelegation"}}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Create webhook invalid",
+			method:         http.MethodPost,
+			url:            "/v1/webhooks",
+			body:           `{"url":"ftp://localhost/hook"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{name: "Webhook", url: "/v1/webhooks/" + webhookID, wantStatusCode: http.StatusOK},
+		{name: "Webhook deliveries", url: "/v1/webhooks/" + webhookID + "/deliveries", wantStatusCode: 200},
+		{
+			name:           "Replay webhook delivery",
+			method:         http.MethodPost,
+			url:            "/v1/webhooks/" + webhookID + "/deliveries/" + deliveryID + "/replay",
+			wantStatusCode: http.StatusAccepted,
+		},
+		{name: "Webhook not found", url: "/v1/webhooks/unknown", wantStatusCode: http.StatusNotFound},
+		{name: "Webhooks unauthorized", url: "/v1/webhooks", apiKey: "unknown", wantStatusCode: 401},
+		{name: "Legacy delegations", url: "/xtz/delegations?size=1", wantStatusCode: http.StatusOK},
+		{name: "Legacy delegations cursor", url: "/xtz/delegations?cursor=&size=1", wantStatusCode: http.StatusOK},
+		{name: "Legacy delegations changes", url: "/xtz/delegations/changes", wantStatusCode: http.StatusOK},
+		{name: "Legacy bakers", url: "/xtz/bakers?sort=-delegatedAmount&size=1", wantStatusCode: http.StatusOK},
+		{name: "Legacy bakers invalid sort", url: "/xtz/bakers?sort=name", wantStatusCode: http.StatusBadRequest},
+		{name: "Legacy baker", url: "/xtz/bakers/" + bakerAddress, wantStatusCode: http.StatusOK},
+		{name: "Legacy baker not found", url: "/xtz/bakers/tz1unknown", wantStatusCode: http.StatusNotFound},
+		{
+			name:           "Legacy baker delegators",
+			url:            "/xtz/bakers/" + bakerAddress + "/delegators?as_of=4000000&size=1",
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "Legacy delegator", url: "/xtz/delegators/" + delegatorAddress, wantStatusCode: http.StatusOK},
+		{
+			name:           "Legacy delegator delegations",
+			url:            "/xtz/delegators/" + delegatorAddress + "/delegations",
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "Legacy delegator not found", url: "/xtz/delegators/tz1unknown", wantStatusCode: http.StatusNotFound},
+		{name: "Legacy stats", url: "/xtz/stats/delegations?interval=month", wantStatusCode: http.StatusOK},
+		{name: "Legacy stats invalid interval", url: "/xtz/stats/delegations?interval=hour", wantStatusCode: 400},
+		{name: "Legacy webhooks", url: "/xtz/webhooks", wantStatusCode: http.StatusOK},
+		{
+			name:           "Legacy create webhook",
+			method:         http.MethodPost,
+			url:            "/xtz/webhooks",
+			body:           `{"url":"https://93.184.216.34/hook","filter":{"kind":"undelegation"}}`,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Legacy create webhook invalid",
+			method:         http.MethodPost,
+			url:            "/xtz/webhooks",
+			body:           `{"url":"ftp://localhost/hook"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{name: "Legacy webhook", url: "/xtz/webhooks/" + webhookID, wantStatusCode: http.StatusOK},
+		{name: "Legacy webhook deliveries", url: "/xtz/webhooks/" + webhookID + "/deliveries", wantStatusCode: 200},
+		{
+			name:           "Legacy replay webhook delivery",
+			method:         http.MethodPost,
+			url:            "/xtz/webhooks/" + webhookID + "/deliveries/" + deliveryID + "/replay",
+			wantStatusCode: http.StatusAccepted,
+		},
+		{name: "Legacy webhook not found", url: "/xtz/webhooks/unknown", wantStatusCode: http.StatusNotFound},
+		{name: "Legacy webhooks unauthorized", url: "/xtz/webhooks", apiKey: "unknown", wantStatusCode: 401},
+		{
+			name:           "GraphQL GET",
+			url:            "/graphql?query=" + url.QueryEscape("{ delegations(first: 1) { nodes { id } } }"),
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:   "GraphQL POST",
+			method: http.MethodPost,
+			url:    "/graphql",
+			body: `{"query":"query ($address: String!) { baker(address: $address) { address } }",` +
+				`"variables":{"address":"` + bakerAddress + `"}}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "GraphQL missing query", url: "/graphql", wantStatusCode: http.StatusBadRequest},
+		{name: "OpenAPI specification", url: "/openapi.json", wantStatusCode: http.StatusOK},
+		{name: "Documentation", url: "/docs", wantStatusCode: http.StatusOK},
+		{name: "Documentation asset", url: "/docs/swagger-ui-bundle.js", wantStatusCode: http.StatusOK},
+		{name: "Documentation asset not found", url: "/docs/index.html", wantStatusCode: http.StatusNotFound},
+		{name: "Not found", url: "/v2/delegations", wantStatusCode: http.StatusNotFound},
+	}
+
+	// the cases share the datastore, so they run in order
+	for _, c := range cases {
+		method := c.method
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		req, err := http.NewRequestWithContext(context.Background(), method, c.url, strings.NewReader(c.body))
+		require.NoError(t, err, "Error creating request")
+
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+
+		key := c.apiKey
+		if key == "" {
+			key = apiKey
+		}
+
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		responseRecorder := httptest.NewRecorder()
+		apiRouter.ServeHTTP(responseRecorder, req)
+
+		assert.Equal(t, c.wantStatusCode, responseRecorder.Code, c.name)
+	}
+
+	// deleted last, as the other cases use the webhook
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, "/xtz/webhooks/"+webhookID, nil)
+	require.NoError(t, err, "Error creating request")
+
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	responseRecorder := httptest.NewRecorder()
+	apiRouter.ServeHTTP(responseRecorder, req)
+
+	assert.Equal(t, http.StatusNoContent, responseRecorder.Code)
+}
+
+// TestAPI_Routes checks the routes and their query parameters are documented by the OpenAPI specification.
+func TestAPI_Routes(t *testing.T) {
+	t.Parallel()
+
+	handlers, _ := setupTest(t)
+	validator := openapitest.New(t)
+
+	// subtree routes are checked with a path of each of their operations
+	subtreePaths := map[string][]string{
+		"/v1/bakers/":      {"/v1/bakers/tz1", "/v1/bakers/tz1/delegators"},
+		"/v1/delegators/":  {"/v1/delegators/tz1", "/v1/delegators/tz1/delegations"},
+		"/xtz/bakers/":     {"/xtz/bakers/tz1", "/xtz/bakers/tz1/delegators"},
+		"/xtz/delegators/": {"/xtz/delegators/tz1", "/xtz/delegators/tz1/delegations"},
+		"/docs/":           {"/docs/swagger-ui.css"},
+		"/v1/webhooks/": {
+			"/v1/webhooks/id", "/v1/webhooks/id/deliveries", "/v1/webhooks/id/deliveries/id/replay",
+		},
+		"/xtz/webhooks/": {
+			"/xtz/webhooks/id", "/xtz/webhooks/id/deliveries", "/xtz/webhooks/id/deliveries/id/replay",
+		},
+	}
+
+	for _, route := range api.Routes(handlers) {
+		paths, subtree := subtreePaths[route.Pattern]
+		if !subtree {
+			paths = []string{route.Pattern}
+		}
+
+		documented := false
+
+		for _, path := range paths {
+			for _, method := range route.Methods {
+				params, found := validator.QueryParams(method, path)
+				if !found {
+					continue
+				}
+
+				documented = true
+
+				// the documented parameters are accepted by the route
+				for _, name := range params {
+					assert.NoError(t, param.Check(url.Values{name: nil}, route.Params...), "%s %s", method, path)
+				}
+
+				if subtree {
+					// the route parameters are those of all its operations
+					continue
+				}
+
+				for _, name := range route.Params {
+					assert.True(
+						t,
+						slices.Contains(params, name),
+						"%s %s: query parameter %s isn't documented", method, path, name,
+					)
+				}
+			}
+		}
+
+		assert.True(t, documented, "route %s isn't documented", route.Pattern)
+	}
+}
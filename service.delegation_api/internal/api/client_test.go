@@ -0,0 +1,280 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/client"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/http"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/api"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/openapi/openapitest"
+)
+
+// setupClient returns a client of an API server over the setupTest datastore, the requests and responses
+// being validated against the OpenAPI specification, along with the id of the webhook delivery.
+func setupClient(t *testing.T) (client.API, string) {
+	t.Helper()
+
+	handlers, deliveryID := setupTest(t)
+
+	server := httptest.NewServer(openapitest.New(t).Middleware(api.NewRouter(handlers, true)))
+	t.Cleanup(server.Close)
+
+	apiClient := client.NewClient(&client.Config{
+		HTTP: http.ClientConfig{
+			BaseURL: server.URL,
+			Timeout: 5 * time.Second,
+		},
+		APIKey: apiKey,
+	})
+	apiClient.Init()
+
+	return apiClient, deliveryID
+}
+
+func TestClient_ListDelegations(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+
+	amount := func(amount int64) *int64 {
+		return &amount
+	}
+
+	cases := []struct {
+		name    string
+		query   *client.DelegationsQuery
+		wantIDs []int64
+		wantErr error
+	}{
+		{name: "Success", query: &client.DelegationsQuery{}, wantIDs: []int64{2, 1}},
+		{
+			name: "Filtered and sorted",
+			query: &client.DelegationsQuery{
+				DelegationsFilter: client.DelegationsFilter{Year: 2023, Baker: bakerAddress, AmountGt: amount(100000)},
+				Sort:              "amount",
+			},
+			wantIDs: []int64{1},
+		},
+		{name: "Page", query: &client.DelegationsQuery{Page: 2, Size: 1}, wantIDs: []int64{1}},
+		{name: "Cursor", query: &client.DelegationsQuery{Cursor: "unknown"}, wantErr: client.ErrInvalidParameter},
+		{name: "Invalid size", query: &client.DelegationsQuery{Size: -1}, wantErr: client.ErrInvalidParameter},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := apiClient.ListDelegations(context.Background(), c.query)
+			if c.wantErr != nil {
+				assert.ErrorIs(t, err, c.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			ids := make([]int64, 0, len(page.Data))
+			for _, d := range page.Data {
+				ids = append(ids, d.ID)
+			}
+
+			assert.Equal(t, c.wantIDs, ids)
+		})
+	}
+}
+
+func TestClient_Delegations(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		it := apiClient.Delegations(&client.DelegationsQuery{Size: 1})
+
+		var ids []int64
+		for it.Next(context.Background()) {
+			ids = append(ids, it.Delegation().ID)
+		}
+
+		require.NoError(t, it.Err())
+		assert.Equal(t, []int64{2, 1}, ids)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		it := apiClient.Delegations(&client.DelegationsQuery{Sort: "amount"})
+
+		assert.False(t, it.Next(context.Background()))
+		assert.ErrorIs(t, it.Err(), client.ErrInvalidParameter)
+		assert.Nil(t, it.Delegation())
+	})
+}
+
+func TestClient_ListDelegationsChanges(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+
+	changes, err := apiClient.ListDelegationsChanges(context.Background(), "", 1)
+	require.NoError(t, err)
+	require.Len(t, changes.Changes, 1)
+	assert.Equal(t, int64(1), changes.Changes[0].Delegation.ID)
+
+	changes, err = apiClient.ListDelegationsChanges(context.Background(), changes.Next, 0)
+	require.NoError(t, err)
+	require.Len(t, changes.Changes, 1)
+	assert.Equal(t, int64(2), changes.Changes[0].Delegation.ID)
+
+	_, err = apiClient.ListDelegationsChanges(context.Background(), "!", 0)
+	assert.ErrorIs(t, err, client.ErrInvalidParameter)
+}
+
+func TestClient_ExportDelegations(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+
+	export, err := apiClient.ExportDelegations(
+		context.Background(),
+		&client.DelegationsQuery{Select: []string{"id", "amount"}, Page: 2},
+		client.ExportCSV,
+	)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(export)
+	require.NoError(t, err)
+	require.NoError(t, export.Close())
+	assert.Equal(t, "id,amount\n2,57800\n1,157800\n", string(body))
+
+	_, err = apiClient.ExportDelegations(context.Background(), &client.DelegationsQuery{}, "xml")
+	assert.ErrorIs(t, err, client.ErrInvalidParameter)
+}
+
+func TestClient_Bakers(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+	ctx := context.Background()
+
+	bakers, err := apiClient.ListBakers(ctx, "-delegatedAmount", client.Page{Number: 1, Size: 1})
+	require.NoError(t, err)
+	require.Len(t, bakers.Bakers, 1)
+	assert.Equal(t, bakerAddress, bakers.Bakers[0].Address)
+	assert.Equal(t, 2, bakers.TotalPages)
+
+	baker, err := apiClient.GetBaker(ctx, bakerAddress)
+	require.NoError(t, err)
+	assert.Equal(t, bakers.Bakers[0], baker)
+
+	_, err = apiClient.GetBaker(ctx, "tz1unknown")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	delegators, err := apiClient.ListBakerDelegators(ctx, bakerAddress, "2023-06-01T12:00:00Z", client.Page{})
+	require.NoError(t, err)
+	require.Len(t, delegators, 1)
+	assert.Equal(t, delegatorAddress, delegators[0].Address)
+
+	_, err = apiClient.ListBakers(ctx, "name", client.Page{})
+
+	var apiErr *client.Error
+
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, "sort", apiErr.Param)
+}
+
+func TestClient_Delegators(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+	ctx := context.Background()
+
+	delegator, err := apiClient.GetDelegator(ctx, delegatorAddress)
+	require.NoError(t, err)
+	assert.Equal(t, bakerAddress, delegator.Baker)
+	assert.Equal(t, int64(157800), delegator.Amount)
+
+	delegations, err := apiClient.ListDelegatorDelegations(ctx, delegatorAddress)
+	require.NoError(t, err)
+	require.Len(t, delegations, 1)
+	assert.Equal(t, int64(1), delegations[0].ID)
+	assert.Nil(t, delegations[0].Until)
+
+	_, err = apiClient.GetDelegator(ctx, "tz1unknown")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestClient_GetDelegationsStats(t *testing.T) {
+	t.Parallel()
+
+	apiClient, _ := setupClient(t)
+
+	stats, err := apiClient.GetDelegationsStats(context.Background(), &client.StatsQuery{
+		Interval: "month",
+		From:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*model.DelegationsStats{
+		{
+			Bucket:      time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			Delegations: 2,
+			Amount:      215600,
+			Delegators:  2,
+		},
+	}, stats)
+
+	_, err = apiClient.GetDelegationsStats(context.Background(), &client.StatsQuery{Interval: "hour"})
+	assert.ErrorIs(t, err, client.ErrInvalidParameter)
+}
+
+func TestClient_Webhooks(t *testing.T) {
+	t.Parallel()
+
+	apiClient, deliveryID := setupClient(t)
+	ctx := context.Background()
+
+	created, err := apiClient.CreateWebhook(ctx, &client.CreateWebhookRequest{
+		URL:    "https://93.184.216.34/hook",
+		Filter: model.WebhookFilter{Kind: "undelegation"},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.NotEmpty(t, created.Secret)
+
+	_, err = apiClient.CreateWebhook(ctx, &client.CreateWebhookRequest{URL: "ftp://localhost/hook"})
+	assert.ErrorIs(t, err, client.ErrInvalidBody)
+
+	webhooks, err := apiClient.ListWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, webhooks, 2)
+
+	webhook, err := apiClient.GetWebhook(ctx, webhookID)
+	require.NoError(t, err)
+	assert.Equal(t, bakerAddress, webhook.Filter.Baker)
+
+	deliveries, err := apiClient.ListWebhookDeliveries(ctx, webhookID, client.Page{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, deliveryID, deliveries[0].ID)
+
+	delivery, err := apiClient.ReplayWebhookDelivery(ctx, webhookID, deliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, deliveryID, delivery.ID)
+
+	require.NoError(t, apiClient.DeleteWebhook(ctx, created.ID))
+
+	_, err = apiClient.GetWebhook(ctx, created.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
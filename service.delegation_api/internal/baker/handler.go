@@ -0,0 +1,337 @@
+package baker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/envelope"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Query parameters of the bakers endpoints.
+var (
+	// ListParams are the query parameters of the bakers list endpoint.
+	ListParams = []string{"sort", "page", "size"}
+	// SubpathParams are the query parameters of the bakers sub paths endpoints.
+	SubpathParams = []string{"as_of", "page", "size"}
+)
+
+// APIHandler handles the bakers API requests.
+type APIHandler struct {
+	datastore datastore.Datastorer
+	// maxPageSize is the maximum size of a bakers or delegators page.
+	maxPageSize int
+}
+
+// New creates a new APIHandler.
+func New(datastore datastore.Datastorer, maxPageSize int) *APIHandler {
+	return &APIHandler{
+		datastore:   datastore,
+		maxPageSize: maxPageSize,
+	}
+}
+
+// GetBakersHandler handles the legacy /xtz/bakers endpoint.
+//
+// Bakers are sorted with the sort parameter (e.g. sort=-delegatedAmount, by number of delegators descending
+// by default), and paginated with page and size parameters. The next page is returned in a Link header.
+func (a *APIHandler) GetBakersHandler(w http.ResponseWriter, r *http.Request) {
+	bakers, page, totals, ok := a.getBakers(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totals.TotalPages))
+
+	if page.Number < totals.TotalPages {
+		w.Header().Set("Link", nextLink(r.URL, page.Number+1))
+	}
+
+	writeJSON(w, bakers)
+}
+
+// GetBakersV1Handler handles /v1/bakers endpoint.
+//
+// It takes the parameters of GetBakersHandler, the response body being an envelope with the bakers as data,
+// the pagination (total count and pages, page number and size) and the links to this page and the next one.
+func (a *APIHandler) GetBakersV1Handler(w http.ResponseWriter, r *http.Request) {
+	bakers, page, totals, ok := a.getBakers(w, r)
+	if !ok {
+		return
+	}
+
+	envelope.Write(
+		w,
+		http.StatusOK,
+		envelope.List(r.URL.Path, r.URL, bakers, page, totals, page.Number >= totals.TotalPages),
+	)
+}
+
+// getBakers gets the page of bakers of a bakers list request, along with the bakers totals. The request is
+// answered with an error when the parameters are invalid or the bakers can't be read.
+func (a *APIHandler) getBakers(
+	w http.ResponseWriter,
+	r *http.Request,
+) ([]*model.Baker, datastore.Page, *envelope.Totals, bool) {
+	sortParam := r.URL.Query().Get("sort")
+
+	bakersSort, err := datastore.ParseBakersSort(sortParam)
+	if err != nil {
+		zap.L().Error("error parsing sort parameter", zap.String("sort", sortParam), zap.Error(err))
+		problem.BadRequest(w, param.Errorf("sort", "couldn't parse value %s for query parameter sort", sortParam))
+
+		return nil, datastore.Page{}, nil, false
+	}
+
+	page, err := param.Page(r.URL.Query(), param.DefaultPageSize, a.maxPageSize)
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return nil, datastore.Page{}, nil, false
+	}
+
+	bakers, err := a.datastore.GetBakers(r.Context(), bakersSort, page)
+	if err != nil {
+		zap.L().Error("couldn't get bakers from datastore", zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, datastore.Page{}, nil, false
+	}
+
+	if bakers == nil {
+		bakers = []*model.Baker{}
+	}
+
+	totalBakers, err := a.datastore.GetBakersCount(r.Context())
+	if err != nil {
+		zap.L().Error("couldn't get bakers count from datastore", zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, datastore.Page{}, nil, false
+	}
+
+	return bakers, page, envelope.NewTotals(totalBakers, page.Size), true
+}
+
+// GetBakerHandler handles the legacy /xtz/bakers/{address} endpoint, the request path being the baker address.
+func (a *APIHandler) GetBakerHandler(w http.ResponseWriter, r *http.Request) {
+	if baker, ok := a.getBaker(w, r); ok {
+		writeJSON(w, baker)
+	}
+}
+
+// GetBakerV1Handler handles /v1/bakers/{address} endpoint, the request path being the baker address.
+// The response body is an envelope with the baker as data.
+func (a *APIHandler) GetBakerV1Handler(w http.ResponseWriter, r *http.Request) {
+	if baker, ok := a.getBaker(w, r); ok {
+		envelope.Write(w, http.StatusOK, envelope.Body{Data: baker})
+	}
+}
+
+// getBaker gets the baker of the request path, answering the request with an error when it isn't found or
+// can't be read.
+func (a *APIHandler) getBaker(w http.ResponseWriter, r *http.Request) (*model.Baker, bool) {
+	address := r.URL.Path
+	if address == "" || strings.Contains(address, "/") {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	baker, err := a.datastore.GetBaker(r.Context(), address)
+	if err != nil {
+		zap.L().Error("couldn't get baker from datastore", zap.String("address", address), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	if baker == nil {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	return baker, true
+}
+
+// GetBakerDelegatorsHandler handles the legacy /xtz/bakers/{address}/delegators endpoint, the request path
+// being {address}/delegators.
+//
+// It returns the delegators of the baker sorted by address, paginated with page and size parameters.
+// The as_of parameter, either a RFC3339 timestamp or a block level, returns the delegators of the baker
+// at that point in history instead of the current ones. The next page is returned in a Link header.
+func (a *APIHandler) GetBakerDelegatorsHandler(w http.ResponseWriter, r *http.Request) {
+	delegators, page, ok := a.getBakerDelegators(w, r)
+	if !ok {
+		return
+	}
+
+	// delegators aren't counted, a full page may have a next page
+	if len(delegators) == page.Size {
+		// the request path is stripped of the /xtz/bakers/ prefix
+		current := *r.URL
+		current.Path = "/xtz/bakers/" + r.URL.Path
+		w.Header().Set("Link", nextLink(&current, page.Number+1))
+	}
+
+	writeJSON(w, delegators)
+}
+
+// GetBakerDelegatorsV1Handler handles /v1/bakers/{address}/delegators endpoint, the request path being
+// {address}/delegators.
+//
+// It takes the parameters of GetBakerDelegatorsHandler, the response body being an envelope with the
+// delegators as data, the pagination (page number and size, the delegators not being counted) and the links
+// to this page and the next one.
+func (a *APIHandler) GetBakerDelegatorsV1Handler(w http.ResponseWriter, r *http.Request) {
+	delegators, page, ok := a.getBakerDelegators(w, r)
+	if !ok {
+		return
+	}
+
+	// the request path is stripped of the /v1/bakers/ prefix, a full page may have a next page
+	envelope.Write(
+		w,
+		http.StatusOK,
+		envelope.List("/v1/bakers/"+r.URL.Path, r.URL, delegators, page, nil, len(delegators) < page.Size),
+	)
+}
+
+// getBakerDelegators gets the page of delegators of a baker delegators request. The request is answered with
+// an error when the parameters are invalid or the delegators can't be read.
+func (a *APIHandler) getBakerDelegators(
+	w http.ResponseWriter,
+	r *http.Request,
+) ([]*model.Delegator, datastore.Page, bool) {
+	address, found := strings.CutSuffix(r.URL.Path, "/delegators")
+	if !found || address == "" || strings.Contains(address, "/") {
+		problem.NotFound(w)
+
+		return nil, datastore.Page{}, false
+	}
+
+	asOf, err := parseAsOf(r.URL.Query().Get("as_of"))
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return nil, datastore.Page{}, false
+	}
+
+	page, err := param.Page(r.URL.Query(), param.DefaultPageSize, a.maxPageSize)
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return nil, datastore.Page{}, false
+	}
+
+	delegators, err := a.datastore.GetBakerDelegators(
+		r.Context(),
+		address,
+		asOf,
+		page,
+	)
+	if err != nil {
+		zap.L().Error("couldn't get baker delegators from datastore", zap.String("address", address), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, datastore.Page{}, false
+	}
+
+	if delegators == nil {
+		delegators = []*model.Delegator{}
+	}
+
+	return delegators, page, true
+}
+
+// SubpathHandler handles the legacy /xtz/bakers/ sub paths, the request path being either {address}
+// or {address}/delegators.
+func (a *APIHandler) SubpathHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/delegators") {
+		a.GetBakerDelegatorsHandler(w, r)
+
+		return
+	}
+
+	a.GetBakerHandler(w, r)
+}
+
+// SubpathV1Handler handles the /v1/bakers/ sub paths, the request path being either {address}
+// or {address}/delegators.
+func (a *APIHandler) SubpathV1Handler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/delegators") {
+		a.GetBakerDelegatorsV1Handler(w, r)
+
+		return
+	}
+
+	a.GetBakerV1Handler(w, r)
+}
+
+// parseAsOf parses the as_of query parameter, either a block level or a RFC3339 timestamp.
+// An empty parameter is now.
+func parseAsOf(value string) (datastore.AsOf, error) {
+	if value == "" {
+		return datastore.AsOf{}, nil
+	}
+
+	level, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && level > 0 {
+		return datastore.AsOf{Level: level}, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		zap.L().Error("couldn't parse as_of query parameter value", zap.String("paramValue", value), zap.Error(err))
+
+		return datastore.AsOf{}, param.Errorf(
+			"as_of",
+			"couldn't parse value %s for query parameter as_of, expected RFC3339 timestamp or level",
+			value,
+		)
+	}
+
+	return datastore.AsOf{Timestamp: timestamp}, nil
+}
+
+// nextLink returns the Link header value pointing to the next page.
+func nextLink(current *url.URL, page int) string {
+	query := current.Query()
+	query.Set("page", strconv.Itoa(page))
+
+	link := url.URL{
+		Path:     current.Path,
+		RawQuery: query.Encode(),
+	}
+
+	return fmt.Sprintf(`<%s>; rel="next"`, link.String())
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		zap.L().Error("error marshalling response to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
@@ -0,0 +1,661 @@
+package baker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/baker"
+)
+
+// maxPageSize is the maximum page size of the handlers under test.
+const maxPageSize = 1000
+
+type underTest struct {
+	mockCtrl      *gomock.Controller
+	mockDatastore *datastoremock.MockDatastorer
+	apiHandler    *baker.APIHandler
+}
+
+func setupTest(t *testing.T) *underTest {
+	t.Helper()
+
+	ut := &underTest{}
+
+	ut.mockCtrl = gomock.NewController(t)
+
+	ut.mockDatastore = datastoremock.NewMockDatastorer(ut.mockCtrl)
+
+	ut.apiHandler = baker.New(ut.mockDatastore, maxPageSize)
+
+	return ut
+}
+
+var (
+	errGetBakers   = errors.New("error getting bakers")
+	errCountBakers = errors.New("error count bakers")
+)
+
+func TestBaker_GetBakersHandler(t *testing.T) {
+	t.Parallel()
+
+	bakers := []*model.Baker{
+		{Address: "tz1bakerB", Delegators: 2, DelegatedAmount: 300, Inflows: 2, InflowAmount: 300},
+		{Address: "tz1bakerA", Delegators: 1, DelegatedAmount: 100, Inflows: 3, InflowAmount: 400, Outflows: 2},
+	}
+
+	cases := []struct {
+		name           string
+		url            string
+		init           func(*underTest)
+		want           []*model.Baker
+		wantErr        string
+		wantStatusCode int
+		wantTotalPages string
+		wantLink       string
+	}{
+		{
+			name: "Success with default sort",
+			url:  "/xtz/bakers",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetBakers(
+					gomock.Any(),
+					gomock.Eq(datastore.BakersSort{Field: datastore.BakersSortDelegators, Descending: true}),
+					gomock.Eq(datastore.Page{Number: 1, Size: 100}),
+				).Return(bakers, nil)
+				ut.mockDatastore.EXPECT().GetBakersCount(gomock.Any()).Return(2, nil)
+			},
+			want:           bakers,
+			wantStatusCode: http.StatusOK,
+			wantTotalPages: "1",
+		},
+		{
+			name: "Success with sort and pagination",
+			url:  "/xtz/bakers?sort=delegatedAmount&page=1&size=1",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetBakers(
+					gomock.Any(),
+					gomock.Eq(datastore.BakersSort{Field: datastore.BakersSortDelegatedAmount}),
+					gomock.Eq(datastore.Page{Number: 1, Size: 1}),
+				).Return(bakers[1:], nil)
+				ut.mockDatastore.EXPECT().GetBakersCount(gomock.Any()).Return(2, nil)
+			},
+			want:           bakers[1:],
+			wantStatusCode: http.StatusOK,
+			wantTotalPages: "2",
+			wantLink:       `</xtz/bakers?page=2&size=1&sort=delegatedAmount>; rel="next"`,
+		},
+		{
+			name: "Success without bakers",
+			url:  "/xtz/bakers",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetBakers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+				ut.mockDatastore.EXPECT().GetBakersCount(gomock.Any()).Return(0, nil)
+			},
+			want:           []*model.Baker{},
+			wantStatusCode: http.StatusOK,
+			wantTotalPages: "0",
+		},
+		{
+			name: "Error invalid sort",
+			url:  "/xtz/bakers?sort=-unknown",
+			init: func(ut *underTest) {},
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"sort","detail":"couldn't parse value -unknown for query parameter sort"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Error GetBakers from datastore",
+			url:  "/xtz/bakers",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetBakers(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errGetBakers)
+			},
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "Error GetBakersCount from datastore",
+			url:  "/xtz/bakers",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetBakers(gomock.Any(), gomock.Any(), gomock.Any()).Return(bakers, nil)
+				ut.mockDatastore.EXPECT().GetBakersCount(gomock.Any()).Return(0, errCountBakers)
+			},
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetBakersHandler(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			assert.Equal(t, c.wantTotalPages, responseRecorder.Header().Get("X-Total-Pages"))
+			assert.Equal(t, c.wantLink, responseRecorder.Header().Get("Link"))
+
+			var result []*model.Baker
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+func TestBaker_GetBakerHandler(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID:        1,
+			Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Amount:    57800,
+			Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+			Block:     "123456",
+			Baker:     "tz1bakerA",
+		},
+		{
+			ID:            2,
+			Timestamp:     time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			Amount:        60000,
+			Delegator:     "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+			Block:         "56897",
+			Baker:         "tz1bakerB",
+			PreviousBaker: "tz1bakerA",
+		},
+	}))
+
+	apiHandler := baker.New(datastore, maxPageSize)
+	server := http.StripPrefix("/xtz/bakers/", http.HandlerFunc(apiHandler.GetBakerHandler))
+
+	cases := []struct {
+		name           string
+		url            string
+		want           *model.Baker
+		wantStatusCode int
+	}{
+		{
+			name: "Success",
+			url:  "/xtz/bakers/tz1bakerB",
+			want: &model.Baker{
+				Address:         "tz1bakerB",
+				Delegators:      1,
+				DelegatedAmount: 60000,
+				Inflows:         1,
+				InflowAmount:    60000,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success previous baker",
+			url:  "/xtz/bakers/tz1bakerA",
+			want: &model.Baker{
+				Address:       "tz1bakerA",
+				Inflows:       1,
+				InflowAmount:  57800,
+				Outflows:      1,
+				OutflowAmount: 60000,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Error unknown baker",
+			url:            "/xtz/bakers/tz1unknown",
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Error missing address",
+			url:            "/xtz/bakers/",
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Error sub path",
+			url:            "/xtz/bakers/tz1bakerB/delegators",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			server.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.want == nil {
+				return
+			}
+
+			var result *model.Baker
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+//nolint:funlen
+func TestBaker_SubpathHandler(t *testing.T) {
+	t.Parallel()
+
+	delegations := []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 57800,
+			Delegator: "tz1delegatorA", Block: "123456", Level: 100, Baker: "tz1bakerA",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 1000,
+			Delegator: "tz1delegatorB", Block: "123457", Level: 101, Baker: "tz1bakerA",
+		},
+		{
+			ID: 3, Timestamp: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Amount: 60000,
+			Delegator: "tz1delegatorA", Block: "56897", Level: 102, Baker: "tz1bakerB", PreviousBaker: "tz1bakerA",
+		},
+	}
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), delegations))
+
+	delegatorA := &model.Delegator{
+		Address: "tz1delegatorA", Baker: "tz1bakerA", Amount: 57800,
+		Timestamp: delegations[0].Timestamp, Level: 100, DelegationID: 1,
+	}
+	delegatorB := &model.Delegator{
+		Address: "tz1delegatorB", Baker: "tz1bakerA", Amount: 1000,
+		Timestamp: delegations[1].Timestamp, Level: 101, DelegationID: 2,
+	}
+
+	apiHandler := baker.New(datastore, maxPageSize)
+	server := http.StripPrefix("/xtz/bakers/", http.HandlerFunc(apiHandler.SubpathHandler))
+
+	cases := []struct {
+		name           string
+		url            string
+		want           []*model.Delegator
+		wantErr        string
+		wantStatusCode int
+		wantLink       string
+	}{
+		{
+			name:           "Success current delegators",
+			url:            "/xtz/bakers/tz1bakerA/delegators",
+			want:           []*model.Delegator{delegatorB},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Success as of timestamp",
+			url:            "/xtz/bakers/tz1bakerA/delegators?as_of=2023-01-02T12:00:00Z",
+			want:           []*model.Delegator{delegatorA, delegatorB},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Success as of level with next page",
+			url:            "/xtz/bakers/tz1bakerA/delegators?as_of=101&size=1",
+			want:           []*model.Delegator{delegatorA},
+			wantStatusCode: http.StatusOK,
+			wantLink:       `</xtz/bakers/tz1bakerA/delegators?as_of=101&page=2&size=1>; rel="next"`,
+		},
+		{
+			name:           "Success baker",
+			url:            "/xtz/bakers/tz1bakerB",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Error invalid as_of",
+			url:  "/xtz/bakers/tz1bakerA/delegators?as_of=yesterday",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"as_of",` +
+				`"detail":"couldn't parse value yesterday for query parameter as_of, expected RFC3339 timestamp or ` +
+				`level"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Error missing address",
+			url:            "/xtz/bakers//delegators",
+			wantErr:        `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			server.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+			assert.Equal(t, c.wantLink, responseRecorder.Header().Get("Link"))
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			if c.want == nil {
+				return
+			}
+
+			var result []*model.Delegator
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+//nolint:funlen
+func TestBaker_GetBakersV1Handler(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 1000,
+			Delegator: "tz1delegatorA", Block: "123456", Level: 100, Baker: "tz1bakerA",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 3000,
+			Delegator: "tz1delegatorB", Block: "123457", Level: 101, Baker: "tz1bakerB",
+		},
+	}))
+
+	apiHandler := baker.New(datastore, maxPageSize)
+
+	cases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "Success first page",
+			url:  "/v1/bakers?sort=-delegatedAmount&size=1",
+			want: `{
+				"pagination": {"totalCount": 2, "totalPages": 2, "page": 1, "size": 1},
+				"links": {
+					"self": "/v1/bakers?sort=-delegatedAmount&size=1",
+					"next": "/v1/bakers?page=2&size=1&sort=-delegatedAmount"
+				},
+				"data": [{
+					"address": "tz1bakerB", "delegators": 1, "delegatedAmount": 3000, "inflows": 1,
+					"inflowAmount": 3000, "outflows": 0, "outflowAmount": 0
+				}]
+			}`,
+		},
+		{
+			name: "Success last page",
+			url:  "/v1/bakers?sort=-delegatedAmount&size=1&page=2",
+			want: `{
+				"pagination": {"totalCount": 2, "totalPages": 2, "page": 2, "size": 1},
+				"links": {"self": "/v1/bakers?sort=-delegatedAmount&size=1&page=2"},
+				"data": [{
+					"address": "tz1bakerA", "delegators": 1, "delegatedAmount": 1000, "inflows": 1,
+					"inflowAmount": 1000, "outflows": 0, "outflowAmount": 0
+				}]
+			}`,
+		},
+		{
+			name: "Success empty page",
+			url:  "/v1/bakers?page=3",
+			want: `{
+				"pagination": {"totalCount": 2, "totalPages": 1, "page": 3, "size": 100},
+				"links": {"self": "/v1/bakers?page=3"},
+				"data": []
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			apiHandler.GetBakersV1Handler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.Equal(t, "application/json", responseRecorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, c.want, responseRecorder.Body.String())
+
+			// the pagination is in the body only
+			assert.Empty(t, responseRecorder.Header().Get("Link"))
+			assert.Empty(t, responseRecorder.Header().Get("X-Total-Pages"))
+		})
+	}
+}
+
+//nolint:funlen
+func TestBaker_SubpathV1Handler(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 57800,
+			Delegator: "tz1delegatorA", Block: "123456", Level: 100, Baker: "tz1bakerA",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 1000,
+			Delegator: "tz1delegatorB", Block: "123457", Level: 101, Baker: "tz1bakerA",
+		},
+	}))
+
+	apiHandler := baker.New(datastore, maxPageSize)
+	server := http.StripPrefix("/v1/bakers/", http.HandlerFunc(apiHandler.SubpathV1Handler))
+
+	cases := []struct {
+		name           string
+		url            string
+		want           string
+		wantStatusCode int
+	}{
+		{
+			name: "Success delegators with next page",
+			url:  "/v1/bakers/tz1bakerA/delegators?size=1",
+			want: `{
+				"pagination": {"page": 1, "size": 1},
+				"links": {
+					"self": "/v1/bakers/tz1bakerA/delegators?size=1",
+					"next": "/v1/bakers/tz1bakerA/delegators?page=2&size=1"
+				},
+				"data": [{
+					"address": "tz1delegatorA", "baker": "tz1bakerA", "amount": 57800,
+					"timestamp": "2023-01-01T00:00:00Z", "level": 100, "delegationId": 1
+				}]
+			}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success delegators last page",
+			url:  "/v1/bakers/tz1bakerA/delegators?size=1&page=2",
+			want: `{
+				"pagination": {"page": 2, "size": 1},
+				"links": {
+					"self": "/v1/bakers/tz1bakerA/delegators?size=1&page=2",
+					"next": "/v1/bakers/tz1bakerA/delegators?page=3&size=1"
+				},
+				"data": [{
+					"address": "tz1delegatorB", "baker": "tz1bakerA", "amount": 1000,
+					"timestamp": "2023-01-02T00:00:00Z", "level": 101, "delegationId": 2
+				}]
+			}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success delegators past the last page",
+			url:  "/v1/bakers/tz1bakerA/delegators?size=1&page=3",
+			want: `{
+				"pagination": {"page": 3, "size": 1},
+				"links": {"self": "/v1/bakers/tz1bakerA/delegators?size=1&page=3"},
+				"data": []
+			}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success baker",
+			url:  "/v1/bakers/tz1bakerA",
+			want: `{"data": {
+				"address": "tz1bakerA", "delegators": 2, "delegatedAmount": 58800, "inflows": 2,
+				"inflowAmount": 58800, "outflows": 0, "outflowAmount": 0
+			}}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Error unknown baker",
+			url:            "/v1/bakers/tz1unknown",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			server.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.want != "" {
+				assert.JSONEq(t, c.want, responseRecorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestBaker_MaxPageSize(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 1000,
+			Delegator: "tz1delegatorA", Block: "123456", Level: 100, Baker: "tz1bakerA",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 3000,
+			Delegator: "tz1delegatorB", Block: "123457", Level: 101, Baker: "tz1bakerB",
+		},
+	}))
+
+	// the pages are at most of the configured size, which is also their default size when smaller
+	apiHandler := baker.New(datastore, 1)
+	server := http.StripPrefix("/v1/bakers/", http.HandlerFunc(apiHandler.SubpathV1Handler))
+
+	cases := []struct {
+		name           string
+		url            string
+		handler        http.Handler
+		wantStatusCode int
+		wantSize       int
+	}{
+		{
+			name:           "Success bakers default size",
+			url:            "/v1/bakers",
+			handler:        http.HandlerFunc(apiHandler.GetBakersV1Handler),
+			wantStatusCode: http.StatusOK,
+			wantSize:       1,
+		},
+		{
+			name:           "Error bakers size above the maximum",
+			url:            "/v1/bakers?size=2",
+			handler:        http.HandlerFunc(apiHandler.GetBakersV1Handler),
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Success delegators default size",
+			url:            "/v1/bakers/tz1bakerA/delegators",
+			handler:        server,
+			wantStatusCode: http.StatusOK,
+			wantSize:       1,
+		},
+		{
+			name:           "Error delegators size above the maximum",
+			url:            "/v1/bakers/tz1bakerA/delegators?size=2",
+			handler:        server,
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			c.handler.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantStatusCode != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				Pagination struct {
+					Size int `json:"size"`
+				} `json:"pagination"`
+			}
+			require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &result), "Error parsing JSON response")
+			assert.Equal(t, c.wantSize, result.Pagination.Size)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package delegation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// ChangesParams are the query parameters of the delegations changes endpoint.
+var ChangesParams = []string{"since", "size"}
+
+// changesPage is the response body of the delegations changes endpoint.
+type changesPage struct {
+	Changes []*model.DelegationChange `json:"changes"`
+	// Next is the token to resume reading the changes after this page, the since token when there are no
+	// new changes yet.
+	Next string `json:"next"`
+}
+
+// GetDelegationsChangesHandler handles /xtz/delegations/changes endpoint.
+//
+// It returns the inserts, updates and deletions of delegations in order, after the opaque since token
+// (from the start of the change log when empty), at most size changes. The next token resumes reading
+// the changes, and the next page is returned in a Link header when the page is full.
+//
+//nolint:funlen
+func (a *APIHandler) GetDelegationsChangesHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("since")
+
+	since, err := datastore.DecodeChangeToken(token)
+	if err != nil {
+		zap.L().Error("error decoding since parameter", zap.String("since", token), zap.Error(err))
+		problem.BadRequest(w, param.Errorf("since", "couldn't decode value %s for query parameter since", token))
+
+		return
+	}
+
+	pageSize, err := param.IntRange("size", r.URL.Query().Get("size"), param.Between(1, a.maxPageSize))
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return
+	}
+
+	if pageSize == 0 {
+		pageSize = min(param.DefaultPageSize, a.maxPageSize)
+	}
+
+	changes, err := a.datastore.GetDelegationsChanges(r.Context(), since, pageSize)
+	if err != nil {
+		zap.L().Error("couldn't get delegations changes from datastore", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	if changes == nil {
+		changes = []*model.DelegationChange{}
+	}
+
+	next := since
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].Sequence
+	}
+
+	response := changesPage{
+		Changes: changes,
+		Next:    datastore.EncodeChangeToken(next),
+	}
+
+	if len(changes) == pageSize {
+		w.Header().Set("Link", nextChangesLink(r.URL, response.Next))
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		zap.L().Error("error marshalling delegations changes to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
+
+// nextChangesLink returns the Link header value pointing to the changes after the next token.
+func nextChangesLink(current *url.URL, next string) string {
+	query := current.Query()
+	query.Set("since", next)
+
+	link := url.URL{
+		Path:     current.Path,
+		RawQuery: query.Encode(),
+	}
+
+	return fmt.Sprintf(`<%s>; rel="next"`, link.String())
+}
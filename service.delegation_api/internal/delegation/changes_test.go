@@ -0,0 +1,145 @@
+package delegation_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+var errGetChanges = errors.New("error getting changes")
+
+// changesPage is the changes response body.
+type changesPage struct {
+	Changes []*model.DelegationChange `json:"changes"`
+	Next    string                    `json:"next"`
+}
+
+//nolint:funlen
+func TestDelegation_GetDelegationsChangesHandler(t *testing.T) {
+	t.Parallel()
+
+	changes := []*model.DelegationChange{
+		{
+			Sequence:  2,
+			Operation: model.ChangeUpdate,
+			Delegation: &model.Delegation{
+				ID:        1,
+				Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				Amount:    60,
+				Delegator: "tz1delegator",
+				Block:     "block1",
+			},
+		},
+		{
+			Sequence:  3,
+			Operation: model.ChangeDelete,
+			Delegation: &model.Delegation{
+				ID:        2,
+				Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				Amount:    50,
+				Delegator: "tz1delegator",
+				Block:     "block2",
+			},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		url            string
+		init           func(*underTest)
+		want           *changesPage
+		wantErr        string
+		wantStatusCode int
+		wantLink       string
+	}{
+		{
+			name: "Success from the start",
+			url:  "/xtz/delegations/changes",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsChanges(gomock.Any(), int64(0), 100).Return(changes, nil)
+			},
+			want:           &changesPage{Changes: changes, Next: datastore.EncodeChangeToken(3)},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success since with full page",
+			url:  "/xtz/delegations/changes?since=" + datastore.EncodeChangeToken(1) + "&size=2",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsChanges(gomock.Any(), int64(1), 2).Return(changes, nil)
+			},
+			want:           &changesPage{Changes: changes, Next: datastore.EncodeChangeToken(3)},
+			wantStatusCode: http.StatusOK,
+			wantLink:       `</xtz/delegations/changes?since=` + datastore.EncodeChangeToken(3) + `&size=2>; rel="next"`,
+		},
+		{
+			name: "Success up to date",
+			url:  "/xtz/delegations/changes?since=" + datastore.EncodeChangeToken(3),
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsChanges(gomock.Any(), int64(3), 100).Return(nil, nil)
+			},
+			want:           &changesPage{Changes: []*model.DelegationChange{}, Next: datastore.EncodeChangeToken(3)},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Error invalid since",
+			url:  "/xtz/delegations/changes?since=invalid!",
+			init: func(ut *underTest) {},
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"since","detail":"couldn't decode value invalid! for query parameter since"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Error GetDelegationsChanges from datastore",
+			url:  "/xtz/delegations/changes",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsChanges(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errGetChanges)
+			},
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetDelegationsChangesHandler(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+			assert.Equal(t, c.wantLink, responseRecorder.Header().Get("Link"))
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			var result *changesPage
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package delegation
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/parquet"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Export formats.
+const (
+	formatCSV     = "csv"
+	formatNDJSON  = "ndjson"
+	formatParquet = "parquet"
+)
+
+// flushRows is the number of exported delegations after which the response is flushed to the client.
+const flushRows = 1000
+
+// exportContentTypes are the content types of the export formats.
+var exportContentTypes = map[string]string{
+	formatCSV:     "text/csv",
+	formatNDJSON:  "application/x-ndjson",
+	formatParquet: parquet.ContentType,
+}
+
+// exportFormats are the export formats of the negotiated media types.
+var exportFormats = map[string]string{
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatNDJSON,
+	"application/ndjson":   formatNDJSON,
+	parquet.ContentType:    formatParquet,
+	"text/*":               formatCSV,
+	"application/*":        formatNDJSON,
+	"*/*":                  formatCSV,
+}
+
+// exporter writes exported delegations.
+type exporter interface {
+	// write writes a delegation.
+	write(delegation *model.Delegation) error
+	// flush writes the buffered delegations to the response.
+	flush() error
+	// close writes the remaining delegations to the response.
+	close() error
+}
+
+// ExportParams are the query parameters of the delegations export endpoint.
+var ExportParams = append([]string{"sort", "select", "format"}, FilterParams...)
+
+// GetDelegationsExportHandler handles /xtz/delegations/export endpoint.
+//
+// It exports all the delegations matching the same filter, sort and select parameters as the delegations
+// endpoint, as CSV, newline delimited JSON or Parquet (without select). The format parameter (csv, ndjson or
+// parquet) selects the format, otherwise it is negotiated from the Accept header, CSV by default.
+//
+// The delegations are streamed from the datastore as they are read, the response being flushed every
+// flushRows delegations, without the server write timeout. An error after the response started aborts it,
+// so that a truncated export isn't mistaken for a complete one.
+//
+//nolint:funlen
+func (a *APIHandler) GetDelegationsExportHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := exportFormat(r)
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return
+	}
+
+	if format == "" {
+		problem.NotAcceptable(w, "expected text/csv, application/x-ndjson or "+parquet.ContentType)
+
+		return
+	}
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		zap.L().Error("error parsing filter parameters", zap.Error(err))
+		problem.BadRequest(w, err)
+
+		return
+	}
+
+	sort, projection, err := parseSortSelect(r.URL.Query())
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return
+	}
+
+	if format == formatParquet && projection != nil {
+		problem.BadRequest(w, param.Errorf("select", "select isn't supported by the parquet format"))
+
+		return
+	}
+
+	// the export outlives the server write timeout
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		zap.L().Error("couldn't clear delegations export write deadline", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(
+		"attachment",
+		map[string]string{"filename": exportFilename(filter, format)},
+	))
+
+	body := &responseBody{Writer: w}
+
+	var out exporter
+
+	switch format {
+	case formatCSV:
+		out, err = newCSVExporter(body, projection)
+	case formatNDJSON:
+		out = newNDJSONExporter(body, projection)
+	case formatParquet:
+		out = newParquetExporter(body)
+	}
+
+	if err != nil {
+		zap.L().Error("error writing export header", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	rows := 0
+
+	err = a.datastore.IterateDelegations(
+		r.Context(),
+		filter,
+		sort,
+		projection,
+		datastore.Page{},
+		func(delegation *model.Delegation) error {
+			if err := out.write(delegation); err != nil {
+				return err
+			}
+
+			rows++
+
+			if rows%flushRows == 0 {
+				return flush(w, out)
+			}
+
+			return nil
+		},
+	)
+	if err == nil {
+		err = out.close()
+	}
+
+	if err == nil {
+		err = flush(w, out)
+	}
+
+	if err != nil {
+		zap.L().Error("couldn't export delegations", zap.Int("rows", rows), zap.Error(err))
+
+		if !body.started {
+			w.Header().Del("Content-Disposition")
+			problem.InternalError(w)
+
+			return
+		}
+
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportFormat returns the export format of the format parameter, or negotiated from the Accept header,
+// empty when no format is acceptable.
+func exportFormat(r *http.Request) (string, error) {
+	if r.URL.Query().Has("format") {
+		format := r.URL.Query().Get("format")
+		if _, found := exportContentTypes[format]; !found {
+			return "", param.Errorf("format", "invalid format %s, expected csv, ndjson or parquet", format)
+		}
+
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatCSV, nil
+	}
+
+	// media types are tried in order, quality values are ignored
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+
+		if format, found := exportFormats[mediaType]; found {
+			return format, nil
+		}
+	}
+
+	return "", nil
+}
+
+// exportFilename returns the filename of an export, suffixed by the filtered year.
+func exportFilename(filter datastore.Filter, format string) string {
+	if filter.Year != 0 {
+		return fmt.Sprintf("delegations-%d.%s", filter.Year, format)
+	}
+
+	return "delegations." + format
+}
+
+// flush flushes the exported delegations, then the response.
+func flush(w http.ResponseWriter, out exporter) error {
+	if err := out.flush(); err != nil {
+		return err
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// csvExporter exports delegations as CSV, with a header row of the exported fields.
+type csvExporter struct {
+	writer *csv.Writer
+	fields []string
+	record []string
+}
+
+// newCSVExporter creates a csvExporter of the projected fields, writing the header row.
+func newCSVExporter(w io.Writer, projection datastore.Projection) (*csvExporter, error) {
+	fields := projection
+	if fields == nil {
+		fields = datastore.Fields
+	}
+
+	e := &csvExporter{
+		writer: csv.NewWriter(w),
+		fields: fields,
+		record: make([]string, len(fields)),
+	}
+
+	if err := e.writer.Write(fields); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *csvExporter) write(delegation *model.Delegation) error {
+	for i, field := range e.fields {
+		switch value := fieldValue(delegation, field).(type) {
+		case int64:
+			e.record[i] = strconv.FormatInt(value, 10)
+		case time.Time:
+			e.record[i] = value.Format(time.RFC3339Nano)
+		case string:
+			e.record[i] = value
+		}
+	}
+
+	return e.writer.Write(e.record)
+}
+
+func (e *csvExporter) flush() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+func (e *csvExporter) close() error {
+	return e.flush()
+}
+
+// ndjsonExporter exports delegations as newline delimited JSON, with the JSON keys of the delegations endpoint.
+type ndjsonExporter struct {
+	buffer     *bufio.Writer
+	encoder    *json.Encoder
+	projection datastore.Projection
+}
+
+// newNDJSONExporter creates a ndjsonExporter of the projected fields.
+func newNDJSONExporter(w io.Writer, projection datastore.Projection) *ndjsonExporter {
+	buffer := bufio.NewWriter(w)
+
+	return &ndjsonExporter{
+		buffer:     buffer,
+		encoder:    json.NewEncoder(buffer),
+		projection: projection,
+	}
+}
+
+func (e *ndjsonExporter) write(delegation *model.Delegation) error {
+	if e.projection == nil {
+		return e.encoder.Encode(delegation)
+	}
+
+	return e.encoder.Encode(selectFields(delegation, e.projection))
+}
+
+func (e *ndjsonExporter) flush() error {
+	return e.buffer.Flush()
+}
+
+func (e *ndjsonExporter) close() error {
+	return e.flush()
+}
+
+// parquetExporter exports delegations as Parquet, the rows being written by row groups.
+type parquetExporter struct {
+	writer *parquet.Writer
+}
+
+// newParquetExporter creates a parquetExporter.
+func newParquetExporter(w io.Writer) *parquetExporter {
+	return &parquetExporter{writer: parquet.NewWriter(w)}
+}
+
+func (e *parquetExporter) write(delegation *model.Delegation) error {
+	return e.writer.Write(delegation)
+}
+
+func (e *parquetExporter) flush() error {
+	return nil
+}
+
+func (e *parquetExporter) close() error {
+	return e.writer.Close()
+}
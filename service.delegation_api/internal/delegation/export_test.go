@@ -0,0 +1,343 @@
+package delegation_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/parquet"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegation"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+var errIterateDelegations = errors.New("error iterating delegations")
+
+//nolint:funlen
+func TestDelegation_GetDelegationsExportHandler(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 57800,
+			Delegator: "tz1delegator1", Block: "block1", Level: 1, Baker: "tz1baker",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 257800,
+			Delegator: "tz1delegator2", Block: "block2", Level: 2, Baker: "tz1baker",
+		},
+		{
+			ID: 3, Timestamp: time.Date(2022, 6, 2, 0, 0, 0, 123000000, time.UTC), Amount: 157800,
+			Delegator: "tz1delegator3", Block: "block3", Level: 3, PreviousBaker: "tz1baker",
+		},
+	}))
+
+	apiHandler := delegation.New(datastore, maxPageSize)
+
+	cases := []struct {
+		name                   string
+		url                    string
+		accept                 string
+		want                   string
+		wantContentType        string
+		wantContentDisposition string
+	}{
+		{
+			name: "Success CSV by default",
+			url:  "/xtz/delegations/export?year=2022",
+			want: "id,timestamp,amount,delegator,block,level,baker,previousBaker\n" +
+				"3,2022-06-02T00:00:00.123Z,157800,tz1delegator3,block3,3,,tz1baker\n" +
+				"2,2022-01-02T00:00:00Z,257800,tz1delegator2,block2,2,tz1baker,\n",
+			wantContentType:        "text/csv",
+			wantContentDisposition: "attachment; filename=delegations-2022.csv",
+		},
+		{
+			name:                   "Success CSV sorted with selected fields",
+			url:                    "/xtz/delegations/export?format=csv&sort=amount&select=amount,id",
+			want:                   "amount,id\n57800,1\n157800,3\n257800,2\n",
+			wantContentType:        "text/csv",
+			wantContentDisposition: "attachment; filename=delegations.csv",
+		},
+		{
+			name: "Success NDJSON",
+			url:  "/xtz/delegations/export?format=ndjson&baker=tz1baker&sort=timestamp",
+			want: `{"id":1,"Timestamp":"2021-01-01T00:00:00Z","amount":57800,"delegator":"tz1delegator1",` +
+				`"block":"block1","level":1,"baker":"tz1baker"}` + "\n" +
+				`{"id":2,"Timestamp":"2022-01-02T00:00:00Z","amount":257800,"delegator":"tz1delegator2",` +
+				`"block":"block2","level":2,"baker":"tz1baker"}` + "\n",
+			wantContentType:        "application/x-ndjson",
+			wantContentDisposition: "attachment; filename=delegations.ndjson",
+		},
+		{
+			name:                   "Success NDJSON negotiated with selected fields",
+			url:                    "/xtz/delegations/export?select=id,baker&year=2021",
+			accept:                 "application/json;q=0.9, application/x-ndjson",
+			want:                   `{"baker":"tz1baker","id":1}` + "\n",
+			wantContentType:        "application/x-ndjson",
+			wantContentDisposition: "attachment; filename=delegations-2021.ndjson",
+		},
+		{
+			name:                   "Success empty CSV",
+			url:                    "/xtz/delegations/export?year=2020&select=id",
+			accept:                 "text/csv",
+			want:                   "id\n",
+			wantContentType:        "text/csv",
+			wantContentDisposition: "attachment; filename=delegations-2020.csv",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			apiHandler.GetDelegationsExportHandler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.Equal(t, c.wantContentType, responseRecorder.Header().Get("Content-Type"))
+			assert.Equal(t, c.wantContentDisposition, responseRecorder.Header().Get("Content-Disposition"))
+			assert.Equal(t, c.want, responseRecorder.Body.String())
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsExportHandler_Parquet(t *testing.T) {
+	t.Parallel()
+
+	delegations := []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 57800,
+			Delegator: "tz1delegator1", Block: "block1", Level: 1, Baker: "tz1baker",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 257800,
+			Delegator: "tz1delegator2", Block: "block2", Level: 2, Baker: "tz1baker",
+		},
+	}
+
+	ds := memory.New()
+	require.NoError(t, ds.StoreDelegations(context.Background(), delegations))
+
+	var want bytes.Buffer
+
+	_, err := parquet.Export(context.Background(), ds, &want, datastore.Filter{Year: 2022})
+	require.NoError(t, err)
+
+	apiHandler := delegation.New(ds, maxPageSize)
+
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"/xtz/delegations/export?year=2022",
+		nil,
+	)
+	require.NoError(t, err, "Error creating request")
+	req.Header.Set("Accept", "application/vnd.apache.parquet")
+
+	responseRecorder := httptest.NewRecorder()
+	apiHandler.GetDelegationsExportHandler(responseRecorder, req)
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, "application/vnd.apache.parquet", responseRecorder.Header().Get("Content-Type"))
+	assert.Equal(
+		t,
+		"attachment; filename=delegations-2022.parquet",
+		responseRecorder.Header().Get("Content-Disposition"),
+	)
+	assert.Equal(t, want.Bytes(), responseRecorder.Body.Bytes())
+}
+
+func TestDelegation_GetDelegationsExportHandler_WriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		format string
+		// wantSuffix is the end of the export, after the last delegation.
+		wantSuffix string
+	}{
+		{format: "csv", wantSuffix: "3,1970-01-01T00:00:03Z,0,,,0,,\n"},
+		{
+			format:     "ndjson",
+			wantSuffix: `{"id":3,"Timestamp":"1970-01-01T00:00:03Z","amount":0,"delegator":"","block":""}` + "\n",
+		},
+		{format: "parquet", wantSuffix: "PAR1"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.format, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+
+			// the delegations are read slower than the server write timeout
+			ut.mockDatastore.EXPECT().IterateDelegations(
+				gomock.Any(),
+				gomock.Eq(datastore.Filter{}),
+				gomock.Eq(datastore.DelegationsSort{}),
+				gomock.Nil(),
+				gomock.Eq(datastore.Page{}),
+				gomock.Any(),
+			).DoAndReturn(func(
+				_ context.Context,
+				_ datastore.Filter,
+				_ datastore.DelegationsSort,
+				_ []string,
+				_ datastore.Page,
+				fn func(*model.Delegation) error,
+			) error {
+				for id := int64(1); id <= 3; id++ {
+					time.Sleep(100 * time.Millisecond)
+
+					if err := fn(&model.Delegation{ID: id, Timestamp: time.Unix(id, 0).UTC()}); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(ut.apiHandler.GetDelegationsExportHandler))
+			server.Config.WriteTimeout = 50 * time.Millisecond
+			server.Start()
+			t.Cleanup(server.Close)
+
+			req, err := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodGet,
+				server.URL+"/xtz/delegations/export?format="+c.format,
+				nil,
+			)
+			require.NoError(t, err)
+
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.True(t, bytes.HasSuffix(body, []byte(c.wantSuffix)), string(body))
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsExportHandler_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		url            string
+		accept         string
+		init           func(*underTest)
+		wantStatusCode int
+		wantErr        string
+	}{
+		{
+			name:           "Error invalid format",
+			url:            "/xtz/delegations/export?format=xlsx",
+			wantStatusCode: http.StatusBadRequest,
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"format","detail":"invalid format xlsx, expected csv, ndjson or parquet"}`,
+		},
+		{
+			name:           "Error not acceptable",
+			url:            "/xtz/delegations/export",
+			accept:         "image/png",
+			wantStatusCode: http.StatusNotAcceptable,
+			wantErr: `{"type":"about:blank","title":"Not Acceptable","status":406,"code":"not_acceptable",` +
+				`"detail":"expected text/csv, application/x-ndjson or application/vnd.apache.parquet"}`,
+		},
+		{
+			name:           "Error parquet with selected fields",
+			url:            "/xtz/delegations/export?format=parquet&select=id",
+			wantStatusCode: http.StatusBadRequest,
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"select","detail":"select isn't supported by the parquet format"}`,
+		},
+		{
+			name:           "Error invalid filter",
+			url:            "/xtz/delegations/export?amount.ge=1",
+			wantStatusCode: http.StatusBadRequest,
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"amount.ge","detail":"unsupported filter operator ge for query parameter amount"}`,
+		},
+		{
+			name:           "Error invalid sort",
+			url:            "/xtz/delegations/export?sort=delegator",
+			wantStatusCode: http.StatusBadRequest,
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"sort",` +
+				`"detail":"invalid sort delegator, expected timestamp, amount or level, prefixed by - for a ` +
+				`descending order"}`,
+		},
+		{
+			name: "Error datastore before the export started",
+			url:  "/xtz/delegations/export?format=ndjson",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().IterateDelegations(
+					gomock.Any(),
+					gomock.Eq(datastore.Filter{}),
+					gomock.Eq(datastore.DelegationsSort{}),
+					gomock.Nil(),
+					gomock.Eq(datastore.Page{}),
+					gomock.Any(),
+				).Return(errIterateDelegations)
+			},
+			wantStatusCode: http.StatusInternalServerError,
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			if c.init != nil {
+				c.init(ut)
+			}
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetDelegationsExportHandler(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+			assert.Equal(t, problem.ContentType, responseRecorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+			assert.Empty(t, responseRecorder.Header().Get("Content-Disposition"))
+		})
+	}
+}
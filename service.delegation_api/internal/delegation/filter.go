@@ -0,0 +1,136 @@
+package delegation
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+)
+
+// filterFields are the delegation fields the delegations can be filtered on, with their supported operators,
+// TzKT style: a field parameter filters on equality, a field.operator parameter on the operator.
+var filterFields = map[string][]string{
+	"delegator": nil,
+	"baker":     nil,
+	"block":     nil,
+	"level":     nil,
+	"kind":      nil,
+	"status":    nil,
+	"amount":    {"gt", "lt"},
+	"timestamp": {"ge", "lt"},
+}
+
+// FilterParams are the query parameters of the delegations filter, operator filters by their full name.
+var FilterParams = []string{
+	"year", "from", "to", "delegator", "baker", "block", "level", "kind", "status",
+	"amount.gt", "amount.lt", "timestamp.ge", "timestamp.lt",
+}
+
+// ParseFilter parses the delegations filter query parameters: year, from and to, and the operator filters
+// delegator, baker, block, level, kind, status, amount.gt, amount.lt, timestamp.ge (an alias of from) and
+// timestamp.lt (an alias of to).
+//
+//nolint:funlen,cyclop
+func ParseFilter(query url.Values) (datastore.Filter, error) {
+	for name := range query {
+		field, operator, found := strings.Cut(name, ".")
+
+		operators, isField := filterFields[field]
+		if !isField || !found {
+			continue
+		}
+
+		supported := false
+
+		for _, o := range operators {
+			supported = supported || o == operator
+		}
+
+		if !supported {
+			return datastore.Filter{}, param.Errorf(
+				name,
+				"unsupported filter operator %s for query parameter %s",
+				operator,
+				field,
+			)
+		}
+	}
+
+	year, err := param.Year("year", query.Get("year"))
+	if err != nil {
+		return datastore.Filter{}, err
+	}
+
+	from, err := timeParam(query, "from", "timestamp.ge")
+	if err != nil {
+		return datastore.Filter{}, err
+	}
+
+	to, err := timeParam(query, "to", "timestamp.lt")
+	if err != nil {
+		return datastore.Filter{}, err
+	}
+
+	filter := datastore.Filter{
+		Year:      year,
+		From:      from,
+		To:        to,
+		Delegator: query.Get("delegator"),
+		Baker:     query.Get("baker"),
+		Block:     query.Get("block"),
+	}
+
+	level, err := param.Int64("level", query.Get("level"))
+	if err != nil {
+		return datastore.Filter{}, err
+	}
+
+	if level != nil {
+		filter.Level = *level
+	}
+
+	filter.AmountGt, err = param.Int64("amount.gt", query.Get("amount.gt"))
+	if err != nil {
+		return datastore.Filter{}, err
+	}
+
+	filter.AmountLt, err = param.Int64("amount.lt", query.Get("amount.lt"))
+	if err != nil {
+		return datastore.Filter{}, err
+	}
+
+	filter.Kind, err = datastore.ParseDelegationKind(query.Get("kind"))
+	if err != nil {
+		return datastore.Filter{}, param.Errorf(
+			"kind",
+			"invalid kind %s, expected delegation, redelegation or undelegation",
+			query.Get("kind"),
+		)
+	}
+
+	filter.Status, err = datastore.ParseDelegationStatus(query.Get("status"))
+	if err != nil {
+		return datastore.Filter{}, param.Errorf(
+			"status",
+			"invalid status %s, expected applied, failed, backtracked or skipped",
+			query.Get("status"),
+		)
+	}
+
+	return filter, nil
+}
+
+// timeParam parses a timestamp query parameter which can be given under two names, but not both.
+func timeParam(query url.Values, name, alias string) (time.Time, error) {
+	if query.Has(name) && query.Has(alias) {
+		return time.Time{}, param.Errorf(alias, "query parameters %s and %s are exclusive", name, alias)
+	}
+
+	if query.Has(alias) {
+		return param.Time(alias, query.Get(alias))
+	}
+
+	return param.Time(name, query.Get(name))
+}
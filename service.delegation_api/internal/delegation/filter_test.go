@@ -0,0 +1,164 @@
+package delegation_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+func TestDelegation_GetDelegationsHandler_Filters(t *testing.T) {
+	t.Parallel()
+
+	amountGt, amountLt := int64(100), int64(1000)
+
+	cases := []struct {
+		name string
+		url  string
+		want datastore.Filter
+	}{
+		{
+			name: "Success fields",
+			url:  "/xtz/delegations?delegator=tz1delegator&baker=tz1baker&block=block1&level=3000001",
+			want: datastore.Filter{Delegator: "tz1delegator", Baker: "tz1baker", Block: "block1", Level: 3000001},
+		},
+		{
+			name: "Success amount range",
+			url:  "/xtz/delegations?amount.gt=100&amount.lt=1000",
+			want: datastore.Filter{AmountGt: &amountGt, AmountLt: &amountLt},
+		},
+		{
+			name: "Success timestamp range",
+			url:  "/xtz/delegations?timestamp.ge=2023-01-01T00:00:00Z&timestamp.lt=2023-02-01T00:00:00Z",
+			want: datastore.Filter{
+				From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Success kind and status",
+			url:  "/xtz/delegations?year=2023&kind=redelegation&status=applied",
+			want: datastore.Filter{Year: 2023, Kind: model.KindRedelegation, Status: model.StatusApplied},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			expectIterateDelegations(
+				ut,
+				c.want,
+				datastore.Page{Number: 1, Size: 100},
+				[]*model.Delegation{},
+			)
+			ut.mockDatastore.EXPECT().GetDelegationsCount(gomock.Any(), gomock.Eq(c.want)).Return(0, nil)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsHandler_InvalidFilters(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		url       string
+		wantErr   string
+		wantParam string
+	}{
+		{
+			name:      "Error amount not an integer",
+			url:       "/xtz/delegations?amount.gt=abc",
+			wantErr:   "couldn't parse value abc for query parameter amount.gt",
+			wantParam: "amount.gt",
+		},
+		{
+			name:      "Error level not an integer",
+			url:       "/xtz/delegations?level=abc",
+			wantErr:   "couldn't parse value abc for query parameter level",
+			wantParam: "level",
+		},
+		{
+			name:      "Error unsupported operator",
+			url:       "/xtz/delegations?amount.ge=100",
+			wantErr:   "unsupported filter operator ge for query parameter amount",
+			wantParam: "amount.ge",
+		},
+		{
+			name:      "Error operator on an equality field",
+			url:       "/xtz/delegations?baker.in=tz1baker",
+			wantErr:   "unsupported filter operator in for query parameter baker",
+			wantParam: "baker.in",
+		},
+		{
+			name:      "Error from and timestamp.ge",
+			url:       "/xtz/delegations?from=2023-01-01T00:00:00Z&timestamp.ge=2023-01-01T00:00:00Z",
+			wantErr:   "query parameters from and timestamp.ge are exclusive",
+			wantParam: "timestamp.ge",
+		},
+		{
+			name:      "Error invalid timestamp",
+			url:       "/xtz/delegations?timestamp.lt=2023-01-01",
+			wantErr:   "couldn't parse value 2023-01-01 for query parameter timestamp.lt, expected RFC3339 format",
+			wantParam: "timestamp.lt",
+		},
+		{
+			name:      "Error invalid kind",
+			url:       "/xtz/delegations?kind=transfer",
+			wantErr:   "invalid kind transfer, expected delegation, redelegation or undelegation",
+			wantParam: "kind",
+		},
+		{
+			name:      "Error invalid status",
+			url:       "/xtz/delegations?status=pending",
+			wantErr:   "invalid status pending, expected applied, failed, backtracked or skipped",
+			wantParam: "status",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+
+			var got problem.Problem
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &got)
+			require.NoError(t, err, "Error parsing problem response")
+			assert.Equal(t, problem.CodeInvalidParameter, got.Code)
+			assert.Equal(t, c.wantErr, got.Detail)
+			assert.Equal(t, c.wantParam, got.Param)
+		})
+	}
+}
@@ -1,136 +1,517 @@
 package delegation
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
 )
 
+// ListParams are the query parameters of the delegations list endpoints.
+var ListParams = append([]string{"sort", "select", "page", "size", "cursor"}, FilterParams...)
+
 // APIHandler handles the API requests.
 type APIHandler struct {
 	datastore datastore.Datastorer
+	// maxPageSize is the maximum size of a delegations page.
+	maxPageSize int
 }
 
 // New creates a new APIHandler.
-func New(datastore datastore.Datastorer) *APIHandler {
+func New(datastore datastore.Datastorer, maxPageSize int) *APIHandler {
 	return &APIHandler{
-		datastore: datastore,
+		datastore:   datastore,
+		maxPageSize: maxPageSize,
 	}
 }
 
-// GetDelegationsHandler handles /xtz/delegations endpoint.
+// GetDelegationsHandler handles the legacy /xtz/delegations endpoint.
+//
+// Delegations are filtered with year, from and to parameters, and TzKT style operator filters (see ParseFilter).
+// They are sorted by timestamp descending, unless a sort parameter sorts them by timestamp, amount or level
+// (prefixed by - for a descending order), and a select parameter lists the returned fields.
+//
+// Delegations are paginated either with page and size parameters (the response body is the delegations array),
+// or with an opaque cursor parameter, empty for the first page (the response body is an object with the
+// delegations and the next cursor). In both cases the next page is returned in a Link header, and the number
+// of pages in a X-Total-Pages header. Cursors require the default sort. The size is at most the configured
+// maximum page size.
 //
-//nolint:funlen
+// Pages of numbers are streamed from the datastore to the response, so the memory used doesn't grow with the
+// page size, the next page number sent in the Link header being known from the total count. The next cursor
+// being known from the end of the page only, cursor pages are read once in a buffer, at most the maximum page
+// size, before the Link header is sent.
 func (a *APIHandler) GetDelegationsHandler(w http.ResponseWriter, r *http.Request) {
-	yearParam := r.URL.Query().Get("year")
+	query, ok := a.parseDelegationsQuery(w, r)
+	if !ok {
+		return
+	}
 
-	year, err := parseParamInt("year", yearParam)
-	if err != nil {
-		zap.L().Error("error parsing year parameter", zap.Error(err))
-		http.Error(w, fmt.Sprintf("Bad Request: %s", err), http.StatusBadRequest)
+	pagination, ok := a.paginate(w, r, query)
+	if !ok {
+		return
+	}
+
+	if query.cursorMode {
+		a.getDelegationsCursorPage(w, r, query, pagination)
 
 		return
 	}
 
-	pageParam := r.URL.Query().Get("page")
+	if query.page.Number*query.page.Size < pagination.TotalCount {
+		pagination.next = nextPageURL(r.URL, query.page.Number+1)
+	}
+
+	setPageHeaders(w, pagination)
 
-	pageNumber, err := parseParamInt("page", pageParam)
+	err := a.streamDelegations(r, w, query, "[", func(*model.Delegation, bool) (string, error) {
+		return "]", nil
+	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Bad Request: %s", err), http.StatusBadRequest)
+		zap.L().Error("couldn't stream delegations from datastore", zap.Error(err))
+	}
+}
 
-		return
+// getDelegationsCursorPage writes a legacy cursor page, an object with the delegations and the next cursor.
+func (a *APIHandler) getDelegationsCursorPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	query delegationsQuery,
+	pagination *pagination,
+) {
+	var body bytes.Buffer
+
+	buffer := bufio.NewWriter(&body)
+
+	suffix := func(last *model.Delegation, more bool) (string, error) {
+		pagination.setNext(r.URL, query, last, more)
+
+		if pagination.NextCursor != "" {
+			return fmt.Sprintf(`],"next":%q}`, pagination.NextCursor), nil
+		}
+
+		return "]}", nil
 	}
 
-	size := r.URL.Query().Get("size")
+	err := a.writeDelegations(r, buffer, query, `{"delegations":[`, suffix)
+	if err == nil {
+		err = buffer.Flush()
+	}
 
-	pageSize, err := parseParamInt("size", size)
 	if err != nil {
-		zap.L().Error("error parsing size parameter", zap.Error(err))
-		http.Error(w, fmt.Sprintf("Bad Request: %s", err), http.StatusBadRequest)
+		zap.L().Error("couldn't get delegations from datastore", zap.Error(err))
+		problem.InternalError(w)
 
 		return
 	}
 
-	// Ensure default values for pageNumber and pageSize
-	if pageNumber <= 0 {
-		pageNumber = 1
+	setPageHeaders(w, pagination)
+
+	if _, err := body.WriteTo(w); err != nil {
+		zap.L().Error("couldn't write delegations response", zap.Error(err))
 	}
+}
 
-	if pageSize <= 0 {
-		pageSize = 100
+// setPageHeaders sets the Link header to the next page, the X-Total-Pages header and the JSON content type of
+// a legacy delegations page.
+func setPageHeaders(w http.ResponseWriter, pagination *pagination) {
+	if pagination.next != "" {
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, pagination.next))
 	}
 
-	delegations, err := a.datastore.GetDelegations(
-		r.Context(),
-		pageNumber,
-		pageSize,
-		year,
-	)
-	if err != nil {
-		zap.L().Error("couldn't get delegations from datastore", zap.Error(err))
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	w.Header().Set("X-Total-Pages", strconv.Itoa(pagination.TotalPages))
+	w.Header().Set("Content-Type", "application/json")
+}
+
+// GetDelegationsV1Handler handles /v1/delegations endpoint.
+//
+// It takes the parameters of GetDelegationsHandler, the response body being an envelope with the delegations
+// as data, the pagination (total count and pages, page number and size, next cursor) and the links to this
+// page and the next one. The pagination and links are written after the streamed delegations, the next page
+// following the last one.
+func (a *APIHandler) GetDelegationsV1Handler(w http.ResponseWriter, r *http.Request) {
+	query, ok := a.parseDelegationsQuery(w, r)
+	if !ok {
+		return
+	}
 
+	page, ok := a.paginate(w, r, query)
+	if !ok {
 		return
 	}
 
-	responseJSON, err := json.Marshal(delegations)
+	w.Header().Set("Content-Type", "application/json")
+
+	err := a.streamDelegations(r, w, query, `{"data":[`, func(last *model.Delegation, more bool) (string, error) {
+		page.setNext(r.URL, query, last, more)
+
+		// the page number is meaningless when paginating with cursors
+		if query.cursorMode {
+			page.Page = 0
+		}
+
+		self := url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+
+		suffix, err := json.Marshal(struct {
+			Pagination *pagination `json:"pagination"`
+			Links      links       `json:"links"`
+		}{
+			Pagination: page,
+			Links:      links{Self: self.String(), Next: page.next},
+		})
+
+		return "]," + string(suffix[1:]), err
+	})
 	if err != nil {
-		zap.L().Error("error marshalling delegations to JSON", zap.Error(err))
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		zap.L().Error("couldn't stream delegations from datastore", zap.Error(err))
+	}
+}
 
-		return
+// delegationsQuery is a parsed delegations list request.
+type delegationsQuery struct {
+	filter     datastore.Filter
+	sort       datastore.DelegationsSort
+	projection datastore.Projection
+	page       datastore.Page
+	// cursorMode is true when paginating with cursors
+	cursorMode bool
+}
+
+// pagination is the pagination of a delegations page.
+type pagination struct {
+	TotalCount int    `json:"totalCount"`
+	TotalPages int    `json:"totalPages"`
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	// next is the URL of the next page, empty for the last page
+	next string
+}
+
+// links are the links of a delegations page.
+type links struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+}
+
+// lookahead returns the page of the query, reading one more delegation telling whether more delegations follow.
+func (q delegationsQuery) lookahead() datastore.Page {
+	page := q.page
+	page.Lookahead = true
+
+	return page
+}
+
+// parseDelegationsQuery parses the delegations list request parameters. The request is answered with a bad
+// request error when they are invalid.
+func (a *APIHandler) parseDelegationsQuery(w http.ResponseWriter, r *http.Request) (delegationsQuery, bool) {
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		zap.L().Error("error parsing filter parameters", zap.Error(err))
+		problem.BadRequest(w, err)
+
+		return delegationsQuery{}, false
+	}
+
+	sort, projection, err := parseSortSelect(r.URL.Query())
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return delegationsQuery{}, false
+	}
+
+	page, err := param.Page(r.URL.Query(), param.DefaultPageSize, a.maxPageSize)
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return delegationsQuery{}, false
+	}
+
+	query := delegationsQuery{
+		filter:     filter,
+		sort:       sort,
+		projection: projection,
+		page:       page,
+		cursorMode: r.URL.Query().Has("cursor"),
+	}
+
+	if query.cursorMode && !sort.IsDefault() {
+		problem.BadRequest(w, param.Errorf("cursor", "%s", datastore.ErrCursorSort))
+
+		return delegationsQuery{}, false
 	}
 
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		query.page.After, err = datastore.DecodeCursor(token)
+		if err != nil {
+			zap.L().Error("error decoding cursor parameter", zap.String("cursor", token), zap.Error(err))
+			problem.BadRequest(
+				w,
+				param.Errorf("cursor", "couldn't decode value %s for query parameter cursor", token),
+			)
+
+			return delegationsQuery{}, false
+		}
+	}
+
+	return query, true
+}
+
+// paginate returns the pagination of a delegations page, without its next page. The request is answered with
+// an internal server error when the datastore fails.
+func (a *APIHandler) paginate(w http.ResponseWriter, r *http.Request, query delegationsQuery) (*pagination, bool) {
 	// Calculate the maximum number of pages based on the total number of documents and page size
-	totalDocuments, err := a.datastore.GetDelegationsCount(r.Context(), year)
+	totalDocuments, err := a.datastore.GetDelegationsCount(r.Context(), query.filter)
 	if err != nil {
 		zap.L().Error("couldn't get delegations count from datastore", zap.Error(err))
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		problem.InternalError(w)
 
-		return
+		return nil, false
 	}
 
-	maxPages := totalDocuments / pageSize
-	if totalDocuments%pageSize != 0 {
+	maxPages := totalDocuments / query.page.Size
+	if totalDocuments%query.page.Size != 0 {
 		maxPages++
 	}
 
-	w.Header().Set("X-Total-Pages", strconv.Itoa(maxPages))
+	return &pagination{
+		TotalCount: totalDocuments,
+		TotalPages: maxPages,
+		Page:       query.page.Number,
+		Size:       query.page.Size,
+	}, true
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+// setNext sets the next page of a pagination, after the last delegation of a page when more delegations follow.
+// The next page is positioned by a cursor on the timestamp and id of the last delegation with the default sort,
+// by its number otherwise.
+func (p *pagination) setNext(current *url.URL, query delegationsQuery, last *model.Delegation, more bool) {
+	switch {
+	case !more:
+	case query.sort.IsDefault():
+		p.NextCursor = datastore.NewCursor(last).Encode()
+		p.next = nextCursorURL(current, p.NextCursor)
+	default:
+		p.next = nextPageURL(current, query.page.Number+1)
+	}
+}
+
+// streamDelegations writes the JSON array of a page of delegations, or of their selected fields, between a
+// prefix and a suffix (see writeDelegations). An error before the response started is returned as an internal
+// server error, after it aborts the response, so that a truncated page isn't mistaken for a complete one.
+func (a *APIHandler) streamDelegations(
+	r *http.Request,
+	w http.ResponseWriter,
+	query delegationsQuery,
+	prefix string,
+	suffix func(last *model.Delegation, more bool) (string, error),
+) error {
+	body := &responseBody{Writer: w}
+	buffer := bufio.NewWriter(body)
+
+	err := a.writeDelegations(r, buffer, query, prefix, suffix)
+	if err == nil {
+		err = buffer.Flush()
+	}
+
+	if err != nil && !body.started {
+		w.Header().Del("Link")
+		problem.InternalError(w)
+
+		return err
+	}
 
-	_, err = w.Write(responseJSON)
 	if err != nil {
-		zap.L().Error("error writing JSON response", zap.Error(err))
+		zap.L().Error("aborting delegations response", zap.Error(err))
+		panic(http.ErrAbortHandler)
 	}
+
+	return nil
 }
 
-func parseParamInt(paramName, paramValue string) (int, error) {
-	if paramValue == "" {
-		return 0, nil
+// writeDelegations writes the JSON array of a page of delegations, or of their selected fields, between a
+// prefix and a suffix, as they are read from the datastore. The suffix is given the last written delegation,
+// with at least its timestamp and id, and whether more delegations follow it.
+func (a *APIHandler) writeDelegations(
+	r *http.Request,
+	buffer *bufio.Writer,
+	query delegationsQuery,
+	prefix string,
+	suffix func(last *model.Delegation, more bool) (string, error),
+) error {
+	encoder := json.NewEncoder(buffer)
+
+	if _, err := buffer.WriteString(prefix); err != nil {
+		return err
+	}
+
+	var (
+		count int
+		last  *model.Delegation
+	)
+
+	err := a.datastore.IterateDelegations(
+		r.Context(),
+		query.filter,
+		query.sort,
+		query.projection.With(datastore.FieldTimestamp, datastore.FieldID),
+		query.lookahead(),
+		func(delegation *model.Delegation) error {
+			count++
+
+			// the lookahead delegation is only read
+			if count > query.page.Size {
+				return nil
+			}
+
+			if count > 1 {
+				if err := buffer.WriteByte(','); err != nil {
+					return err
+				}
+			}
+
+			last = delegation
+
+			if query.projection != nil {
+				return encoder.Encode(selectFields(delegation, query.projection))
+			}
+
+			return encoder.Encode(delegation)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	end, err := suffix(last, count > query.page.Size)
+	if err != nil {
+		return err
 	}
 
-	parsedValue, err := strconv.Atoi(paramValue)
+	_, err = buffer.WriteString(end)
+
+	return err
+}
+
+// responseBody is the body of a streamed response, recording whether it started being written.
+type responseBody struct {
+	io.Writer
+	started bool
+}
+
+func (b *responseBody) Write(p []byte) (int, error) {
+	b.started = true
+
+	return b.Writer.Write(p)
+}
+
+// parseSortSelect parses the delegations sort and select query parameters.
+func parseSortSelect(query url.Values) (datastore.DelegationsSort, datastore.Projection, error) {
+	sort, err := datastore.ParseDelegationsSort(query.Get("sort"))
 	if err != nil {
-		zap.L().Error(
-			"couldn't parse query parameter value",
-			zap.String("paramName", paramName),
-			zap.String("paramValue", paramValue),
-			zap.Error(err),
+		return datastore.DelegationsSort{}, nil, param.Errorf(
+			"sort",
+			"invalid sort %s, expected timestamp, amount or level, prefixed by - for a descending order",
+			query.Get("sort"),
 		)
+	}
 
-		return 0, fmt.Errorf(
-			"couldn't parse value %s for query parameter %s",
-			paramName,
-			paramValue,
+	projection, err := datastore.ParseProjection(query.Get("select"))
+	if err != nil {
+		return datastore.DelegationsSort{}, nil, param.Errorf(
+			"select",
+			"invalid select %s, expected a comma separated list of %s",
+			query.Get("select"),
+			strings.Join(datastore.Fields, ", "),
 		)
 	}
 
-	return parsedValue, nil
+	return sort, projection, nil
+}
+
+// nextCursorURL returns the URL of the page after the next cursor.
+func nextCursorURL(current *url.URL, next string) string {
+	query := current.Query()
+	query.Del("page")
+	query.Set("cursor", next)
+
+	link := url.URL{
+		Path:     current.Path,
+		RawQuery: query.Encode(),
+	}
+
+	return link.String()
+}
+
+// nextPageURL returns the URL of the next page number.
+func nextPageURL(current *url.URL, next int) string {
+	query := current.Query()
+	query.Set("page", strconv.Itoa(next))
+
+	link := url.URL{
+		Path:     current.Path,
+		RawQuery: query.Encode(),
+	}
+
+	return link.String()
+}
+
+// fieldKeys are the JSON keys of the delegation fields, as marshalled from model.Delegation.
+var fieldKeys = map[string]string{
+	datastore.FieldID:            "id",
+	datastore.FieldTimestamp:     "Timestamp",
+	datastore.FieldAmount:        "amount",
+	datastore.FieldDelegator:     "delegator",
+	datastore.FieldBlock:         "block",
+	datastore.FieldLevel:         "level",
+	datastore.FieldBaker:         "baker",
+	datastore.FieldPreviousBaker: "previousBaker",
+}
+
+// selectFields returns the selected fields of a delegation, with the JSON keys of model.Delegation.
+// Selected fields are always returned, even when empty.
+func selectFields(delegation *model.Delegation, projection datastore.Projection) map[string]any {
+	fields := make(map[string]any, len(projection))
+
+	for _, field := range projection {
+		fields[fieldKeys[field]] = fieldValue(delegation, field)
+	}
+
+	return fields
+}
+
+// fieldValue returns the value of a delegation field.
+func fieldValue(delegation *model.Delegation, field string) any {
+	switch field {
+	case datastore.FieldID:
+		return delegation.ID
+	case datastore.FieldTimestamp:
+		return delegation.Timestamp
+	case datastore.FieldAmount:
+		return delegation.Amount
+	case datastore.FieldDelegator:
+		return delegation.Delegator
+	case datastore.FieldBlock:
+		return delegation.Block
+	case datastore.FieldLevel:
+		return delegation.Level
+	case datastore.FieldBaker:
+		return delegation.Baker
+	case datastore.FieldPreviousBaker:
+		return delegation.PreviousBaker
+	}
+
+	return nil
 }
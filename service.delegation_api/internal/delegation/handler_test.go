@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,11 +14,17 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
 	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
 	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
 	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegation"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
 )
 
+// maxPageSize is the maximum page size of the handlers under test.
+const maxPageSize = 1000
+
 type underTest struct {
 	mockCtrl      *gomock.Controller
 	mockDatastore *datastoremock.MockDatastorer
@@ -33,7 +40,7 @@ func setupTest(t *testing.T) *underTest {
 
 	ut.mockDatastore = datastoremock.NewMockDatastorer(ut.mockCtrl)
 
-	ut.apiHandler = delegation.New(ut.mockDatastore)
+	ut.apiHandler = delegation.New(ut.mockDatastore, maxPageSize)
 
 	return ut
 }
@@ -51,7 +58,7 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 		request        func() *http.Request
 		init           func(*underTest)
 		want           []*model.Delegation
-		wantErr        error
+		wantErr        string
 		wantStatusCode int
 	}{
 		{
@@ -69,29 +76,29 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 				return req
 			},
 			init: func(ut *underTest) {
-				ut.mockDatastore.EXPECT().GetDelegations(
-					gomock.Any(),
-					gomock.Eq(1),
-					gomock.Eq(100),
-					gomock.Eq(0),
-				).Return([]*model.Delegation{
-					{
-						Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
-						Amount:    57800,
-						Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
-						Block:     "123456",
-					},
-					{
-						Timestamp: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
-						Amount:    157800,
-						Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
-						Block:     "56897",
+				expectIterateDelegations(
+					ut,
+					datastore.Filter{},
+					datastore.Page{Number: 1, Size: 100},
+					[]*model.Delegation{
+						{
+							Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+							Amount:    57800,
+							Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+							Block:     "123456",
+						},
+						{
+							Timestamp: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+							Amount:    157800,
+							Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
+							Block:     "56897",
+						},
 					},
-				}, nil)
+				)
 
 				ut.mockDatastore.EXPECT().GetDelegationsCount(
 					gomock.Any(),
-					gomock.Eq(0),
+					gomock.Eq(datastore.Filter{}),
 				).Return(2, nil)
 			},
 			want: []*model.Delegation{
@@ -120,16 +127,16 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 				return req
 			},
 			init: func(ut *underTest) {
-				ut.mockDatastore.EXPECT().GetDelegations(
-					gomock.Any(),
-					gomock.Eq(1),
-					gomock.Eq(100),
-					gomock.Eq(0),
-				).Return([]*model.Delegation{}, nil)
+				expectIterateDelegations(
+					ut,
+					datastore.Filter{},
+					datastore.Page{Number: 1, Size: 100},
+					[]*model.Delegation{},
+				)
 
 				ut.mockDatastore.EXPECT().GetDelegationsCount(
 					gomock.Any(),
-					gomock.Eq(0),
+					gomock.Eq(datastore.Filter{}),
 				).Return(0, nil)
 			},
 			want:           []*model.Delegation{},
@@ -145,29 +152,29 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 				return req
 			},
 			init: func(ut *underTest) {
-				ut.mockDatastore.EXPECT().GetDelegations(
-					gomock.Any(),
-					gomock.Eq(1),
-					gomock.Eq(100),
-					gomock.Eq(2020),
-				).Return([]*model.Delegation{
-					{
-						Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
-						Amount:    57800,
-						Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
-						Block:     "123456",
-					},
-					{
-						Timestamp: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
-						Amount:    157800,
-						Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
-						Block:     "56897",
+				expectIterateDelegations(
+					ut,
+					datastore.Filter{Year: 2020},
+					datastore.Page{Number: 1, Size: 100},
+					[]*model.Delegation{
+						{
+							Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+							Amount:    57800,
+							Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+							Block:     "123456",
+						},
+						{
+							Timestamp: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+							Amount:    157800,
+							Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
+							Block:     "56897",
+						},
 					},
-				}, nil)
+				)
 
 				ut.mockDatastore.EXPECT().GetDelegationsCount(
 					gomock.Any(),
-					gomock.Eq(2020),
+					gomock.Eq(datastore.Filter{Year: 2020}),
 				).Return(2, nil)
 			},
 			want: []*model.Delegation{
@@ -186,6 +193,68 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "Success with from and to parameters",
+			request: func() *http.Request {
+				req, err := http.NewRequestWithContext(
+					context.Background(),
+					http.MethodGet,
+					"/delegations?from=2020-01-01T00:00:00Z&to=2020-02-01T00:00:00%2B01:00",
+					nil,
+				)
+				require.NoError(t, err, "Error creating request")
+
+				return req
+			},
+			init: func(ut *underTest) {
+				filter := datastore.Filter{
+					From: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2020, 2, 1, 0, 0, 0, 0, time.FixedZone("", 60*60)),
+				}
+
+				expectIterateDelegations(
+					ut,
+					filter,
+					datastore.Page{Number: 1, Size: 100},
+					[]*model.Delegation{
+						{
+							Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+							Amount:    57800,
+							Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+							Block:     "123456",
+						},
+					},
+				)
+
+				ut.mockDatastore.EXPECT().GetDelegationsCount(
+					gomock.Any(),
+					gomock.Eq(filter),
+				).Return(1, nil)
+			},
+			want: []*model.Delegation{
+				{
+					Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+					Amount:    57800,
+					Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+					Block:     "123456",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Error from parameter",
+			request: func() *http.Request {
+				req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/delegations?from=2020-01-01", nil)
+				require.NoError(t, err, "Error creating request")
+
+				return req
+			},
+			init: func(ut *underTest) {},
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"from",` +
+				`"detail":"couldn't parse value 2020-01-01 for query parameter from, expected RFC3339 format"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
 		{
 			name: "Error year parameter",
 			request: func() *http.Request {
@@ -194,8 +263,10 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 
 				return req
 			},
-			init:           func(ut *underTest) {},
-			wantErr:        errors.New("Bad Request: couldn't parse value year for query parameter deux\n"), //nolint:revive
+			init: func(ut *underTest) {},
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"year",` +
+				`"range":{"min":2018,"max":9999},"detail":"couldn't parse value deux for query parameter year"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
@@ -208,14 +279,22 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 				return req
 			},
 			init: func(ut *underTest) {
-				ut.mockDatastore.EXPECT().GetDelegations(
+				ut.mockDatastore.EXPECT().GetDelegationsCount(
+					gomock.Any(),
+					gomock.Eq(datastore.Filter{}),
+				).Return(2, nil)
+
+				ut.mockDatastore.EXPECT().IterateDelegations(
+					gomock.Any(),
+					gomock.Eq(datastore.Filter{}),
+					gomock.Eq(datastore.DelegationsSort{}),
+					gomock.Nil(),
+					gomock.Eq(datastore.Page{Number: 1, Size: 100, Lookahead: true}),
 					gomock.Any(),
-					gomock.Eq(1),
-					gomock.Eq(100),
-					gomock.Eq(0),
-				).Return(nil, errGetDelegations)
+				).Return(errGetDelegations)
 			},
-			wantErr:        errors.New("Internal Server Error\n"), //nolint:revive
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
 			wantStatusCode: http.StatusInternalServerError,
 		},
 		{
@@ -228,32 +307,13 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 				return req
 			},
 			init: func(ut *underTest) {
-				ut.mockDatastore.EXPECT().GetDelegations(
-					gomock.Any(),
-					gomock.Eq(1),
-					gomock.Eq(100),
-					gomock.Eq(0),
-				).Return([]*model.Delegation{
-					{
-						Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
-						Amount:    57800,
-						Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
-						Block:     "123456",
-					},
-					{
-						Timestamp: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
-						Amount:    157800,
-						Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
-						Block:     "56897",
-					},
-				}, nil)
-
 				ut.mockDatastore.EXPECT().GetDelegationsCount(
 					gomock.Any(),
-					gomock.Eq(0),
+					gomock.Eq(datastore.Filter{}),
 				).Return(0, errCountDelegations)
 			},
-			wantErr:        errors.New("Internal Server Error\n"), //nolint:revive
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
 			wantStatusCode: http.StatusInternalServerError,
 		},
 	}
@@ -273,8 +333,8 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 			// Check the status code
 			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
 
-			if c.wantErr != nil {
-				assert.Equal(t, c.wantErr.Error(), responseRecorder.Body.String())
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
 			} else {
 				// Parse the JSON response
 				var result []*model.Delegation
@@ -285,3 +345,673 @@ func TestDelegation_GetDelegationsHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestDelegation_GetDelegationsHandler_MemoryDatastore(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID:        1,
+			Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			Amount:    57800,
+			Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+			Block:     "123456",
+		},
+		{
+			ID:        2,
+			Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+			Amount:    157800,
+			Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
+			Block:     "56897",
+		},
+		{
+			ID:        3,
+			Timestamp: time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC),
+			Amount:    257800,
+			Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK8",
+			Block:     "78910",
+		},
+	}))
+
+	apiHandler := delegation.New(datastore, maxPageSize)
+
+	cases := []struct {
+		name           string
+		url            string
+		want           []*model.Delegation
+		wantTotalPages string
+	}{
+		{
+			name: "Success first page",
+			url:  "/delegations?page=1&size=2",
+			want: []*model.Delegation{
+				{
+					ID:        3,
+					Timestamp: time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC),
+					Amount:    257800,
+					Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK8",
+					Block:     "78910",
+				},
+				{
+					ID:        2,
+					Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+					Amount:    157800,
+					Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK7",
+					Block:     "56897",
+				},
+			},
+			wantTotalPages: "2",
+		},
+		{
+			name: "Success last page",
+			url:  "/delegations?page=2&size=2",
+			want: []*model.Delegation{
+				{
+					ID:        1,
+					Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					Amount:    57800,
+					Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+					Block:     "123456",
+				},
+			},
+			wantTotalPages: "2",
+		},
+		{
+			name: "Success with year parameter",
+			url:  "/delegations?year=2021",
+			want: []*model.Delegation{
+				{
+					ID:        1,
+					Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					Amount:    57800,
+					Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+					Block:     "123456",
+				},
+			},
+			wantTotalPages: "1",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.Equal(t, c.wantTotalPages, responseRecorder.Header().Get("X-Total-Pages"))
+
+			var result []*model.Delegation
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsHandler_Cursor(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+
+	var want []*model.Delegation
+
+	for i := 5; i > 0; i-- {
+		want = append(want, &model.Delegation{
+			ID:        int64(i),
+			Timestamp: time.Date(2022, 1, i, 0, 0, 0, 0, time.UTC),
+			Amount:    int64(i * 1000),
+			Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+			Block:     "123456",
+		})
+	}
+
+	require.NoError(t, datastore.StoreDelegations(context.Background(), want))
+
+	apiHandler := delegation.New(datastore, maxPageSize)
+
+	var (
+		got   []*model.Delegation
+		pages int
+	)
+
+	// follow the next cursors from the first page, until the last page
+	url := "/xtz/delegations?year=2022&size=2&cursor="
+	for url != "" {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+		require.NoError(t, err, "Error creating request")
+
+		responseRecorder := httptest.NewRecorder()
+		apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+		require.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		var page struct {
+			Delegations []*model.Delegation `json:"delegations"`
+			Next        string              `json:"next"`
+		}
+		require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &page), "Error parsing JSON response")
+
+		got = append(got, page.Delegations...)
+		pages++
+
+		url = ""
+
+		if page.Next != "" {
+			url = "/xtz/delegations?year=2022&size=2&cursor=" + page.Next
+			assert.Equal(
+				t,
+				`</xtz/delegations?cursor=`+page.Next+`&size=2&year=2022>; rel="next"`,
+				responseRecorder.Header().Get("Link"),
+			)
+		} else {
+			assert.Empty(t, responseRecorder.Header().Get("Link"))
+		}
+	}
+
+	assert.Equal(t, want, got)
+	assert.Equal(t, 3, pages)
+}
+
+func TestDelegation_GetDelegationsHandler_InvalidCursor(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/xtz/delegations?cursor=invalid", nil)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder := httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter","param":"cursor",`+
+			`"detail":"couldn't decode value invalid for query parameter cursor"}`,
+		responseRecorder.Body.String(),
+	)
+}
+
+func TestDelegation_GetDelegationsHandler_SortSelect(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{
+			ID: 1, Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 57800,
+			Delegator: "tz1delegator1", Block: "block1", Level: 1, Baker: "tz1baker",
+		},
+		{
+			ID: 2, Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 257800,
+			Delegator: "tz1delegator2", Block: "block2", Level: 2, Baker: "tz1baker",
+		},
+		{
+			ID: 3, Timestamp: time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC), Amount: 157800,
+			Delegator: "tz1delegator3", Block: "block3", Level: 3,
+		},
+	}))
+
+	apiHandler := delegation.New(datastore, maxPageSize)
+
+	cases := []struct {
+		name     string
+		url      string
+		want     string
+		wantLink string
+	}{
+		{
+			name:     "Success sorted by amount descending",
+			url:      "/xtz/delegations?sort=-amount&select=id,amount&size=2",
+			want:     `[{"id":2,"amount":257800},{"id":3,"amount":157800}]`,
+			wantLink: `</xtz/delegations?page=2&select=id%2Camount&size=2&sort=-amount>; rel="next"`,
+		},
+		{
+			name: "Success sorted by level ascending",
+			url:  "/xtz/delegations?sort=level&select=level,baker",
+			want: `[{"level":1,"baker":"tz1baker"},{"level":2,"baker":"tz1baker"},{"level":3,"baker":""}]`,
+		},
+		{
+			name: "Success selected timestamp",
+			url:  "/xtz/delegations?sort=timestamp&select=timestamp&page=2&size=2",
+			want: `[{"Timestamp":"2022-06-02T00:00:00Z"}]`,
+		},
+		{
+			name:     "Success default sort page",
+			url:      "/xtz/delegations?select=id&size=2",
+			want:     `[{"id":3},{"id":2}]`,
+			wantLink: `</xtz/delegations?page=2&select=id&size=2>; rel="next"`,
+		},
+		{
+			name:     "Success cursor with selected fields",
+			url:      "/xtz/delegations?select=delegator&size=1&cursor=",
+			want:     `{"delegations":[{"delegator":"tz1delegator3"}],"next":"MTY1NDEyODAwMDAwMDoz"}`,
+			wantLink: `</xtz/delegations?cursor=MTY1NDEyODAwMDAwMDoz&select=delegator&size=1>; rel="next"`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.JSONEq(t, c.want, responseRecorder.Body.String())
+			assert.Equal(t, c.wantLink, responseRecorder.Header().Get("Link"))
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsHandler_InvalidSortSelect(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{
+			name: "Error invalid sort",
+			url:  "/xtz/delegations?sort=-delegator",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"sort",` +
+				`"detail":"invalid sort -delegator, expected timestamp, amount or level, prefixed by - for a ` +
+				`descending order"}`,
+		},
+		{
+			name: "Error invalid select",
+			url:  "/xtz/delegations?select=id,status",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"select","detail":"invalid select id,status, expected a comma separated list of ` +
+				`id, timestamp, amount, delegator, block, level, baker, previousBaker"}`,
+		},
+		{
+			name: "Error cursor with a sort",
+			url:  "/xtz/delegations?sort=amount&cursor=",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"cursor","detail":"cursor pagination requires the default delegations sort"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+			assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsHandler_PageSize(t *testing.T) {
+	t.Parallel()
+
+	datastore := memory.New()
+	require.NoError(t, datastore.StoreDelegations(context.Background(), []*model.Delegation{
+		{ID: 1, Timestamp: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 1, Delegator: "tz1delegator1"},
+		{ID: 2, Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), Amount: 2, Delegator: "tz1delegator2"},
+		{ID: 3, Timestamp: time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), Amount: 3, Delegator: "tz1delegator3"},
+	}))
+
+	apiHandler := delegation.New(datastore, 2)
+
+	cases := []struct {
+		name           string
+		url            string
+		wantIDs        []int64
+		wantStatusCode int
+		wantErr        string
+	}{
+		{
+			name:           "Success default size capped by the maximum page size",
+			url:            "/xtz/delegations",
+			wantIDs:        []int64{3, 2},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Success maximum page size",
+			url:            "/xtz/delegations?page=2&size=2",
+			wantIDs:        []int64{1},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Error size over the maximum page size",
+			url:            "/xtz/delegations?size=3",
+			wantStatusCode: http.StatusBadRequest,
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"size","range":{"min":1,"max":2},` +
+				`"detail":"invalid value 3 for query parameter size, expected between 1 and 2"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			var result []*model.Delegation
+			require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &result))
+
+			ids := make([]int64, len(result))
+			for i, d := range result {
+				ids[i] = d.ID
+			}
+
+			assert.Equal(t, c.wantIDs, ids)
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsHandler_StreamError(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	// a cursor page is read once, before the Link header is sent, failing after some delegations
+
+	ut.mockDatastore.EXPECT().GetDelegationsCount(gomock.Any(), gomock.Eq(datastore.Filter{})).Return(2, nil)
+	ut.mockDatastore.EXPECT().IterateDelegations(
+		gomock.Any(),
+		gomock.Eq(datastore.Filter{}),
+		gomock.Eq(datastore.DelegationsSort{}),
+		gomock.Nil(),
+		gomock.Eq(datastore.Page{Number: 1, Size: 2, Lookahead: true}),
+		gomock.Any(),
+	).DoAndReturn(func(
+		_ context.Context,
+		_ datastore.Filter,
+		_ datastore.DelegationsSort,
+		_ datastore.Projection,
+		_ datastore.Page,
+		fn func(delegation *model.Delegation) error,
+	) error {
+		for id := int64(3); id > 1; id-- {
+			if err := fn(&model.Delegation{ID: id, Timestamp: time.Unix(id, 0).UTC()}); err != nil {
+				return err
+			}
+		}
+
+		return errGetDelegations
+	})
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/xtz/delegations?size=2&cursor=", nil)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder := httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsHandler(responseRecorder, req)
+
+	// nothing was written yet, so the error is returned instead of a truncated page
+	assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code)
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		responseRecorder.Body.String(),
+	)
+	assert.Empty(t, responseRecorder.Header().Get("Link"))
+}
+
+//nolint:funlen
+func TestDelegation_GetDelegationsV1Handler(t *testing.T) {
+	t.Parallel()
+
+	ds := memory.New()
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, ds.StoreDelegations(context.Background(), []*model.Delegation{{
+			ID:        int64(i),
+			Timestamp: time.Date(2022, 1, i, 0, 0, 0, 0, time.UTC),
+			Amount:    int64(i * 1000),
+			Delegator: "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+		}}))
+	}
+
+	next := datastore.NewCursor(&model.Delegation{
+		ID:        2,
+		Timestamp: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+	}).Encode()
+
+	apiHandler := delegation.New(ds, maxPageSize)
+
+	cases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "Success first page",
+			url:  "/v1/delegations?size=2&select=id",
+			want: `{
+				"pagination": {"totalCount": 3, "totalPages": 2, "page": 1, "size": 2, "nextCursor": "` + next + `"},
+				"links": {
+					"self": "/v1/delegations?size=2&select=id",
+					"next": "/v1/delegations?cursor=` + next + `&select=id&size=2"
+				},
+				"data": [{"id": 3}, {"id": 2}]
+			}`,
+		},
+		{
+			name: "Success last page with a cursor",
+			url:  "/v1/delegations?size=2&select=id&cursor=" + next,
+			want: `{
+				"pagination": {"totalCount": 3, "totalPages": 2, "size": 2},
+				"links": {"self": "/v1/delegations?size=2&select=id&cursor=` + next + `"},
+				"data": [{"id": 1}]
+			}`,
+		},
+		{
+			name: "Success sorted page",
+			url:  "/v1/delegations?sort=amount&size=2&select=id,amount",
+			want: `{
+				"pagination": {"totalCount": 3, "totalPages": 2, "page": 1, "size": 2},
+				"links": {
+					"self": "/v1/delegations?sort=amount&size=2&select=id,amount",
+					"next": "/v1/delegations?page=2&select=id%2Camount&size=2&sort=amount"
+				},
+				"data": [{"id": 1, "amount": 1000}, {"id": 2, "amount": 2000}]
+			}`,
+		},
+		{
+			name: "Success empty",
+			url:  "/v1/delegations?year=2020",
+			want: `{
+				"pagination": {"totalCount": 0, "totalPages": 0, "page": 1, "size": 100},
+				"links": {"self": "/v1/delegations?year=2020"},
+				"data": []
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			apiHandler.GetDelegationsV1Handler(responseRecorder, req)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.Equal(t, "application/json", responseRecorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, c.want, responseRecorder.Body.String())
+
+			// the pagination is in the body only
+			assert.Empty(t, responseRecorder.Header().Get("Link"))
+			assert.Empty(t, responseRecorder.Header().Get("X-Total-Pages"))
+		})
+	}
+}
+
+func TestDelegation_GetDelegationsV1Handler_SingleRead(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	ut.mockDatastore.EXPECT().GetDelegationsCount(gomock.Any(), gomock.Eq(datastore.Filter{})).Return(5, nil)
+
+	// the page is read once, the selected fields with the timestamp and id of the next cursor
+	ut.mockDatastore.EXPECT().IterateDelegations(
+		gomock.Any(),
+		gomock.Eq(datastore.Filter{}),
+		gomock.Eq(datastore.DelegationsSort{}),
+		gomock.Eq(datastore.Projection{datastore.FieldID, datastore.FieldTimestamp}),
+		gomock.Eq(datastore.Page{Number: 1, Size: 2, Lookahead: true}),
+		gomock.Any(),
+	).DoAndReturn(func(
+		_ context.Context,
+		_ datastore.Filter,
+		_ datastore.DelegationsSort,
+		_ datastore.Projection,
+		_ datastore.Page,
+		fn func(delegation *model.Delegation) error,
+	) error {
+		for id := int64(5); id > 2; id-- {
+			if err := fn(&model.Delegation{ID: id, Timestamp: time.Unix(id, 0).UTC()}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"/v1/delegations?size=2&select=id",
+		nil,
+	)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder := httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsV1Handler(responseRecorder, req)
+
+	next := datastore.NewCursor(&model.Delegation{ID: 4, Timestamp: time.Unix(4, 0).UTC()}).Encode()
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"data": [{"id": 5}, {"id": 4}],
+			"pagination": {"totalCount": 5, "totalPages": 3, "page": 1, "size": 2, "nextCursor": "`+next+`"},
+			"links": {
+				"self": "/v1/delegations?size=2&select=id",
+				"next": "/v1/delegations?cursor=`+next+`&select=id&size=2"
+			}
+		}`,
+		responseRecorder.Body.String(),
+	)
+	// the pagination is written after the streamed delegations
+	assert.True(t, strings.HasPrefix(responseRecorder.Body.String(), `{"data":[`))
+}
+
+func TestDelegation_GetDelegationsV1Handler_Errors(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	ut.mockDatastore.EXPECT().GetDelegationsCount(gomock.Any(), gomock.Eq(datastore.Filter{})).
+		Return(0, errCountDelegations)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/v1/delegations", nil)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder := httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsV1Handler(responseRecorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code)
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		responseRecorder.Body.String(),
+	)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, "/v1/delegations?size=1001", nil)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder = httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsV1Handler(responseRecorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	assert.Equal(t, problem.ContentType, responseRecorder.Header().Get("Content-Type"))
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter","param":"size",`+
+			`"range":{"min":1,"max":1000},`+
+			`"detail":"invalid value 1001 for query parameter size, expected between 1 and 1000"}`,
+		responseRecorder.Body.String(),
+	)
+}
+
+// expectIterateDelegations expects the handler to stream the delegations of a page, read with a lookahead.
+func expectIterateDelegations(
+	ut *underTest,
+	filter datastore.Filter,
+	page datastore.Page,
+	delegations []*model.Delegation,
+) {
+	page.Lookahead = true
+
+	ut.mockDatastore.EXPECT().IterateDelegations(
+		gomock.Any(),
+		gomock.Eq(filter),
+		gomock.Eq(datastore.DelegationsSort{}),
+		gomock.Nil(),
+		gomock.Eq(page),
+		gomock.Any(),
+	).DoAndReturn(func(
+		_ context.Context,
+		_ datastore.Filter,
+		_ datastore.DelegationsSort,
+		_ datastore.Projection,
+		_ datastore.Page,
+		fn func(delegation *model.Delegation) error,
+	) error {
+		for _, delegation := range delegations {
+			if err := fn(delegation); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,8 @@
+package delegator
+
+import "time"
+
+// SetNow sets the clock used to compute the duration of the current delegation.
+func (a *APIHandler) SetNow(now func() time.Time) {
+	a.now = now
+}
@@ -0,0 +1,200 @@
+package delegator
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/envelope"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// APIHandler handles the delegators API requests.
+type APIHandler struct {
+	datastore datastore.Datastorer
+	now       func() time.Time
+}
+
+// New creates a new APIHandler.
+func New(datastore datastore.Datastorer) *APIHandler {
+	return &APIHandler{
+		datastore: datastore,
+		now:       time.Now,
+	}
+}
+
+// timelineDelegation is a delegation of a delegator timeline, with how long it lasted.
+type timelineDelegation struct {
+	*model.Delegation
+	// Until is the timestamp of the next delegation of the delegator, nil for the current delegation.
+	Until *time.Time `json:"until,omitempty"`
+	// DurationSeconds is how long the delegation lasted, until now for the current delegation.
+	DurationSeconds int64 `json:"durationSeconds"`
+}
+
+// GetDelegatorHandler handles the legacy /xtz/delegators/{address} endpoint, the request path being the
+// delegator address.
+//
+// It returns the current delegation of the delegator: its baker, empty when undelegated, and delegated amount.
+func (a *APIHandler) GetDelegatorHandler(w http.ResponseWriter, r *http.Request) {
+	delegator, ok := a.getDelegator(w, r)
+	if !ok {
+		return
+	}
+
+	responseJSON, err := json.Marshal(delegator)
+	if err != nil {
+		zap.L().Error("error marshalling delegator to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
+
+// GetDelegatorV1Handler handles /v1/delegators/{address} endpoint, the request path being the delegator
+// address. The response body is an envelope with the delegator as data.
+func (a *APIHandler) GetDelegatorV1Handler(w http.ResponseWriter, r *http.Request) {
+	if delegator, ok := a.getDelegator(w, r); ok {
+		envelope.Write(w, http.StatusOK, envelope.Body{Data: delegator})
+	}
+}
+
+// getDelegator gets the delegator of the request path, answering the request with an error when it isn't
+// found or can't be read.
+func (a *APIHandler) getDelegator(w http.ResponseWriter, r *http.Request) (*model.Delegator, bool) {
+	address := r.URL.Path
+	if address == "" || strings.Contains(address, "/") {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	delegator, err := a.datastore.GetDelegator(r.Context(), address)
+	if err != nil {
+		zap.L().Error("couldn't get delegator from datastore", zap.String("address", address), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	if delegator == nil {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	return delegator, true
+}
+
+// SubpathHandler handles the legacy /xtz/delegators/ sub paths, the request path being either {address}
+// or {address}/delegations.
+func (a *APIHandler) SubpathHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/delegations") {
+		a.GetDelegationsHandler(w, r)
+
+		return
+	}
+
+	a.GetDelegatorHandler(w, r)
+}
+
+// SubpathV1Handler handles the /v1/delegators/ sub paths, the request path being either {address}
+// or {address}/delegations.
+func (a *APIHandler) SubpathV1Handler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/delegations") {
+		a.GetDelegationsV1Handler(w, r)
+
+		return
+	}
+
+	a.GetDelegatorV1Handler(w, r)
+}
+
+// GetDelegationsHandler handles the legacy /xtz/delegators/{address}/delegations endpoint, the request path
+// being {address}/delegations.
+//
+// It returns every delegation of the delegator in chronological order, with the previous and new baker
+// and how long each delegation lasted.
+func (a *APIHandler) GetDelegationsHandler(w http.ResponseWriter, r *http.Request) {
+	timeline, ok := a.getTimeline(w, r)
+	if !ok {
+		return
+	}
+
+	responseJSON, err := json.Marshal(timeline)
+	if err != nil {
+		zap.L().Error("error marshalling delegator delegations to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
+
+// GetDelegationsV1Handler handles /v1/delegators/{address}/delegations endpoint, the request path being
+// {address}/delegations. The response body is an envelope with the timeline of GetDelegationsHandler as data,
+// the timeline not being paginated.
+func (a *APIHandler) GetDelegationsV1Handler(w http.ResponseWriter, r *http.Request) {
+	if timeline, ok := a.getTimeline(w, r); ok {
+		envelope.Write(w, http.StatusOK, envelope.Body{Data: timeline})
+	}
+}
+
+// getTimeline gets the delegations timeline of the delegator of the request path, answering the request with
+// an error when it can't be read.
+func (a *APIHandler) getTimeline(w http.ResponseWriter, r *http.Request) ([]timelineDelegation, bool) {
+	address, found := strings.CutSuffix(r.URL.Path, "/delegations")
+	if !found || address == "" || strings.Contains(address, "/") {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	delegations, err := a.datastore.GetDelegatorDelegations(r.Context(), address)
+	if err != nil {
+		zap.L().Error(
+			"couldn't get delegator delegations from datastore",
+			zap.String("address", address),
+			zap.Error(err),
+		)
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	timeline := make([]timelineDelegation, len(delegations))
+
+	for i, delegation := range delegations {
+		until := a.now()
+
+		if i+1 < len(delegations) {
+			until = delegations[i+1].Timestamp
+			timeline[i].Until = &until
+		}
+
+		timeline[i].Delegation = delegation
+		timeline[i].DurationSeconds = int64(until.Sub(delegation.Timestamp) / time.Second)
+	}
+
+	return timeline, true
+}
@@ -0,0 +1,357 @@
+package delegator_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegator"
+)
+
+type underTest struct {
+	mockCtrl      *gomock.Controller
+	mockDatastore *datastoremock.MockDatastorer
+	apiHandler    *delegator.APIHandler
+	server        http.Handler
+}
+
+var now = time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+
+func setupTest(t *testing.T) *underTest {
+	t.Helper()
+
+	ut := &underTest{}
+
+	ut.mockCtrl = gomock.NewController(t)
+
+	ut.mockDatastore = datastoremock.NewMockDatastorer(ut.mockCtrl)
+
+	ut.apiHandler = delegator.New(ut.mockDatastore)
+	ut.apiHandler.SetNow(func() time.Time { return now })
+
+	ut.server = http.StripPrefix("/xtz/delegators/", http.HandlerFunc(ut.apiHandler.GetDelegationsHandler))
+
+	return ut
+}
+
+var errGetDelegations = errors.New("error getting delegations")
+
+// timelineDelegation is the timeline response item.
+type timelineDelegation struct {
+	ID              int64      `json:"id"`
+	Timestamp       time.Time  `json:"timestamp"`
+	Baker           string     `json:"baker"`
+	PreviousBaker   string     `json:"previousBaker"`
+	Until           *time.Time `json:"until"`
+	DurationSeconds int64      `json:"durationSeconds"`
+}
+
+//nolint:funlen
+func TestDelegator_GetDelegationsHandler(t *testing.T) {
+	t.Parallel()
+
+	secondDelegation := time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name           string
+		url            string
+		init           func(*underTest)
+		want           []timelineDelegation
+		wantErr        string
+		wantStatusCode int
+	}{
+		{
+			name: "Success",
+			url:  "/xtz/delegators/tz1delegator/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegatorDelegations(gomock.Any(), gomock.Eq("tz1delegator")).
+					Return([]*model.Delegation{
+						{
+							ID:        1,
+							Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+							Delegator: "tz1delegator",
+							Baker:     "tz1bakerA",
+						},
+						{
+							ID:            2,
+							Timestamp:     secondDelegation,
+							Delegator:     "tz1delegator",
+							Baker:         "tz1bakerB",
+							PreviousBaker: "tz1bakerA",
+						},
+					}, nil)
+			},
+			want: []timelineDelegation{
+				{
+					ID:              1,
+					Timestamp:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+					Baker:           "tz1bakerA",
+					Until:           &secondDelegation,
+					DurationSeconds: 2 * 24 * 60 * 60,
+				},
+				{
+					ID:              2,
+					Timestamp:       secondDelegation,
+					Baker:           "tz1bakerB",
+					PreviousBaker:   "tz1bakerA",
+					DurationSeconds: 7 * 24 * 60 * 60,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success without delegations",
+			url:  "/xtz/delegators/tz1unknown/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegatorDelegations(gomock.Any(), gomock.Eq("tz1unknown")).
+					Return(nil, nil)
+			},
+			want:           []timelineDelegation{},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Error unknown sub path",
+			url:            "/xtz/delegators/tz1delegator/bakers",
+			init:           func(ut *underTest) {},
+			wantErr:        `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Error missing address",
+			url:            "/xtz/delegators//delegations",
+			init:           func(ut *underTest) {},
+			wantErr:        `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Error GetDelegatorDelegations from datastore",
+			url:  "/xtz/delegators/tz1delegator/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegatorDelegations(gomock.Any(), gomock.Any()).
+					Return(nil, errGetDelegations)
+			},
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.server.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			var result []timelineDelegation
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+var errGetDelegator = errors.New("error getting delegator")
+
+//nolint:funlen
+func TestDelegator_SubpathHandler(t *testing.T) {
+	t.Parallel()
+
+	current := &model.Delegator{
+		Address:      "tz1delegator",
+		Baker:        "tz1bakerB",
+		Amount:       120,
+		Timestamp:    time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC),
+		Level:        3000003,
+		DelegationID: 2,
+	}
+
+	cases := []struct {
+		name           string
+		url            string
+		init           func(*underTest)
+		want           *model.Delegator
+		wantErr        string
+		wantStatusCode int
+	}{
+		{
+			name: "Success current delegation",
+			url:  "/xtz/delegators/tz1delegator",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegator(gomock.Any(), gomock.Eq("tz1delegator")).Return(current, nil)
+			},
+			want:           current,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success delegations",
+			url:  "/xtz/delegators/tz1delegator/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegatorDelegations(gomock.Any(), gomock.Eq("tz1delegator")).
+					Return(nil, nil)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Error unknown delegator",
+			url:  "/xtz/delegators/tz1unknown",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegator(gomock.Any(), gomock.Eq("tz1unknown")).Return(nil, nil)
+			},
+			wantErr:        `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "Error unknown sub path",
+			url:            "/xtz/delegators/tz1delegator/bakers",
+			init:           func(ut *underTest) {},
+			wantErr:        `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Error GetDelegator from datastore",
+			url:  "/xtz/delegators/tz1delegator",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegator(gomock.Any(), gomock.Any()).Return(nil, errGetDelegator)
+			},
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			server := http.StripPrefix("/xtz/delegators/", http.HandlerFunc(ut.apiHandler.SubpathHandler))
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			server.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			if c.want == nil {
+				return
+			}
+
+			var result *model.Delegator
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+//nolint:funlen
+func TestDelegator_SubpathV1Handler(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		url            string
+		init           func(*underTest)
+		want           string
+		wantStatusCode int
+	}{
+		{
+			name: "Success current delegation",
+			url:  "/v1/delegators/tz1delegator",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegator(gomock.Any(), gomock.Eq("tz1delegator")).Return(
+					&model.Delegator{
+						Address: "tz1delegator", Baker: "tz1baker", Amount: 1000,
+						Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Level: 100, DelegationID: 1,
+					},
+					nil,
+				)
+			},
+			want: `{"data": {
+				"address": "tz1delegator", "baker": "tz1baker", "amount": 1000,
+				"timestamp": "2023-01-01T00:00:00Z", "level": 100, "delegationId": 1
+			}}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success delegations",
+			url:  "/v1/delegators/tz1delegator/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegatorDelegations(gomock.Any(), gomock.Eq("tz1delegator")).
+					Return(nil, nil)
+			},
+			want:           `{"data": []}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Error unknown delegator",
+			url:  "/v1/delegators/tz1unknown",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegator(gomock.Any(), gomock.Eq("tz1unknown")).Return(nil, nil)
+			},
+			want:           `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			server := http.StripPrefix("/v1/delegators/", http.HandlerFunc(ut.apiHandler.SubpathV1Handler))
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			server.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+			assert.JSONEq(t, c.want, responseRecorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package envelope writes the v1 API responses, enveloping their data with its pagination and links.
+package envelope
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Body is the body of a v1 response, the pagination and links being set for lists only.
+type Body struct {
+	Data       any         `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Links      *Links      `json:"links,omitempty"`
+}
+
+// Pagination is the pagination of a list, with its totals when the list is counted.
+type Pagination struct {
+	*Totals
+	Page int `json:"page"`
+	Size int `json:"size"`
+}
+
+// Totals are the total count of a counted list and its number of pages.
+type Totals struct {
+	TotalCount int `json:"totalCount"`
+	TotalPages int `json:"totalPages"`
+}
+
+// Links are the links of a list page.
+type Links struct {
+	Self string `json:"self"`
+	// Next is empty for the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// NewTotals returns the totals of a list of count elements, paginated by pages of size.
+func NewTotals(count, size int) *Totals {
+	pages := count / size
+	if count%size != 0 {
+		pages++
+	}
+
+	return &Totals{TotalCount: count, TotalPages: pages}
+}
+
+// List returns the body of a list page, its links being relative to path, the request path before being
+// stripped of its prefix. The next link is set unless last is true.
+func List(path string, current *url.URL, data any, page datastore.Page, totals *Totals, last bool) Body {
+	self := url.URL{Path: path, RawQuery: current.RawQuery}
+	links := &Links{Self: self.String()}
+
+	if !last {
+		query := current.Query()
+		query.Set("page", strconv.Itoa(page.Number+1))
+
+		next := url.URL{Path: path, RawQuery: query.Encode()}
+		links.Next = next.String()
+	}
+
+	return Body{
+		Data:       data,
+		Pagination: &Pagination{Totals: totals, Page: page.Number, Size: page.Size},
+		Links:      links,
+	}
+}
+
+// Write writes the JSON body of a v1 response with its status code.
+func Write(w http.ResponseWriter, statusCode int, body Body) {
+	responseJSON, err := json.Marshal(body)
+	if err != nil {
+		zap.L().Error("error marshalling response to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
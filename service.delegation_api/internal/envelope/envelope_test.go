@@ -0,0 +1,99 @@
+package envelope_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/envelope"
+)
+
+func TestEnvelope_NewTotals(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		count int
+		size  int
+		want  *envelope.Totals
+	}{
+		{name: "Empty", count: 0, size: 10, want: &envelope.Totals{}},
+		{name: "Full pages", count: 20, size: 10, want: &envelope.Totals{TotalCount: 20, TotalPages: 2}},
+		{name: "Partial last page", count: 21, size: 10, want: &envelope.Totals{TotalCount: 21, TotalPages: 3}},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, c.want, envelope.NewTotals(c.count, c.size))
+		})
+	}
+}
+
+func TestEnvelope_Write(t *testing.T) {
+	t.Parallel()
+
+	current, err := url.Parse("/id/items?size=2&as_of=100")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		body envelope.Body
+		want string
+	}{
+		{
+			name: "Object",
+			body: envelope.Body{Data: map[string]string{"id": "id"}},
+			want: `{"data": {"id": "id"}}`,
+		},
+		{
+			name: "Counted list",
+			body: envelope.List(
+				"/v1/items/id/items", current, []int{1, 2}, datastore.Page{Number: 1, Size: 2},
+				envelope.NewTotals(3, 2), false,
+			),
+			want: `{
+				"data": [1, 2],
+				"pagination": {"totalCount": 3, "totalPages": 2, "page": 1, "size": 2},
+				"links": {
+					"self": "/v1/items/id/items?size=2&as_of=100",
+					"next": "/v1/items/id/items?as_of=100&page=2&size=2"
+				}
+			}`,
+		},
+		{
+			name: "Uncounted last page",
+			body: envelope.List(
+				"/v1/items/id/items", current, []int{}, datastore.Page{Number: 1, Size: 2}, nil, true,
+			),
+			want: `{
+				"data": [],
+				"pagination": {"page": 1, "size": 2},
+				"links": {"self": "/v1/items/id/items?size=2&as_of=100"}
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			responseRecorder := httptest.NewRecorder()
+			envelope.Write(responseRecorder, http.StatusOK, c.body)
+
+			assert.Equal(t, http.StatusOK, responseRecorder.Code)
+			assert.Equal(t, "application/json", responseRecorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, c.want, responseRecorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Codes of the errors of the queries exceeding the limits, in their extensions.
+const (
+	CodeQueryTooDeep   = "query_too_deep"
+	CodeQueryTooCostly = "query_too_costly"
+)
+
+// Limits are the limits of the queries, a query exceeding them being rejected before being executed.
+type Limits struct {
+	// MaxDepth is the maximum depth of the fields of a query.
+	MaxDepth int
+	// MaxCost is the maximum cost of a query, the estimated number of objects it resolves.
+	MaxCost int
+}
+
+// analysis computes the depth and the cost of an operation.
+//
+// Every object field costs the number of times it is resolved: a list field multiplies the cost of its
+// selections by the size of the page requested with its first argument, or with the first argument of its
+// connection. Introspection fields aren't counted.
+type analysis struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	// maxCost bounds the multipliers, so that the cost of deeply nested pages doesn't overflow.
+	maxCost int
+	depth   int
+	cost    int
+}
+
+// checkLimits returns the error of the first operation of a validated document exceeding the limits,
+// nil if none does.
+func checkLimits(
+	schema *graphql.Schema,
+	doc *ast.Document,
+	variables map[string]any,
+	limits Limits,
+) *gqlerrors.FormattedError {
+	fragments := map[string]*ast.FragmentDefinition{}
+
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok || operation.Operation != ast.OperationTypeQuery {
+			continue
+		}
+
+		a := &analysis{
+			fragments: fragments,
+			variables: operationVariables(operation, variables),
+			maxCost:   limits.MaxCost,
+		}
+		a.walk(schema.QueryType(), operation.SelectionSet, 1, 1, 1)
+
+		if a.depth > limits.MaxDepth {
+			return limitError(
+				CodeQueryTooDeep,
+				fmt.Sprintf("query depth %d exceeds the maximum depth %d", a.depth, limits.MaxDepth),
+			)
+		}
+
+		if a.cost > limits.MaxCost {
+			return limitError(
+				CodeQueryTooCostly,
+				fmt.Sprintf("query cost %d exceeds the maximum cost %d", a.cost, limits.MaxCost),
+			)
+		}
+	}
+
+	return nil
+}
+
+// walk adds the depth and the cost of the selections of an object resolved multiplier times, size being
+// the size of the page of the enclosing connection.
+func (a *analysis) walk(parent *graphql.Object, selectionSet *ast.SelectionSet, depth, multiplier, size int) {
+	if selectionSet == nil {
+		return
+	}
+
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.InlineFragment:
+			a.walk(parent, selection.SelectionSet, depth, multiplier, size)
+		case *ast.FragmentSpread:
+			if fragment, found := a.fragments[selection.Name.Value]; found {
+				a.walk(parent, fragment.SelectionSet, depth, multiplier, size)
+			}
+		case *ast.Field:
+			a.walkField(parent, selection, depth, multiplier, size)
+		}
+	}
+}
+
+// walkField adds the depth and the cost of a field of an object resolved multiplier times.
+func (a *analysis) walkField(parent *graphql.Object, field *ast.Field, depth, multiplier, size int) {
+	definition, found := parent.Fields()[field.Name.Value]
+	if !found {
+		// introspection fields
+		return
+	}
+
+	a.depth = max(a.depth, depth)
+
+	object, ok := graphql.GetNamed(definition.Type).(*graphql.Object)
+	if !ok {
+		return
+	}
+
+	a.cost += multiplier
+
+	if first, found := a.first(definition, field); found {
+		size = first
+	}
+
+	if isList(definition.Type) {
+		// bounded so that a too costly query stays too costly without overflowing
+		multiplier = min(multiplier*size, a.maxCost+1)
+	}
+
+	a.walk(object, field.SelectionSet, depth+1, multiplier, size)
+}
+
+// first returns the value of the first argument of a field, at least 1, and whether the field has one.
+func (a *analysis) first(definition *graphql.FieldDefinition, field *ast.Field) (int, bool) {
+	for _, argument := range definition.Args {
+		if argument.Name() != argFirst {
+			continue
+		}
+
+		value, _ := argument.DefaultValue.(int)
+
+		for _, fieldArgument := range field.Arguments {
+			if fieldArgument.Name.Value == argFirst {
+				value = a.intValue(fieldArgument.Value, value)
+			}
+		}
+
+		return max(value, 1), true
+	}
+
+	return 0, false
+}
+
+// intValue returns the value of an int literal or variable, or fallback when it isn't set.
+func (a *analysis) intValue(value ast.Value, fallback int) int {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		if i, err := strconv.Atoi(value.Value); err == nil {
+			return i
+		}
+	case *ast.Variable:
+		switch variable := a.variables[value.Name.Value].(type) {
+		case int:
+			return variable
+		case float64:
+			return int(variable)
+		}
+	}
+
+	return fallback
+}
+
+// operationVariables returns the variables of an operation, with the default values of the variables
+// missing from variables.
+func operationVariables(operation *ast.OperationDefinition, variables map[string]any) map[string]any {
+	values := make(map[string]any, len(variables))
+	for name, value := range variables {
+		values[name] = value
+	}
+
+	for _, definition := range operation.VariableDefinitions {
+		name := definition.Variable.Name.Value
+		if _, set := values[name]; set {
+			continue
+		}
+
+		if value, ok := definition.DefaultValue.(*ast.IntValue); ok {
+			if i, err := strconv.Atoi(value.Value); err == nil {
+				values[name] = i
+			}
+		}
+	}
+
+	return values
+}
+
+// isList reports whether a type is a list, or a non null list.
+func isList(t graphql.Type) bool {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+
+	_, ok := t.(*graphql.List)
+
+	return ok
+}
+
+func limitError(code, message string) *gqlerrors.FormattedError {
+	return &gqlerrors.FormattedError{
+		Message:    message,
+		Extensions: map[string]any{"code": code},
+	}
+}
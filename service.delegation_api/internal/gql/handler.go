@@ -0,0 +1,151 @@
+// Package gql serves the delegations, delegators and bakers over GraphQL, alongside the REST API.
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Params are the query parameters of the GraphQL endpoint, for the GET requests.
+var Params = []string{"query", "variables", "operationName"}
+
+// maxBodySize is the maximum size of a GraphQL request body.
+const maxBodySize = 64 << 10
+
+// errMissingQuery is returned for a GraphQL request without query.
+var errMissingQuery = errors.New("missing GraphQL query")
+
+// request is a GraphQL request.
+type request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// APIHandler handles the GraphQL API requests.
+type APIHandler struct {
+	datastore datastore.Datastorer
+	schema    graphql.Schema
+	limits    Limits
+}
+
+// New creates a new APIHandler, with pages of at most maxPageSize nodes and queries within limits.
+func New(datastore datastore.Datastorer, maxPageSize int, limits Limits) *APIHandler {
+	schema, err := newSchema(&resolver{datastore: datastore, maxPageSize: maxPageSize})
+	if err != nil {
+		// the schema is static, it is only invalid because of a programming error
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+
+	return &APIHandler{
+		datastore: datastore,
+		schema:    schema,
+		limits:    limits,
+	}
+}
+
+// GraphQLHandler handles /graphql endpoint.
+//
+// The query is read from the query parameters of a GET request, or from the JSON body of a POST request,
+// with its variables and operation name. The result is returned as JSON, the GraphQL errors being part
+// of the result. Queries exceeding the depth or cost limits are rejected without being executed.
+func (a *APIHandler) GraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req request
+
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+
+		if err := decoder.Decode(&req); err != nil {
+			problem.InvalidBody(w, fmt.Errorf("couldn't decode GraphQL request: %w", err))
+
+			return
+		}
+
+		if req.Query == "" {
+			problem.InvalidBody(w, errMissingQuery)
+
+			return
+		}
+	} else {
+		query := r.URL.Query()
+
+		req.Query = query.Get("query")
+		if req.Query == "" {
+			problem.BadRequest(w, param.Errorf("query", "missing query parameter query"))
+
+			return
+		}
+
+		req.OperationName = query.Get("operationName")
+
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				problem.BadRequest(
+					w,
+					param.Errorf("variables", "couldn't parse value %s for query parameter variables", variables),
+				)
+
+				return
+			}
+		}
+	}
+
+	writeJSON(w, a.execute(r.Context(), req))
+}
+
+// execute parses and validates a request, checks it is within the limits, then executes it.
+func (a *APIHandler) execute(ctx context.Context, req request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if validation := graphql.ValidateDocument(&a.schema, doc, nil); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if limitErr := checkLimits(&a.schema, doc, req.Variables, a.limits); limitErr != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{*limitErr}}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        a.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, newLoaders(a.datastore)),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		zap.L().Error("error marshalling response to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
@@ -0,0 +1,642 @@
+package gql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/gql"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+const (
+	bakerA     = "tz1bakerA"
+	bakerB     = "tz1bakerB"
+	delegatorA = "tz1delegatorA"
+	delegatorB = "tz1delegatorB"
+	delegatorC = "tz1delegatorC"
+)
+
+var errDatastore = errors.New("datastore error")
+
+// limits are the limits of the tested handlers.
+var limits = gql.Limits{MaxDepth: 6, MaxCost: 100}
+
+// response is a GraphQL response, its data being kept raw to be compared as JSON.
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// setupTest returns a handler over a memory datastore storing 4 delegations of 2023: delegatorA to bakerA (1),
+// delegatorB to bakerA (2), delegatorA redelegating to bakerB (3) and delegatorC to bakerA (4).
+func setupTest(t *testing.T) *gql.APIHandler {
+	t.Helper()
+
+	ds := memory.New()
+	require.NoError(t, ds.StoreDelegations(context.Background(), []*model.Delegation{
+		delegation(1, delegatorA, bakerA, ""),
+		delegation(2, delegatorB, bakerA, ""),
+		delegation(3, delegatorA, bakerB, bakerA),
+		delegation(4, delegatorC, bakerA, ""),
+	}))
+
+	return gql.New(ds, 10, limits)
+}
+
+func delegation(id int64, delegator, baker, previousBaker string) *model.Delegation {
+	return &model.Delegation{
+		ID:            id,
+		Timestamp:     time.Date(2023, 6, int(id), 0, 0, 0, 0, time.UTC),
+		Amount:        id * 100,
+		Delegator:     delegator,
+		Block:         "BLock",
+		Level:         4000000 + id,
+		Baker:         baker,
+		PreviousBaker: previousBaker,
+	}
+}
+
+// query posts a GraphQL query to the handler, and returns its response.
+func query(t *testing.T, handler *gql.APIHandler, query string, variables map[string]any) response {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodPost,
+		"/graphql",
+		strings.NewReader(string(body)),
+	)
+	require.NoError(t, err)
+
+	responseRecorder := httptest.NewRecorder()
+	handler.GraphQLHandler(responseRecorder, req)
+
+	require.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, "application/json", responseRecorder.Header().Get("Content-Type"))
+
+	var resp response
+	require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &resp))
+
+	return resp
+}
+
+//nolint:funlen
+func TestAPIHandler_Delegations(t *testing.T) {
+	t.Parallel()
+
+	handler := setupTest(t)
+
+	cases := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantData  string
+		wantError string
+	}{
+		{
+			name:  "Success",
+			query: `{ delegations { nodes { id } totalCount pageInfo { hasNextPage } } }`,
+			wantData: `{"delegations":{
+				"nodes":[{"id":"4"},{"id":"3"},{"id":"2"},{"id":"1"}],"totalCount":4,"pageInfo":{"hasNextPage":false}
+			}}`,
+		},
+		{
+			name: "Success all fields",
+			query: `{ delegations(first: 1, filter: {kind: REDELEGATION}) { nodes {
+				id timestamp amount delegator block level kind baker { address } previousBaker { address }
+			} } }`,
+			wantData: `{"delegations":{"nodes":[{
+				"id":"3","timestamp":"2023-06-03T00:00:00Z","amount":300,"delegator":"tz1delegatorA","block":"BLock",
+				"level":4000003,"kind":"REDELEGATION","baker":{"address":"tz1bakerB"},
+				"previousBaker":{"address":"tz1bakerA"}
+			}]}}`,
+		},
+		{
+			name: "Success filtered",
+			query: `query ($filter: DelegationsFilter) {
+				delegations(filter: $filter) { nodes { id } totalCount }
+			}`,
+			variables: map[string]any{
+				"filter": map[string]any{
+					"year": 2023, "baker": bakerA, "amountGt": 100, "from": "2023-06-02T00:00:00Z",
+				},
+			},
+			wantData: `{"delegations":{"nodes":[{"id":"4"},{"id":"2"}],"totalCount":2}}`,
+		},
+		{
+			name:  "Success first page",
+			query: `{ delegations(first: 2) { nodes { id } pageInfo { endCursor hasNextPage } } }`,
+			wantData: `{"delegations":{
+				"nodes":[{"id":"4"},{"id":"3"}],"pageInfo":{"endCursor":"MTY4NTc1MDQwMDAwMDoz","hasNextPage":true}
+			}}`,
+		},
+		{
+			name:     "Success next page",
+			query:    `{ delegations(first: 2, after: "MTY4NTc1MDQwMDAwMDoz") { nodes { id } } }`,
+			wantData: `{"delegations":{"nodes":[{"id":"2"},{"id":"1"}]}}`,
+		},
+		{
+			name:     "Success empty page",
+			query:    `{ delegations(filter: {year: 2022}) { nodes { id } pageInfo { endCursor hasNextPage } } }`,
+			wantData: `{"delegations":{"nodes":[],"pageInfo":{"endCursor":null,"hasNextPage":false}}}`,
+		},
+		{
+			name:      "Invalid year",
+			query:     `{ delegations(filter: {year: 2000}) { nodes { id } } }`,
+			wantError: "invalid year 2000, expected between 2018 and 9999",
+		},
+		{
+			name:      "Invalid first",
+			query:     `{ delegations(first: 11) { nodes { id } } }`,
+			wantError: "invalid first 11, expected between 1 and 10",
+		},
+		{
+			name:      "Invalid cursor",
+			query:     `{ delegations(after: "!") { nodes { id } } }`,
+			wantError: "invalid cursor",
+		},
+		{
+			name:      "Unknown field",
+			query:     `{ delegations { nodes { hash } } }`,
+			wantError: `Cannot query field "hash" on type "Delegation".`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := query(t, handler, c.query, c.variables)
+
+			if c.wantError != "" {
+				require.Len(t, resp.Errors, 1)
+				assert.Equal(t, c.wantError, resp.Errors[0].Message)
+
+				return
+			}
+
+			assert.Empty(t, resp.Errors)
+			assert.JSONEq(t, c.wantData, string(resp.Data))
+		})
+	}
+}
+
+//nolint:funlen
+func TestAPIHandler_Bakers(t *testing.T) {
+	t.Parallel()
+
+	handler := setupTest(t)
+
+	cases := []struct {
+		name      string
+		query     string
+		wantData  string
+		wantError string
+	}{
+		{
+			name:  "Success bakers",
+			query: `{ bakers { nodes { address delegatorsCount delegatedAmount } totalCount } }`,
+			wantData: `{"bakers":{"nodes":[
+				{"address":"tz1bakerA","delegatorsCount":2,"delegatedAmount":600},
+				{"address":"tz1bakerB","delegatorsCount":1,"delegatedAmount":300}
+			],"totalCount":2}}`,
+		},
+		{
+			name: "Success bakers sorted first page",
+			query: `{ bakers(sort: "-inflows", first: 1) {
+				nodes { address inflows } pageInfo { endCursor hasNextPage }
+			} }`,
+			wantData: `{"bakers":{
+				"nodes":[{"address":"tz1bakerA","inflows":3}],"pageInfo":{"endCursor":"b2Zmc2V0OjE","hasNextPage":true}
+			}}`,
+		},
+		{
+			name: "Success bakers next page",
+			query: `{ bakers(sort: "-inflows", first: 1, after: "b2Zmc2V0OjE") {
+				nodes { address } pageInfo { hasNextPage }
+			} }`,
+			wantData: `{"bakers":{"nodes":[{"address":"tz1bakerB"}],"pageInfo":{"hasNextPage":true}}}`,
+		},
+		{
+			name: "Success baker delegators with their recent delegations",
+			query: `{ baker(address: "tz1bakerA") {
+				address outflows outflowAmount
+				delegators(first: 1) { nodes { address amount baker { address } delegations(first: 1) { id } } }
+			} }`,
+			wantData: `{"baker":{"address":"tz1bakerA","outflows":1,"outflowAmount":300,"delegators":{"nodes":[
+				{"address":"tz1delegatorB","amount":200,"baker":{"address":"tz1bakerA"},"delegations":[{"id":"2"}]}
+			]}}}`,
+		},
+		{
+			name: "Success baker delegators as of a level",
+			query: `{ baker(address: "tz1bakerA") {
+				delegators(asOf: {level: 4000002}) { nodes { address } }
+			} }`,
+			wantData: `{"baker":{"delegators":{"nodes":[{"address":"tz1delegatorA"},{"address":"tz1delegatorB"}]}}}`,
+		},
+		{
+			name:     "Success unknown baker",
+			query:    `{ baker(address: "tz1unknown") { address } }`,
+			wantData: `{"baker":null}`,
+		},
+		{
+			name: "Success delegator",
+			query: `{ delegator(address: "tz1delegatorA") {
+				address delegationId baker { address } delegations { id kind }
+			} }`,
+			wantData: `{"delegator":{"address":"tz1delegatorA","delegationId":"3","baker":{"address":"tz1bakerB"},
+				"delegations":[{"id":"3","kind":"REDELEGATION"},{"id":"1","kind":"DELEGATION"}]}}`,
+		},
+		{
+			name:     "Success unknown delegator",
+			query:    `{ delegator(address: "tz1unknown") { address } }`,
+			wantData: `{"delegator":null}`,
+		},
+		{
+			name:      "Invalid sort",
+			query:     `{ bakers(sort: "name") { nodes { address } } }`,
+			wantError: "invalid sort name",
+		},
+		{
+			name:      "Invalid cursor",
+			query:     `{ bakers(after: "b2Zmc2V0Oi0x") { nodes { address } } }`,
+			wantError: "invalid cursor",
+		},
+		{
+			name:      "Invalid cursor of another page size",
+			query:     `{ bakers(first: 2, after: "b2Zmc2V0OjE") { nodes { address } } }`,
+			wantError: "invalid after, expected the end cursor of a previous page of 2 nodes",
+		},
+		{
+			name:      "Invalid cursor beyond the maximum offset",
+			query:     `{ bakers(first: 10, after: "b2Zmc2V0OjEwMDEw") { nodes { address } } }`,
+			wantError: "invalid after, expected a cursor within the first 10000 nodes",
+		},
+		{
+			name: "Invalid as of",
+			query: `{ baker(address: "tz1bakerA") {
+				delegators(asOf: {level: 1, timestamp: "2023-06-01T00:00:00Z"}) { nodes { address } }
+			} }`,
+			wantError: "invalid as of, expected a timestamp or a level",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := query(t, handler, c.query, nil)
+
+			if c.wantError != "" {
+				require.Len(t, resp.Errors, 1)
+				assert.Equal(t, c.wantError, resp.Errors[0].Message)
+
+				return
+			}
+
+			assert.Empty(t, resp.Errors)
+			assert.JSONEq(t, c.wantData, string(resp.Data))
+		})
+	}
+}
+
+// TestAPIHandler_Batching checks the bakers and the delegations of the nodes of a level are loaded at once.
+func TestAPIHandler_Batching(t *testing.T) {
+	t.Parallel()
+
+	ds := datastoremock.NewMockDatastorer(gomock.NewController(t))
+	handler := gql.New(ds, 10, gql.Limits{MaxDepth: 10, MaxCost: 1000})
+
+	bakers := []*model.Baker{{Address: bakerA, Delegators: 3}, {Address: bakerB}}
+	delegations := []*model.Delegation{
+		delegation(1, delegatorA, bakerA, ""),
+		delegation(2, delegatorB, bakerA, ""),
+		delegation(3, delegatorB, bakerB, bakerA),
+		delegation(4, delegatorC, bakerA, ""),
+	}
+
+	gomock.InOrder(
+		ds.EXPECT().GetBakersByAddresses(gomock.Any(), []string{bakerA}).Return(bakers[:1], nil),
+		ds.EXPECT().
+			GetBakersDelegators(gomock.Any(), []string{bakerA}, datastore.AsOf{}, datastore.Page{Number: 1, Size: 10}).
+			Return([]*model.Delegator{
+				datastore.NewDelegator(delegations[0]),
+				datastore.NewDelegator(delegations[1]),
+				datastore.NewDelegator(delegations[3]),
+			}, nil),
+		ds.EXPECT().
+			GetDelegatorsDelegations(gomock.Any(), []string{delegatorA, delegatorB, delegatorC}, 10).
+			Return(delegations, nil),
+		// bakerA is already loaded
+		ds.EXPECT().GetBakersByAddresses(gomock.Any(), []string{bakerB}).Return(bakers[1:], nil),
+	)
+
+	resp := query(t, handler, `{ baker(address: "tz1bakerA") { delegators { nodes {
+		address delegations { id baker { address delegatorsCount } }
+	} } } }`, nil)
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"baker":{"delegators":{"nodes":[
+		{"address":"tz1delegatorA","delegations":[{"id":"1","baker":{"address":"tz1bakerA","delegatorsCount":3}}]},
+		{"address":"tz1delegatorB","delegations":[
+			{"id":"3","baker":{"address":"tz1bakerB","delegatorsCount":0}},
+			{"id":"2","baker":{"address":"tz1bakerA","delegatorsCount":3}}
+		]},
+		{"address":"tz1delegatorC","delegations":[{"id":"4","baker":{"address":"tz1bakerA","delegatorsCount":3}}]}
+	]}}}`, string(resp.Data))
+}
+
+// TestAPIHandler_BatchingDelegators checks the delegators of the bakers of a level are loaded by page.
+func TestAPIHandler_BatchingDelegators(t *testing.T) {
+	t.Parallel()
+
+	ds := datastoremock.NewMockDatastorer(gomock.NewController(t))
+	handler := gql.New(ds, 10, limits)
+
+	asOf := datastore.AsOf{Level: 3}
+
+	ds.EXPECT().GetBakers(gomock.Any(), gomock.Any(), datastore.Page{Number: 1, Size: 2}).
+		Return([]*model.Baker{{Address: bakerA}, {Address: bakerB}}, nil)
+	// a query by page, whatever the order of the fields
+	ds.EXPECT().
+		GetBakersDelegators(gomock.Any(), []string{bakerA, bakerB}, asOf, datastore.Page{Number: 1, Size: 1}).
+		Return([]*model.Delegator{
+			datastore.NewDelegator(delegation(1, delegatorA, bakerA, "")),
+			datastore.NewDelegator(delegation(3, delegatorB, bakerB, bakerA)),
+		}, nil)
+	ds.EXPECT().
+		GetBakersDelegators(gomock.Any(), []string{bakerA, bakerB}, asOf, datastore.Page{Number: 1, Size: 2}).
+		Return([]*model.Delegator{datastore.NewDelegator(delegation(1, delegatorA, bakerA, ""))}, nil)
+
+	resp := query(t, handler, `{ bakers(first: 2) { nodes {
+		first: delegators(first: 1, asOf: {level: 3}) { nodes { address } pageInfo { hasNextPage } }
+		two: delegators(first: 2, asOf: {level: 3}) { nodes { address } }
+	} } }`, nil)
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"bakers":{"nodes":[
+		{
+			"first":{"nodes":[{"address":"tz1delegatorA"}],"pageInfo":{"hasNextPage":true}},
+			"two":{"nodes":[{"address":"tz1delegatorA"}]}
+		},
+		{
+			"first":{"nodes":[{"address":"tz1delegatorB"}],"pageInfo":{"hasNextPage":true}},
+			"two":{"nodes":[]}
+		}
+	]}}`, string(resp.Data))
+}
+
+// TestAPIHandler_BatchingLimits checks the recent delegations of the nodes of a level are loaded by limit.
+func TestAPIHandler_BatchingLimits(t *testing.T) {
+	t.Parallel()
+
+	ds := datastoremock.NewMockDatastorer(gomock.NewController(t))
+	handler := gql.New(ds, 10, limits)
+
+	delegations := []*model.Delegation{
+		delegation(1, delegatorA, bakerA, ""),
+		delegation(2, delegatorB, bakerA, ""),
+		delegation(3, delegatorB, bakerB, bakerA),
+	}
+
+	ds.EXPECT().GetDelegator(gomock.Any(), delegatorA).Return(datastore.NewDelegator(delegations[0]), nil)
+	ds.EXPECT().GetDelegator(gomock.Any(), delegatorB).Return(datastore.NewDelegator(delegations[2]), nil)
+	// a query by limit, whatever the order of the fields
+	ds.EXPECT().GetDelegatorsDelegations(gomock.Any(), []string{delegatorA}, 1).Return(delegations[:1], nil)
+	ds.EXPECT().GetDelegatorsDelegations(gomock.Any(), []string{delegatorB}, 2).Return(delegations[1:], nil)
+
+	resp := query(t, handler, `{
+		a: delegator(address: "tz1delegatorA") { delegations(first: 1) { id } }
+		b: delegator(address: "tz1delegatorB") { delegations(first: 2) { id } }
+	}`, nil)
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"a":{"delegations":[{"id":"1"}]},
+		"b":{"delegations":[{"id":"3"},{"id":"2"}]}
+	}`, string(resp.Data))
+}
+
+func TestAPIHandler_DatastoreError(t *testing.T) {
+	t.Parallel()
+
+	ds := datastoremock.NewMockDatastorer(gomock.NewController(t))
+	handler := gql.New(ds, 10, limits)
+
+	ds.EXPECT().GetDelegations(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]*model.Delegation{delegation(1, delegatorA, bakerA, "")}, nil)
+	ds.EXPECT().GetBakersByAddresses(gomock.Any(), []string{bakerA}).Return(nil, errDatastore)
+
+	resp := query(t, handler, `{ delegations { nodes { id baker { address } } } }`, nil)
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "couldn't get bakers", resp.Errors[0].Message)
+	assert.JSONEq(t, `{"delegations":{"nodes":[{"id":"1","baker":null}]}}`, string(resp.Data))
+}
+
+//nolint:funlen
+func TestAPIHandler_Limits(t *testing.T) {
+	t.Parallel()
+
+	handler := setupTest(t)
+
+	cases := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantCode  string
+	}{
+		{
+			// cost 1 + 1 + 1 + 10 (delegations) + 10 * 10 (bakers)
+			name: "Too costly",
+			query: `{ baker(address: "tz1bakerA") { delegators(first: 10) { nodes {
+				delegations(first: 10) { baker { address } }
+			} } } }`,
+			wantCode: gql.CodeQueryTooCostly,
+		},
+		{
+			name: "Too costly with variables",
+			query: `query ($size: Int = 1) { baker(address: "tz1bakerA") { delegators(first: $size) { nodes {
+				delegations(first: $size) { baker { address } }
+			} } } }`,
+			variables: map[string]any{"size": 10},
+			wantCode:  gql.CodeQueryTooCostly,
+		},
+		{
+			name: "Too costly with fragments",
+			query: `{ bakers(first: 10) { nodes { ...baker } } }
+			fragment baker on Baker { delegators(first: 10) { nodes { ... on Delegator { baker { address } } } } }`,
+			wantCode: gql.CodeQueryTooCostly,
+		},
+		{
+			name: "Too deep",
+			query: `{ baker(address: "tz1bakerA") { delegators(first: 1) { nodes { delegations(first: 1) {
+				baker { delegators(first: 1) { nodes { address } } }
+			} } } } }`,
+			wantCode: gql.CodeQueryTooDeep,
+		},
+		{
+			name: "Within limits",
+			query: `query ($size: Int = 3) { baker(address: "tz1bakerA") { delegators(first: $size) { nodes {
+				delegations(first: $size) { baker { address } }
+			} } } }`,
+		},
+		{
+			name:  "Introspection",
+			query: `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := query(t, handler, c.query, c.variables)
+
+			if c.wantCode == "" {
+				assert.Empty(t, resp.Errors)
+
+				return
+			}
+
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, c.wantCode, resp.Errors[0].Extensions["code"])
+			assert.JSONEq(t, "null", string(resp.Data))
+		})
+	}
+}
+
+//nolint:funlen
+func TestAPIHandler_GraphQLHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := setupTest(t)
+
+	cases := []struct {
+		name           string
+		method         string
+		query          url.Values
+		body           string
+		wantStatusCode int
+		wantProblem    string
+		wantData       string
+	}{
+		{
+			name:   "Success GET",
+			method: http.MethodGet,
+			query: url.Values{
+				"query":         {`query Latest($size: Int) { delegations(first: $size) { nodes { id } } }`},
+				"variables":     {`{"size": 1}`},
+				"operationName": {"Latest"},
+			},
+			wantStatusCode: http.StatusOK,
+			wantData:       `{"delegations":{"nodes":[{"id":"4"}]}}`,
+		},
+		{
+			name:           "Success POST",
+			method:         http.MethodPost,
+			body:           `{"query": "{ delegations(first: 1) { nodes { id } } }"}`,
+			wantStatusCode: http.StatusOK,
+			wantData:       `{"delegations":{"nodes":[{"id":"4"}]}}`,
+		},
+		{
+			name:           "Syntax error",
+			method:         http.MethodPost,
+			body:           `{"query": "{ delegations"}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Missing query parameter",
+			method:         http.MethodGet,
+			wantStatusCode: http.StatusBadRequest,
+			wantProblem:    problem.CodeInvalidParameter,
+		},
+		{
+			name:           "Invalid variables",
+			method:         http.MethodGet,
+			query:          url.Values{"query": {"{ delegations { totalCount } }"}, "variables": {"["}},
+			wantStatusCode: http.StatusBadRequest,
+			wantProblem:    problem.CodeInvalidParameter,
+		},
+		{
+			name:           "Invalid body",
+			method:         http.MethodPost,
+			body:           `{"query":`,
+			wantStatusCode: http.StatusBadRequest,
+			wantProblem:    problem.CodeInvalidBody,
+		},
+		{
+			name:           "Missing query",
+			method:         http.MethodPost,
+			body:           `{"variables": {}}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantProblem:    problem.CodeInvalidBody,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			target := url.URL{Path: "/graphql", RawQuery: c.query.Encode()}
+
+			req, err := http.NewRequestWithContext(
+				context.Background(),
+				c.method,
+				target.String(),
+				strings.NewReader(c.body),
+			)
+			require.NoError(t, err)
+
+			responseRecorder := httptest.NewRecorder()
+			handler.GraphQLHandler(responseRecorder, req)
+
+			require.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantProblem != "" {
+				var p problem.Problem
+				require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &p))
+				assert.Equal(t, c.wantProblem, p.Code)
+
+				return
+			}
+
+			var resp response
+			require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &resp))
+
+			if c.wantData == "" {
+				assert.NotEmpty(t, resp.Errors)
+
+				return
+			}
+
+			assert.Empty(t, resp.Errors)
+			assert.JSONEq(t, c.wantData, string(resp.Data))
+		})
+	}
+}
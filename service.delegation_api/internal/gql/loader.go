@@ -0,0 +1,214 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// Errors of the batched loads, the datastore errors being logged instead of returned to the clients.
+var (
+	errGetBakers      = errors.New("couldn't get bakers")
+	errGetDelegations = errors.New("couldn't get delegations")
+	errGetDelegators  = errors.New("couldn't get delegators")
+)
+
+// loaded is the result of the load of a key.
+type loaded[V any] struct {
+	value V
+	err   error
+}
+
+// loader batches the loads of the keys requested while resolving a level of a query into a single fetch,
+// and caches the loaded values for the duration of the query.
+//
+// The query executor resolves the fields of a level before calling the thunks they return, so the keys of
+// sibling fields are all pending when the first thunk is called. A loader isn't safe for concurrent use,
+// a query being executed by a single goroutine.
+type loader[K comparable, V any] struct {
+	// fetch returns the values of keys, by key, a missing key having the zero value.
+	fetch   func(ctx context.Context, keys []K) (map[K]V, error)
+	pending []K
+	results map[K]*loaded[V]
+}
+
+// newLoader creates a new loader fetching its values with fetch.
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		results: map[K]*loaded[V]{},
+	}
+}
+
+// load schedules the load of a key, and returns a thunk resolving its value. The first thunk called fetches
+// all the pending keys at once.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (V, error) {
+	if _, scheduled := l.results[key]; !scheduled {
+		l.results[key] = nil
+		l.pending = append(l.pending, key)
+	}
+
+	return func() (V, error) {
+		if l.results[key] == nil {
+			l.dispatch(ctx)
+		}
+
+		result := l.results[key]
+
+		return result.value, result.err
+	}
+}
+
+// dispatch fetches the pending keys.
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+
+	for _, key := range keys {
+		l.results[key] = &loaded[V]{value: values[key], err: err}
+	}
+}
+
+// recentKey is the key of the recent delegations of a delegator, at most limit.
+type recentKey struct {
+	delegator string
+	limit     int
+}
+
+// delegatorsKey is the key of a page of the delegators of a baker as of a point in history, its timestamp in
+// UTC so that the keys of equal timestamps are equal.
+type delegatorsKey struct {
+	baker string
+	// bakersPage is the page of the delegators, shared by the bakers fetched at once.
+	bakersPage
+}
+
+// bakersPage is a page of the delegators of bakers as of a point in history.
+type bakersPage struct {
+	asOf   datastore.AsOf
+	offset int
+	size   int
+}
+
+// loaders are the loaders of a query.
+type loaders struct {
+	// bakers loads the bakers by address, nil when unknown.
+	bakers *loader[string, *model.Baker]
+	// delegations loads the recent delegations of the delegators, in chronological order.
+	delegations *loader[recentKey, []*model.Delegation]
+	// delegators loads pages of the delegators of the bakers, by address.
+	delegators *loader[delegatorsKey, []*model.Delegator]
+}
+
+// newLoaders creates the loaders of a query, loading from the datastore.
+func newLoaders(ds datastore.Datastorer) *loaders {
+	return &loaders{
+		bakers: newLoader(func(ctx context.Context, addresses []string) (map[string]*model.Baker, error) {
+			bakers, err := ds.GetBakersByAddresses(ctx, addresses)
+			if err != nil {
+				zap.L().Error("couldn't get bakers from datastore", zap.Error(err))
+
+				return nil, errGetBakers
+			}
+
+			results := make(map[string]*model.Baker, len(bakers))
+			for _, baker := range bakers {
+				results[baker.Address] = baker
+			}
+
+			return results, nil
+		}),
+		delegations: newLoader(func(ctx context.Context, keys []recentKey) (map[recentKey][]*model.Delegation, error) {
+			// the delegators are fetched by limit, the sibling fields usually sharing the same one
+			var limits []int
+
+			delegators := map[int][]string{}
+
+			for _, key := range keys {
+				if _, found := delegators[key.limit]; !found {
+					limits = append(limits, key.limit)
+				}
+
+				delegators[key.limit] = append(delegators[key.limit], key.delegator)
+			}
+
+			results := make(map[recentKey][]*model.Delegation, len(keys))
+
+			for _, limit := range limits {
+				delegations, err := ds.GetDelegatorsDelegations(ctx, delegators[limit], limit)
+				if err != nil {
+					zap.L().Error("couldn't get delegators delegations from datastore", zap.Error(err))
+
+					return nil, errGetDelegations
+				}
+
+				for _, delegation := range delegations {
+					key := recentKey{delegator: delegation.Delegator, limit: limit}
+					results[key] = append(results[key], delegation)
+				}
+			}
+
+			return results, nil
+		}),
+		delegators: newLoader(func(
+			ctx context.Context,
+			keys []delegatorsKey,
+		) (map[delegatorsKey][]*model.Delegator, error) {
+			// the bakers are fetched by page, the sibling fields usually sharing the same one
+			var pages []bakersPage
+
+			bakers := map[bakersPage][]string{}
+
+			for _, key := range keys {
+				if _, found := bakers[key.bakersPage]; !found {
+					pages = append(pages, key.bakersPage)
+				}
+
+				bakers[key.bakersPage] = append(bakers[key.bakersPage], key.baker)
+			}
+
+			results := make(map[delegatorsKey][]*model.Delegator, len(keys))
+
+			for _, page := range pages {
+				delegators, err := ds.GetBakersDelegators(
+					ctx,
+					bakers[page],
+					page.asOf,
+					offsetPage(page.offset, page.size),
+				)
+				if err != nil {
+					zap.L().Error("couldn't get bakers delegators from datastore", zap.Error(err))
+
+					return nil, errGetDelegators
+				}
+
+				for _, delegator := range delegators {
+					key := delegatorsKey{baker: delegator.Baker, bakersPage: page}
+					results[key] = append(results[key], delegator)
+				}
+			}
+
+			return results, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+// withLoaders returns a copy of ctx carrying the loaders of a query.
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+// loadersFrom returns the loaders of the query of ctx.
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+
+	return l
+}
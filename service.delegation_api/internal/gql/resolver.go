@@ -0,0 +1,405 @@
+package gql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+)
+
+// Arguments of the paginated fields.
+const (
+	argFirst = "first"
+	argAfter = "after"
+)
+
+// defaultRecentDelegations is the default number of recent delegations of a delegator.
+const defaultRecentDelegations = 10
+
+// offsetPrefix prefixes the offset cursors of the connections paginated by offset.
+const offsetPrefix = "offset:"
+
+// maxOffset is the maximum offset of the connections paginated by offset, bounding the nodes skipped by the
+// datastores.
+const maxOffset = 10000
+
+// errInvalidCursor is returned for an after argument which isn't a cursor of the connection.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// connection is a page of nodes, with its page info.
+type connection struct {
+	Nodes    any
+	PageInfo pageInfo
+	// count returns the total number of nodes, only called when requested.
+	count func(ctx context.Context) (int, error)
+}
+
+// pageInfo describes the pagination of a connection.
+type pageInfo struct {
+	// EndCursor is the cursor of the last node, empty when there is no node.
+	EndCursor string
+	// HasNextPage is true when the page is full, the next page possibly being empty.
+	HasNextPage bool
+}
+
+// resolver resolves the fields of the schema from the datastore.
+type resolver struct {
+	datastore datastore.Datastorer
+	// maxPageSize is the maximum size of a page.
+	maxPageSize int
+}
+
+// delegations resolves a page of the delegations matching the filter, the latest first, paginated by
+// delegation cursor.
+func (r *resolver) delegations(p graphql.ResolveParams) (any, error) {
+	filter, err := toFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := r.pageSize(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	page := datastore.Page{Number: 1, Size: size}
+
+	if after, ok := p.Args[argAfter].(string); ok {
+		page.After, err = datastore.DecodeCursor(after)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+
+	delegations, err := r.datastore.GetDelegations(p.Context, filter, datastore.DelegationsSort{}, nil, page)
+	if err != nil {
+		zap.L().Error("couldn't get delegations from datastore", zap.Error(err))
+
+		return nil, errGetDelegations
+	}
+
+	if delegations == nil {
+		delegations = []*model.Delegation{}
+	}
+
+	result := &connection{
+		Nodes:    delegations,
+		PageInfo: pageInfo{HasNextPage: len(delegations) == size},
+		count: func(ctx context.Context) (int, error) {
+			count, err := r.datastore.GetDelegationsCount(ctx, filter)
+			if err != nil {
+				zap.L().Error("couldn't get delegations count from datastore", zap.Error(err))
+
+				return 0, errors.New("couldn't count delegations")
+			}
+
+			return count, nil
+		},
+	}
+
+	if len(delegations) > 0 {
+		result.PageInfo.EndCursor = datastore.NewCursor(delegations[len(delegations)-1]).Encode()
+	}
+
+	return result, nil
+}
+
+// bakers resolves a page of the bakers, sorted like the REST bakers list, paginated by offset.
+func (r *resolver) bakers(p graphql.ResolveParams) (any, error) {
+	sortArg, _ := p.Args["sort"].(string)
+
+	bakersSort, err := datastore.ParseBakersSort(sortArg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sort %s", sortArg)
+	}
+
+	size, offset, err := r.offsetPagination(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	bakers, err := r.datastore.GetBakers(p.Context, bakersSort, offsetPage(offset, size))
+	if err != nil {
+		zap.L().Error("couldn't get bakers from datastore", zap.Error(err))
+
+		return nil, errGetBakers
+	}
+
+	if bakers == nil {
+		bakers = []*model.Baker{}
+	}
+
+	return &connection{
+		Nodes:    bakers,
+		PageInfo: offsetPageInfo(offset, len(bakers), size),
+		count: func(ctx context.Context) (int, error) {
+			count, err := r.datastore.GetBakersCount(ctx)
+			if err != nil {
+				zap.L().Error("couldn't get bakers count from datastore", zap.Error(err))
+
+				return 0, errors.New("couldn't count bakers")
+			}
+
+			return count, nil
+		},
+	}, nil
+}
+
+// baker resolves a baker by address, null when unknown.
+func (r *resolver) baker(p graphql.ResolveParams) (any, error) {
+	address, _ := p.Args["address"].(string)
+
+	return loadBaker(p.Context, address), nil
+}
+
+// delegator resolves the current delegation of a delegator by address, null when unknown.
+func (r *resolver) delegator(p graphql.ResolveParams) (any, error) {
+	address, _ := p.Args["address"].(string)
+
+	delegator, err := r.datastore.GetDelegator(p.Context, address)
+	if err != nil {
+		zap.L().Error("couldn't get delegator from datastore", zap.String("address", address), zap.Error(err))
+
+		return nil, errors.New("couldn't get delegator")
+	}
+
+	if delegator == nil {
+		return nil, nil
+	}
+
+	return delegator, nil
+}
+
+// bakerDelegators resolves a page of the delegators of a baker as of a point in history, sorted by address,
+// paginated by offset. The pages of the delegators of the bakers of a level are loaded at once.
+func (r *resolver) bakerDelegators(p graphql.ResolveParams) (any, error) {
+	baker, _ := p.Source.(*model.Baker)
+
+	asOf, err := toAsOf(p.Args["asOf"])
+	if err != nil {
+		return nil, err
+	}
+
+	size, offset, err := r.offsetPagination(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf.Timestamp = asOf.Timestamp.UTC()
+
+	thunk := loadersFrom(p.Context).delegators.load(p.Context, delegatorsKey{
+		baker:      baker.Address,
+		bakersPage: bakersPage{asOf: asOf, offset: offset, size: size},
+	})
+
+	return func() (any, error) {
+		delegators, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+
+		if delegators == nil {
+			delegators = []*model.Delegator{}
+		}
+
+		return &connection{
+			Nodes:    delegators,
+			PageInfo: offsetPageInfo(offset, len(delegators), size),
+		}, nil
+	}, nil
+}
+
+// delegatorDelegations resolves the recent delegations of a delegator, the latest first. The recent delegations
+// of the delegators of a level are loaded at once, at most the page size by delegator.
+func (r *resolver) delegatorDelegations(p graphql.ResolveParams) (any, error) {
+	delegator, _ := p.Source.(*model.Delegator)
+
+	size, err := r.pageSize(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	thunk := loadersFrom(p.Context).delegations.load(p.Context, recentKey{delegator: delegator.Address, limit: size})
+
+	return func() (any, error) {
+		delegations, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+
+		recent := make([]*model.Delegation, 0, len(delegations))
+		for i := len(delegations) - 1; i >= 0; i-- {
+			recent = append(recent, delegations[i])
+		}
+
+		return recent, nil
+	}, nil
+}
+
+// pageSize returns the size of a page from the first argument.
+func (r *resolver) pageSize(args map[string]any) (int, error) {
+	size, _ := args[argFirst].(int)
+	if size < 1 || size > r.maxPageSize {
+		return 0, fmt.Errorf("invalid first %d, expected between 1 and %d", size, r.maxPageSize)
+	}
+
+	return size, nil
+}
+
+// offsetPagination returns the size of a page from the first argument, and its offset from the after
+// argument. The offset is the end of a previous page of the same size, at most maxOffset.
+func (r *resolver) offsetPagination(args map[string]any) (int, int, error) {
+	size, err := r.pageSize(args)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	offset := 0
+
+	if after, ok := args[argAfter].(string); ok {
+		offset, err = decodeOffset(after)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if offset%size != 0 {
+		return 0, 0, fmt.Errorf("invalid after, expected the end cursor of a previous page of %d nodes", size)
+	}
+
+	if offset > maxOffset {
+		return 0, 0, fmt.Errorf("invalid after, expected a cursor within the first %d nodes", maxOffset)
+	}
+
+	return size, offset, nil
+}
+
+// loadBaker returns a thunk resolving a baker by address, null when unknown or when the address is empty.
+// The bakers of a level are loaded at once.
+func loadBaker(ctx context.Context, address string) any {
+	if address == "" {
+		return nil
+	}
+
+	thunk := loadersFrom(ctx).bakers.load(ctx, address)
+
+	return func() (any, error) {
+		baker, err := thunk()
+		if err != nil || baker == nil {
+			return nil, err
+		}
+
+		return baker, nil
+	}
+}
+
+// offsetPage returns the page starting at offset, a multiple of size, for the datastores paginating by page
+// number.
+func offsetPage(offset, size int) datastore.Page {
+	return datastore.Page{Number: offset/size + 1, Size: size}
+}
+
+// offsetPageInfo returns the page info of a page of nodes starting at offset.
+func offsetPageInfo(offset, nodes, size int) pageInfo {
+	info := pageInfo{HasNextPage: nodes == size}
+	if nodes > 0 {
+		info.EndCursor = encodeOffset(offset + nodes)
+	}
+
+	return info
+}
+
+// encodeOffset encodes the cursor of the nodes following offset, to an opaque URL safe token.
+func encodeOffset(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetPrefix + strconv.Itoa(offset)))
+}
+
+// decodeOffset decodes a cursor returned by encodeOffset.
+func decodeOffset(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	value, found := strings.CutPrefix(string(raw), offsetPrefix)
+	if !found {
+		return 0, errInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+
+	return offset, nil
+}
+
+// toFilter converts a delegations filter argument, nil matching every delegation.
+func toFilter(arg any) (datastore.Filter, error) {
+	fields, _ := arg.(map[string]any)
+
+	filter := datastore.Filter{}
+
+	if year, ok := fields["year"].(int); ok {
+		if year < param.MinYear || year > param.MaxYear {
+			return datastore.Filter{}, fmt.Errorf(
+				"invalid year %d, expected between %d and %d",
+				year,
+				param.MinYear,
+				param.MaxYear,
+			)
+		}
+
+		filter.Year = year
+	}
+
+	filter.From, _ = fields["from"].(time.Time)
+	filter.To, _ = fields["to"].(time.Time)
+	filter.Delegator, _ = fields["delegator"].(string)
+	filter.Baker, _ = fields["baker"].(string)
+	filter.Block, _ = fields["block"].(string)
+	filter.Kind, _ = fields["kind"].(string)
+	filter.Status, _ = fields["status"].(string)
+
+	if level, ok := fields["level"].(int); ok {
+		filter.Level = int64(level)
+	}
+
+	if amount, ok := fields["amountGt"].(int64); ok {
+		filter.AmountGt = &amount
+	}
+
+	if amount, ok := fields["amountLt"].(int64); ok {
+		filter.AmountLt = &amount
+	}
+
+	return filter, nil
+}
+
+// toAsOf converts an as of argument, nil being now.
+func toAsOf(arg any) (datastore.AsOf, error) {
+	fields, _ := arg.(map[string]any)
+
+	asOf := datastore.AsOf{}
+	asOf.Timestamp, _ = fields["timestamp"].(time.Time)
+
+	if level, ok := fields["level"].(int); ok {
+		asOf.Level = int64(level)
+	}
+
+	if !asOf.Timestamp.IsZero() && asOf.Level != 0 {
+		return datastore.AsOf{}, errors.New("invalid as of, expected a timestamp or a level")
+	}
+
+	return asOf, nil
+}
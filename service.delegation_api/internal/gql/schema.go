@@ -0,0 +1,316 @@
+package gql
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+)
+
+// mutez is an amount in mutez. Amounts overflow the 32 bits GraphQL Int.
+var mutez = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Mutez",
+	Description: "An amount in mutez (1 tez is 1,000,000 mutez), serialized as an integer.",
+	Serialize: func(value any) any {
+		if amount, ok := value.(int64); ok {
+			return amount
+		}
+
+		return nil
+	},
+	ParseValue: func(value any) any {
+		switch value := value.(type) {
+		case int:
+			return int64(value)
+		case float64:
+			// variables are decoded from JSON as floats
+			if value == math.Trunc(value) {
+				return int64(value)
+			}
+		}
+
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		if value, ok := valueAST.(*ast.IntValue); ok {
+			if amount, err := strconv.ParseInt(value.Value, 10, 64); err == nil {
+				return amount
+			}
+		}
+
+		return nil
+	},
+})
+
+var delegationKind = graphql.NewEnum(graphql.EnumConfig{
+	Name: "DelegationKind",
+	Values: graphql.EnumValueConfigMap{
+		"DELEGATION": {
+			Value:       model.KindDelegation,
+			Description: "A delegation to a baker from an undelegated address.",
+		},
+		"REDELEGATION": {Value: model.KindRedelegation, Description: "A delegation moving from a baker to another."},
+		"UNDELEGATION": {Value: model.KindUndelegation, Description: "A delegation removed, without baker."},
+	},
+})
+
+var delegationStatus = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "DelegationStatus",
+	Description: "The status of a delegation operation, only applied delegations being ingested.",
+	Values: graphql.EnumValueConfigMap{
+		"APPLIED":     {Value: model.StatusApplied},
+		"FAILED":      {Value: model.StatusFailed},
+		"BACKTRACKED": {Value: model.StatusBacktracked},
+		"SKIPPED":     {Value: model.StatusSkipped},
+	},
+})
+
+var delegationsFilter = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "DelegationsFilter",
+	Description: "The filters of the delegations, each set field narrowing the matched delegations.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"year":      {Type: graphql.Int, Description: "Delegations of the year (UTC)."},
+		"from":      {Type: graphql.DateTime, Description: "Delegations at or after the timestamp."},
+		"to":        {Type: graphql.DateTime, Description: "Delegations before the timestamp."},
+		"delegator": {Type: graphql.String, Description: "Delegations of the delegator address."},
+		"baker":     {Type: graphql.String, Description: "Delegations to the baker address."},
+		"block":     {Type: graphql.String, Description: "Delegations of the block hash."},
+		"level":     {Type: graphql.Int, Description: "Delegations of the block level."},
+		"amountGt":  {Type: mutez, Description: "Delegations of an amount greater than the value."},
+		"amountLt":  {Type: mutez, Description: "Delegations of an amount lower than the value."},
+		"kind":      {Type: delegationKind, Description: "Delegations of the kind."},
+		"status":    {Type: delegationStatus, Description: "Delegations of the status."},
+	},
+})
+
+var asOfInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "AsOf",
+	Description: "A point in the delegations history, either a timestamp or a block level.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"timestamp": {Type: graphql.DateTime},
+		"level":     {Type: graphql.Int},
+	},
+})
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"endCursor": {
+			Type:        graphql.String,
+			Description: "The cursor of the last node, to request the next page with after, null without node.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				info, _ := p.Source.(pageInfo)
+				if info.EndCursor == "" {
+					return nil, nil
+				}
+
+				return info.EndCursor, nil
+			},
+		},
+		"hasNextPage": {
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: "Whether the page is full, the next page possibly being empty.",
+		},
+	},
+})
+
+// newSchema returns the schema of the delegations, delegators and bakers, resolved by r.
+//
+//nolint:funlen
+func newSchema(r *resolver) (graphql.Schema, error) {
+	var bakerType, delegatorType, delegationType *graphql.Object
+
+	// the types reference each other, so their fields are thunks
+	bakerField := func(description string, resolve graphql.FieldResolveFn) *graphql.Field {
+		return &graphql.Field{Type: bakerType, Description: description, Resolve: resolve}
+	}
+
+	first := func(defaultSize int) *graphql.ArgumentConfig {
+		return &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: min(defaultSize, r.maxPageSize),
+			Description:  "The size of the page, at most " + strconv.Itoa(r.maxPageSize) + ".",
+		}
+	}
+
+	after := &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "The end cursor of the previous page.",
+	}
+
+	offsetAfter := &graphql.ArgumentConfig{
+		Type: graphql.String,
+		Description: "The end cursor of the previous page, of the same size, within the first " +
+			strconv.Itoa(maxOffset) + " nodes.",
+	}
+
+	delegationType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Delegation",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        {Type: graphql.NewNonNull(graphql.ID), Description: "The tezos operation id."},
+				"timestamp": {Type: graphql.NewNonNull(graphql.DateTime)},
+				"amount":    {Type: graphql.NewNonNull(mutez)},
+				"delegator": {Type: graphql.NewNonNull(graphql.String), Description: "The delegator address."},
+				"block":     {Type: graphql.NewNonNull(graphql.String), Description: "The block hash."},
+				"level":     {Type: graphql.Int, Description: "The block level, 0 when unknown."},
+				"kind": {
+					Type: graphql.NewNonNull(delegationKind),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						delegation, _ := p.Source.(*model.Delegation)
+
+						return datastore.DelegationKind(delegation), nil
+					},
+				},
+				"baker": bakerField(
+					"The baker delegated to, null for an undelegation.",
+					func(p graphql.ResolveParams) (any, error) {
+						delegation, _ := p.Source.(*model.Delegation)
+
+						return loadBaker(p.Context, delegation.Baker), nil
+					},
+				),
+				"previousBaker": bakerField(
+					"The baker delegated to before, null if none.",
+					func(p graphql.ResolveParams) (any, error) {
+						delegation, _ := p.Source.(*model.Delegation)
+
+						return loadBaker(p.Context, delegation.PreviousBaker), nil
+					},
+				),
+			}
+		}),
+	})
+
+	delegatorType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Delegator",
+		Description: "The current delegation of an address, which is its latest delegation.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"address":      {Type: graphql.NewNonNull(graphql.String)},
+				"amount":       {Type: graphql.NewNonNull(mutez)},
+				"timestamp":    {Type: graphql.NewNonNull(graphql.DateTime)},
+				"level":        {Type: graphql.Int},
+				"delegationId": {Type: graphql.NewNonNull(graphql.ID)},
+				"baker": bakerField(
+					"The baker currently delegated to, null when undelegated.",
+					func(p graphql.ResolveParams) (any, error) {
+						delegator, _ := p.Source.(*model.Delegator)
+
+						return loadBaker(p.Context, delegator.Baker), nil
+					},
+				),
+				"delegations": {
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(delegationType))),
+					Description: "The recent delegations of the delegator, the latest first.",
+					Args:        graphql.FieldConfigArgument{argFirst: first(defaultRecentDelegations)},
+					Resolve:     r.delegatorDelegations,
+				},
+			}
+		}),
+	})
+
+	bakerType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Baker",
+		Description: "The delegations aggregate of a baker.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"address": {Type: graphql.NewNonNull(graphql.String)},
+				"delegatorsCount": {
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The number of addresses currently delegating to the baker.",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						baker, _ := p.Source.(*model.Baker)
+
+						return baker.Delegators, nil
+					},
+				},
+				"delegatedAmount": {Type: graphql.NewNonNull(mutez)},
+				"inflows":         {Type: graphql.NewNonNull(graphql.Int)},
+				"inflowAmount":    {Type: graphql.NewNonNull(mutez)},
+				"outflows":        {Type: graphql.NewNonNull(graphql.Int)},
+				"outflowAmount":   {Type: graphql.NewNonNull(mutez)},
+				"delegators": {
+					Type:        graphql.NewNonNull(connectionType("DelegatorConnection", delegatorType, false)),
+					Description: "The delegators of the baker as of a point in history, now by default, by address.",
+					Args: graphql.FieldConfigArgument{
+						argFirst: first(param.DefaultPageSize),
+						argAfter: offsetAfter,
+						"asOf":   {Type: asOfInput},
+					},
+					Resolve: r.bakerDelegators,
+				},
+			}
+		}),
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"delegations": {
+				Type:        graphql.NewNonNull(connectionType("DelegationConnection", delegationType, true)),
+				Description: "The delegations matching the filter, the latest first.",
+				Args: graphql.FieldConfigArgument{
+					"filter": {Type: delegationsFilter},
+					argFirst: first(param.DefaultPageSize),
+					argAfter: after,
+				},
+				Resolve: r.delegations,
+			},
+			"bakers": {
+				Type:        graphql.NewNonNull(connectionType("BakerConnection", bakerType, true)),
+				Description: "The bakers, by number of delegators descending by default.",
+				Args: graphql.FieldConfigArgument{
+					"sort": {
+						Type: graphql.String,
+						Description: "The sort field (address, delegators, delegatedAmount, inflows or outflows), " +
+							"prefixed by - for a descending order.",
+					},
+					argFirst: first(param.DefaultPageSize),
+					argAfter: offsetAfter,
+				},
+				Resolve: r.bakers,
+			},
+			"baker": {
+				Type:        bakerType,
+				Description: "A baker by address, null when unknown.",
+				Args:        graphql.FieldConfigArgument{"address": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve:     r.baker,
+			},
+			"delegator": {
+				Type:        delegatorType,
+				Description: "A delegator by address, null when unknown.",
+				Args:        graphql.FieldConfigArgument{"address": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve:     r.delegator,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// connectionType returns the type of the connections of nodes, with their total count when counted.
+func connectionType(name string, node *graphql.Object, counted bool) *graphql.Object {
+	fields := graphql.Fields{
+		"nodes":    {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(node)))},
+		"pageInfo": {Type: graphql.NewNonNull(pageInfoType)},
+	}
+
+	if counted {
+		fields["totalCount"] = &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				c, _ := p.Source.(*connection)
+
+				return c.count(p.Context)
+			},
+		}
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: fields})
+}
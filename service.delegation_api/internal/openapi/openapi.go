@@ -0,0 +1,80 @@
+// Package openapi serves the OpenAPI specification of the API and its Swagger UI documentation.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	swaggerfiles "github.com/swaggo/files/v2"
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Spec is the OpenAPI 3.1 specification of the API, as JSON.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// docsPage is the Swagger UI page of the specification, its assets being served by AssetsHandler.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Tezos delegation API</title>
+  <link rel="stylesheet" href="/docs/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// SpecHandler handles /openapi.json endpoint, returning the OpenAPI specification.
+func SpecHandler(w http.ResponseWriter, _ *http.Request) {
+	write(w, "application/json", Spec)
+}
+
+// DocsHandler handles /docs endpoint, returning the Swagger UI page of the specification.
+func DocsHandler(w http.ResponseWriter, _ *http.Request) {
+	write(w, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+// AssetsHandler handles /docs/ endpoint, returning the Swagger UI assets of the documentation page. The assets
+// are embedded from the version of the swaggo/files module pinned by go.mod, instead of being loaded from a CDN.
+func AssetsHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/docs/")
+
+	// only the assets of the documentation page are served, with their content type
+	var contentType string
+
+	switch name {
+	case "swagger-ui.css":
+		contentType = "text/css; charset=utf-8"
+	case "swagger-ui-bundle.js":
+		contentType = "text/javascript; charset=utf-8"
+	default:
+		problem.NotFound(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.StripPrefix("/docs/", http.FileServer(http.FS(swaggerfiles.FS))).ServeHTTP(w, r)
+}
+
+func write(w http.ResponseWriter, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(body); err != nil {
+		zap.L().Error("error writing documentation response", zap.Error(err))
+	}
+}
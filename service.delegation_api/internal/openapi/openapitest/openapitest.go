@@ -0,0 +1,457 @@
+// Package openapitest validates the API requests and responses against its OpenAPI specification in tests,
+// so that the handlers and the specification can't drift apart.
+package openapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/openapi"
+)
+
+// problemSchema is the JSON pointer of the problem details schema, which undocumented operations answer with.
+const problemSchema = "#/components/schemas/Problem"
+
+// Validator validates requests and responses against the OpenAPI specification.
+type Validator struct {
+	t testing.TB
+	// document is the specification, schemas being validated by reference into it
+	document map[string]any
+	paths    []*path
+
+	mu sync.Mutex
+	// schemas are the compiled schemas by JSON pointer
+	schemas map[string]*gojsonschema.Schema
+}
+
+// path is a documented path, e.g. /xtz/bakers/{address}.
+type path struct {
+	segments   []string
+	operations map[string]*operation
+}
+
+// operation is a documented operation of a path.
+type operation struct {
+	parameters []*parameter
+	// body is the JSON pointer of the request body schema, empty when there is no request body
+	body string
+	// responses are the responses by status code
+	responses map[string]*response
+}
+
+// parameter is a documented parameter of an operation.
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	// schema is the JSON pointer of the parameter schema
+	schema string
+	// integer is true when the parameter is an integer, parsed before being validated
+	integer bool
+}
+
+// response is a documented response of an operation.
+type response struct {
+	// content are the JSON pointers of the response schemas by media type
+	content map[string]string
+}
+
+// New returns a Validator of the API OpenAPI specification, failing the test when it can't be parsed.
+func New(t testing.TB) *Validator {
+	t.Helper()
+
+	v := &Validator{t: t, schemas: map[string]*gojsonschema.Schema{}}
+	require.NoError(t, json.Unmarshal(openapi.Spec, &v.document), "Error parsing OpenAPI specification")
+
+	paths, ok := v.document["paths"].(map[string]any)
+	require.True(t, ok, "OpenAPI specification without paths")
+
+	for template := range paths {
+		p := &path{
+			segments:   strings.Split(template, "/"),
+			operations: map[string]*operation{},
+		}
+
+		pathPointer := "#/paths/" + escape(template)
+		for method := range v.object(pathPointer) {
+			if method == "parameters" {
+				continue
+			}
+
+			p.operations[strings.ToUpper(method)] = v.operation(pathPointer + "/" + method)
+		}
+
+		v.paths = append(v.paths, p)
+	}
+
+	return v
+}
+
+// Middleware returns a handler validating the requests served by next and its responses, failing the test
+// when they drift from the specification:
+//   - an undocumented operation must be answered with a 404 or 405 problem,
+//   - a successful request must have documented and valid parameters and body,
+//   - only a 400 response may answer a request with undocumented query parameters,
+//   - the response status, media type and body must be documented by the operation.
+//
+// JSON and newline delimited JSON bodies are validated against their schema, the other ones by media type.
+// The response is buffered, so next mustn't stream endlessly.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+
+		if r.Body != nil {
+			var err error
+
+			body, err = io.ReadAll(r.Body)
+			require.NoError(v.t, err, "Error reading request body")
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		recorder := httptest.NewRecorder()
+		next.ServeHTTP(recorder, r)
+
+		v.validate(r, body, recorder)
+
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(recorder.Body.Bytes())
+	})
+}
+
+// QueryParams returns the names of the documented query parameters of the operation of a request, false when
+// the operation isn't documented.
+func (v *Validator) QueryParams(method, requestPath string) ([]string, bool) {
+	op := v.match(method, requestPath)
+	if op == nil {
+		return nil, false
+	}
+
+	var names []string
+
+	for _, p := range op.parameters {
+		if p.in == "query" {
+			names = append(names, p.name)
+		}
+	}
+
+	return names, true
+}
+
+// validate validates a served request and its response.
+func (v *Validator) validate(r *http.Request, body []byte, recorder *httptest.ResponseRecorder) {
+	request := r.Method + " " + r.URL.RequestURI()
+	status := recorder.Code
+
+	op := v.match(r.Method, r.URL.Path)
+	if op == nil {
+		if status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+			v.t.Errorf("%s: undocumented operation answered with status %d", request, status)
+
+			return
+		}
+
+		v.validateBody(request, problemSchema, recorder.Body.Bytes())
+
+		return
+	}
+
+	if status < http.StatusBadRequest {
+		v.validateRequest(request, op, r, body)
+	}
+
+	if status != http.StatusBadRequest {
+		for name := range r.URL.Query() {
+			if !op.hasQueryParam(name) {
+				v.t.Errorf("%s: undocumented query parameter %s answered with status %d", request, name, status)
+			}
+		}
+	}
+
+	v.validateResponse(request, op, recorder)
+}
+
+// validateRequest validates the parameters and body of a request which was accepted by its handler.
+func (v *Validator) validateRequest(request string, op *operation, r *http.Request, body []byte) {
+	for _, p := range op.parameters {
+		if p.in != "query" {
+			continue
+		}
+
+		values, found := r.URL.Query()[p.name]
+		if !found {
+			if p.required {
+				v.t.Errorf("%s: required query parameter %s is missing", request, p.name)
+			}
+
+			continue
+		}
+
+		var value any = values[0]
+
+		if p.integer {
+			parsed, err := strconv.ParseInt(values[0], 10, 64)
+			if err != nil {
+				v.t.Errorf("%s: accepted query parameter %s isn't an integer", request, p.name)
+
+				continue
+			}
+
+			value = parsed
+		}
+
+		v.validateValue(fmt.Sprintf("%s: query parameter %s", request, p.name), p.schema, value)
+	}
+
+	if op.body != "" {
+		v.validateBody(request+": request body", op.body, body)
+	}
+}
+
+// validateResponse validates the status, media type and body of a response.
+func (v *Validator) validateResponse(request string, op *operation, recorder *httptest.ResponseRecorder) {
+	resp, found := op.responses[strconv.Itoa(recorder.Code)]
+	if !found {
+		resp, found = op.responses["default"]
+	}
+
+	if !found {
+		v.t.Errorf("%s: undocumented response status %d", request, recorder.Code)
+
+		return
+	}
+
+	if len(resp.content) == 0 {
+		if recorder.Body.Len() != 0 {
+			v.t.Errorf("%s: undocumented response body with status %d", request, recorder.Code)
+		}
+
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	if err != nil {
+		v.t.Errorf("%s: invalid response content type %q", request, recorder.Header().Get("Content-Type"))
+
+		return
+	}
+
+	schema, found := resp.content[mediaType]
+	if !found {
+		v.t.Errorf("%s: undocumented response media type %s with status %d", request, mediaType, recorder.Code)
+
+		return
+	}
+
+	switch {
+	case schema == "":
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		v.validateBody(request, schema, recorder.Body.Bytes())
+	case mediaType == "application/x-ndjson":
+		for i, line := range bytes.Split(bytes.TrimSpace(recorder.Body.Bytes()), []byte("\n")) {
+			if len(line) > 0 {
+				v.validateBody(fmt.Sprintf("%s: line %d", request, i+1), schema, line)
+			}
+		}
+	}
+}
+
+// validateBody validates a JSON body against the schema at a JSON pointer.
+func (v *Validator) validateBody(context, schema string, body []byte) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		v.t.Errorf("%s: invalid JSON body %q: %v", context, body, err)
+
+		return
+	}
+
+	v.validateValue(context, schema, value)
+}
+
+// validateValue validates a value against the schema at a JSON pointer.
+func (v *Validator) validateValue(context, schema string, value any) {
+	result, err := v.schema(schema).Validate(gojsonschema.NewGoLoader(value))
+	require.NoError(v.t, err, "Error validating %s", context)
+
+	for _, resultErr := range result.Errors() {
+		v.t.Errorf("%s: %s", context, resultErr)
+	}
+}
+
+// schema returns the compiled schema at a JSON pointer, resolving its references in the specification.
+func (v *Validator) schema(pointer string) *gojsonschema.Schema {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if schema, found := v.schemas[pointer]; found {
+		return schema
+	}
+
+	// the schema is the specification referencing the pointer, so that the references it contains resolve
+	document := make(map[string]any, len(v.document)+1)
+	for key, value := range v.document {
+		document[key] = value
+	}
+
+	document["$ref"] = pointer
+
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(document))
+	require.NoError(v.t, err, "Error compiling schema %s", pointer)
+
+	v.schemas[pointer] = schema
+
+	return schema
+}
+
+// match returns the operation of a request, the path with the most literal segments matching, nil when the
+// operation isn't documented.
+func (v *Validator) match(method, requestPath string) *operation {
+	segments := strings.Split(requestPath, "/")
+
+	var (
+		matched  *operation
+		literals = -1
+	)
+
+	for _, p := range v.paths {
+		op, found := p.operations[method]
+		if !found || len(p.segments) != len(segments) {
+			continue
+		}
+
+		pathLiterals, matches := 0, true
+
+		for i, segment := range p.segments {
+			switch {
+			case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+				matches = matches && segments[i] != ""
+			case segment == segments[i]:
+				pathLiterals++
+			default:
+				matches = false
+			}
+		}
+
+		if matches && pathLiterals > literals {
+			matched, literals = op, pathLiterals
+		}
+	}
+
+	return matched
+}
+
+// operation parses the operation at a JSON pointer.
+func (v *Validator) operation(pointer string) *operation {
+	node := v.object(pointer)
+	op := &operation{responses: map[string]*response{}}
+
+	parameters, _ := node["parameters"].([]any)
+	for i := range parameters {
+		paramPointer, param := v.resolve(fmt.Sprintf("%s/parameters/%d", pointer, i))
+		schema, _ := param["schema"].(map[string]any)
+
+		op.parameters = append(op.parameters, &parameter{
+			name:     fmt.Sprint(param["name"]),
+			in:       fmt.Sprint(param["in"]),
+			required: param["required"] == true,
+			schema:   paramPointer + "/schema",
+			integer:  schema["type"] == "integer",
+		})
+	}
+
+	if _, found := node["requestBody"]; found {
+		bodyPointer, _ := v.resolve(pointer + "/requestBody")
+		op.body = bodyPointer + "/content/" + escape("application/json") + "/schema"
+	}
+
+	for status := range v.object(pointer + "/responses") {
+		responsePointer, node := v.resolve(pointer + "/responses/" + escape(status))
+		resp := &response{content: map[string]string{}}
+
+		content, _ := node["content"].(map[string]any)
+		for mediaType, mediaNode := range content {
+			resp.content[mediaType] = ""
+
+			if _, found := mediaNode.(map[string]any)["schema"]; found {
+				resp.content[mediaType] = responsePointer + "/content/" + escape(mediaType) + "/schema"
+			}
+		}
+
+		op.responses[status] = resp
+	}
+
+	return op
+}
+
+// resolve returns the object at a JSON pointer, following its reference, along with its resolved pointer.
+func (v *Validator) resolve(pointer string) (string, map[string]any) {
+	node := v.object(pointer)
+
+	if ref, found := node["$ref"].(string); found {
+		return v.resolve(ref)
+	}
+
+	return pointer, node
+}
+
+// object returns the object at a JSON pointer, failing the test when there is none.
+func (v *Validator) object(pointer string) map[string]any {
+	var node any = v.document
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch parent := node.(type) {
+		case map[string]any:
+			node = parent[token]
+		case []any:
+			i, err := strconv.Atoi(token)
+			require.True(
+				v.t,
+				err == nil && i >= 0 && i < len(parent),
+				"Invalid OpenAPI specification pointer %s", pointer,
+			)
+
+			node = parent[i]
+		default:
+			require.Fail(v.t, "Invalid OpenAPI specification pointer "+pointer)
+		}
+	}
+
+	object, ok := node.(map[string]any)
+	require.True(v.t, ok, "Invalid OpenAPI specification pointer %s", pointer)
+
+	return object
+}
+
+// hasQueryParam reports whether the operation documents a query parameter.
+func (o *operation) hasQueryParam(name string) bool {
+	for _, p := range o.parameters {
+		if p.in == "query" && p.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// escape escapes a JSON pointer token.
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
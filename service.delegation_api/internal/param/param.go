@@ -0,0 +1,228 @@
+// Package param parses the API query parameters.
+package param
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+)
+
+const (
+	// MinYear is the minimum year query parameter, the year the Tezos mainnet launched.
+	MinYear = 2018
+	// MaxYear is the maximum year query parameter, the last year of a RFC3339 timestamp.
+	MaxYear = 9999
+	// DefaultPageSize is the size of a page when not given.
+	DefaultPageSize = 100
+	// MaxPageSize is the maximum size of a page, unless configured otherwise.
+	MaxPageSize = 1000
+)
+
+// Error is an invalid query parameter.
+type Error struct {
+	// Param is the name of the query parameter.
+	Param string
+	// Range is the allowed range of an integer query parameter, nil for the other parameters.
+	Range *Range
+	// Unknown is true when the query parameter isn't supported by the endpoint.
+	Unknown bool
+	message string
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+// Errorf returns an invalid query parameter error, formatting its message.
+func Errorf(paramName, format string, args ...any) *Error {
+	return &Error{Param: paramName, message: fmt.Sprintf(format, args...)}
+}
+
+// Range is the inclusive range of an integer query parameter, a nil bound being unbounded.
+type Range struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// Between returns the [min, max] range.
+func Between(min, max int) *Range {
+	low, high := int64(min), int64(max)
+
+	return &Range{Min: &low, Max: &high}
+}
+
+// AtLeast returns the [min, +inf) range.
+func AtLeast(min int) *Range {
+	low := int64(min)
+
+	return &Range{Min: &low}
+}
+
+// contains returns whether a value is within the range.
+func (r *Range) contains(value int) bool {
+	return (r.Min == nil || int64(value) >= *r.Min) && (r.Max == nil || int64(value) <= *r.Max)
+}
+
+// String returns the range as a message, e.g. between 1 and 100.
+func (r *Range) String() string {
+	switch {
+	case r.Max == nil:
+		return fmt.Sprintf("at least %d", *r.Min)
+	case r.Min == nil:
+		return fmt.Sprintf("at most %d", *r.Max)
+	default:
+		return fmt.Sprintf("between %d and %d", *r.Min, *r.Max)
+	}
+}
+
+// Int parses an integer query parameter, 0 when the parameter is empty.
+func Int(paramName, paramValue string) (int, error) {
+	if paramValue == "" {
+		return 0, nil
+	}
+
+	parsedValue, err := strconv.Atoi(paramValue)
+	if err != nil {
+		zap.L().Error(
+			"couldn't parse query parameter value",
+			zap.String("paramName", paramName),
+			zap.String("paramValue", paramValue),
+			zap.Error(err),
+		)
+
+		return 0, Errorf(paramName, "couldn't parse value %s for query parameter %s", paramValue, paramName)
+	}
+
+	return parsedValue, nil
+}
+
+// IntRange parses an integer query parameter within a range, 0 when the parameter is empty.
+func IntRange(paramName, paramValue string, r *Range) (int, error) {
+	parsedValue, err := Int(paramName, paramValue)
+	if err != nil {
+		return 0, &Error{Param: paramName, Range: r, message: err.Error()}
+	}
+
+	if paramValue != "" && !r.contains(parsedValue) {
+		return 0, &Error{
+			Param: paramName,
+			Range: r,
+			message: fmt.Sprintf(
+				"invalid value %d for query parameter %s, expected %s",
+				parsedValue,
+				paramName,
+				r,
+			),
+		}
+	}
+
+	return parsedValue, nil
+}
+
+// Year parses a year query parameter, between MinYear and MaxYear, 0 when the parameter is empty.
+func Year(paramName, paramValue string) (int, error) {
+	return IntRange(paramName, paramValue, Between(MinYear, MaxYear))
+}
+
+// Page parses the page and size query parameters. The page number is at least 1, 1 by default, and the size
+// between 1 and maxSize, defaultSize by default.
+func Page(query url.Values, defaultSize, maxSize int) (datastore.Page, error) {
+	number, err := IntRange("page", query.Get("page"), AtLeast(1))
+	if err != nil {
+		return datastore.Page{}, err
+	}
+
+	size, err := IntRange("size", query.Get("size"), Between(1, maxSize))
+	if err != nil {
+		return datastore.Page{}, err
+	}
+
+	if number == 0 {
+		number = 1
+	}
+
+	if size == 0 {
+		size = min(defaultSize, maxSize)
+	}
+
+	return datastore.Page{Number: number, Size: size}, nil
+}
+
+// Int64 parses a 64-bit integer query parameter, nil when the parameter is empty.
+func Int64(paramName, paramValue string) (*int64, error) {
+	if paramValue == "" {
+		return nil, nil
+	}
+
+	parsedValue, err := strconv.ParseInt(paramValue, 10, 64)
+	if err != nil {
+		zap.L().Error(
+			"couldn't parse query parameter value",
+			zap.String("paramName", paramName),
+			zap.String("paramValue", paramValue),
+			zap.Error(err),
+		)
+
+		return nil, Errorf(paramName, "couldn't parse value %s for query parameter %s", paramValue, paramName)
+	}
+
+	return &parsedValue, nil
+}
+
+// Time parses a RFC3339 timestamp query parameter, the zero time when the parameter is empty.
+func Time(paramName, paramValue string) (time.Time, error) {
+	if paramValue == "" {
+		return time.Time{}, nil
+	}
+
+	parsedValue, err := time.Parse(time.RFC3339, paramValue)
+	if err != nil {
+		zap.L().Error(
+			"couldn't parse query parameter value",
+			zap.String("paramName", paramName),
+			zap.String("paramValue", paramValue),
+			zap.Error(err),
+		)
+
+		return time.Time{}, Errorf(
+			paramName,
+			"couldn't parse value %s for query parameter %s, expected RFC3339 format",
+			paramValue,
+			paramName,
+		)
+	}
+
+	return parsedValue, nil
+}
+
+// Check returns an unknown query parameter error for the first query parameter, by name, which isn't
+// allowed. Operator filters are allowed by their full name, e.g. amount.gt isn't allowed by amount.
+func Check(query url.Values, allowed ...string) error {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !slices.Contains(allowed, name) {
+			message := fmt.Sprintf("unknown query parameter %s, expected %s", name, strings.Join(allowed, ", "))
+			if len(allowed) == 0 {
+				message = fmt.Sprintf("unknown query parameter %s, expected no query parameter", name)
+			}
+
+			return &Error{Param: name, Unknown: true, message: message}
+		}
+	}
+
+	return nil
+}
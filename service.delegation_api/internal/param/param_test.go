@@ -0,0 +1,303 @@
+package param_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+)
+
+func TestInt(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr string
+	}{
+		{name: "Success", value: "2023", want: 2023},
+		{name: "Success empty", value: "", want: 0},
+		{name: "Error not an integer", value: "abc", wantErr: "couldn't parse value abc for query parameter year"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := param.Int("year", c.value)
+			if c.wantErr != "" {
+				assert.EqualError(t, err, c.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestIntRange(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		value   string
+		r       *param.Range
+		want    int
+		wantErr string
+	}{
+		{name: "Success", value: "10", r: param.Between(1, 100), want: 10},
+		{name: "Success bound", value: "100", r: param.Between(1, 100), want: 100},
+		{name: "Success empty", value: "", r: param.Between(1, 100)},
+		{name: "Success unbounded", value: "1000000", r: param.AtLeast(1), want: 1000000},
+		{
+			name:    "Error below the range",
+			value:   "0",
+			r:       param.Between(1, 100),
+			wantErr: "invalid value 0 for query parameter size, expected between 1 and 100",
+		},
+		{
+			name:    "Error above the range",
+			value:   "101",
+			r:       param.Between(1, 100),
+			wantErr: "invalid value 101 for query parameter size, expected between 1 and 100",
+		},
+		{
+			name:    "Error below an unbounded range",
+			value:   "-1",
+			r:       param.AtLeast(1),
+			wantErr: "invalid value -1 for query parameter size, expected at least 1",
+		},
+		{
+			name:    "Error not an integer",
+			value:   "abc",
+			r:       param.Between(1, 100),
+			wantErr: "couldn't parse value abc for query parameter size",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := param.IntRange("size", c.value, c.r)
+			assert.Equal(t, c.want, got)
+
+			if c.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, c.wantErr)
+
+			var paramErr *param.Error
+			require.ErrorAs(t, err, &paramErr)
+			assert.Equal(t, "size", paramErr.Param)
+			assert.Equal(t, c.r, paramErr.Range)
+		})
+	}
+}
+
+func TestYear(t *testing.T) {
+	t.Parallel()
+
+	got, err := param.Year("year", "2023")
+	require.NoError(t, err)
+	assert.Equal(t, 2023, got)
+
+	_, err = param.Year("year", "1999")
+	assert.EqualError(t, err, "invalid value 1999 for query parameter year, expected between 2018 and 9999")
+}
+
+func TestPage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		query   url.Values
+		want    datastore.Page
+		wantErr string
+	}{
+		{name: "Success default", query: url.Values{}, want: datastore.Page{Number: 1, Size: 100}},
+		{
+			name:  "Success page and size",
+			query: url.Values{"page": {"3"}, "size": {"1000"}},
+			want:  datastore.Page{Number: 3, Size: 1000},
+		},
+		{
+			name:    "Error page 0",
+			query:   url.Values{"page": {"0"}},
+			wantErr: "invalid value 0 for query parameter page, expected at least 1",
+		},
+		{
+			name:    "Error size 0",
+			query:   url.Values{"size": {"0"}},
+			wantErr: "invalid value 0 for query parameter size, expected between 1 and 1000",
+		},
+		{
+			name:    "Error size over the maximum",
+			query:   url.Values{"size": {"1001"}},
+			wantErr: "invalid value 1001 for query parameter size, expected between 1 and 1000",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := param.Page(c.query, param.DefaultPageSize, param.MaxPageSize)
+			if c.wantErr != "" {
+				assert.EqualError(t, err, c.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, c.want, got)
+		})
+	}
+
+	t.Run("Success default over the maximum", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := param.Page(url.Values{}, param.DefaultPageSize, 10)
+		require.NoError(t, err)
+		assert.Equal(t, datastore.Page{Number: 1, Size: 10}, got)
+	})
+}
+
+func TestInt64(t *testing.T) {
+	t.Parallel()
+
+	amount := int64(100000000000)
+
+	cases := []struct {
+		name    string
+		value   string
+		want    *int64
+		wantErr string
+	}{
+		{name: "Success", value: "100000000000", want: &amount},
+		{name: "Success empty", value: ""},
+		{
+			name:    "Error not an integer",
+			value:   "abc",
+			wantErr: "couldn't parse value abc for query parameter amount.gt",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := param.Int64("amount.gt", c.value)
+			if c.wantErr != "" {
+				assert.EqualError(t, err, c.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestTime(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Success", value: "2023-06-01T00:00:00Z", want: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Success empty", value: ""},
+		{name: "Error not RFC3339", value: "2023-06-01", wantErr: true},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := param.Time("from", c.value)
+			assert.Equal(t, c.wantErr, err != nil)
+			assert.True(t, c.want.Equal(got))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		query   url.Values
+		allowed []string
+		wantErr string
+	}{
+		{name: "Success", query: url.Values{"size": {"1"}}, allowed: []string{"page", "size"}},
+		{name: "Success operator", query: url.Values{"amount.gt": {"1"}}, allowed: []string{"amount.gt"}},
+		{name: "Success no query parameter", query: url.Values{}},
+		{
+			name:    "Error unknown",
+			query:   url.Values{"size": {"1"}, "pag": {"1"}, "zzz": {"1"}},
+			allowed: []string{"page", "size"},
+			wantErr: "unknown query parameter pag, expected page, size",
+		},
+		{
+			name:    "Error operator",
+			query:   url.Values{"amount.in": {"1"}},
+			allowed: []string{"amount", "amount.gt"},
+			wantErr: "unknown query parameter amount.in, expected amount, amount.gt",
+		},
+		{
+			name:    "Error operator of a parameter without operator",
+			query:   url.Values{"year.gt": {"2023"}},
+			allowed: []string{"year"},
+			wantErr: "unknown query parameter year.gt, expected year",
+		},
+		{
+			name:    "Error no query parameter expected",
+			query:   url.Values{"page": {"1"}},
+			wantErr: "unknown query parameter page, expected no query parameter",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := param.Check(c.query, c.allowed...)
+			if c.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, c.wantErr)
+
+			var paramErr *param.Error
+			require.ErrorAs(t, err, &paramErr)
+			assert.True(t, paramErr.Unknown)
+		})
+	}
+}
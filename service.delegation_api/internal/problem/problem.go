@@ -0,0 +1,123 @@
+// Package problem writes the API errors as RFC 7807 problem details.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+)
+
+// ContentType is the media type of the problem details.
+const ContentType = "application/problem+json"
+
+// Codes of the problems, stable for clients to handle the errors without parsing their detail.
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnknownParameter = "unknown_parameter"
+	CodeInvalidBody      = "invalid_body"
+	CodeUnauthorized     = "unauthorized"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeNotAcceptable    = "not_acceptable"
+	CodeInternalError    = "internal_error"
+)
+
+// Problem is a RFC 7807 problem details, with the problem code and the offending query parameter as
+// extension members.
+type Problem struct {
+	// Type is about:blank, the problem being identified by its code.
+	Type string `json:"type"`
+	// Title is the HTTP status text.
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Code is the stable code of the problem.
+	Code string `json:"code"`
+	// Param is the offending query parameter of an invalid or unknown parameter problem.
+	Param string `json:"param,omitempty"`
+	// Range is the allowed range of an invalid integer query parameter.
+	Range *param.Range `json:"range,omitempty"`
+}
+
+// New creates a problem.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Write writes a problem response.
+func Write(w http.ResponseWriter, problem *Problem) {
+	responseJSON, err := json.Marshal(problem)
+	if err != nil {
+		zap.L().Error("error marshalling problem to JSON", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing problem response", zap.Error(err))
+	}
+}
+
+// BadRequest writes an invalid request problem, with the offending query parameter and its allowed range
+// when err is a param.Error.
+func BadRequest(w http.ResponseWriter, err error) {
+	problem := New(http.StatusBadRequest, CodeInvalidParameter, err.Error())
+
+	var paramErr *param.Error
+	if errors.As(err, &paramErr) {
+		problem.Param = paramErr.Param
+		problem.Range = paramErr.Range
+
+		if paramErr.Unknown {
+			problem.Code = CodeUnknownParameter
+		}
+	}
+
+	Write(w, problem)
+}
+
+// InvalidBody writes an invalid request body problem.
+func InvalidBody(w http.ResponseWriter, err error) {
+	Write(w, New(http.StatusBadRequest, CodeInvalidBody, err.Error()))
+}
+
+// Unauthorized writes an unauthorized problem, challenging the client for a bearer token.
+func Unauthorized(w http.ResponseWriter, detail string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	Write(w, New(http.StatusUnauthorized, CodeUnauthorized, detail))
+}
+
+// NotFound writes a not found problem.
+func NotFound(w http.ResponseWriter) {
+	Write(w, New(http.StatusNotFound, CodeNotFound, ""))
+}
+
+// MethodNotAllowed writes a method not allowed problem, listing the allowed methods in the Allow header.
+func MethodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	Write(w, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, ""))
+}
+
+// NotAcceptable writes a not acceptable problem.
+func NotAcceptable(w http.ResponseWriter, detail string) {
+	Write(w, New(http.StatusNotAcceptable, CodeNotAcceptable, detail))
+}
+
+// InternalError writes an internal error problem, without detail so that internal errors aren't leaked.
+func InternalError(w http.ResponseWriter) {
+	Write(w, New(http.StatusInternalServerError, CodeInternalError, ""))
+}
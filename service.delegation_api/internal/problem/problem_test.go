@@ -0,0 +1,115 @@
+package problem_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+var errInvalid = errors.New("invalid request")
+
+func TestBadRequest(t *testing.T) {
+	t.Parallel()
+
+	_, invalidSize := param.IntRange("size", "0", param.Between(1, 100))
+
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "Invalid parameter with a range",
+			err:  invalidSize,
+			want: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"size",` +
+				`"range":{"min":1,"max":100},` +
+				`"detail":"invalid value 0 for query parameter size, expected between 1 and 100"}`,
+		},
+		{
+			name: "Invalid parameter",
+			err:  param.Errorf("sort", "invalid sort %s", "id"),
+			want: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"sort",` +
+				`"detail":"invalid sort id"}`,
+		},
+		{
+			name: "Unknown parameter",
+			err:  param.Check(map[string][]string{"pag": {"1"}}, "page"),
+			want: `{"type":"about:blank","title":"Bad Request","status":400,"code":"unknown_parameter","param":"pag",` +
+				`"detail":"unknown query parameter pag, expected page"}`,
+		},
+		{
+			name: "Other error",
+			err:  errInvalid,
+			want: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"detail":"invalid request"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			responseRecorder := httptest.NewRecorder()
+			problem.BadRequest(responseRecorder, c.err)
+
+			assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+			assert.Equal(t, problem.ContentType, responseRecorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, c.want, responseRecorder.Body.String())
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	responseRecorder := httptest.NewRecorder()
+	problem.MethodNotAllowed(responseRecorder, http.MethodGet, http.MethodPost)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, responseRecorder.Code)
+	assert.Equal(t, "GET, POST", responseRecorder.Header().Get("Allow"))
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Method Not Allowed","status":405,"code":"method_not_allowed"}`,
+		responseRecorder.Body.String(),
+	)
+}
+
+func TestUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	responseRecorder := httptest.NewRecorder()
+	problem.Unauthorized(responseRecorder, "missing bearer token")
+
+	assert.Equal(t, http.StatusUnauthorized, responseRecorder.Code)
+	assert.Equal(t, "Bearer", responseRecorder.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Unauthorized","status":401,"code":"unauthorized",`+
+			`"detail":"missing bearer token"}`,
+		responseRecorder.Body.String(),
+	)
+}
+
+func TestInternalError(t *testing.T) {
+	t.Parallel()
+
+	responseRecorder := httptest.NewRecorder()
+	problem.InternalError(responseRecorder)
+
+	assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code)
+	assert.JSONEq(
+		t,
+		`{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		responseRecorder.Body.String(),
+	)
+}
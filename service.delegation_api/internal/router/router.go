@@ -0,0 +1,82 @@
+// Package router routes the API requests by method and path.
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Router routes requests by method and path. A pattern ending with a / matches its whole subtree, the
+// longest matching pattern winning, like http.ServeMux. Requests matching a pattern registered for other
+// methods get a 405 Method Not Allowed problem listing the allowed methods.
+type Router struct {
+	// routes are the handlers by pattern and method
+	routes map[string]map[string]http.Handler
+}
+
+// New creates a new Router.
+func New() *Router {
+	return &Router{routes: map[string]map[string]http.Handler{}}
+}
+
+// Handle registers the handler of a method and pattern. A pattern can be registered for several methods.
+func (r *Router) Handle(method, pattern string, handler http.Handler) {
+	if r.routes[pattern] == nil {
+		r.routes[pattern] = map[string]http.Handler{}
+	}
+
+	r.routes[pattern][method] = handler
+}
+
+// HandleFunc registers the handler function of a method and pattern.
+func (r *Router) HandleFunc(method, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(method, pattern, http.HandlerFunc(handler))
+}
+
+// ServeHTTP dispatches the request to the handler of its method and path.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	handlers := r.match(req.URL.Path)
+	if handlers == nil {
+		problem.NotFound(w)
+
+		return
+	}
+
+	handler, ok := handlers[req.Method]
+	if !ok {
+		allowed := make([]string, 0, len(handlers))
+		for method := range handlers {
+			allowed = append(allowed, method)
+		}
+
+		sort.Strings(allowed)
+		problem.MethodNotAllowed(w, allowed...)
+
+		return
+	}
+
+	handler.ServeHTTP(w, req)
+}
+
+// match returns the handlers by method of the pattern matching a path, nil when none matches.
+func (r *Router) match(path string) map[string]http.Handler {
+	if handlers, ok := r.routes[path]; ok {
+		return handlers
+	}
+
+	var (
+		longest  string
+		handlers map[string]http.Handler
+	)
+
+	for pattern, patternHandlers := range r.routes {
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(path, pattern) && len(pattern) > len(longest) {
+			longest, handlers = pattern, patternHandlers
+		}
+	}
+
+	return handlers
+}
@@ -0,0 +1,122 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/router"
+)
+
+//nolint:funlen
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	// handler writes its name, to check which handler served a request
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(name))
+		}
+	}
+
+	r := router.New()
+	r.HandleFunc(http.MethodGet, "/v1/delegations", handler("delegations"))
+	r.HandleFunc(http.MethodGet, "/v1/webhooks", handler("webhooks"))
+	r.HandleFunc(http.MethodPost, "/v1/webhooks", handler("create webhook"))
+	r.HandleFunc(http.MethodGet, "/v1/bakers/", handler("baker"))
+	r.HandleFunc(http.MethodGet, "/v1/bakers/tz1baker/", handler("baker subpath"))
+
+	cases := []struct {
+		name           string
+		method         string
+		path           string
+		wantStatusCode int
+		wantBody       string
+		wantAllow      string
+	}{
+		{
+			name:           "Success exact pattern",
+			method:         http.MethodGet,
+			path:           "/v1/delegations",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "delegations",
+		},
+		{
+			name:           "Success method of a pattern",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "create webhook",
+		},
+		{
+			name:           "Success subtree pattern",
+			method:         http.MethodGet,
+			path:           "/v1/bakers/tz1other/delegators",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "baker",
+		},
+		{
+			name:           "Success longest subtree pattern",
+			method:         http.MethodGet,
+			path:           "/v1/bakers/tz1baker/delegators",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "baker subpath",
+		},
+		{
+			name:           "Error method not allowed",
+			method:         http.MethodDelete,
+			path:           "/v1/webhooks",
+			wantStatusCode: http.StatusMethodNotAllowed,
+			wantBody: `{"type":"about:blank","title":"Method Not Allowed","status":405,` +
+				`"code":"method_not_allowed"}`,
+			wantAllow: "GET, POST",
+		},
+		{
+			name:           "Error method not allowed on a subtree",
+			method:         http.MethodPost,
+			path:           "/v1/bakers/tz1other",
+			wantStatusCode: http.StatusMethodNotAllowed,
+			wantBody: `{"type":"about:blank","title":"Method Not Allowed","status":405,` +
+				`"code":"method_not_allowed"}`,
+			wantAllow: "GET",
+		},
+		{
+			name:           "Error not found",
+			method:         http.MethodGet,
+			path:           "/v1/delegations/unknown",
+			wantStatusCode: http.StatusNotFound,
+			wantBody:       `{"type":"about:blank","title":"Not Found","status":404,"code":"not_found"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), c.method, c.path, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			r.ServeHTTP(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+			assert.Equal(t, c.wantAllow, responseRecorder.Header().Get("Allow"))
+
+			if c.wantStatusCode != http.StatusOK {
+				assert.Equal(t, problem.ContentType, responseRecorder.Header().Get("Content-Type"))
+				assert.JSONEq(t, c.wantBody, responseRecorder.Body.String())
+
+				return
+			}
+
+			assert.Equal(t, c.wantBody, responseRecorder.Body.String())
+		})
+	}
+}
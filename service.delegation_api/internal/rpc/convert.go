@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	delegationv1 "github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/proto/delegation/v1"
+)
+
+// kinds are the delegation kinds of the protobuf kinds.
+var kinds = map[delegationv1.DelegationKind]string{
+	delegationv1.DelegationKind_DELEGATION_KIND_UNSPECIFIED:  "",
+	delegationv1.DelegationKind_DELEGATION_KIND_DELEGATION:   model.KindDelegation,
+	delegationv1.DelegationKind_DELEGATION_KIND_REDELEGATION: model.KindRedelegation,
+	delegationv1.DelegationKind_DELEGATION_KIND_UNDELEGATION: model.KindUndelegation,
+}
+
+// statuses are the delegation statuses of the protobuf statuses.
+var statuses = map[delegationv1.DelegationStatus]string{
+	delegationv1.DelegationStatus_DELEGATION_STATUS_UNSPECIFIED: "",
+	delegationv1.DelegationStatus_DELEGATION_STATUS_APPLIED:     model.StatusApplied,
+	delegationv1.DelegationStatus_DELEGATION_STATUS_FAILED:      model.StatusFailed,
+	delegationv1.DelegationStatus_DELEGATION_STATUS_BACKTRACKED: model.StatusBacktracked,
+	delegationv1.DelegationStatus_DELEGATION_STATUS_SKIPPED:     model.StatusSkipped,
+}
+
+// sortFields are the delegations sort fields of the protobuf sort fields.
+var sortFields = map[delegationv1.DelegationsSort_Field]string{
+	delegationv1.DelegationsSort_FIELD_UNSPECIFIED: "",
+	delegationv1.DelegationsSort_FIELD_TIMESTAMP:   "",
+	delegationv1.DelegationsSort_FIELD_AMOUNT:      datastore.DelegationsSortAmount,
+	delegationv1.DelegationsSort_FIELD_LEVEL:       datastore.DelegationsSortLevel,
+}
+
+// toFilter converts a protobuf filter, nil matching every delegation. An invalid filter is returned as an
+// invalid argument status error.
+func toFilter(filter *delegationv1.DelegationsFilter) (datastore.Filter, error) {
+	if filter == nil {
+		return datastore.Filter{}, nil
+	}
+
+	year := int(filter.GetYear())
+	if year != 0 && (year < param.MinYear || year > param.MaxYear) {
+		return datastore.Filter{}, status.Errorf(
+			codes.InvalidArgument,
+			"invalid year %d, expected between %d and %d",
+			year,
+			param.MinYear,
+			param.MaxYear,
+		)
+	}
+
+	kind, ok := kinds[filter.GetKind()]
+	if !ok {
+		return datastore.Filter{}, status.Errorf(codes.InvalidArgument, "invalid kind %s", filter.GetKind())
+	}
+
+	delegationStatus, ok := statuses[filter.GetStatus()]
+	if !ok {
+		return datastore.Filter{}, status.Errorf(codes.InvalidArgument, "invalid status %s", filter.GetStatus())
+	}
+
+	f := datastore.Filter{
+		Year:      year,
+		Delegator: filter.GetDelegator(),
+		Baker:     filter.GetBaker(),
+		Block:     filter.GetBlock(),
+		Level:     filter.GetLevel(),
+		AmountGt:  filter.AmountGt,
+		AmountLt:  filter.AmountLt,
+		Kind:      kind,
+		Status:    delegationStatus,
+	}
+
+	if filter.GetFrom() != nil {
+		f.From = filter.GetFrom().AsTime()
+	}
+
+	if filter.GetTo() != nil {
+		f.To = filter.GetTo().AsTime()
+	}
+
+	return f, nil
+}
+
+// toSort converts a protobuf sort, nil being the default sort. An invalid sort is returned as an invalid
+// argument status error.
+func toSort(sort *delegationv1.DelegationsSort) (datastore.DelegationsSort, error) {
+	field, ok := sortFields[sort.GetField()]
+	if !ok {
+		return datastore.DelegationsSort{}, status.Errorf(
+			codes.InvalidArgument,
+			"invalid sort field %s",
+			sort.GetField(),
+		)
+	}
+
+	return datastore.DelegationsSort{Field: field, Ascending: sort.GetAscending()}, nil
+}
+
+// toDelegation converts a delegation to protobuf.
+func toDelegation(delegation *model.Delegation) *delegationv1.Delegation {
+	return &delegationv1.Delegation{
+		Id:            delegation.ID,
+		Timestamp:     timestamppb.New(delegation.Timestamp),
+		Amount:        delegation.Amount,
+		Delegator:     delegation.Delegator,
+		Block:         delegation.Block,
+		Level:         delegation.Level,
+		Baker:         delegation.Baker,
+		PreviousBaker: delegation.PreviousBaker,
+	}
+}
@@ -0,0 +1,198 @@
+// Package rpc serves the delegations over gRPC, alongside the REST API.
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stream"
+	delegationv1 "github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/proto/delegation/v1"
+)
+
+// Server implements the delegation gRPC service.
+type Server struct {
+	delegationv1.UnimplementedDelegationServiceServer
+	datastore datastore.Datastorer
+	stream    *stream.APIHandler
+	// maxPageSize is the maximum size of a delegations page.
+	maxPageSize int
+}
+
+// New creates a new Server, streaming the new delegations with the stream handler.
+func New(datastore datastore.Datastorer, stream *stream.APIHandler, maxPageSize int) *Server {
+	return &Server{
+		datastore:   datastore,
+		stream:      stream,
+		maxPageSize: maxPageSize,
+	}
+}
+
+// ListDelegations returns a page of delegations matching the filter, the latest first. The next page token
+// is the cursor of the last delegation of a full page.
+func (s *Server) ListDelegations(
+	ctx context.Context,
+	req *delegationv1.ListDelegationsRequest,
+) (*delegationv1.ListDelegationsResponse, error) {
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	page := datastore.Page{Number: 1, Size: int(req.GetPageSize())}
+
+	switch {
+	case page.Size == 0:
+		page.Size = min(param.DefaultPageSize, s.maxPageSize)
+	case page.Size < 0 || page.Size > s.maxPageSize:
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"invalid page size %d, expected between 1 and %d",
+			page.Size,
+			s.maxPageSize,
+		)
+	}
+
+	if token := req.GetPageToken(); token != "" {
+		page.After, err = datastore.DecodeCursor(token)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "couldn't decode page token %s", token)
+		}
+	}
+
+	delegations, err := s.datastore.GetDelegations(ctx, filter, datastore.DelegationsSort{}, nil, page)
+	if err != nil {
+		zap.L().Error("couldn't get delegations from datastore", zap.Error(err))
+
+		return nil, status.Error(codes.Internal, "couldn't get delegations")
+	}
+
+	resp := &delegationv1.ListDelegationsResponse{
+		Delegations: make([]*delegationv1.Delegation, 0, len(delegations)),
+	}
+
+	for _, delegation := range delegations {
+		resp.Delegations = append(resp.Delegations, toDelegation(delegation))
+	}
+
+	if len(delegations) == page.Size {
+		resp.NextPageToken = datastore.NewCursor(delegations[len(delegations)-1]).Encode()
+	}
+
+	return resp, nil
+}
+
+// CountDelegations returns the number of delegations matching the filter.
+func (s *Server) CountDelegations(
+	ctx context.Context,
+	req *delegationv1.CountDelegationsRequest,
+) (*delegationv1.CountDelegationsResponse, error) {
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.datastore.GetDelegationsCount(ctx, filter)
+	if err != nil {
+		zap.L().Error("couldn't get delegations count from datastore", zap.Error(err))
+
+		return nil, status.Error(codes.Internal, "couldn't count delegations")
+	}
+
+	return &delegationv1.CountDelegationsResponse{Count: int64(count)}, nil
+}
+
+// ExportDelegations streams all the delegations matching the filter in order, as they are read from the
+// datastore.
+func (s *Server) ExportDelegations(
+	req *delegationv1.ExportDelegationsRequest,
+	srv delegationv1.DelegationService_ExportDelegationsServer,
+) error {
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	sort, err := toSort(req.GetSort())
+	if err != nil {
+		return err
+	}
+
+	// sendErr is the error sending a delegation, the client having gone away
+	var sendErr error
+
+	err = s.datastore.IterateDelegations(
+		srv.Context(),
+		filter,
+		sort,
+		nil,
+		datastore.Page{},
+		func(delegation *model.Delegation) error {
+			sendErr = srv.Send(toDelegation(delegation))
+
+			return sendErr
+		},
+	)
+
+	switch {
+	case sendErr != nil:
+		return sendErr
+	case err != nil:
+		zap.L().Error("couldn't export delegations from datastore", zap.Error(err))
+
+		return status.Error(codes.Internal, "couldn't export delegations")
+	default:
+		return nil
+	}
+}
+
+// StreamDelegations streams the new delegations matching the filter until the client cancels the stream,
+// after replaying the delegations stored after the last event id when set.
+func (s *Server) StreamDelegations(
+	req *delegationv1.StreamDelegationsRequest,
+	srv delegationv1.DelegationService_StreamDelegationsServer,
+) error {
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	var since *int64
+
+	if lastEventID := req.GetLastEventId(); lastEventID != "" {
+		sequence, err := datastore.DecodeChangeToken(lastEventID)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "couldn't decode last event id %s", lastEventID)
+		}
+
+		since = &sequence
+	}
+
+	err = s.stream.Stream(srv.Context(), filter, since, func(e stream.Event) error {
+		resp := &delegationv1.StreamDelegationsResponse{Id: e.ID}
+
+		switch e.Type {
+		case stream.EventDelegation:
+			resp.Type = delegationv1.StreamDelegationsResponse_TYPE_DELEGATION
+			resp.Delegation = toDelegation(e.Delegation)
+		case stream.EventHeartbeat:
+			resp.Type = delegationv1.StreamDelegationsResponse_TYPE_HEARTBEAT
+		default:
+			// the overflow is answered with the stream status
+			return nil
+		}
+
+		return srv.Send(resp)
+	})
+	if errors.Is(err, stream.ErrSlowClient) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,352 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/rpc"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stream"
+	delegationv1 "github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/proto/delegation/v1"
+)
+
+const bakerAddress = "tz1aRoaRhSpRYvFdyvgWLL6TGyRoGF51wDjM"
+
+var errDatastore = errors.New("datastore error")
+
+// setupTest returns a client of a gRPC server over a datastore, its first 3 delegations being the 2023
+// delegations 1 (amount 300), 2 (amount 100, to bakerAddress) and 3 (amount 200), stored in that order.
+func setupTest(t *testing.T, ds datastore.Datastorer) delegationv1.DelegationServiceClient {
+	t.Helper()
+
+	if ds == nil {
+		memoryDatastore := memory.New()
+		require.NoError(t, memoryDatastore.StoreDelegations(context.Background(), []*model.Delegation{
+			delegation(1, 300, ""),
+			delegation(2, 100, bakerAddress),
+			delegation(3, 200, ""),
+		}))
+
+		ds = memoryDatastore
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	broker := stream.NewBroker(ds, 5*time.Millisecond, 16)
+	require.NoError(t, broker.Init(ctx))
+
+	go broker.Run(ctx)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	delegationv1.RegisterDelegationServiceServer(server, rpc.New(ds, stream.New(ds, broker, time.Hour), 2))
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(
+		ctx,
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return delegationv1.NewDelegationServiceClient(conn)
+}
+
+func delegation(id, amount int64, baker string) *model.Delegation {
+	return &model.Delegation{
+		ID:        id,
+		Timestamp: time.Date(2023, 6, int(id), 0, 0, 0, 0, time.UTC),
+		Amount:    amount,
+		Delegator: "tz1delegator",
+		Block:     "BLock",
+		Level:     4000000 + id,
+		Baker:     baker,
+	}
+}
+
+func ids(delegations []*delegationv1.Delegation) []int64 {
+	result := make([]int64, 0, len(delegations))
+	for _, d := range delegations {
+		result = append(result, d.GetId())
+	}
+
+	return result
+}
+
+func TestServer_ListDelegations(t *testing.T) {
+	t.Parallel()
+
+	client := setupTest(t, nil)
+
+	cases := []struct {
+		name          string
+		req           *delegationv1.ListDelegationsRequest
+		wantIDs       []int64
+		wantNextToken bool
+		wantCode      codes.Code
+	}{
+		{
+			name:          "Default page size",
+			req:           &delegationv1.ListDelegationsRequest{},
+			wantIDs:       []int64{3, 2},
+			wantNextToken: true,
+		},
+		{
+			name: "Filtered",
+			req: &delegationv1.ListDelegationsRequest{
+				Filter:   &delegationv1.DelegationsFilter{AmountGt: proto.Int64(150)},
+				PageSize: 1,
+			},
+			wantIDs:       []int64{3},
+			wantNextToken: true,
+		},
+		{
+			name:    "Last page",
+			req:     &delegationv1.ListDelegationsRequest{Filter: &delegationv1.DelegationsFilter{Baker: bakerAddress}},
+			wantIDs: []int64{2},
+		},
+		{
+			name: "Kind",
+			req: &delegationv1.ListDelegationsRequest{
+				Filter: &delegationv1.DelegationsFilter{Kind: delegationv1.DelegationKind_DELEGATION_KIND_DELEGATION},
+			},
+			wantIDs: []int64{2},
+		},
+		{
+			name: "Timestamp range",
+			req: &delegationv1.ListDelegationsRequest{
+				Filter: &delegationv1.DelegationsFilter{
+					From: timestamppb.New(time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)),
+					To:   timestamppb.New(time.Date(2023, 6, 3, 0, 0, 0, 0, time.UTC)),
+				},
+			},
+			wantIDs: []int64{2},
+		},
+		{
+			name:     "Invalid year",
+			req:      &delegationv1.ListDelegationsRequest{Filter: &delegationv1.DelegationsFilter{Year: 2000}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Invalid kind",
+			req:      &delegationv1.ListDelegationsRequest{Filter: &delegationv1.DelegationsFilter{Kind: 42}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Invalid page size",
+			req:      &delegationv1.ListDelegationsRequest{PageSize: 3},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Invalid page token",
+			req:      &delegationv1.ListDelegationsRequest{PageToken: "!"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := client.ListDelegations(context.Background(), c.req)
+			if c.wantCode != codes.OK {
+				assert.Equal(t, c.wantCode, status.Code(err))
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, c.wantIDs, ids(resp.GetDelegations()))
+			assert.Equal(t, c.wantNextToken, resp.GetNextPageToken() != "")
+		})
+	}
+}
+
+func TestServer_ListDelegations_Pages(t *testing.T) {
+	t.Parallel()
+
+	client := setupTest(t, nil)
+
+	var (
+		got   []int64
+		token string
+	)
+
+	for {
+		resp, err := client.ListDelegations(
+			context.Background(),
+			&delegationv1.ListDelegationsRequest{PageSize: 1, PageToken: token},
+		)
+		require.NoError(t, err)
+
+		got = append(got, ids(resp.GetDelegations())...)
+		token = resp.GetNextPageToken()
+
+		if token == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []int64{3, 2, 1}, got)
+}
+
+func TestServer_CountDelegations(t *testing.T) {
+	t.Parallel()
+
+	client := setupTest(t, nil)
+
+	resp, err := client.CountDelegations(
+		context.Background(),
+		&delegationv1.CountDelegationsRequest{Filter: &delegationv1.DelegationsFilter{Baker: bakerAddress}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.GetCount())
+
+	resp, err = client.CountDelegations(context.Background(), &delegationv1.CountDelegationsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.GetCount())
+}
+
+func TestServer_CountDelegations_Error(t *testing.T) {
+	t.Parallel()
+
+	mockDatastore := datastoremock.NewMockDatastorer(gomock.NewController(t))
+	mockDatastore.EXPECT().GetLatestDelegationsChange(gomock.Any()).Return(nil, nil)
+	mockDatastore.EXPECT().GetDelegationsChanges(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockDatastore.EXPECT().GetDelegationsCount(gomock.Any(), datastore.Filter{}).Return(0, errDatastore)
+
+	client := setupTest(t, mockDatastore)
+
+	_, err := client.CountDelegations(context.Background(), &delegationv1.CountDelegationsRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestServer_ExportDelegations(t *testing.T) {
+	t.Parallel()
+
+	client := setupTest(t, nil)
+
+	cases := []struct {
+		name     string
+		req      *delegationv1.ExportDelegationsRequest
+		wantIDs  []int64
+		wantCode codes.Code
+	}{
+		{name: "Success", req: &delegationv1.ExportDelegationsRequest{}, wantIDs: []int64{3, 2, 1}},
+		{
+			name: "Sorted by amount",
+			req: &delegationv1.ExportDelegationsRequest{
+				Sort: &delegationv1.DelegationsSort{Field: delegationv1.DelegationsSort_FIELD_AMOUNT, Ascending: true},
+			},
+			wantIDs: []int64{2, 3, 1},
+		},
+		{
+			name:     "Invalid sort",
+			req:      &delegationv1.ExportDelegationsRequest{Sort: &delegationv1.DelegationsSort{Field: 42}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			export, err := client.ExportDelegations(context.Background(), c.req)
+			require.NoError(t, err)
+
+			var got []*delegationv1.Delegation
+
+			for {
+				d, err := export.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				if c.wantCode != codes.OK {
+					assert.Equal(t, c.wantCode, status.Code(err))
+
+					return
+				}
+
+				require.NoError(t, err)
+
+				got = append(got, d)
+			}
+
+			assert.Equal(t, c.wantIDs, ids(got))
+		})
+	}
+}
+
+func TestServer_StreamDelegations(t *testing.T) {
+	t.Parallel()
+
+	client := setupTest(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the delegations stored after the first one are replayed before the stream is live
+	events, err := client.StreamDelegations(ctx, &delegationv1.StreamDelegationsRequest{
+		Filter:      &delegationv1.DelegationsFilter{AmountGt: proto.Int64(150)},
+		LastEventId: datastore.EncodeChangeToken(1),
+	})
+	require.NoError(t, err)
+
+	event, err := events.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, delegationv1.StreamDelegationsResponse_TYPE_DELEGATION, event.GetType())
+	assert.Equal(t, int64(3), event.GetDelegation().GetId())
+	assert.Equal(t, int64(200), event.GetDelegation().GetAmount())
+	assert.Equal(t, time.Date(2023, 6, 3, 0, 0, 0, 0, time.UTC), event.GetDelegation().GetTimestamp().AsTime())
+	assert.Equal(t, datastore.EncodeChangeToken(3), event.GetId())
+
+	event, err = events.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, delegationv1.StreamDelegationsResponse_TYPE_HEARTBEAT, event.GetType())
+}
+
+func TestServer_StreamDelegations_InvalidLastEventID(t *testing.T) {
+	t.Parallel()
+
+	client := setupTest(t, nil)
+
+	events, err := client.StreamDelegations(
+		context.Background(),
+		&delegationv1.StreamDelegationsRequest{LastEventId: "!"},
+	)
+	require.NoError(t, err)
+
+	_, err = events.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds the number of cached responses, as cache keys depend on the request parameters.
+const maxCacheEntries = 1000
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// cache is an in-memory cache of responses expiring after a ttl, safe for concurrent use.
+// A zero ttl disables the cache.
+type cache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:     ttl,
+		entries: map[string]cacheEntry{},
+		now:     time.Now,
+	}
+}
+
+// get returns the cached value of key, if not expired.
+func (c *cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// set caches the value of key, unless the cache is disabled or full.
+func (c *cache) set(key string, value []byte) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	if len(c.entries) >= maxCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+
+	c.entries[key] = cacheEntry{
+		value:   value,
+		expires: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := newCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set("key", []byte("value"))
+
+	got, found := c.get("key")
+	assert.True(t, found)
+	assert.Equal(t, []byte("value"), got)
+
+	now = now.Add(time.Minute)
+
+	_, found = c.get("key")
+	assert.False(t, found, "entries expire after the ttl")
+}
+
+func TestCache_Disabled(t *testing.T) {
+	t.Parallel()
+
+	c := newCache(0)
+	c.set("key", []byte("value"))
+
+	_, found := c.get("key")
+	assert.False(t, found)
+}
+
+func TestCache_Full(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := newCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < maxCacheEntries; i++ {
+		c.set(strconv.Itoa(i), []byte("value"))
+	}
+
+	c.set("full", []byte("value"))
+
+	_, found := c.get("full")
+	assert.False(t, found, "entries aren't cached when the cache is full")
+
+	// expired entries are evicted to make room
+	now = now.Add(time.Minute)
+	c.set("full", []byte("value"))
+
+	_, found = c.get("full")
+	assert.True(t, found)
+}
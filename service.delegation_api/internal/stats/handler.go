@@ -0,0 +1,140 @@
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/envelope"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Params are the query parameters of the delegations stats endpoint.
+var Params = []string{"interval", "from", "to"}
+
+// APIHandler handles the stats API requests.
+type APIHandler struct {
+	datastore datastore.Datastorer
+	cache     *cache
+}
+
+// New creates a new APIHandler, caching the computed stats for cacheTTL (0 disables the cache).
+func New(datastore datastore.Datastorer, cacheTTL time.Duration) *APIHandler {
+	return &APIHandler{
+		datastore: datastore,
+		cache:     newCache(cacheTTL),
+	}
+}
+
+// GetDelegationsStatsHandler handles the legacy /xtz/stats/delegations endpoint.
+//
+// It returns the delegations stats by bucket of interval (day by default, week, month or year),
+// for the delegations in the optional from (inclusive) / to (exclusive) RFC3339 timestamp range.
+func (a *APIHandler) GetDelegationsStatsHandler(w http.ResponseWriter, r *http.Request) {
+	statsJSON, ok := a.getDelegationsStats(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(statsJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
+
+// GetDelegationsStatsV1Handler handles /v1/stats/delegations endpoint.
+//
+// It takes the parameters of GetDelegationsStatsHandler, the response body being an envelope with the stats
+// as data.
+func (a *APIHandler) GetDelegationsStatsV1Handler(w http.ResponseWriter, r *http.Request) {
+	if statsJSON, ok := a.getDelegationsStats(w, r); ok {
+		envelope.Write(w, http.StatusOK, envelope.Body{Data: json.RawMessage(statsJSON)})
+	}
+}
+
+// getDelegationsStats gets the JSON array of the delegations stats of a request, from the cache when found.
+// The request is answered with an error when the parameters are invalid or the stats can't be computed.
+//
+//nolint:funlen
+func (a *APIHandler) getDelegationsStats(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	intervalParam := r.URL.Query().Get("interval")
+	if intervalParam == "" {
+		intervalParam = string(datastore.IntervalDay)
+	}
+
+	interval, err := datastore.ParseInterval(intervalParam)
+	if err != nil {
+		zap.L().Error("error parsing interval parameter", zap.String("interval", intervalParam), zap.Error(err))
+		problem.BadRequest(
+			w,
+			param.Errorf("interval", "couldn't parse value %s for query parameter interval", intervalParam),
+		)
+
+		return nil, false
+	}
+
+	from, err := param.Time("from", r.URL.Query().Get("from"))
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return nil, false
+	}
+
+	to, err := param.Time("to", r.URL.Query().Get("to"))
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return nil, false
+	}
+
+	key := cacheKey(interval, from, to)
+
+	responseJSON, found := a.cache.get(key)
+	if !found {
+		stats, err := a.datastore.GetDelegationsStats(r.Context(), datastore.Filter{From: from, To: to}, interval)
+		if err != nil {
+			zap.L().Error("couldn't get delegations stats from datastore", zap.Error(err))
+			problem.InternalError(w)
+
+			return nil, false
+		}
+
+		if stats == nil {
+			stats = []*model.DelegationsStats{}
+		}
+
+		responseJSON, err = json.Marshal(stats)
+		if err != nil {
+			zap.L().Error("error marshalling delegations stats to JSON", zap.Error(err))
+			problem.InternalError(w)
+
+			return nil, false
+		}
+
+		a.cache.set(key, responseJSON)
+	}
+
+	return responseJSON, true
+}
+
+// cacheKey returns the cache key of the stats of a request, timestamps being compared as instants.
+func cacheKey(interval datastore.Interval, from, to time.Time) string {
+	bound := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	return strings.Join([]string{string(interval), bound(from), bound(to)}, "|")
+}
@@ -0,0 +1,257 @@
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stats"
+)
+
+type underTest struct {
+	mockCtrl      *gomock.Controller
+	mockDatastore *datastoremock.MockDatastorer
+	apiHandler    *stats.APIHandler
+}
+
+func setupTest(t *testing.T) *underTest {
+	t.Helper()
+
+	ut := &underTest{}
+
+	ut.mockCtrl = gomock.NewController(t)
+
+	ut.mockDatastore = datastoremock.NewMockDatastorer(ut.mockCtrl)
+
+	ut.apiHandler = stats.New(ut.mockDatastore, time.Minute)
+
+	return ut
+}
+
+var errGetStats = errors.New("error getting stats")
+
+func TestStats_GetDelegationsStatsHandler(t *testing.T) {
+	t.Parallel()
+
+	delegationsStats := []*model.DelegationsStats{
+		{
+			Bucket:        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Delegations:   3,
+			Amount:        220,
+			Delegators:    2,
+			Undelegations: 1,
+		},
+	}
+
+	cases := []struct {
+		name           string
+		url            string
+		init           func(*underTest)
+		want           []*model.DelegationsStats
+		wantErr        string
+		wantStatusCode int
+	}{
+		{
+			name: "Success with default interval",
+			url:  "/xtz/stats/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsStats(
+					gomock.Any(),
+					gomock.Eq(datastore.Filter{}),
+					gomock.Eq(datastore.IntervalDay),
+				).Return(delegationsStats, nil)
+			},
+			want:           delegationsStats,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success with interval and range",
+			url:  "/xtz/stats/delegations?interval=month&from=2023-01-01T00:00:00Z&to=2024-01-01T00:00:00Z",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsStats(
+					gomock.Any(),
+					gomock.Eq(datastore.Filter{
+						From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						To:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					}),
+					gomock.Eq(datastore.IntervalMonth),
+				).Return(delegationsStats, nil)
+			},
+			want:           delegationsStats,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Success without delegations",
+			url:  "/xtz/stats/delegations?interval=year",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsStats(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			want:           []*model.DelegationsStats{},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "Error invalid interval",
+			url:  "/xtz/stats/delegations?interval=hour",
+			init: func(ut *underTest) {},
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"interval","detail":"couldn't parse value hour for query parameter interval"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Error invalid from",
+			url:  "/xtz/stats/delegations?from=2023-01-01",
+			init: func(ut *underTest) {},
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"from",` +
+				`"detail":"couldn't parse value 2023-01-01 for query parameter from, expected RFC3339 format"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Error GetDelegationsStats from datastore",
+			url:  "/xtz/stats/delegations",
+			init: func(ut *underTest) {
+				ut.mockDatastore.EXPECT().GetDelegationsStats(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errGetStats)
+			},
+			wantErr: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"code":"internal_error"}`,
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+			c.init(ut)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.url, nil)
+			require.NoError(t, err, "Error creating request")
+
+			responseRecorder := httptest.NewRecorder()
+			ut.apiHandler.GetDelegationsStatsHandler(responseRecorder, req)
+
+			assert.Equal(t, c.wantStatusCode, responseRecorder.Code)
+
+			if c.wantErr != "" {
+				assert.JSONEq(t, c.wantErr, responseRecorder.Body.String())
+
+				return
+			}
+
+			var result []*model.DelegationsStats
+			err = json.Unmarshal(responseRecorder.Body.Bytes(), &result)
+			require.NoError(t, err, "Error parsing JSON response")
+			assert.Equal(t, c.want, result)
+		})
+	}
+}
+
+func TestStats_GetDelegationsStatsHandler_Cache(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	// the same stats requested with equivalent ranges are computed once
+	ut.mockDatastore.EXPECT().GetDelegationsStats(gomock.Any(), gomock.Any(), gomock.Eq(datastore.IntervalWeek)).
+		Return([]*model.DelegationsStats{{Bucket: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Delegations: 1}}, nil).
+		Times(1)
+	ut.mockDatastore.EXPECT().GetDelegationsStats(gomock.Any(), gomock.Any(), gomock.Eq(datastore.IntervalMonth)).
+		Return(nil, nil).
+		Times(1)
+
+	urls := []string{
+		"/xtz/stats/delegations?interval=week&from=2023-01-01T00:00:00Z",
+		"/xtz/stats/delegations?interval=week&from=2023-01-01T02:00:00%2B02:00",
+		"/xtz/stats/delegations?interval=month&from=2023-01-01T00:00:00Z",
+	}
+
+	var bodies []string
+
+	for _, url := range urls {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+		require.NoError(t, err, "Error creating request")
+
+		responseRecorder := httptest.NewRecorder()
+		ut.apiHandler.GetDelegationsStatsHandler(responseRecorder, req)
+
+		require.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		bodies = append(bodies, responseRecorder.Body.String())
+	}
+
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, "[]", bodies[2])
+}
+
+func TestStats_GetDelegationsStatsV1Handler(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	// the cached stats of the legacy endpoint are enveloped by the v1 one
+	ut.mockDatastore.EXPECT().GetDelegationsStats(gomock.Any(), gomock.Any(), gomock.Eq(datastore.IntervalYear)).
+		Return([]*model.DelegationsStats{{Bucket: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Delegations: 1}}, nil).
+		Times(1)
+
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"/xtz/stats/delegations?interval=year",
+		nil,
+	)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder := httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsStatsHandler(responseRecorder, req)
+
+	require.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	req, err = http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"/v1/stats/delegations?interval=year",
+		nil,
+	)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder = httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsStatsV1Handler(responseRecorder, req)
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, "application/json", responseRecorder.Header().Get("Content-Type"))
+	assert.JSONEq(
+		t,
+		`{"data": [{
+			"bucket": "2023-01-01T00:00:00Z", "delegations": 1, "amount": 0, "delegators": 0, "undelegations": 0
+		}]}`,
+		responseRecorder.Body.String(),
+	)
+
+	req, err = http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		"/v1/stats/delegations?interval=hour",
+		nil,
+	)
+	require.NoError(t, err, "Error creating request")
+
+	responseRecorder = httptest.NewRecorder()
+	ut.apiHandler.GetDelegationsStatsV1Handler(responseRecorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+}
@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+// pollSize is the maximum number of changes read from the change log at once.
+const pollSize = 100
+
+// subscriber receives the new delegations published by the broker.
+type subscriber struct {
+	// events receives the insert changes, it is closed when the subscriber is too slow to keep up.
+	events chan *model.DelegationChange
+	// sequence is the change log sequence the subscriber receives the changes after.
+	sequence int64
+}
+
+// Broker tails the delegations change log, written by the cron, and publishes the new delegations to its
+// subscribers. It is safe for concurrent use.
+//
+// Subscribers have a bounded buffer: a subscriber which doesn't keep up is dropped, its events channel
+// being closed, rather than slowing down the other subscribers or growing the memory without bound.
+type Broker struct {
+	datastore    datastore.Datastorer
+	pollInterval time.Duration
+	bufferSize   int
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	// sequence is the sequence of the last change read from the change log.
+	sequence int64
+}
+
+// NewBroker creates a new Broker polling the change log every pollInterval, subscribers buffering at most
+// bufferSize delegations.
+func NewBroker(datastore datastore.Datastorer, pollInterval time.Duration, bufferSize int) *Broker {
+	return &Broker{
+		datastore:    datastore,
+		pollInterval: pollInterval,
+		bufferSize:   bufferSize,
+		subscribers:  map[*subscriber]struct{}{},
+	}
+}
+
+// Init positions the broker at the end of the change log, so only the delegations stored from now on
+// are published.
+func (b *Broker) Init(ctx context.Context) error {
+	latest, err := b.datastore.GetLatestDelegationsChange(ctx)
+	if err != nil {
+		return err
+	}
+
+	if latest != nil {
+		b.mu.Lock()
+		b.sequence = latest.Sequence
+		b.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Run polls the change log until the context is done.
+func (b *Broker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.poll(ctx); err != nil {
+				zap.L().Error("couldn't poll delegations changes from datastore", zap.Error(err))
+			}
+		}
+	}
+}
+
+// poll reads the new changes of the change log and publishes the new delegations.
+func (b *Broker) poll(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		since := b.sequence
+		b.mu.Unlock()
+
+		changes, err := b.datastore.GetDelegationsChanges(ctx, since, pollSize)
+		if err != nil {
+			return err
+		}
+
+		b.publish(changes)
+
+		if len(changes) < pollSize {
+			return nil
+		}
+	}
+}
+
+// publish sends the insert changes to the subscribers, dropping the subscribers whose buffer is full.
+func (b *Broker) publish(changes []*model.DelegationChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, change := range changes {
+		b.sequence = change.Sequence
+
+		if change.Operation != model.ChangeInsert {
+			continue
+		}
+
+		for sub := range b.subscribers {
+			select {
+			case sub.events <- change:
+			default:
+				zap.L().Warn("dropping slow delegations stream subscriber", zap.Int64("sequence", change.Sequence))
+				close(sub.events)
+				delete(b.subscribers, sub)
+			}
+		}
+	}
+}
+
+// subscribe registers a new subscriber, receiving the new delegations after the current sequence.
+func (b *Broker) subscribe() *subscriber {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscriber{
+		events:   make(chan *model.DelegationChange, b.bufferSize),
+		sequence: b.sequence,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+// unsubscribe unregisters a subscriber, if it wasn't dropped already.
+func (b *Broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, found := b.subscribers[sub]; found {
+		close(sub.events)
+		delete(b.subscribers, sub)
+	}
+}
@@ -0,0 +1,70 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+)
+
+func TestBroker_Poll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := memory.New()
+	stored := &model.Delegation{ID: 1, Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Delegator: "tz1"}
+	require.NoError(t, store.StoreDelegations(ctx, []*model.Delegation{stored}))
+
+	broker := NewBroker(store, time.Hour, 10)
+	require.NoError(t, broker.Init(ctx))
+
+	sub := broker.subscribe()
+	assert.Equal(t, int64(1), sub.sequence)
+
+	// an update and a new delegation, only the new delegation is published
+	updated := *stored
+	updated.Amount = 10
+	inserted := &model.Delegation{ID: 2, Timestamp: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Delegator: "tz2"}
+	require.NoError(t, store.StoreDelegations(ctx, []*model.Delegation{&updated, inserted}))
+
+	require.NoError(t, broker.poll(ctx))
+
+	require.Len(t, sub.events, 1)
+	change := <-sub.events
+	assert.Equal(t, int64(3), change.Sequence)
+	assert.Equal(t, inserted.ID, change.Delegation.ID)
+
+	broker.unsubscribe(sub)
+
+	_, open := <-sub.events
+	assert.False(t, open)
+}
+
+func TestBroker_PublishDropsSlowSubscribers(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(memory.New(), time.Hour, 1)
+	slow := broker.subscribe()
+
+	broker.publish([]*model.DelegationChange{
+		{Sequence: 1, Operation: model.ChangeInsert, Delegation: &model.Delegation{ID: 1}},
+		{Sequence: 2, Operation: model.ChangeInsert, Delegation: &model.Delegation{ID: 2}},
+	})
+
+	// the buffered delegation is still received, then the channel is closed
+	change, open := <-slow.events
+	require.True(t, open)
+	assert.Equal(t, int64(1), change.Sequence)
+
+	_, open = <-slow.events
+	assert.False(t, open)
+	assert.Empty(t, broker.subscribers)
+
+	// unsubscribing a dropped subscriber is a no-op
+	broker.unsubscribe(slow)
+}
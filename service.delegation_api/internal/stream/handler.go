@@ -0,0 +1,312 @@
+// Package stream pushes the new delegations to live clients, with Server-Sent Events or WebSocket.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/delegation"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// Stream event types.
+const (
+	EventDelegation = "delegation"
+	EventHeartbeat  = "heartbeat"
+	// EventOverflow is sent before closing the stream of a client too slow to keep up, which can resume
+	// from its last event id.
+	EventOverflow = "overflow"
+)
+
+// Params are the query parameters of the delegations stream endpoint.
+var Params = append([]string{"last_event_id"}, delegation.FilterParams...)
+
+// ErrSlowClient is returned when the stream of a client too slow to keep up is closed.
+var ErrSlowClient = errors.New("client too slow to keep up with the delegations stream")
+
+// Event is a message of the delegations stream.
+type Event struct {
+	// Type is the event type: delegation, heartbeat or overflow.
+	Type string `json:"type"`
+	// ID is the token to resume the stream after the delegation, empty for the other events.
+	ID string `json:"id,omitempty"`
+	// Delegation is the new delegation, nil for the other events.
+	Delegation *model.Delegation `json:"delegation,omitempty"`
+}
+
+// APIHandler handles the delegations stream requests.
+type APIHandler struct {
+	datastore         datastore.Datastorer
+	broker            *Broker
+	heartbeatInterval time.Duration
+}
+
+// New creates a new APIHandler streaming the delegations published by broker, sending a heartbeat every
+// heartbeatInterval.
+func New(datastore datastore.Datastorer, broker *Broker, heartbeatInterval time.Duration) *APIHandler {
+	return &APIHandler{
+		datastore:         datastore,
+		broker:            broker,
+		heartbeatInterval: heartbeatInterval,
+	}
+}
+
+// GetDelegationsStreamHandler handles /xtz/delegations/stream endpoint.
+//
+// It pushes the new delegations as they are stored, with Server-Sent Events or, when the request is a
+// WebSocket upgrade, with WebSocket JSON messages. Delegations are filtered with the same filter parameters as
+// the delegations endpoint. A Last-Event-ID header (or last_event_id parameter) resumes the stream after the
+// delegation of that event id.
+//
+//nolint:funlen
+func (a *APIHandler) GetDelegationsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := delegation.ParseFilter(r.URL.Query())
+	if err != nil {
+		zap.L().Error("error parsing filter parameters", zap.Error(err))
+		problem.BadRequest(w, err)
+
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	var since *int64
+
+	if lastEventID != "" {
+		sequence, err := datastore.DecodeChangeToken(lastEventID)
+		if err != nil {
+			zap.L().Error("error decoding last event id", zap.String("lastEventID", lastEventID), zap.Error(err))
+			problem.BadRequest(
+				w,
+				param.Errorf("last_event_id", "couldn't decode last event id %s", lastEventID),
+			)
+
+			return
+		}
+
+		since = &sequence
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		a.serveWebSocket(w, r, filter, since)
+
+		return
+	}
+
+	a.serveSSE(w, r, filter, since)
+}
+
+// serveSSE streams the delegations with Server-Sent Events.
+func (a *APIHandler) serveSSE(w http.ResponseWriter, r *http.Request, filter datastore.Filter, since *int64) {
+	controller := http.NewResponseController(w)
+
+	// the stream outlives the server write timeout
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		zap.L().Error("couldn't clear delegations stream write deadline", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(e Event) error {
+		data := []byte("{}")
+
+		if e.Delegation != nil {
+			var err error
+
+			data, err = json.Marshal(e.Delegation)
+			if err != nil {
+				return err
+			}
+		}
+
+		if e.ID != "" {
+			if _, err := fmt.Fprintf(w, "id: %s\n", e.ID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+			return err
+		}
+
+		return controller.Flush()
+	}
+
+	if err := a.Stream(r.Context(), filter, since, send); err != nil {
+		zap.L().Info("delegations stream closed", zap.Error(err))
+	}
+}
+
+// serveWebSocket streams the delegations with WebSocket JSON messages.
+func (a *APIHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, filter datastore.Filter, since *int64) {
+	server := websocket.Server{
+		Handler: func(conn *websocket.Conn) {
+			// the stream outlives the server timeouts
+			if err := conn.SetDeadline(time.Time{}); err != nil {
+				zap.L().Error("couldn't clear delegations stream deadline", zap.Error(err))
+
+				return
+			}
+
+			// the request context isn't canceled once the connection is hijacked: the connection is read
+			// until the client closes it
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+
+			go func() {
+				defer cancel()
+
+				var discarded []byte
+
+				for {
+					if err := websocket.Message.Receive(conn, &discarded); err != nil {
+						return
+					}
+				}
+			}()
+
+			send := func(e Event) error {
+				return websocket.JSON.Send(conn, e)
+			}
+
+			if err := a.Stream(ctx, filter, since, send); err != nil {
+				zap.L().Info("delegations stream closed", zap.Error(err))
+			}
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+// Stream sends the new delegations matching the filter until the context is done, after replaying the
+// delegations stored after since from the change log when set. A first heartbeat is sent once the stream
+// is live, then heartbeats are sent in between. A client too slow to keep up is sent an overflow event, and
+// ErrSlowClient is returned.
+//
+//nolint:cyclop
+func (a *APIHandler) Stream(
+	ctx context.Context,
+	filter datastore.Filter,
+	since *int64,
+	send func(Event) error,
+) error {
+	sub := a.broker.subscribe()
+	defer a.broker.unsubscribe(sub)
+
+	// the subscriber receives the delegations after its sequence, the previous ones are replayed
+	last := sub.sequence
+
+	if since != nil {
+		var err error
+
+		last, err = a.replay(ctx, filter, *since, sub.sequence, send)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := send(Event{Type: EventHeartbeat}); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(a.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if err := send(Event{Type: EventHeartbeat}); err != nil {
+				return err
+			}
+		case change, ok := <-sub.events:
+			if !ok {
+				_ = send(Event{Type: EventOverflow})
+
+				return ErrSlowClient
+			}
+
+			if change.Sequence <= last {
+				continue
+			}
+
+			last = change.Sequence
+
+			if !filter.Matches(change.Delegation) {
+				continue
+			}
+
+			err := send(Event{
+				Type:       EventDelegation,
+				ID:         datastore.EncodeChangeToken(change.Sequence),
+				Delegation: change.Delegation,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replay sends the delegations inserted in the change log in (since, until] matching the filter, and returns
+// the sequence of the last change replayed.
+func (a *APIHandler) replay(
+	ctx context.Context,
+	filter datastore.Filter,
+	since, until int64,
+	send func(Event) error,
+) (int64, error) {
+	last := since
+
+	for last < until {
+		changes, err := a.datastore.GetDelegationsChanges(ctx, last, pollSize)
+		if err != nil {
+			return last, err
+		}
+
+		if len(changes) == 0 {
+			break
+		}
+
+		for _, change := range changes {
+			if change.Sequence > until {
+				return last, nil
+			}
+
+			last = change.Sequence
+
+			if change.Operation != model.ChangeInsert || !filter.Matches(change.Delegation) {
+				continue
+			}
+
+			err := send(Event{
+				Type:       EventDelegation,
+				ID:         datastore.EncodeChangeToken(change.Sequence),
+				Delegation: change.Delegation,
+			})
+			if err != nil {
+				return last, err
+			}
+		}
+	}
+
+	return last, nil
+}
@@ -0,0 +1,290 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/stream"
+)
+
+type underTest struct {
+	datastore *memory.Datastore
+	server    *httptest.Server
+}
+
+// setupTest starts a stream server over a datastore storing a first 2023 delegation, with sequence 1.
+func setupTest(t *testing.T) *underTest {
+	t.Helper()
+
+	ut := &underTest{datastore: memory.New()}
+
+	ut.store(t, delegation(1, 2023))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	broker := stream.NewBroker(ut.datastore, 5*time.Millisecond, 16)
+	require.NoError(t, broker.Init(ctx))
+
+	go broker.Run(ctx)
+
+	apiHandler := stream.New(ut.datastore, broker, time.Hour)
+	ut.server = httptest.NewServer(http.HandlerFunc(apiHandler.GetDelegationsStreamHandler))
+	t.Cleanup(ut.server.Close)
+
+	return ut
+}
+
+func (ut *underTest) store(t *testing.T, delegations ...*model.Delegation) {
+	t.Helper()
+
+	require.NoError(t, ut.datastore.StoreDelegations(context.Background(), delegations))
+}
+
+func delegation(id int64, year int) *model.Delegation {
+	return &model.Delegation{
+		ID:        id,
+		Timestamp: time.Date(year, 6, 1, 0, 0, 0, 0, time.UTC),
+		Amount:    100,
+		Delegator: "tz1delegator",
+		Block:     "block",
+	}
+}
+
+// sseEvent is a parsed Server-Sent Event.
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var e sseEvent
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return e
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+
+		switch field {
+		case "id":
+			e.id = value
+		case "event":
+			e.event = value
+		case "data":
+			e.data = value
+		}
+	}
+}
+
+// readSSEDelegation reads the next delegation event, skipping heartbeats.
+func readSSEDelegation(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	for {
+		if e := readSSEEvent(t, reader); e.event != "heartbeat" {
+			return e
+		}
+	}
+}
+
+func openSSE(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	return bufio.NewReader(resp.Body)
+}
+
+func TestStream_GetDelegationsStreamHandler_SSE(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	reader := openSSE(t, ut.server.URL+"?year=2023", "")
+
+	// the stream is live once the first heartbeat is received
+	assert.Equal(t, sseEvent{event: "heartbeat", data: "{}"}, readSSEEvent(t, reader))
+
+	ut.store(t, delegation(2, 2022))
+	ut.store(t, delegation(3, 2023))
+
+	// the 2022 delegation is filtered out
+	got := readSSEEvent(t, reader)
+	assert.Equal(t, datastore.EncodeChangeToken(3), got.id)
+	assert.Equal(t, "delegation", got.event)
+
+	var result model.Delegation
+	require.NoError(t, json.Unmarshal([]byte(got.data), &result))
+	assert.Equal(t, int64(3), result.ID)
+}
+
+func TestStream_GetDelegationsStreamHandler_SSEFilter(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	reader := openSSE(t, ut.server.URL+"?baker=tz1baker&amount.gt=100", "")
+
+	assert.Equal(t, sseEvent{event: "heartbeat", data: "{}"}, readSSEEvent(t, reader))
+
+	toBaker := delegation(2, 2023)
+	toBaker.Baker = "tz1baker"
+
+	large := delegation(3, 2023)
+	large.Amount = 200
+
+	largeToBaker := delegation(4, 2023)
+	largeToBaker.Amount = 200
+	largeToBaker.Baker = "tz1baker"
+
+	ut.store(t, toBaker, large, largeToBaker)
+
+	// the stream is filtered like the delegations list
+	assert.Equal(t, datastore.EncodeChangeToken(4), readSSEDelegation(t, reader).id)
+}
+
+func TestStream_GetDelegationsStreamHandler_SSEResume(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	ut.store(t, delegation(2, 2023))
+
+	// the delegations stored after the last event id are received, replayed or published, only once
+	reader := openSSE(t, ut.server.URL, datastore.EncodeChangeToken(1))
+
+	got := readSSEDelegation(t, reader)
+	assert.Equal(t, datastore.EncodeChangeToken(2), got.id)
+	assert.Equal(t, "delegation", got.event)
+
+	ut.store(t, delegation(3, 2023))
+
+	assert.Equal(t, datastore.EncodeChangeToken(3), readSSEDelegation(t, reader).id)
+}
+
+func TestStream_GetDelegationsStreamHandler_WebSocket(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	conn, err := websocket.Dial(
+		"ws"+strings.TrimPrefix(ut.server.URL, "http")+"?last_event_id="+datastore.EncodeChangeToken(0),
+		"",
+		ut.server.URL,
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	type message struct {
+		Type       string            `json:"type"`
+		ID         string            `json:"id"`
+		Delegation *model.Delegation `json:"delegation"`
+	}
+
+	var got message
+
+	// replayed from the start of the change log
+	require.NoError(t, websocket.JSON.Receive(conn, &got))
+	assert.Equal(t, "delegation", got.Type)
+	assert.Equal(t, datastore.EncodeChangeToken(1), got.ID)
+
+	require.NoError(t, websocket.JSON.Receive(conn, &got))
+	assert.Equal(t, "heartbeat", got.Type)
+
+	ut.store(t, delegation(2, 2023))
+
+	got = message{}
+	require.NoError(t, websocket.JSON.Receive(conn, &got))
+	assert.Equal(t, "delegation", got.Type)
+	assert.Equal(t, datastore.EncodeChangeToken(2), got.ID)
+	require.NotNil(t, got.Delegation)
+	assert.Equal(t, int64(2), got.Delegation.ID)
+}
+
+func TestStream_GetDelegationsStreamHandler_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	cases := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{
+			name: "Error invalid year",
+			url:  "?year=abc",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"year",` +
+				`"range":{"min":2018,"max":9999},"detail":"couldn't parse value abc for query parameter year"}`,
+		},
+		{
+			name: "Error unsupported filter operator",
+			url:  "?amount.ge=100",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"amount.ge","detail":"unsupported filter operator ge for query parameter amount"}`,
+		},
+		{
+			name: "Error invalid last event id",
+			url:  "?last_event_id=invalid!",
+			wantErr: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_parameter",` +
+				`"param":"last_event_id","detail":"couldn't decode last event id invalid!"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, ut.server.URL+c.url, nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body := new(strings.Builder)
+			_, err = bufio.NewReader(resp.Body).WriteTo(body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+			assert.JSONEq(t, c.wantErr, body.String())
+		})
+	}
+}
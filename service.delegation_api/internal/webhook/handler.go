@@ -0,0 +1,611 @@
+// Package webhook manages the webhooks subscriptions to the ingested delegations and their deliveries.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	pkgwebhook "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/webhook"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/envelope"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/param"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/problem"
+)
+
+// errNegativeMinAmount is returned when creating a webhook with a negative minimum amount.
+var errNegativeMinAmount = errors.New("minAmount must not be negative")
+
+const (
+	// maxBodySize is the maximum size of a webhook creation request body.
+	maxBodySize = 64 << 10
+	// secretSize is the number of random bytes of a generated webhook secret.
+	secretSize = 32
+)
+
+// SubpathParams are the query parameters of the webhooks sub paths endpoints.
+var SubpathParams = []string{"page", "size"}
+
+// Config describes the webhooks API configuration.
+type Config struct {
+	// APIKeys are the keys of the clients of the webhooks API, the API being disabled without keys.
+	APIKeys []APIKey `validate:"dive"`
+	// AllowPrivateNetworks allows webhook URLs resolving to non public addresses, for tests and development.
+	AllowPrivateNetworks bool
+}
+
+// APIKey authenticates a client of the webhooks API, which only manages the webhooks it created.
+type APIKey struct {
+	Owner string `validate:"required"`
+	Key   string `validate:"required"`
+}
+
+// APIHandler handles the webhooks API requests.
+type APIHandler struct {
+	cfg       *Config
+	datastore datastore.Datastorer
+	// maxPageSize is the maximum size of a deliveries page.
+	maxPageSize int
+	resolver    *net.Resolver
+	now         func() time.Time
+}
+
+// New creates a new APIHandler.
+func New(cfg *Config, datastore datastore.Datastorer, maxPageSize int) *APIHandler {
+	return &APIHandler{
+		cfg:         cfg,
+		datastore:   datastore,
+		maxPageSize: maxPageSize,
+		resolver:    net.DefaultResolver,
+		now:         time.Now,
+	}
+}
+
+// createWebhookRequest is the body of a webhook creation request.
+type createWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret is the key of the deliveries signature, generated when empty.
+	Secret string              `json:"secret"`
+	Filter model.WebhookFilter `json:"filter"`
+}
+
+// WebhooksHandler handles the legacy /xtz/webhooks endpoint: GET lists the webhooks and POST creates a webhook.
+func (a *APIHandler) WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	owner, authenticated := a.authenticate(w, r)
+	if !authenticated {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.GetWebhooksHandler(w, r, owner)
+	case http.MethodPost:
+		a.CreateWebhookHandler(w, r, owner)
+	default:
+		problem.MethodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// WebhooksV1Handler handles /v1/webhooks endpoint: GET lists the webhooks and POST creates a webhook.
+func (a *APIHandler) WebhooksV1Handler(w http.ResponseWriter, r *http.Request) {
+	owner, authenticated := a.authenticate(w, r)
+	if !authenticated {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.GetWebhooksV1Handler(w, r, owner)
+	case http.MethodPost:
+		a.CreateWebhookV1Handler(w, r, owner)
+	default:
+		problem.MethodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// GetWebhooksHandler handles GET /xtz/webhooks endpoint.
+//
+// It returns the webhooks of the owner sorted by creation, without their secret.
+func (a *APIHandler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request, owner string) {
+	if webhooks, ok := a.getWebhooks(w, r, owner); ok {
+		writeJSON(w, http.StatusOK, webhooks)
+	}
+}
+
+// GetWebhooksV1Handler handles GET /v1/webhooks endpoint.
+//
+// The response body is an envelope with the webhooks of GetWebhooksHandler as data, the webhooks not being
+// paginated.
+func (a *APIHandler) GetWebhooksV1Handler(w http.ResponseWriter, r *http.Request, owner string) {
+	if webhooks, ok := a.getWebhooks(w, r, owner); ok {
+		envelope.Write(w, http.StatusOK, envelope.Body{Data: webhooks})
+	}
+}
+
+// getWebhooks gets the webhooks of the owner without their secret, answering the request with an error when
+// they can't be read.
+func (a *APIHandler) getWebhooks(w http.ResponseWriter, r *http.Request, owner string) ([]*model.Webhook, bool) {
+	webhooks, err := a.datastore.GetWebhooks(r.Context(), owner)
+	if err != nil {
+		zap.L().Error("couldn't get webhooks from datastore", zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	if webhooks == nil {
+		webhooks = []*model.Webhook{}
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	return webhooks, true
+}
+
+// CreateWebhookHandler handles POST /xtz/webhooks endpoint.
+//
+// It creates a webhook of the owner called for every ingested delegation matching its filter, and returns it
+// along with its secret, which isn't returned afterwards.
+func (a *APIHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request, owner string) {
+	webhook, ok := a.createWebhook(w, r, owner)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Location", "/xtz/webhooks/"+webhook.ID)
+	writeJSON(w, http.StatusCreated, webhook)
+}
+
+// CreateWebhookV1Handler handles POST /v1/webhooks endpoint.
+//
+// It creates a webhook as CreateWebhookHandler does, the response body being an envelope with the webhook
+// as data.
+func (a *APIHandler) CreateWebhookV1Handler(w http.ResponseWriter, r *http.Request, owner string) {
+	webhook, ok := a.createWebhook(w, r, owner)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Location", "/v1/webhooks/"+webhook.ID)
+	envelope.Write(w, http.StatusCreated, envelope.Body{Data: webhook})
+}
+
+// createWebhook creates the webhook of a creation request body. The request is answered with an error when
+// the body is invalid or the webhook can't be created.
+//
+//nolint:funlen
+func (a *APIHandler) createWebhook(w http.ResponseWriter, r *http.Request, owner string) (*model.Webhook, bool) {
+	var request createWebhookRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&request); err != nil {
+		problem.InvalidBody(w, fmt.Errorf("couldn't decode webhook: %w", err))
+
+		return nil, false
+	}
+
+	if err := a.validateURL(r.Context(), request.URL); err != nil {
+		problem.InvalidBody(w, err)
+
+		return nil, false
+	}
+
+	if _, err := datastore.ParseDelegationKind(request.Filter.Kind); err != nil {
+		problem.InvalidBody(
+			w,
+			fmt.Errorf("invalid kind %s, expected delegation, redelegation or undelegation", request.Filter.Kind),
+		)
+
+		return nil, false
+	}
+
+	if request.Filter.MinAmount < 0 {
+		problem.InvalidBody(w, errNegativeMinAmount)
+
+		return nil, false
+	}
+
+	id, err := datastore.NewWebhookID()
+	if err != nil {
+		zap.L().Error("couldn't generate webhook id", zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	if request.Secret == "" {
+		request.Secret, err = newSecret()
+		if err != nil {
+			zap.L().Error("couldn't generate webhook secret", zap.Error(err))
+			problem.InternalError(w)
+
+			return nil, false
+		}
+	}
+
+	webhook := &model.Webhook{
+		ID:        id,
+		Owner:     owner,
+		URL:       request.URL,
+		Secret:    request.Secret,
+		Filter:    request.Filter,
+		CreatedAt: a.now().UTC().Truncate(time.Millisecond),
+	}
+
+	if err := a.datastore.CreateWebhook(r.Context(), webhook); err != nil {
+		zap.L().Error("couldn't create webhook in datastore", zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// subpathHandlers are the handlers of the webhooks sub paths of an API surface, either the legacy or the v1
+// one. The deletion has no response body, so it is shared by both.
+type subpathHandlers struct {
+	getWebhook     func(w http.ResponseWriter, r *http.Request, owner, id string)
+	getDeliveries  func(w http.ResponseWriter, r *http.Request, owner, id string)
+	replayDelivery func(w http.ResponseWriter, r *http.Request, owner, id, deliveryID string)
+}
+
+// SubpathHandler handles the legacy /xtz/webhooks/ sub paths, the request path being either {id},
+// {id}/deliveries or {id}/deliveries/{deliveryID}/replay. The webhooks of other owners aren't found.
+func (a *APIHandler) SubpathHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSubpath(w, r, subpathHandlers{
+		getWebhook:     a.GetWebhookHandler,
+		getDeliveries:  a.GetWebhookDeliveriesHandler,
+		replayDelivery: a.ReplayWebhookDeliveryHandler,
+	})
+}
+
+// SubpathV1Handler handles the /v1/webhooks/ sub paths, the request path being either {id},
+// {id}/deliveries or {id}/deliveries/{deliveryID}/replay. The webhooks of other owners aren't found.
+func (a *APIHandler) SubpathV1Handler(w http.ResponseWriter, r *http.Request) {
+	a.serveSubpath(w, r, subpathHandlers{
+		getWebhook:     a.GetWebhookV1Handler,
+		getDeliveries:  a.GetWebhookDeliveriesV1Handler,
+		replayDelivery: a.ReplayWebhookDeliveryV1Handler,
+	})
+}
+
+// serveSubpath authenticates a webhooks sub path request and routes it to its handler.
+func (a *APIHandler) serveSubpath(w http.ResponseWriter, r *http.Request, handlers subpathHandlers) {
+	owner, authenticated := a.authenticate(w, r)
+	if !authenticated {
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		switch r.Method {
+		case http.MethodGet:
+			handlers.getWebhook(w, r, owner, parts[0])
+		case http.MethodDelete:
+			a.DeleteWebhookHandler(w, r, owner, parts[0])
+		default:
+			problem.MethodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "deliveries":
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed(w, http.MethodGet)
+
+			return
+		}
+
+		handlers.getDeliveries(w, r, owner, parts[0])
+	case len(parts) == 4 && parts[0] != "" && parts[1] == "deliveries" && parts[2] != "" && parts[3] == "replay":
+		if r.Method != http.MethodPost {
+			problem.MethodNotAllowed(w, http.MethodPost)
+
+			return
+		}
+
+		handlers.replayDelivery(w, r, owner, parts[0], parts[2])
+	default:
+		problem.NotFound(w)
+	}
+}
+
+// GetWebhookHandler handles GET /xtz/webhooks/{id} endpoint.
+//
+// It returns the webhook, without its secret.
+func (a *APIHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request, owner, id string) {
+	webhook, found := a.getWebhook(w, r, owner, id)
+	if !found {
+		return
+	}
+
+	webhook.Secret = ""
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// GetWebhookV1Handler handles GET /v1/webhooks/{id} endpoint.
+//
+// The response body is an envelope with the webhook, without its secret, as data.
+func (a *APIHandler) GetWebhookV1Handler(w http.ResponseWriter, r *http.Request, owner, id string) {
+	webhook, found := a.getWebhook(w, r, owner, id)
+	if !found {
+		return
+	}
+
+	webhook.Secret = ""
+
+	envelope.Write(w, http.StatusOK, envelope.Body{Data: webhook})
+}
+
+// DeleteWebhookHandler handles DELETE /xtz/webhooks/{id} and /v1/webhooks/{id} endpoints.
+//
+// It deletes the webhook along with its deliveries.
+func (a *APIHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request, owner, id string) {
+	if _, found := a.getWebhook(w, r, owner, id); !found {
+		return
+	}
+
+	if err := a.datastore.DeleteWebhook(r.Context(), id); err != nil {
+		zap.L().Error("couldn't delete webhook from datastore", zap.String("id", id), zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveriesHandler handles GET /xtz/webhooks/{id}/deliveries endpoint.
+//
+// It returns the deliveries of the webhook, the latest delegations first, paginated with page and size
+// parameters. The next page is returned in a Link header.
+func (a *APIHandler) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request, owner, id string) {
+	deliveries, page, ok := a.getWebhookDeliveries(w, r, owner, id)
+	if !ok {
+		return
+	}
+
+	// deliveries aren't counted, a full page may have a next page
+	if len(deliveries) == page.Size {
+		// the request path is stripped of the /xtz/webhooks/ prefix
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page.Number+1))
+
+		link := url.URL{Path: "/xtz/webhooks/" + r.URL.Path, RawQuery: query.Encode()}
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, link.String()))
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// GetWebhookDeliveriesV1Handler handles GET /v1/webhooks/{id}/deliveries endpoint.
+//
+// It takes the parameters of GetWebhookDeliveriesHandler, the response body being an envelope with the
+// deliveries as data, the pagination (page number and size, the deliveries not being counted) and the links
+// to this page and the next one.
+func (a *APIHandler) GetWebhookDeliveriesV1Handler(w http.ResponseWriter, r *http.Request, owner, id string) {
+	deliveries, page, ok := a.getWebhookDeliveries(w, r, owner, id)
+	if !ok {
+		return
+	}
+
+	// the request path is stripped of the /v1/webhooks/ prefix, a full page may have a next page
+	envelope.Write(
+		w,
+		http.StatusOK,
+		envelope.List("/v1/webhooks/"+r.URL.Path, r.URL, deliveries, page, nil, len(deliveries) < page.Size),
+	)
+}
+
+// getWebhookDeliveries gets the page of deliveries of a webhook of the owner. The request is answered with an
+// error when the parameters are invalid, the webhook isn't found or the deliveries can't be read.
+func (a *APIHandler) getWebhookDeliveries(
+	w http.ResponseWriter,
+	r *http.Request,
+	owner, id string,
+) ([]*model.WebhookDelivery, datastore.Page, bool) {
+	page, err := param.Page(r.URL.Query(), param.DefaultPageSize, a.maxPageSize)
+	if err != nil {
+		problem.BadRequest(w, err)
+
+		return nil, datastore.Page{}, false
+	}
+
+	if _, found := a.getWebhook(w, r, owner, id); !found {
+		return nil, datastore.Page{}, false
+	}
+
+	deliveries, err := a.datastore.GetWebhookDeliveries(
+		r.Context(),
+		id,
+		page,
+	)
+	if err != nil {
+		zap.L().Error("couldn't get webhook deliveries from datastore", zap.String("id", id), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, datastore.Page{}, false
+	}
+
+	if deliveries == nil {
+		deliveries = []*model.WebhookDelivery{}
+	}
+
+	return deliveries, page, true
+}
+
+// ReplayWebhookDeliveryHandler handles POST /xtz/webhooks/{id}/deliveries/{deliveryID}/replay endpoint.
+//
+// It schedules the delivery again, whatever its status, with a full retry budget. The delivery is sent
+// by the next cron run.
+func (a *APIHandler) ReplayWebhookDeliveryHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+	owner, id, deliveryID string,
+) {
+	if delivery, ok := a.replayWebhookDelivery(w, r, owner, id, deliveryID); ok {
+		writeJSON(w, http.StatusAccepted, delivery)
+	}
+}
+
+// ReplayWebhookDeliveryV1Handler handles POST /v1/webhooks/{id}/deliveries/{deliveryID}/replay endpoint.
+//
+// It schedules the delivery again as ReplayWebhookDeliveryHandler does, the response body being an envelope
+// with the delivery as data.
+func (a *APIHandler) ReplayWebhookDeliveryV1Handler(
+	w http.ResponseWriter,
+	r *http.Request,
+	owner, id, deliveryID string,
+) {
+	if delivery, ok := a.replayWebhookDelivery(w, r, owner, id, deliveryID); ok {
+		envelope.Write(w, http.StatusAccepted, envelope.Body{Data: delivery})
+	}
+}
+
+// replayWebhookDelivery schedules a delivery of a webhook of the owner again. The request is answered with an
+// error when the webhook or the delivery isn't found, or the delivery can't be updated.
+func (a *APIHandler) replayWebhookDelivery(
+	w http.ResponseWriter,
+	r *http.Request,
+	owner, id, deliveryID string,
+) (*model.WebhookDelivery, bool) {
+	if _, found := a.getWebhook(w, r, owner, id); !found {
+		return nil, false
+	}
+
+	delivery, err := a.datastore.GetWebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		zap.L().Error("couldn't get webhook delivery from datastore", zap.String("id", deliveryID), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	if delivery == nil || delivery.WebhookID != id {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	delivery.Status = model.DeliveryPending
+	delivery.Attempts = 0
+	delivery.StatusCode = 0
+	delivery.Error = ""
+	delivery.NextAttemptAt = a.now().UTC().Truncate(time.Millisecond)
+
+	if err := a.datastore.UpdateWebhookDelivery(r.Context(), delivery); err != nil {
+		zap.L().Error("couldn't update webhook delivery in datastore", zap.String("id", deliveryID), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	return delivery, true
+}
+
+// authenticate returns the owner of the API key of the request bearer token, writing an unauthorized response
+// when the token is missing or unknown.
+func (a *APIHandler) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		problem.Unauthorized(w, "missing bearer token")
+
+		return "", false
+	}
+
+	for _, apiKey := range a.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(apiKey.Key)) == 1 {
+			return apiKey.Owner, true
+		}
+	}
+
+	problem.Unauthorized(w, "invalid bearer token")
+
+	return "", false
+}
+
+// getWebhook gets a webhook of the owner, writing the error response when it isn't found or can't be read.
+// The webhooks of other owners aren't found, so that their existence isn't disclosed.
+func (a *APIHandler) getWebhook(w http.ResponseWriter, r *http.Request, owner, id string) (*model.Webhook, bool) {
+	webhook, err := a.datastore.GetWebhook(r.Context(), id)
+	if err != nil {
+		zap.L().Error("couldn't get webhook from datastore", zap.String("id", id), zap.Error(err))
+		problem.InternalError(w)
+
+		return nil, false
+	}
+
+	if webhook == nil || webhook.Owner != owner {
+		problem.NotFound(w)
+
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// validateURL checks a webhook URL is an absolute http or https URL, resolving to public addresses unless
+// private networks are allowed.
+func (a *APIHandler) validateURL(ctx context.Context, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("invalid url %s, expected an absolute http or https URL", value)
+	}
+
+	if a.cfg.AllowPrivateNetworks {
+		return nil
+	}
+
+	if err := pkgwebhook.CheckHost(ctx, a.resolver, parsed.Hostname()); err != nil {
+		return fmt.Errorf("invalid url %s: %w", value, err)
+	}
+
+	return nil
+}
+
+// newSecret returns a new random webhook secret.
+func newSecret() (string, error) {
+	secret := make([]byte, secretSize)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, response any) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		zap.L().Error("error marshalling response to JSON", zap.Error(err))
+		problem.InternalError(w)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		zap.L().Error("error writing JSON response", zap.Error(err))
+	}
+}
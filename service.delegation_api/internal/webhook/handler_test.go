@@ -0,0 +1,653 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/memory"
+	datastoremock "github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/mock"
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/tezos/datastore/model"
+	"github.com/guillaumedebavelaere/tezos-delegation/service.delegation_api/internal/webhook"
+)
+
+// apiConfig authenticates 2 owners, the requests being sent with the key of the first one. Private networks are
+// allowed, so the webhooks URLs aren't resolved.
+var apiConfig = &webhook.Config{
+	APIKeys:              []webhook.APIKey{{Owner: "owner1", Key: "key1"}, {Owner: "owner2", Key: "key2"}},
+	AllowPrivateNetworks: true,
+}
+
+// maxPageSize is the maximum page size of the handlers under test.
+const maxPageSize = 1000
+
+type underTest struct {
+	datastore  *memory.Datastore
+	apiHandler *webhook.APIHandler
+	mux        *http.ServeMux
+}
+
+func setupTest(t *testing.T) *underTest {
+	t.Helper()
+
+	return setupTestWithConfig(t, apiConfig)
+}
+
+func setupTestWithConfig(t *testing.T, cfg *webhook.Config) *underTest {
+	t.Helper()
+
+	ut := &underTest{datastore: memory.New()}
+	ut.apiHandler = webhook.New(cfg, ut.datastore, maxPageSize)
+
+	ut.mux = http.NewServeMux()
+	ut.mux.HandleFunc("/xtz/webhooks", ut.apiHandler.WebhooksHandler)
+	ut.mux.Handle("/xtz/webhooks/", http.StripPrefix("/xtz/webhooks/", http.HandlerFunc(ut.apiHandler.SubpathHandler)))
+	ut.mux.HandleFunc("/v1/webhooks", ut.apiHandler.WebhooksV1Handler)
+	ut.mux.Handle("/v1/webhooks/", http.StripPrefix("/v1/webhooks/", http.HandlerFunc(ut.apiHandler.SubpathV1Handler)))
+
+	return ut
+}
+
+func (ut *underTest) serve(method, url, body string) *httptest.ResponseRecorder {
+	return ut.serveAs("key1", method, url, body)
+}
+
+// serveAs serves a request authenticated with an API key, unauthenticated when empty.
+func (ut *underTest) serveAs(key, method, url, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	rr := httptest.NewRecorder()
+	ut.mux.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// create creates a webhook with the API.
+func (ut *underTest) create(t *testing.T, body string) *model.Webhook {
+	t.Helper()
+
+	rr := ut.serve(http.MethodPost, "/xtz/webhooks", body)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	var created model.Webhook
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+
+	return &created
+}
+
+var delegations = []*model.Delegation{
+	{
+		ID: 1, Timestamp: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), Amount: 100,
+		Delegator: "tz1delegator", Block: "block1", Baker: "tz1baker",
+	},
+	{
+		ID: 2, Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), Amount: 50,
+		Delegator: "tz1delegator", Block: "block2", PreviousBaker: "tz1baker",
+	},
+}
+
+func TestWebhook_CreateWebhookHandler(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	created := ut.create(
+		t,
+		`{"url":"https://example.com/hook","secret":"s3cret",`+
+			`"filter":{"baker":"tz1baker","minAmount":10,"kind":"delegation"}}`,
+	)
+	assert.Len(t, created.ID, 32)
+	assert.Equal(t, "https://example.com/hook", created.URL)
+	assert.Equal(t, "s3cret", created.Secret)
+	assert.Equal(
+		t,
+		model.WebhookFilter{Baker: "tz1baker", MinAmount: 10, Kind: model.KindDelegation},
+		created.Filter,
+	)
+
+	stored, err := ut.datastore.GetWebhook(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Secret, stored.Secret)
+
+	// the secret is generated when not given
+	generated := ut.create(t, `{"url":"http://localhost:8080/hook"}`)
+	assert.Len(t, generated.Secret, 64)
+
+	t.Run("Location", func(t *testing.T) {
+		rr := ut.serve(http.MethodPost, "/xtz/webhooks", `{"url":"http://localhost/hook"}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.True(t, strings.HasPrefix(rr.Header().Get("Location"), "/xtz/webhooks/"))
+	})
+}
+
+func TestWebhook_CreateWebhookHandler_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		body     string
+		wantBody string
+	}{
+		{
+			name: "Error invalid JSON",
+			body: `{"url":`,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",` +
+				`"detail":"couldn't decode webhook: unexpected EOF"}`,
+		},
+		{
+			name: "Error unknown field",
+			body: `{"url":"http://localhost/hook","events":["delegation"]}`,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",` +
+				`"detail":"couldn't decode webhook: json: unknown field \"events\""}`,
+		},
+		{
+			name: "Error relative url",
+			body: `{"url":"/hook"}`,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",` +
+				`"detail":"invalid url /hook, expected an absolute http or https URL"}`,
+		},
+		{
+			name: "Error unsupported scheme",
+			body: `{"url":"ftp://localhost/hook"}`,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",` +
+				`"detail":"invalid url ftp://localhost/hook, expected an absolute http or https URL"}`,
+		},
+		{
+			name: "Error invalid kind",
+			body: `{"url":"http://localhost/hook","filter":{"kind":"transfer"}}`,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",` +
+				`"detail":"invalid kind transfer, expected delegation, redelegation or undelegation"}`,
+		},
+		{
+			name: "Error negative min amount",
+			body: `{"url":"http://localhost/hook","filter":{"minAmount":-1}}`,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",` +
+				`"detail":"minAmount must not be negative"}`,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+
+			rr := ut.serve(http.MethodPost, "/xtz/webhooks", c.body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.JSONEq(t, c.wantBody, rr.Body.String())
+
+			webhooks, err := ut.datastore.GetWebhooks(context.Background(), "")
+			require.NoError(t, err)
+			assert.Empty(t, webhooks)
+		})
+	}
+}
+
+func TestWebhook_CreateWebhookHandler_NonPublic(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		url        string
+		wantDetail string
+	}{
+		{
+			name: "Error loopback",
+			url:  "http://127.0.0.1:8080/hook",
+			wantDetail: "invalid url http://127.0.0.1:8080/hook: " +
+				"host 127.0.0.1 resolves to 127.0.0.1: non public address",
+		},
+		{
+			name:       "Error IPv6 loopback",
+			url:        "http://[::1]/hook",
+			wantDetail: "invalid url http://[::1]/hook: host ::1 resolves to ::1: non public address",
+		},
+		{
+			name:       "Error private",
+			url:        "https://10.0.0.1/hook",
+			wantDetail: "invalid url https://10.0.0.1/hook: host 10.0.0.1 resolves to 10.0.0.1: non public address",
+		},
+		{
+			name: "Error link local",
+			url:  "http://169.254.169.254/latest/meta-data",
+			wantDetail: "invalid url http://169.254.169.254/latest/meta-data: " +
+				"host 169.254.169.254 resolves to 169.254.169.254: non public address",
+		},
+		{
+			name: "Error IPv4-mapped",
+			url:  "http://[::ffff:192.168.1.1]/hook",
+			wantDetail: "invalid url http://[::ffff:192.168.1.1]/hook: " +
+				"host ::ffff:192.168.1.1 resolves to 192.168.1.1: non public address",
+		},
+		{
+			name: "Error shared",
+			url:  "http://100.64.0.1/hook",
+			wantDetail: "invalid url http://100.64.0.1/hook: " +
+				"host 100.64.0.1 resolves to 100.64.0.1: non public address",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTestWithConfig(t, &webhook.Config{APIKeys: apiConfig.APIKeys})
+
+			rr := ut.serve(http.MethodPost, "/xtz/webhooks", `{"url":"`+c.url+`"}`)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.JSONEq(
+				t,
+				`{"type":"about:blank","title":"Bad Request","status":400,"code":"invalid_body",`+
+					`"detail":"`+c.wantDetail+`"}`,
+				rr.Body.String(),
+			)
+		})
+	}
+
+	t.Run("Public", func(t *testing.T) {
+		t.Parallel()
+
+		ut := setupTestWithConfig(t, &webhook.Config{APIKeys: apiConfig.APIKeys})
+
+		created := ut.create(t, `{"url":"https://93.184.216.34/hook"}`)
+		assert.Equal(t, "https://93.184.216.34/hook", created.URL)
+	})
+}
+
+func TestWebhook_GetWebhooksHandler(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	rr := ut.serve(http.MethodGet, "/xtz/webhooks", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+
+	created := ut.create(t, `{"url":"http://localhost/hook"}`)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var got []*model.Webhook
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, created.ID, got[0].ID)
+	assert.Empty(t, got[0].Secret, "secrets are only returned on creation")
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var webhook model.Webhook
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &webhook))
+	assert.Equal(t, created.ID, webhook.ID)
+	assert.Empty(t, webhook.Secret)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestWebhook_DeleteWebhookHandler(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	created := ut.create(t, `{"url":"http://localhost/hook"}`)
+
+	rr := ut.serve(http.MethodDelete, "/xtz/webhooks/"+created.ID, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ut.serve(http.MethodDelete, "/xtz/webhooks/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestWebhook_GetWebhookDeliveriesHandler(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	ctx := context.Background()
+
+	created := ut.create(t, `{"url":"http://localhost/hook"}`)
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+
+	rr := ut.serve(http.MethodGet, "/xtz/webhooks/"+created.ID+"/deliveries?size=1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(
+		t,
+		`</xtz/webhooks/`+created.ID+`/deliveries?page=2&size=1>; rel="next"`,
+		rr.Header().Get("Link"),
+	)
+
+	var got []*model.WebhookDelivery
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, created.ID+"-2", got[0].ID)
+	assert.Equal(t, model.DeliveryPending, got[0].Status)
+	assert.Equal(t, delegations[1].ID, got[0].Delegation.ID)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/"+created.ID+"/deliveries", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Link"))
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Len(t, got, 2)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/unknown/deliveries", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/"+created.ID+"/deliveries?page=abc", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestWebhook_GetWebhookDeliveriesHandler_MaxPageSize(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	created := ut.create(t, `{"url":"http://localhost/hook"}`)
+	require.NoError(t, ut.datastore.StoreDelegations(context.Background(), delegations))
+
+	// the pages are at most of the configured size, which is also their default size when smaller
+	apiHandler := webhook.New(apiConfig, ut.datastore, 1)
+	server := http.StripPrefix("/xtz/webhooks/", http.HandlerFunc(apiHandler.SubpathHandler))
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer key1")
+
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := serve("/xtz/webhooks/" + created.ID + "/deliveries")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got []*model.WebhookDelivery
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Len(t, got, 1)
+
+	rr = serve("/xtz/webhooks/" + created.ID + "/deliveries?size=2")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestWebhook_ReplayWebhookDeliveryHandler(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	ctx := context.Background()
+
+	created := ut.create(t, `{"url":"http://localhost/hook"}`)
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations[:1]))
+
+	deliveryID := created.ID + "-1"
+
+	delivery, err := ut.datastore.GetWebhookDelivery(ctx, deliveryID)
+	require.NoError(t, err)
+
+	delivery.Status = model.DeliveryFailed
+	delivery.Attempts = 8
+	delivery.StatusCode = http.StatusServiceUnavailable
+	delivery.Error = "Service Unavailable"
+	require.NoError(t, ut.datastore.UpdateWebhookDelivery(ctx, delivery))
+
+	rr := ut.serve(http.MethodPost, "/xtz/webhooks/"+created.ID+"/deliveries/"+deliveryID+"/replay", "")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	replayed, err := ut.datastore.GetWebhookDelivery(ctx, deliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryPending, replayed.Status)
+	assert.Zero(t, replayed.Attempts)
+	assert.Zero(t, replayed.StatusCode)
+	assert.Empty(t, replayed.Error)
+
+	due, err := ut.datastore.GetDueWebhookDeliveries(ctx, time.Now().Add(time.Second), 0)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	rr = ut.serve(http.MethodPost, "/xtz/webhooks/other/deliveries/"+deliveryID+"/replay", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ut.serve(http.MethodPost, "/xtz/webhooks/"+created.ID+"/deliveries/unknown/replay", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+//nolint:funlen
+func TestWebhook_V1Handlers(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	ctx := context.Background()
+
+	rr := ut.serve(http.MethodPost, "/v1/webhooks", `{"url":"http://localhost/hook","secret":"s3cret"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	var created struct {
+		Data *model.Webhook `json:"data"`
+	}
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	require.NotNil(t, created.Data)
+	assert.Equal(t, "s3cret", created.Data.Secret)
+	assert.Equal(t, "/v1/webhooks/"+created.Data.ID, rr.Header().Get("Location"))
+
+	id := created.Data.ID
+
+	rr = ut.serve(http.MethodGet, "/v1/webhooks", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(
+		t,
+		`{"data": [{
+			"id": "`+id+`", "url": "http://localhost/hook", "filter": {},
+			"createdAt": "`+created.Data.CreatedAt.Format(time.RFC3339Nano)+`"
+		}]}`,
+		rr.Body.String(),
+	)
+
+	rr = ut.serve(http.MethodGet, "/v1/webhooks/"+id, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "s3cret", "secrets are only returned on creation")
+
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations))
+
+	rr = ut.serve(http.MethodGet, "/v1/webhooks/"+id+"/deliveries?size=1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Link"), "the pagination is in the body only")
+
+	var page struct {
+		Data       []*model.WebhookDelivery `json:"data"`
+		Pagination map[string]int           `json:"pagination"`
+		Links      map[string]string        `json:"links"`
+	}
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, id+"-2", page.Data[0].ID)
+	assert.Equal(t, map[string]int{"page": 1, "size": 1}, page.Pagination)
+	assert.Equal(
+		t,
+		map[string]string{
+			"self": "/v1/webhooks/" + id + "/deliveries?size=1",
+			"next": "/v1/webhooks/" + id + "/deliveries?page=2&size=1",
+		},
+		page.Links,
+	)
+
+	rr = ut.serve(http.MethodPost, "/v1/webhooks/"+id+"/deliveries/"+id+"-1/replay", "")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	var replayed struct {
+		Data *model.WebhookDelivery `json:"data"`
+	}
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &replayed))
+	require.NotNil(t, replayed.Data)
+	assert.Equal(t, model.DeliveryPending, replayed.Data.Status)
+
+	rr = ut.serve(http.MethodDelete, "/v1/webhooks/"+id, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = ut.serve(http.MethodGet, "/v1/webhooks/"+id, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = ut.serveAs("", http.MethodGet, "/v1/webhooks", "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestWebhook_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	cases := []struct {
+		name       string
+		key        string
+		wantDetail string
+	}{
+		{name: "Missing key", key: "", wantDetail: "missing bearer token"},
+		{name: "Invalid key", key: "unknown", wantDetail: "invalid bearer token"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, url := range []string{"/xtz/webhooks", "/xtz/webhooks/id", "/xtz/webhooks/id/deliveries"} {
+				rr := ut.serveAs(c.key, http.MethodGet, url, "")
+				assert.Equal(t, http.StatusUnauthorized, rr.Code, url)
+				assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"), url)
+				assert.JSONEq(
+					t,
+					`{"type":"about:blank","title":"Unauthorized","status":401,"code":"unauthorized",`+
+						`"detail":"`+c.wantDetail+`"}`,
+					rr.Body.String(),
+					url,
+				)
+			}
+		})
+	}
+}
+
+// TestWebhook_Owner checks the webhooks of an owner can't be read nor managed by another owner.
+func TestWebhook_Owner(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+	ctx := context.Background()
+
+	created := ut.create(t, `{"url":"http://localhost/hook"}`)
+	require.NoError(t, ut.datastore.StoreDelegations(ctx, delegations[:1]))
+
+	deliveryID := created.ID + "-1"
+
+	rr := ut.serveAs("key2", http.MethodGet, "/xtz/webhooks", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+
+	for _, request := range []struct{ method, url string }{
+		{http.MethodGet, "/xtz/webhooks/" + created.ID},
+		{http.MethodDelete, "/xtz/webhooks/" + created.ID},
+		{http.MethodGet, "/xtz/webhooks/" + created.ID + "/deliveries"},
+		{http.MethodPost, "/xtz/webhooks/" + created.ID + "/deliveries/" + deliveryID + "/replay"},
+	} {
+		rr := ut.serveAs("key2", request.method, request.url, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code, request.url)
+	}
+
+	// the webhook is left unchanged
+	webhook, err := ut.datastore.GetWebhook(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, webhook)
+	assert.Equal(t, "owner1", webhook.Owner)
+
+	rr = ut.serve(http.MethodGet, "/xtz/webhooks/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestWebhook_MethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		method    string
+		url       string
+		wantAllow string
+	}{
+		{name: "Webhooks", method: http.MethodPut, url: "/xtz/webhooks", wantAllow: "GET, POST"},
+		{name: "Webhook", method: http.MethodPost, url: "/xtz/webhooks/id", wantAllow: "GET, DELETE"},
+		{name: "Deliveries", method: http.MethodPost, url: "/xtz/webhooks/id/deliveries", wantAllow: "GET"},
+		{name: "Replay", method: http.MethodGet, url: "/xtz/webhooks/id/deliveries/id-1/replay", wantAllow: "POST"},
+	}
+
+	for _, c := range cases {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ut := setupTest(t)
+
+			rr := ut.serve(c.method, c.url, "")
+			assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+			assert.Equal(t, c.wantAllow, rr.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestWebhook_SubpathHandler_NotFound(t *testing.T) {
+	t.Parallel()
+
+	ut := setupTest(t)
+
+	for _, url := range []string{"/xtz/webhooks/", "/xtz/webhooks/id/other", "/xtz/webhooks/id/deliveries/id-1"} {
+		rr := ut.serve(http.MethodGet, url, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code, url)
+	}
+}
+
+var errGetWebhooks = errors.New("error getting webhooks")
+
+func TestWebhook_GetWebhooksHandler_Error(t *testing.T) {
+	t.Parallel()
+
+	mockDatastore := datastoremock.NewMockDatastorer(gomock.NewController(t))
+	mockDatastore.EXPECT().GetWebhooks(gomock.Any(), "owner1").Return(nil, errGetWebhooks)
+
+	req := httptest.NewRequest(http.MethodGet, "/xtz/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer key1")
+
+	rr := httptest.NewRecorder()
+	webhook.New(apiConfig, mockDatastore, maxPageSize).WebhooksHandler(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`,
+		rr.Body.String())
+}
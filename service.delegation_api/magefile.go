@@ -3,10 +3,19 @@
 package main
 
 import (
+	"github.com/guillaumedebavelaere/tezos-delegation/pkg/mage/gen"
 	//mage:import
 	"github.com/guillaumedebavelaere/tezos-delegation/tools/mage/service"
 )
 
 func init() {
 	service.Name = "delegation_api"
+	service.GenFiles = []*gen.File{
+		{
+			Name: "delegation",
+			Type: gen.Proto,
+			Path: "delegation/v1/delegation.proto",
+			Dest: "./proto",
+		},
+	}
 }
@@ -0,0 +1,1319 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.24.4
+// source: delegation/v1/delegation.proto
+
+package delegationv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// DelegationKind is the kind of a delegation.
+type DelegationKind int32
+
+const (
+	DelegationKind_DELEGATION_KIND_UNSPECIFIED DelegationKind = 0
+	// DELEGATION_KIND_DELEGATION is a first delegation.
+	DelegationKind_DELEGATION_KIND_DELEGATION DelegationKind = 1
+	// DELEGATION_KIND_REDELEGATION is a change of baker.
+	DelegationKind_DELEGATION_KIND_REDELEGATION DelegationKind = 2
+	// DELEGATION_KIND_UNDELEGATION is a removal of the baker.
+	DelegationKind_DELEGATION_KIND_UNDELEGATION DelegationKind = 3
+)
+
+// Enum value maps for DelegationKind.
+var (
+	DelegationKind_name = map[int32]string{
+		0: "DELEGATION_KIND_UNSPECIFIED",
+		1: "DELEGATION_KIND_DELEGATION",
+		2: "DELEGATION_KIND_REDELEGATION",
+		3: "DELEGATION_KIND_UNDELEGATION",
+	}
+	DelegationKind_value = map[string]int32{
+		"DELEGATION_KIND_UNSPECIFIED":  0,
+		"DELEGATION_KIND_DELEGATION":   1,
+		"DELEGATION_KIND_REDELEGATION": 2,
+		"DELEGATION_KIND_UNDELEGATION": 3,
+	}
+)
+
+func (x DelegationKind) Enum() *DelegationKind {
+	p := new(DelegationKind)
+	*p = x
+	return p
+}
+
+func (x DelegationKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DelegationKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_delegation_v1_delegation_proto_enumTypes[0].Descriptor()
+}
+
+func (DelegationKind) Type() protoreflect.EnumType {
+	return &file_delegation_v1_delegation_proto_enumTypes[0]
+}
+
+func (x DelegationKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DelegationKind.Descriptor instead.
+func (DelegationKind) EnumDescriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{0}
+}
+
+// DelegationStatus is the status of a delegation operation. Only applied delegations are stored.
+type DelegationStatus int32
+
+const (
+	DelegationStatus_DELEGATION_STATUS_UNSPECIFIED DelegationStatus = 0
+	DelegationStatus_DELEGATION_STATUS_APPLIED     DelegationStatus = 1
+	DelegationStatus_DELEGATION_STATUS_FAILED      DelegationStatus = 2
+	DelegationStatus_DELEGATION_STATUS_BACKTRACKED DelegationStatus = 3
+	DelegationStatus_DELEGATION_STATUS_SKIPPED     DelegationStatus = 4
+)
+
+// Enum value maps for DelegationStatus.
+var (
+	DelegationStatus_name = map[int32]string{
+		0: "DELEGATION_STATUS_UNSPECIFIED",
+		1: "DELEGATION_STATUS_APPLIED",
+		2: "DELEGATION_STATUS_FAILED",
+		3: "DELEGATION_STATUS_BACKTRACKED",
+		4: "DELEGATION_STATUS_SKIPPED",
+	}
+	DelegationStatus_value = map[string]int32{
+		"DELEGATION_STATUS_UNSPECIFIED": 0,
+		"DELEGATION_STATUS_APPLIED":     1,
+		"DELEGATION_STATUS_FAILED":      2,
+		"DELEGATION_STATUS_BACKTRACKED": 3,
+		"DELEGATION_STATUS_SKIPPED":     4,
+	}
+)
+
+func (x DelegationStatus) Enum() *DelegationStatus {
+	p := new(DelegationStatus)
+	*p = x
+	return p
+}
+
+func (x DelegationStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DelegationStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_delegation_v1_delegation_proto_enumTypes[1].Descriptor()
+}
+
+func (DelegationStatus) Type() protoreflect.EnumType {
+	return &file_delegation_v1_delegation_proto_enumTypes[1]
+}
+
+func (x DelegationStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DelegationStatus.Descriptor instead.
+func (DelegationStatus) EnumDescriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{1}
+}
+
+// Field is a sort field.
+type DelegationsSort_Field int32
+
+const (
+	// FIELD_UNSPECIFIED sorts by timestamp.
+	DelegationsSort_FIELD_UNSPECIFIED DelegationsSort_Field = 0
+	DelegationsSort_FIELD_TIMESTAMP   DelegationsSort_Field = 1
+	DelegationsSort_FIELD_AMOUNT      DelegationsSort_Field = 2
+	DelegationsSort_FIELD_LEVEL       DelegationsSort_Field = 3
+)
+
+// Enum value maps for DelegationsSort_Field.
+var (
+	DelegationsSort_Field_name = map[int32]string{
+		0: "FIELD_UNSPECIFIED",
+		1: "FIELD_TIMESTAMP",
+		2: "FIELD_AMOUNT",
+		3: "FIELD_LEVEL",
+	}
+	DelegationsSort_Field_value = map[string]int32{
+		"FIELD_UNSPECIFIED": 0,
+		"FIELD_TIMESTAMP":   1,
+		"FIELD_AMOUNT":      2,
+		"FIELD_LEVEL":       3,
+	}
+)
+
+func (x DelegationsSort_Field) Enum() *DelegationsSort_Field {
+	p := new(DelegationsSort_Field)
+	*p = x
+	return p
+}
+
+func (x DelegationsSort_Field) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DelegationsSort_Field) Descriptor() protoreflect.EnumDescriptor {
+	return file_delegation_v1_delegation_proto_enumTypes[2].Descriptor()
+}
+
+func (DelegationsSort_Field) Type() protoreflect.EnumType {
+	return &file_delegation_v1_delegation_proto_enumTypes[2]
+}
+
+func (x DelegationsSort_Field) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DelegationsSort_Field.Descriptor instead.
+func (DelegationsSort_Field) EnumDescriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{2, 0}
+}
+
+// Type is the type of a stream event.
+type StreamDelegationsResponse_Type int32
+
+const (
+	StreamDelegationsResponse_TYPE_UNSPECIFIED StreamDelegationsResponse_Type = 0
+	// TYPE_DELEGATION is a new delegation.
+	StreamDelegationsResponse_TYPE_DELEGATION StreamDelegationsResponse_Type = 1
+	// TYPE_HEARTBEAT is sent once the stream is live, then in between the delegations.
+	StreamDelegationsResponse_TYPE_HEARTBEAT StreamDelegationsResponse_Type = 2
+)
+
+// Enum value maps for StreamDelegationsResponse_Type.
+var (
+	StreamDelegationsResponse_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_DELEGATION",
+		2: "TYPE_HEARTBEAT",
+	}
+	StreamDelegationsResponse_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_DELEGATION":  1,
+		"TYPE_HEARTBEAT":   2,
+	}
+)
+
+func (x StreamDelegationsResponse_Type) Enum() *StreamDelegationsResponse_Type {
+	p := new(StreamDelegationsResponse_Type)
+	*p = x
+	return p
+}
+
+func (x StreamDelegationsResponse_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamDelegationsResponse_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_delegation_v1_delegation_proto_enumTypes[3].Descriptor()
+}
+
+func (StreamDelegationsResponse_Type) Type() protoreflect.EnumType {
+	return &file_delegation_v1_delegation_proto_enumTypes[3]
+}
+
+func (x StreamDelegationsResponse_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamDelegationsResponse_Type.Descriptor instead.
+func (StreamDelegationsResponse_Type) EnumDescriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{9, 0}
+}
+
+// Delegation is a tezos delegation.
+type Delegation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// timestamp is the timestamp of the block.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// amount is the delegated amount, in mutez.
+	Amount int64 `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// delegator is the delegator address.
+	Delegator string `protobuf:"bytes,4,opt,name=delegator,proto3" json:"delegator,omitempty"`
+	// block is the block hash.
+	Block string `protobuf:"bytes,5,opt,name=block,proto3" json:"block,omitempty"`
+	// level is the block level, 0 when unknown.
+	Level int64 `protobuf:"varint,6,opt,name=level,proto3" json:"level,omitempty"`
+	// baker is the baker address, empty for an undelegation.
+	Baker string `protobuf:"bytes,7,opt,name=baker,proto3" json:"baker,omitempty"`
+	// previous_baker is the previous baker address, empty for a first delegation.
+	PreviousBaker string `protobuf:"bytes,8,opt,name=previous_baker,json=previousBaker,proto3" json:"previous_baker,omitempty"`
+}
+
+func (x *Delegation) Reset() {
+	*x = Delegation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Delegation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Delegation) ProtoMessage() {}
+
+func (x *Delegation) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Delegation.ProtoReflect.Descriptor instead.
+func (*Delegation) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Delegation) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Delegation) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Delegation) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Delegation) GetDelegator() string {
+	if x != nil {
+		return x.Delegator
+	}
+	return ""
+}
+
+func (x *Delegation) GetBlock() string {
+	if x != nil {
+		return x.Block
+	}
+	return ""
+}
+
+func (x *Delegation) GetLevel() int64 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *Delegation) GetBaker() string {
+	if x != nil {
+		return x.Baker
+	}
+	return ""
+}
+
+func (x *Delegation) GetPreviousBaker() string {
+	if x != nil {
+		return x.PreviousBaker
+	}
+	return ""
+}
+
+// DelegationsFilter filters the delegations, unset fields not filtering.
+type DelegationsFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// year keeps the delegations of a year (UTC), from 2018.
+	Year int32 `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	// from keeps the delegations with a timestamp greater than or equal to from.
+	From *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	// to keeps the delegations with a timestamp strictly lower than to.
+	To        *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Delegator string                 `protobuf:"bytes,4,opt,name=delegator,proto3" json:"delegator,omitempty"`
+	Baker     string                 `protobuf:"bytes,5,opt,name=baker,proto3" json:"baker,omitempty"`
+	Block     string                 `protobuf:"bytes,6,opt,name=block,proto3" json:"block,omitempty"`
+	Level     int64                  `protobuf:"varint,7,opt,name=level,proto3" json:"level,omitempty"`
+	Kind      DelegationKind         `protobuf:"varint,8,opt,name=kind,proto3,enum=delegation.v1.DelegationKind" json:"kind,omitempty"`
+	Status    DelegationStatus       `protobuf:"varint,9,opt,name=status,proto3,enum=delegation.v1.DelegationStatus" json:"status,omitempty"`
+	// amount_gt keeps the delegations with an amount strictly greater than amount_gt.
+	AmountGt *int64 `protobuf:"varint,10,opt,name=amount_gt,json=amountGt,proto3,oneof" json:"amount_gt,omitempty"`
+	// amount_lt keeps the delegations with an amount strictly lower than amount_lt.
+	AmountLt *int64 `protobuf:"varint,11,opt,name=amount_lt,json=amountLt,proto3,oneof" json:"amount_lt,omitempty"`
+}
+
+func (x *DelegationsFilter) Reset() {
+	*x = DelegationsFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DelegationsFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelegationsFilter) ProtoMessage() {}
+
+func (x *DelegationsFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelegationsFilter.ProtoReflect.Descriptor instead.
+func (*DelegationsFilter) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DelegationsFilter) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *DelegationsFilter) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *DelegationsFilter) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *DelegationsFilter) GetDelegator() string {
+	if x != nil {
+		return x.Delegator
+	}
+	return ""
+}
+
+func (x *DelegationsFilter) GetBaker() string {
+	if x != nil {
+		return x.Baker
+	}
+	return ""
+}
+
+func (x *DelegationsFilter) GetBlock() string {
+	if x != nil {
+		return x.Block
+	}
+	return ""
+}
+
+func (x *DelegationsFilter) GetLevel() int64 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *DelegationsFilter) GetKind() DelegationKind {
+	if x != nil {
+		return x.Kind
+	}
+	return DelegationKind_DELEGATION_KIND_UNSPECIFIED
+}
+
+func (x *DelegationsFilter) GetStatus() DelegationStatus {
+	if x != nil {
+		return x.Status
+	}
+	return DelegationStatus_DELEGATION_STATUS_UNSPECIFIED
+}
+
+func (x *DelegationsFilter) GetAmountGt() int64 {
+	if x != nil && x.AmountGt != nil {
+		return *x.AmountGt
+	}
+	return 0
+}
+
+func (x *DelegationsFilter) GetAmountLt() int64 {
+	if x != nil && x.AmountLt != nil {
+		return *x.AmountLt
+	}
+	return 0
+}
+
+// DelegationsSort sorts the delegations.
+type DelegationsSort struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Field DelegationsSort_Field `protobuf:"varint,1,opt,name=field,proto3,enum=delegation.v1.DelegationsSort_Field" json:"field,omitempty"`
+	// ascending sorts in ascending order, the default order being descending.
+	Ascending bool `protobuf:"varint,2,opt,name=ascending,proto3" json:"ascending,omitempty"`
+}
+
+func (x *DelegationsSort) Reset() {
+	*x = DelegationsSort{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DelegationsSort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelegationsSort) ProtoMessage() {}
+
+func (x *DelegationsSort) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelegationsSort.ProtoReflect.Descriptor instead.
+func (*DelegationsSort) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DelegationsSort) GetField() DelegationsSort_Field {
+	if x != nil {
+		return x.Field
+	}
+	return DelegationsSort_FIELD_UNSPECIFIED
+}
+
+func (x *DelegationsSort) GetAscending() bool {
+	if x != nil {
+		return x.Ascending
+	}
+	return false
+}
+
+type ListDelegationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *DelegationsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// page_size is the maximum number of delegations of the page, 100 by default.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page, empty for the first page.
+	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+}
+
+func (x *ListDelegationsRequest) Reset() {
+	*x = ListDelegationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListDelegationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDelegationsRequest) ProtoMessage() {}
+
+func (x *ListDelegationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDelegationsRequest.ProtoReflect.Descriptor instead.
+func (*ListDelegationsRequest) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListDelegationsRequest) GetFilter() *DelegationsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListDelegationsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDelegationsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListDelegationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Delegations []*Delegation `protobuf:"bytes,1,rep,name=delegations,proto3" json:"delegations,omitempty"`
+	// next_page_token is the token of the next page, empty for the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+}
+
+func (x *ListDelegationsResponse) Reset() {
+	*x = ListDelegationsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListDelegationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDelegationsResponse) ProtoMessage() {}
+
+func (x *ListDelegationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDelegationsResponse.ProtoReflect.Descriptor instead.
+func (*ListDelegationsResponse) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListDelegationsResponse) GetDelegations() []*Delegation {
+	if x != nil {
+		return x.Delegations
+	}
+	return nil
+}
+
+func (x *ListDelegationsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CountDelegationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *DelegationsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+}
+
+func (x *CountDelegationsRequest) Reset() {
+	*x = CountDelegationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountDelegationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountDelegationsRequest) ProtoMessage() {}
+
+func (x *CountDelegationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountDelegationsRequest.ProtoReflect.Descriptor instead.
+func (*CountDelegationsRequest) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CountDelegationsRequest) GetFilter() *DelegationsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type CountDelegationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *CountDelegationsResponse) Reset() {
+	*x = CountDelegationsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountDelegationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountDelegationsResponse) ProtoMessage() {}
+
+func (x *CountDelegationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountDelegationsResponse.ProtoReflect.Descriptor instead.
+func (*CountDelegationsResponse) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CountDelegationsResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ExportDelegationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *DelegationsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort   *DelegationsSort   `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+}
+
+func (x *ExportDelegationsRequest) Reset() {
+	*x = ExportDelegationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportDelegationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportDelegationsRequest) ProtoMessage() {}
+
+func (x *ExportDelegationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportDelegationsRequest.ProtoReflect.Descriptor instead.
+func (*ExportDelegationsRequest) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExportDelegationsRequest) GetFilter() *DelegationsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ExportDelegationsRequest) GetSort() *DelegationsSort {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+type StreamDelegationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// filter keeps the new delegations matching it.
+	Filter *DelegationsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// last_event_id resumes the stream after the delegation of an event id, replaying the delegations stored since.
+	LastEventId string `protobuf:"bytes,2,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+}
+
+func (x *StreamDelegationsRequest) Reset() {
+	*x = StreamDelegationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamDelegationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDelegationsRequest) ProtoMessage() {}
+
+func (x *StreamDelegationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDelegationsRequest.ProtoReflect.Descriptor instead.
+func (*StreamDelegationsRequest) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamDelegationsRequest) GetFilter() *DelegationsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *StreamDelegationsRequest) GetLastEventId() string {
+	if x != nil {
+		return x.LastEventId
+	}
+	return ""
+}
+
+type StreamDelegationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type StreamDelegationsResponse_Type `protobuf:"varint,1,opt,name=type,proto3,enum=delegation.v1.StreamDelegationsResponse_Type" json:"type,omitempty"`
+	// id is the event id to resume the stream after the delegation.
+	Id         string      `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Delegation *Delegation `protobuf:"bytes,3,opt,name=delegation,proto3" json:"delegation,omitempty"`
+}
+
+func (x *StreamDelegationsResponse) Reset() {
+	*x = StreamDelegationsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_delegation_v1_delegation_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamDelegationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDelegationsResponse) ProtoMessage() {}
+
+func (x *StreamDelegationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_delegation_v1_delegation_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDelegationsResponse.ProtoReflect.Descriptor instead.
+func (*StreamDelegationsResponse) Descriptor() ([]byte, []int) {
+	return file_delegation_v1_delegation_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamDelegationsResponse) GetType() StreamDelegationsResponse_Type {
+	if x != nil {
+		return x.Type
+	}
+	return StreamDelegationsResponse_TYPE_UNSPECIFIED
+}
+
+func (x *StreamDelegationsResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StreamDelegationsResponse) GetDelegation() *Delegation {
+	if x != nil {
+		return x.Delegation
+	}
+	return nil
+}
+
+var File_delegation_v1_delegation_proto protoreflect.FileDescriptor
+
+var file_delegation_v1_delegation_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x76, 0x31, 0x2f,
+	0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0d, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x1a,
+	0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0xf5, 0x01, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12,
+	0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x72, 0x12,
+	0x14, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x62,
+	0x61, 0x6b, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x62, 0x61, 0x6b, 0x65,
+	0x72, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x5f, 0x62, 0x61,
+	0x6b, 0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x72, 0x65, 0x76, 0x69,
+	0x6f, 0x75, 0x73, 0x42, 0x61, 0x6b, 0x65, 0x72, 0x22, 0xaf, 0x03, 0x0a, 0x11, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x12,
+	0x0a, 0x04, 0x79, 0x65, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x79, 0x65,
+	0x61, 0x72, 0x12, 0x2e, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x66, 0x72,
+	0x6f, 0x6d, 0x12, 0x2a, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x1c,
+	0x0a, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05,
+	0x62, 0x61, 0x6b, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x62, 0x61, 0x6b,
+	0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x31,
+	0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x64,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e,
+	0x64, 0x12, 0x37, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x1f, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x20, 0x0a, 0x09, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x67, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52,
+	0x08, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x47, 0x74, 0x88, 0x01, 0x01, 0x12, 0x20, 0x0a, 0x09,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x6c, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x48,
+	0x01, 0x52, 0x08, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4c, 0x74, 0x88, 0x01, 0x01, 0x42, 0x0c,
+	0x0a, 0x0a, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x67, 0x74, 0x42, 0x0c, 0x0a, 0x0a,
+	0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x6c, 0x74, 0x22, 0xc3, 0x01, 0x0a, 0x0f, 0x44,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x53, 0x6f, 0x72, 0x74, 0x12, 0x3a,
+	0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x24, 0x2e,
+	0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x53, 0x6f, 0x72, 0x74, 0x2e, 0x46, 0x69,
+	0x65, 0x6c, 0x64, 0x52, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x73,
+	0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x61,
+	0x73, 0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x22, 0x56, 0x0a, 0x05, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x12, 0x15, 0x0a, 0x11, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x49, 0x45, 0x4c,
+	0x44, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x10, 0x01, 0x12, 0x10, 0x0a,
+	0x0c, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x41, 0x4d, 0x4f, 0x55, 0x4e, 0x54, 0x10, 0x02, 0x12,
+	0x0f, 0x0a, 0x0b, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x10, 0x03,
+	0x22, 0x8e, 0x01, 0x0a, 0x16, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a, 0x06, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x64, 0x65,
+	0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69,
+	0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69,
+	0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x22, 0x7e, 0x0a, 0x17, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3b, 0x0a, 0x0b,
+	0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x64, 0x65,
+	0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78,
+	0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x22, 0x53, 0x0a, 0x17, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x64,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x30, 0x0a, 0x18, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x44,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x88, 0x01, 0x0a, 0x18, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12,
+	0x32, 0x0a, 0x04, 0x73, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
+	0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x53, 0x6f, 0x72, 0x74, 0x52, 0x04, 0x73,
+	0x6f, 0x72, 0x74, 0x22, 0x78, 0x0a, 0x18, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x38, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x20, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x22, 0x0a, 0x0d, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x22, 0xf0, 0x01,
+	0x0a, 0x19, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2d, 0x2e, 0x64, 0x65, 0x6c, 0x65,
+	0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x39,
+	0x0a, 0x0a, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x64,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x45, 0x0a, 0x04, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x48, 0x45, 0x41, 0x52, 0x54, 0x42, 0x45, 0x41, 0x54, 0x10, 0x02,
+	0x2a, 0x95, 0x01, 0x0a, 0x0e, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b,
+	0x69, 0x6e, 0x64, 0x12, 0x1f, 0x0a, 0x1b, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f,
+	0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49,
+	0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49,
+	0x4f, 0x4e, 0x10, 0x01, 0x12, 0x20, 0x0a, 0x1c, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49,
+	0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x52, 0x45, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41,
+	0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x12, 0x20, 0x0a, 0x1c, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41,
+	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x44, 0x45, 0x4c, 0x45,
+	0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x03, 0x2a, 0xb4, 0x01, 0x0a, 0x10, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x21, 0x0a,
+	0x1d, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x1d, 0x0a, 0x19, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x53,
+	0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x41, 0x50, 0x50, 0x4c, 0x49, 0x45, 0x44, 0x10, 0x01, 0x12,
+	0x1c, 0x0a, 0x18, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x21, 0x0a,
+	0x1d, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x42, 0x41, 0x43, 0x4b, 0x54, 0x52, 0x41, 0x43, 0x4b, 0x45, 0x44, 0x10, 0x03,
+	0x12, 0x1d, 0x0a, 0x19, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x53,
+	0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x53, 0x4b, 0x49, 0x50, 0x50, 0x45, 0x44, 0x10, 0x04, 0x32,
+	0x9f, 0x03, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x60, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x25, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x26, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x63, 0x0a, 0x10, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x26, 0x2e, 0x64, 0x65,
+	0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x59, 0x0a, 0x11,
+	0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x27, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x64, 0x65, 0x6c,
+	0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x67,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x30, 0x01, 0x12, 0x68, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x27, 0x2e, 0x64,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x44, 0x65, 0x6c, 0x65,
+	0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30,
+	0x01, 0x42, 0x6a, 0x5a, 0x68, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x67, 0x75, 0x69, 0x6c, 0x6c, 0x61, 0x75, 0x6d, 0x65, 0x64, 0x65, 0x62, 0x61, 0x76, 0x65, 0x6c,
+	0x61, 0x65, 0x72, 0x65, 0x2f, 0x74, 0x65, 0x7a, 0x6f, 0x73, 0x2d, 0x64, 0x65, 0x6c, 0x65, 0x67,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x64, 0x65,
+	0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2f, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x76, 0x31,
+	0x3b, 0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x76, 0x31, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_delegation_v1_delegation_proto_rawDescOnce sync.Once
+	file_delegation_v1_delegation_proto_rawDescData = file_delegation_v1_delegation_proto_rawDesc
+)
+
+func file_delegation_v1_delegation_proto_rawDescGZIP() []byte {
+	file_delegation_v1_delegation_proto_rawDescOnce.Do(func() {
+		file_delegation_v1_delegation_proto_rawDescData = protoimpl.X.CompressGZIP(file_delegation_v1_delegation_proto_rawDescData)
+	})
+	return file_delegation_v1_delegation_proto_rawDescData
+}
+
+var file_delegation_v1_delegation_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_delegation_v1_delegation_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_delegation_v1_delegation_proto_goTypes = []interface{}{
+	(DelegationKind)(0),                 // 0: delegation.v1.DelegationKind
+	(DelegationStatus)(0),               // 1: delegation.v1.DelegationStatus
+	(DelegationsSort_Field)(0),          // 2: delegation.v1.DelegationsSort.Field
+	(StreamDelegationsResponse_Type)(0), // 3: delegation.v1.StreamDelegationsResponse.Type
+	(*Delegation)(nil),                  // 4: delegation.v1.Delegation
+	(*DelegationsFilter)(nil),           // 5: delegation.v1.DelegationsFilter
+	(*DelegationsSort)(nil),             // 6: delegation.v1.DelegationsSort
+	(*ListDelegationsRequest)(nil),      // 7: delegation.v1.ListDelegationsRequest
+	(*ListDelegationsResponse)(nil),     // 8: delegation.v1.ListDelegationsResponse
+	(*CountDelegationsRequest)(nil),     // 9: delegation.v1.CountDelegationsRequest
+	(*CountDelegationsResponse)(nil),    // 10: delegation.v1.CountDelegationsResponse
+	(*ExportDelegationsRequest)(nil),    // 11: delegation.v1.ExportDelegationsRequest
+	(*StreamDelegationsRequest)(nil),    // 12: delegation.v1.StreamDelegationsRequest
+	(*StreamDelegationsResponse)(nil),   // 13: delegation.v1.StreamDelegationsResponse
+	(*timestamppb.Timestamp)(nil),       // 14: google.protobuf.Timestamp
+}
+var file_delegation_v1_delegation_proto_depIdxs = []int32{
+	14, // 0: delegation.v1.Delegation.timestamp:type_name -> google.protobuf.Timestamp
+	14, // 1: delegation.v1.DelegationsFilter.from:type_name -> google.protobuf.Timestamp
+	14, // 2: delegation.v1.DelegationsFilter.to:type_name -> google.protobuf.Timestamp
+	0,  // 3: delegation.v1.DelegationsFilter.kind:type_name -> delegation.v1.DelegationKind
+	1,  // 4: delegation.v1.DelegationsFilter.status:type_name -> delegation.v1.DelegationStatus
+	2,  // 5: delegation.v1.DelegationsSort.field:type_name -> delegation.v1.DelegationsSort.Field
+	5,  // 6: delegation.v1.ListDelegationsRequest.filter:type_name -> delegation.v1.DelegationsFilter
+	4,  // 7: delegation.v1.ListDelegationsResponse.delegations:type_name -> delegation.v1.Delegation
+	5,  // 8: delegation.v1.CountDelegationsRequest.filter:type_name -> delegation.v1.DelegationsFilter
+	5,  // 9: delegation.v1.ExportDelegationsRequest.filter:type_name -> delegation.v1.DelegationsFilter
+	6,  // 10: delegation.v1.ExportDelegationsRequest.sort:type_name -> delegation.v1.DelegationsSort
+	5,  // 11: delegation.v1.StreamDelegationsRequest.filter:type_name -> delegation.v1.DelegationsFilter
+	3,  // 12: delegation.v1.StreamDelegationsResponse.type:type_name -> delegation.v1.StreamDelegationsResponse.Type
+	4,  // 13: delegation.v1.StreamDelegationsResponse.delegation:type_name -> delegation.v1.Delegation
+	7,  // 14: delegation.v1.DelegationService.ListDelegations:input_type -> delegation.v1.ListDelegationsRequest
+	9,  // 15: delegation.v1.DelegationService.CountDelegations:input_type -> delegation.v1.CountDelegationsRequest
+	11, // 16: delegation.v1.DelegationService.ExportDelegations:input_type -> delegation.v1.ExportDelegationsRequest
+	12, // 17: delegation.v1.DelegationService.StreamDelegations:input_type -> delegation.v1.StreamDelegationsRequest
+	8,  // 18: delegation.v1.DelegationService.ListDelegations:output_type -> delegation.v1.ListDelegationsResponse
+	10, // 19: delegation.v1.DelegationService.CountDelegations:output_type -> delegation.v1.CountDelegationsResponse
+	4,  // 20: delegation.v1.DelegationService.ExportDelegations:output_type -> delegation.v1.Delegation
+	13, // 21: delegation.v1.DelegationService.StreamDelegations:output_type -> delegation.v1.StreamDelegationsResponse
+	18, // [18:22] is the sub-list for method output_type
+	14, // [14:18] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_delegation_v1_delegation_proto_init() }
+func file_delegation_v1_delegation_proto_init() {
+	if File_delegation_v1_delegation_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_delegation_v1_delegation_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Delegation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DelegationsFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DelegationsSort); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListDelegationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListDelegationsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountDelegationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountDelegationsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportDelegationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamDelegationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_delegation_v1_delegation_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamDelegationsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_delegation_v1_delegation_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_delegation_v1_delegation_proto_rawDesc,
+			NumEnums:      4,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_delegation_v1_delegation_proto_goTypes,
+		DependencyIndexes: file_delegation_v1_delegation_proto_depIdxs,
+		EnumInfos:         file_delegation_v1_delegation_proto_enumTypes,
+		MessageInfos:      file_delegation_v1_delegation_proto_msgTypes,
+	}.Build()
+	File_delegation_v1_delegation_proto = out.File
+	file_delegation_v1_delegation_proto_rawDesc = nil
+	file_delegation_v1_delegation_proto_goTypes = nil
+	file_delegation_v1_delegation_proto_depIdxs = nil
+}
@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: delegation/v1/delegation.proto
+
+package delegationv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	DelegationService_ListDelegations_FullMethodName   = "/delegation.v1.DelegationService/ListDelegations"
+	DelegationService_CountDelegations_FullMethodName  = "/delegation.v1.DelegationService/CountDelegations"
+	DelegationService_ExportDelegations_FullMethodName = "/delegation.v1.DelegationService/ExportDelegations"
+	DelegationService_StreamDelegations_FullMethodName = "/delegation.v1.DelegationService/StreamDelegations"
+)
+
+// DelegationServiceClient is the client API for DelegationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DelegationServiceClient interface {
+	// ListDelegations returns a page of delegations, the latest first.
+	ListDelegations(ctx context.Context, in *ListDelegationsRequest, opts ...grpc.CallOption) (*ListDelegationsResponse, error)
+	// CountDelegations returns the number of delegations matching a filter.
+	CountDelegations(ctx context.Context, in *CountDelegationsRequest, opts ...grpc.CallOption) (*CountDelegationsResponse, error)
+	// ExportDelegations streams all the delegations matching a filter, as they are read from the datastore.
+	ExportDelegations(ctx context.Context, in *ExportDelegationsRequest, opts ...grpc.CallOption) (DelegationService_ExportDelegationsClient, error)
+	// StreamDelegations streams the new delegations matching a filter as they are stored, with heartbeats in
+	// between. The stream is aborted with RESOURCE_EXHAUSTED when the client doesn't keep up.
+	StreamDelegations(ctx context.Context, in *StreamDelegationsRequest, opts ...grpc.CallOption) (DelegationService_StreamDelegationsClient, error)
+}
+
+type delegationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDelegationServiceClient(cc grpc.ClientConnInterface) DelegationServiceClient {
+	return &delegationServiceClient{cc}
+}
+
+func (c *delegationServiceClient) ListDelegations(ctx context.Context, in *ListDelegationsRequest, opts ...grpc.CallOption) (*ListDelegationsResponse, error) {
+	out := new(ListDelegationsResponse)
+	err := c.cc.Invoke(ctx, DelegationService_ListDelegations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *delegationServiceClient) CountDelegations(ctx context.Context, in *CountDelegationsRequest, opts ...grpc.CallOption) (*CountDelegationsResponse, error) {
+	out := new(CountDelegationsResponse)
+	err := c.cc.Invoke(ctx, DelegationService_CountDelegations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *delegationServiceClient) ExportDelegations(ctx context.Context, in *ExportDelegationsRequest, opts ...grpc.CallOption) (DelegationService_ExportDelegationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DelegationService_ServiceDesc.Streams[0], DelegationService_ExportDelegations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &delegationServiceExportDelegationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DelegationService_ExportDelegationsClient interface {
+	Recv() (*Delegation, error)
+	grpc.ClientStream
+}
+
+type delegationServiceExportDelegationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *delegationServiceExportDelegationsClient) Recv() (*Delegation, error) {
+	m := new(Delegation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *delegationServiceClient) StreamDelegations(ctx context.Context, in *StreamDelegationsRequest, opts ...grpc.CallOption) (DelegationService_StreamDelegationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DelegationService_ServiceDesc.Streams[1], DelegationService_StreamDelegations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &delegationServiceStreamDelegationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DelegationService_StreamDelegationsClient interface {
+	Recv() (*StreamDelegationsResponse, error)
+	grpc.ClientStream
+}
+
+type delegationServiceStreamDelegationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *delegationServiceStreamDelegationsClient) Recv() (*StreamDelegationsResponse, error) {
+	m := new(StreamDelegationsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DelegationServiceServer is the server API for DelegationService service.
+// All implementations must embed UnimplementedDelegationServiceServer
+// for forward compatibility
+type DelegationServiceServer interface {
+	// ListDelegations returns a page of delegations, the latest first.
+	ListDelegations(context.Context, *ListDelegationsRequest) (*ListDelegationsResponse, error)
+	// CountDelegations returns the number of delegations matching a filter.
+	CountDelegations(context.Context, *CountDelegationsRequest) (*CountDelegationsResponse, error)
+	// ExportDelegations streams all the delegations matching a filter, as they are read from the datastore.
+	ExportDelegations(*ExportDelegationsRequest, DelegationService_ExportDelegationsServer) error
+	// StreamDelegations streams the new delegations matching a filter as they are stored, with heartbeats in
+	// between. The stream is aborted with RESOURCE_EXHAUSTED when the client doesn't keep up.
+	StreamDelegations(*StreamDelegationsRequest, DelegationService_StreamDelegationsServer) error
+	mustEmbedUnimplementedDelegationServiceServer()
+}
+
+// UnimplementedDelegationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDelegationServiceServer struct {
+}
+
+func (UnimplementedDelegationServiceServer) ListDelegations(context.Context, *ListDelegationsRequest) (*ListDelegationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDelegations not implemented")
+}
+func (UnimplementedDelegationServiceServer) CountDelegations(context.Context, *CountDelegationsRequest) (*CountDelegationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountDelegations not implemented")
+}
+func (UnimplementedDelegationServiceServer) ExportDelegations(*ExportDelegationsRequest, DelegationService_ExportDelegationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportDelegations not implemented")
+}
+func (UnimplementedDelegationServiceServer) StreamDelegations(*StreamDelegationsRequest, DelegationService_StreamDelegationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDelegations not implemented")
+}
+func (UnimplementedDelegationServiceServer) mustEmbedUnimplementedDelegationServiceServer() {}
+
+// UnsafeDelegationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DelegationServiceServer will
+// result in compilation errors.
+type UnsafeDelegationServiceServer interface {
+	mustEmbedUnimplementedDelegationServiceServer()
+}
+
+func RegisterDelegationServiceServer(s grpc.ServiceRegistrar, srv DelegationServiceServer) {
+	s.RegisterService(&DelegationService_ServiceDesc, srv)
+}
+
+func _DelegationService_ListDelegations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDelegationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DelegationServiceServer).ListDelegations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DelegationService_ListDelegations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DelegationServiceServer).ListDelegations(ctx, req.(*ListDelegationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DelegationService_CountDelegations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountDelegationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DelegationServiceServer).CountDelegations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DelegationService_CountDelegations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DelegationServiceServer).CountDelegations(ctx, req.(*CountDelegationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DelegationService_ExportDelegations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportDelegationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DelegationServiceServer).ExportDelegations(m, &delegationServiceExportDelegationsServer{stream})
+}
+
+type DelegationService_ExportDelegationsServer interface {
+	Send(*Delegation) error
+	grpc.ServerStream
+}
+
+type delegationServiceExportDelegationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *delegationServiceExportDelegationsServer) Send(m *Delegation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DelegationService_StreamDelegations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDelegationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DelegationServiceServer).StreamDelegations(m, &delegationServiceStreamDelegationsServer{stream})
+}
+
+type DelegationService_StreamDelegationsServer interface {
+	Send(*StreamDelegationsResponse) error
+	grpc.ServerStream
+}
+
+type delegationServiceStreamDelegationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *delegationServiceStreamDelegationsServer) Send(m *StreamDelegationsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// DelegationService_ServiceDesc is the grpc.ServiceDesc for DelegationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DelegationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "delegation.v1.DelegationService",
+	HandlerType: (*DelegationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDelegations",
+			Handler:    _DelegationService_ListDelegations_Handler,
+		},
+		{
+			MethodName: "CountDelegations",
+			Handler:    _DelegationService_CountDelegations_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportDelegations",
+			Handler:       _DelegationService_ExportDelegations_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDelegations",
+			Handler:       _DelegationService_StreamDelegations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "delegation/v1/delegation.proto",
+}